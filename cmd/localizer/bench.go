@@ -0,0 +1,215 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/getoutreach/localizer/internal/relayagent"
+)
+
+// percentile returns the p-th percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
+
+// dialFunc creates a connection to whatever is being benchmarked
+type dialFunc func() (net.Conn, error)
+
+// benchConnect measures how long it takes to establish n connections with
+// dial, one at a time
+func benchConnect(dial dialFunc, n int) ([]time.Duration, error) {
+	durations := make([]time.Duration, 0, n)
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		conn, err := dial()
+		if err != nil {
+			return nil, err
+		}
+		durations = append(durations, time.Since(start))
+		conn.Close()
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return durations, nil
+}
+
+// benchThroughput writes to a connection from dial for the given duration,
+// while reading anything sent back, returning the number of bytes sent and
+// received
+func benchThroughput(dial dialFunc, d time.Duration) (sent, received int64, err error) {
+	conn, err := dial()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+
+	// count as we read, rather than when the reader finishes, since it
+	// only finishes once we close the connection
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := conn.Read(buf)
+			atomic.AddInt64(&received, int64(n))
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	buf := make([]byte, 32*1024)
+	deadline := time.Now().Add(d)
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return 0, 0, err
+	}
+
+	for time.Now().Before(deadline) {
+		n, err := conn.Write(buf)
+		sent += int64(n)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				break
+			}
+			return sent, atomic.LoadInt64(&received), errors.Wrap(err, "failed to write to service")
+		}
+	}
+
+	// give any in-flight data a moment to come back
+	time.Sleep(100 * time.Millisecond)
+
+	return sent, atomic.LoadInt64(&received), nil
+}
+
+// serviceDialer returns a dialFunc for a service's port, and a function to
+// clean up after it
+func serviceDialer(c *cli.Context, log logrus.FieldLogger) (dialFunc, func(), error) {
+	namespace, name, port, err := parseServicePort(qualifyService(c, c.Args().First()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addr, closer, err := resolveServiceAddress(c.Context, log, c, namespace, name, port)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return func() (net.Conn, error) {
+		conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+		return conn, errors.Wrap(err, "failed to dial service")
+	}, closer, nil
+}
+
+// relayAgentDialer returns a dialFunc for the relay agent's echo target,
+// deploying the agent if it isn't already running
+func relayAgentDialer(c *cli.Context, log logrus.FieldLogger) (dialFunc, func(), error) {
+	if c.String("relay-agent-image") == "" {
+		return nil, nil, fmt.Errorf("--relay-agent-image must be set to measure the relay agent")
+	}
+
+	kconf, k, err := kube.GetKubeClient(log, c.String("context"))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create kube client")
+	}
+
+	ctx, cancel := context.WithCancel(c.Context)
+	rc := relayagent.NewClient(ctx, k, kconf, log, c.String("relay-agent-namespace"), c.String("relay-agent-image"))
+	return func() (net.Conn, error) {
+		return rc.Dial(ctx, relayagent.EchoTarget, c.Bool("compress"))
+	}, cancel, nil
+}
+
+func NewBenchCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:    "bench",
+		Aliases: []string{"speedtest"},
+		Description: "Measure connection setup latency and throughput of the tunnel to a service. " +
+			"Throughput is measured by sending data, so the service should accept it (e.g. an echo or discard server). " +
+			"With --relay-agent, the relay agent is measured instead using its built-in echo",
+		Usage: "bench <namespace/service>:<port>|--relay-agent",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "relay-agent",
+				Usage: "Measure the relay agent (from --relay-agent-image) instead of a service",
+			},
+			&cli.BoolFlag{
+				Name:  "compress",
+				Usage: "Compress connections to the relay agent, only used with --relay-agent",
+			},
+			&cli.IntFlag{
+				Name:  "connections",
+				Usage: "Number of connections to make when measuring connection setup latency",
+				Value: 20,
+			},
+			&cli.DurationFlag{
+				Name:  "duration",
+				Usage: "How long to send data for when measuring throughput, 0 to skip",
+				Value: 5 * time.Second,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			var dial dialFunc
+			var closer func()
+			var err error
+			if c.Bool("relay-agent") {
+				dial, closer, err = relayAgentDialer(c, log)
+			} else {
+				dial, closer, err = serviceDialer(c, log)
+			}
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			log.Infof("measuring connection setup latency (%d connections)", c.Int("connections"))
+			durations, err := benchConnect(dial, c.Int("connections"))
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 10, 0, 3, ' ', 0)
+			fmt.Fprintf(w, "Connect p50:\t%s\n", percentile(durations, 0.50))
+			fmt.Fprintf(w, "Connect p90:\t%s\n", percentile(durations, 0.90))
+			fmt.Fprintf(w, "Connect p99:\t%s\n", percentile(durations, 0.99))
+
+			if d := c.Duration("duration"); d > 0 {
+				log.Infof("measuring throughput for %s", d)
+				sent, received, err := benchThroughput(dial, d)
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(w, "Sent:\t%.2f MiB/s\n", float64(sent)/1024/1024/d.Seconds())
+				fmt.Fprintf(w, "Received:\t%.2f MiB/s\n", float64(received)/1024/1024/d.Seconds())
+			}
+
+			return w.Flush()
+		},
+	}
+}
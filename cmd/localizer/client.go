@@ -0,0 +1,227 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/getoutreach/localizer/internal/ssh"
+	"github.com/getoutreach/localizer/pkg/localizer"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// connectDaemon returns a client for the localizer daemon. If --remote was
+// provided, the daemon's socket on the remote host is tunneled over SSH.
+// If --context was, it's an error for the daemon to use another one.
+func connectDaemon(ctx context.Context, c *cli.Context) (api.LocalizerServiceClient, func(), error) {
+	client, closer, err := dialDaemon(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkDaemonContext(ctx, client, c.String("context")); err != nil {
+		closer()
+		return nil, nil, err
+	}
+
+	return client, closer, nil
+}
+
+// checkDaemonContext returns an error if the daemon forwards services from
+// a kubeconfig context other than kubeContext, if it's set
+func checkDaemonContext(ctx context.Context, client api.LocalizerServiceClient, kubeContext string) error {
+	if kubeContext == "" {
+		return nil
+	}
+
+	conf, err := client.GetConfig(ctx, &api.Empty{})
+	if status.Code(err) == codes.Unimplemented {
+		// an older daemon, which can't tell us
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "failed to get the configuration of the daemon")
+	}
+
+	if !conf.InCluster && conf.KubeContext != "" && conf.KubeContext != kubeContext {
+		return fmt.Errorf("the daemon forwards services from context '%s', not '%s', "+
+			"run one for it with --instance and pass the same --instance to this command", conf.KubeContext, kubeContext)
+	}
+	return nil
+}
+
+// dialDaemon connects to the localizer daemon, tunneling to it over SSH if
+// --remote was provided
+func dialDaemon(ctx context.Context, c *cli.Context) (api.LocalizerServiceClient, func(), error) {
+	opts := []grpc.DialOption{grpc.WithBlock(), grpc.WithInsecure()}
+
+	remote := c.String("remote")
+	if remote == "" {
+		if !localizer.IsRunning() {
+			return nil, nil, fmt.Errorf("localizer daemon not running (run localizer by itself?)")
+		}
+
+		client, closer, err := localizer.Connect(ctx, opts...)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to connect to localizer daemon")
+		}
+		return client, closer, nil
+	}
+
+	d, err := ssh.NewRemoteDialer(ctx, remote)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to connect to remote")
+	}
+
+	opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		socket := c.String("socket")
+		if socket == "" {
+			socket = localizer.SocketPath(c.String("instance"))
+		}
+		return d.Dial(ctx, socket)
+	}))
+
+	client, closer, err := localizer.Connect(ctx, opts...)
+	if err != nil {
+		d.Close() //nolint:errcheck // Why: We're already returning an error
+		return nil, nil, errors.Wrap(err, "failed to connect to remote localizer daemon")
+	}
+
+	return client, func() {
+		closer()
+		d.Close() //nolint:errcheck // Why: Best effort
+	}, nil
+}
+
+// qualifyService prefixes a service without a namespace, e.g. name or
+// name:port, with the namespace given by --namespace, if a single one was
+func qualifyService(c *cli.Context, s string) string {
+	if s == "" || strings.Contains(s, "/") {
+		return s
+	}
+
+	if namespaces := parseList(c.String("namespace")); len(namespaces) == 1 {
+		return namespaces[0] + "/" + s
+	}
+	return s
+}
+
+// parseServicePort parses a service reference in the format of
+// namespace/service:port
+func parseServicePort(s string) (namespace, name string, port int, err error) {
+	idx := strings.LastIndex(s, ":")
+	if idx == -1 {
+		return "", "", 0, fmt.Errorf("invalid service '%s', expected namespace/name:port", s)
+	}
+
+	port, err = strconv.Atoi(s[idx+1:])
+	if err != nil {
+		return "", "", 0, errors.Wrapf(err, "invalid port in '%s'", s)
+	}
+
+	split := strings.Split(s[:idx], "/")
+	if len(split) != 2 {
+		return "", "", 0, fmt.Errorf("invalid service '%s', expected namespace/name:port", s)
+	}
+
+	return split[0], split[1], port, nil
+}
+
+// listPageSize is how many services are requested from the daemon at once
+const listPageSize = 500
+
+// listServices lists every service of the daemon, requesting them a page
+// at a time so that large clusters don't need to be sent in one message
+func listServices(ctx context.Context, client api.LocalizerServiceClient, req *api.ListRequest) (*api.ListResponse, error) {
+	req.PageSize = listPageSize
+	req.PageToken = ""
+
+	var resp *api.ListResponse
+	for {
+		page, err := client.List(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp == nil {
+			resp = page
+		} else {
+			resp.Services = append(resp.Services, page.Services...)
+		}
+
+		if page.NextPageToken == "" {
+			resp.NextPageToken = ""
+			return resp, nil
+		}
+		req.PageToken = page.NextPageToken
+	}
+}
+
+// resolveServiceAddress returns an address that can be used to reach the given
+// port of a service. If localizer is forwarding the service, the IP allocated to
+// it is used, otherwise a temporary port-forward is created. The returned function
+// should be called once the address is no longer needed.
+func resolveServiceAddress(ctx context.Context, log logrus.FieldLogger, c *cli.Context,
+	namespace, name string, port int) (string, func(), error) {
+	if localizer.IsRunning() || c.String("remote") != "" {
+		client, closer, err := connectDaemon(ctx, c)
+		if err == nil {
+			defer closer()
+
+			resp, err := listServices(ctx, client, &api.ListRequest{})
+			if err == nil {
+				for _, s := range resp.Services {
+					if s.Namespace == namespace && s.Name == name && s.Ip != "" && s.Status == "running" {
+						return net.JoinHostPort(s.Ip, strconv.Itoa(port)), func() {}, nil
+					}
+				}
+			}
+		}
+	}
+
+	if c.String("remote") != "" {
+		return "", nil, fmt.Errorf("service %s/%s is not being forwarded by the remote daemon", namespace, name)
+	}
+
+	kconf, k, err := kube.GetKubeClient(log, c.String("context"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	localPort, closer, err := kube.ForwardServicePort(ctx, k, kconf, namespace, name, port)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort)), closer, nil
+}
+
+// logError logs an error a command failed with, including how to fix it
+// if the daemon returned a hint
+func logError(log logrus.FieldLogger, err error) {
+	log.Errorf("failed to run: %v", err)
+	if details := localizer.ErrorDetails(err); details != nil && details.Hint != "" {
+		log.Errorf("hint: %s", details.Hint)
+	}
+}
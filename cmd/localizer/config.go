@@ -0,0 +1,227 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/internal/config"
+	"github.com/getoutreach/localizer/internal/hooks"
+	"github.com/getoutreach/localizer/internal/ippool"
+	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// validateConfigFile returns the problems with the configuration file at
+// path. A missing file is only a problem if it was asked for.
+func validateConfigFile(log logrus.FieldLogger, path string, optional bool) ([]config.Problem, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && optional {
+		log.Infof("no configuration file at %s", path)
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read config file")
+	}
+
+	conf, err := config.Parse(b)
+	if err != nil {
+		return []config.Problem{{Message: err.Error()}}, nil
+	}
+
+	problems := append(conf.Validate(), hooks.Validate(conf)...)
+	for i := range problems {
+		problems[i].Line = config.LineOf(b, problems[i].Path)
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems, nil
+}
+
+// orDefault returns s, or def if it's empty
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// orAll returns items comma separated, or all if there aren't any
+func orAll(items []string) string {
+	if len(items) == 0 {
+		return "all"
+	}
+	return strings.Join(items, ",")
+}
+
+// formatLimit formats a limit that's unlimited when 0
+func formatLimit(n int64) string {
+	if n == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(n)
+}
+
+// writeDaemonConfig writes the configuration of a daemon, followed by its
+// configuration file
+func writeDaemonConfig(out io.Writer, conf *api.GetConfigResponse) error {
+	kubeContext := orDefault(conf.KubeContext, "current-context")
+	if conf.InCluster {
+		kubeContext = "in-cluster"
+	}
+
+	w := tabwriter.NewWriter(out, 10, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "Instance:\t%s\n", orDefault(conf.Instance, "default"))
+	fmt.Fprintf(w, "Socket:\t%s\n", conf.Socket)
+	fmt.Fprintf(w, "Context:\t%s\n", kubeContext)
+	if len(conf.Kubeconfigs) > 0 {
+		fmt.Fprintf(w, "Kubeconfigs:\t%s\n", strings.Join(conf.Kubeconfigs, ", "))
+	}
+	fmt.Fprintf(w, "Cluster Domain:\t%s\n", conf.ClusterDomain)
+	fmt.Fprintf(w, "IP CIDR:\t%s\n", conf.IpCidr)
+	fmt.Fprintf(w, "IPAM File:\t%s\n", orDefault(conf.IpamFile, "none, in memory"))
+	fmt.Fprintf(w, "Namespaces:\t%s\n", orAll(conf.Namespaces))
+	fmt.Fprintf(w, "Services:\t%s\n", orAll(conf.Services))
+	fmt.Fprintf(w, "Port Names:\t%s\n", orAll(conf.PortNames))
+	fmt.Fprintf(w, "System Namespaces:\t%t\n", conf.IncludeSystemNamespaces)
+	fmt.Fprintf(w, "Hosts File:\t%s\n", orDefault(conf.HostsFile, "default"))
+	fmt.Fprintf(w, "Redirect Cluster IPs:\t%t\n", conf.RedirectClusterIps)
+	fmt.Fprintf(w, "Random Ports:\t%t\n", conf.RandomPorts)
+	fmt.Fprintf(w, "Follow Context:\t%t\n", conf.FollowContext)
+	fmt.Fprintf(w, "Unprivileged:\t%t\n", conf.Unprivileged)
+	fmt.Fprintf(w, "Max Tunnels:\t%s\n", formatLimit(conf.MaxTunnels))
+	fmt.Fprintf(w, "Max Connections:\t%s\n", formatLimit(conf.MaxConnections))
+	fmt.Fprintf(w, "Drain Timeout:\t%s\n", time.Duration(conf.DrainTimeoutSeconds)*time.Second)
+	fmt.Fprintf(w, "GC Interval:\t%s\n", time.Duration(conf.GcIntervalSeconds)*time.Second)
+	fmt.Fprintf(w, "Operation Timeout:\t%s\n", time.Duration(conf.OperationTimeoutSeconds)*time.Second)
+	fmt.Fprintf(w, "Node:\t%s\n", orDefault(conf.Node, "none"))
+	fmt.Fprintf(w, "Zone:\t%s\n", orDefault(conf.Zone, "none"))
+	fmt.Fprintf(w, "Relay Agent:\t%s\n", orDefault(conf.RelayAgentImage, "disabled"))
+	fmt.Fprintf(w, "DNS Address:\t%s\n", orDefault(conf.DnsAddress, "disabled"))
+	fmt.Fprintf(w, "Metrics Address:\t%s\n", orDefault(conf.MetricsAddress, "disabled"))
+	fmt.Fprintf(w, "Audit Log:\t%s\n", orDefault(conf.AuditLog, "disabled"))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(conf.Config) != "{}" && conf.Config != "" {
+		fmt.Fprintf(out, "\nConfiguration File:\n%s", conf.Config)
+	}
+	return nil
+}
+
+func NewConfigCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "config",
+		Description: "Work with the configuration file",
+		Usage:       "config",
+		Subcommands: []*cli.Command{
+			{
+				Name: "validate",
+				Description: "Check the configuration file, --ip-cidr, and the kubeconfig context for problems, " +
+					"without changing anything or talking to the cluster",
+				Usage: "config validate",
+				Action: func(c *cli.Context) error {
+					path := c.String("config")
+					optional := path == ""
+					if optional {
+						var err error
+						if path, err = config.DefaultPath(); err != nil {
+							return err
+						}
+					}
+
+					problems, err := validateConfigFile(log, path, optional)
+					if err != nil {
+						return err
+					}
+					for _, p := range problems {
+						fmt.Printf("%s: %s\n", path, p.Error())
+					}
+
+					// the same checks the daemon does before starting
+					found := len(problems)
+					if _, err := ippool.Select(log, c.String("ip-cidr")); err != nil {
+						fmt.Printf("--ip-cidr: %v\n", err)
+						found++
+					}
+					if !c.Bool("in-cluster") {
+						if err := kube.CheckContext(c.String("context")); err != nil {
+							fmt.Printf("--context: %v\n", err)
+							found++
+						}
+					}
+
+					if found > 0 {
+						return cli.Exit(fmt.Sprintf("found %d problem(s)", found), 1)
+					}
+					log.Info("no problems found")
+					return nil
+				},
+			},
+			{
+				Name:        "view",
+				Description: "Print the configuration the running daemon was started with, from its flags and configuration file",
+				Usage:       "config view",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Output format, one of: text, json",
+						Value:   "text",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+					defer cancel()
+
+					client, closer, err := connectDaemon(ctx, c)
+					if err != nil {
+						return err
+					}
+					defer closer()
+
+					resp, err := client.GetConfig(ctx, &api.Empty{})
+					if err != nil {
+						return errors.Wrap(err, "failed to get the configuration of the daemon")
+					}
+
+					switch c.String("output") {
+					case "text":
+						return writeDaemonConfig(os.Stdout, resp)
+					case "json":
+						b, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(resp)
+						if err != nil {
+							return errors.Wrap(err, "failed to marshal configuration")
+						}
+						fmt.Println(string(b))
+						return nil
+					default:
+						return fmt.Errorf("unknown output format '%s', expected one of: text, json", c.String("output"))
+					}
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// parseServiceHost converts a Kubernetes service hostname, e.g. name.namespace.svc,
+// into its namespace and name. If no namespace is provided, default is used.
+func parseServiceHost(host string) (namespace, name string) {
+	split := strings.Split(host, ".")
+	if len(split) == 1 {
+		return "default", split[0]
+	}
+
+	return split[1], split[0]
+}
+
+func NewCurlCommand(log logrus.FieldLogger) *cli.Command { //nolint:funlen
+	return &cli.Command{
+		Name:        "curl",
+		Description: "Make a HTTP request to a service, resolving it through localizer",
+		Usage:       "curl [options] http://<service>.<namespace>[:port]/path",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "request",
+				Aliases: []string{"X"},
+				Usage:   "HTTP method to use",
+				Value:   http.MethodGet,
+			},
+			&cli.StringSliceFlag{
+				Name:    "header",
+				Aliases: []string{"H"},
+				Usage:   "Header to send, in the format of 'Key: Value'",
+			},
+			&cli.StringFlag{
+				Name:    "data",
+				Aliases: []string{"d"},
+				Usage:   "Request body to send",
+			},
+			&cli.BoolFlag{
+				Name:    "include",
+				Aliases: []string{"i"},
+				Usage:   "Include the response status and headers in the output",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			u, err := url.Parse(c.Args().First())
+			if err != nil {
+				return errors.Wrap(err, "failed to parse url")
+			}
+			if u.Scheme != "http" {
+				return fmt.Errorf("only http urls are supported")
+			}
+
+			port := 80
+			if u.Port() != "" {
+				if port, err = strconv.Atoi(u.Port()); err != nil {
+					return errors.Wrap(err, "invalid port")
+				}
+			}
+
+			namespace, name := parseServiceHost(u.Hostname())
+			addr, closer, err := resolveServiceAddress(c.Context, log, c, namespace, name, port)
+			if err != nil {
+				return err
+			}
+			defer closer()
+			log.WithField("address", addr).Debug("resolved service")
+
+			// send all requests to the resolved address, keeping the original
+			// host header
+			client := &http.Client{
+				Timeout: 30 * time.Second,
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+						return (&net.Dialer{}).DialContext(ctx, network, addr)
+					},
+				},
+			}
+
+			req, err := http.NewRequestWithContext(c.Context, strings.ToUpper(c.String("request")), u.String(), strings.NewReader(c.String("data")))
+			if err != nil {
+				return errors.Wrap(err, "failed to create request")
+			}
+
+			for _, h := range c.StringSlice("header") {
+				split := strings.SplitN(h, ":", 2)
+				if len(split) != 2 {
+					return fmt.Errorf("invalid header '%s', expected 'Key: Value'", h)
+				}
+				req.Header.Add(strings.TrimSpace(split[0]), strings.TrimSpace(split[1]))
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return errors.Wrap(err, "failed to make request")
+			}
+			defer resp.Body.Close()
+
+			if c.Bool("include") {
+				fmt.Printf("%s %s\n", resp.Proto, resp.Status)
+				if err := resp.Header.Write(os.Stdout); err != nil {
+					return err
+				}
+				fmt.Println()
+			}
+
+			_, err = io.Copy(os.Stdout, resp.Body)
+			return err
+		},
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/getoutreach/localizer/pkg/hostsfile"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxLogBytes is the maximum amount of a log file, from the end, that is
+// included in a debug bundle
+const maxLogBytes = 5 * 1024 * 1024
+
+// secretPatterns match values that look like credentials. The first group
+// is kept, and the rest of the match is replaced.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(authorization:\s*\w+\s+)\S+`),
+	regexp.MustCompile(`(?i)(bearer\s+)[\w\-.~+/]+=*`),
+	regexp.MustCompile(`(?i)((?:token|password|passwd|secret|api[_-]?key)["']?\s*[:=]\s*["']?)[^\s"',]+`),
+	regexp.MustCompile(`()AKIA[0-9A-Z]{16}`),
+	regexp.MustCompile(`()-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// scrubSecrets replaces anything that looks like a credential in b
+func scrubSecrets(b []byte) []byte {
+	for _, re := range secretPatterns {
+		b = re.ReplaceAll(b, []byte("${1}[REDACTED]"))
+	}
+	return b
+}
+
+// bundle is a tar.gz archive of diagnostic files
+type bundle struct {
+	tw *tar.Writer
+	gw *gzip.Writer
+
+	prefix string
+}
+
+// add writes a file to the bundle, scrubbing secrets from it
+func (b *bundle) add(name string, contents []byte) error {
+	contents = scrubSecrets(contents)
+	if err := b.tw.WriteHeader(&tar.Header{
+		Name:    filepath.Join(b.prefix, name),
+		Mode:    0600,
+		Size:    int64(len(contents)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	_, err := b.tw.Write(contents)
+	return err
+}
+
+// addJSON writes obj to the bundle as JSON
+func (b *bundle) addJSON(name string, obj interface{}) error {
+	contents, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return err
+	}
+	return b.add(name, contents)
+}
+
+func (b *bundle) Close() error {
+	if err := b.tw.Close(); err != nil {
+		return err
+	}
+	return b.gw.Close()
+}
+
+// recentLogFiles returns the most recently modified, non-empty, localizer
+// log files in the temp directory
+func recentLogFiles(limit int) []string {
+	matches, err := filepath.Glob(filepath.Join(os.TempDir(), "localizer-*.log"))
+	if err != nil {
+		return nil
+	}
+
+	type logFile struct {
+		path    string
+		modTime time.Time
+	}
+	files := []logFile{}
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil || info.Size() == 0 {
+			continue
+		}
+		files = append(files, logFile{m, info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+
+	paths := []string{}
+	for i := 0; i < len(files) && i < limit; i++ {
+		paths = append(paths, files[i].path)
+	}
+	return paths
+}
+
+// tailFile reads up to n bytes from the end of a file
+func tailFile(path string, n int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size() > n {
+		if _, err := f.Seek(-n, io.SeekEnd); err != nil {
+			return nil, err
+		}
+	}
+
+	return ioutil.ReadAll(f)
+}
+
+// hostsBlocks returns the localizer managed blocks of a hosts file
+func hostsBlocks(path string) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	for _, line := range hostsfile.Blocks(b) {
+		out.WriteString(line + "\n")
+	}
+
+	return out.Bytes(), nil
+}
+
+// recentEvents returns the warning events in the given namespaces, newest first
+func recentEvents(ctx context.Context, k kubernetes.Interface, namespaces []string) ([]string, error) {
+	type event struct {
+		t    time.Time
+		line string
+	}
+
+	events := []event{}
+	for _, ns := range namespaces {
+		list, err := k.CoreV1().Events(ns).List(ctx, metav1.ListOptions{FieldSelector: "type=Warning"})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list events in namespace %s", ns)
+		}
+
+		for i := range list.Items {
+			e := &list.Items[i]
+			t := e.LastTimestamp.Time
+			if t.IsZero() {
+				t = e.EventTime.Time
+			}
+			events = append(events, event{t, fmt.Sprintf("%s\t%s/%s %s\t%s\t%s",
+				t.Format(time.RFC3339), e.InvolvedObject.Kind, e.Namespace, e.InvolvedObject.Name, e.Reason, e.Message)})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].t.After(events[j].t) })
+
+	lines := make([]string, len(events))
+	for i := range events {
+		lines[i] = events[i].line
+	}
+	return lines, nil
+}
+
+func NewDebugBundleCommand(log logrus.FieldLogger) *cli.Command { //nolint:funlen,gocyclo
+	return &cli.Command{
+		Name:        "debug-bundle",
+		Description: "Gather logs, state, and version information into a tar.gz to attach to bug reports",
+		Usage:       "debug-bundle",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Path to write the bundle to (default: localizer-debug-<time>.tar.gz)",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithTimeout(c.Context, 2*time.Minute)
+			defer cancel()
+
+			name := "localizer-debug-" + strings.ReplaceAll(time.Now().Format(time.RFC3339), ":", "-")
+			output := c.String("output")
+			if output == "" {
+				output = name + ".tar.gz"
+			}
+
+			f, err := os.Create(output)
+			if err != nil {
+				return errors.Wrap(err, "failed to create bundle")
+			}
+			defer f.Close()
+
+			gw := gzip.NewWriter(f)
+			b := &bundle{tw: tar.NewWriter(gw), gw: gw, prefix: name}
+
+			// failures to gather any one thing are recorded in the bundle,
+			// a partial bundle is better than none
+			problems := []string{}
+			addProblem := func(what string, err error) {
+				log.WithError(err).Warnf("failed to gather %s", what)
+				problems = append(problems, fmt.Sprintf("%s: %v", what, err))
+			}
+
+			if err := b.addJSON("version.json", map[string]string{
+				"version": Version,
+				"go":      runtime.Version(),
+				"os":      runtime.GOOS,
+				"arch":    runtime.GOARCH,
+			}); err != nil {
+				return err
+			}
+
+			flags := make(map[string]string)
+			for _, fl := range c.App.Flags {
+				flagName := fl.Names()[0]
+				flags[flagName] = c.String(flagName)
+			}
+			if err := b.addJSON("config.json", flags); err != nil {
+				return err
+			}
+
+			namespaces := []string{}
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				addProblem("daemon state", err)
+			} else {
+				defer closer()
+
+				if resp, err := listServices(ctx, client, &api.ListRequest{}); err != nil {
+					addProblem("services", err)
+				} else {
+					seen := make(map[string]bool)
+					for _, s := range resp.Services {
+						if !seen[s.Namespace] {
+							seen[s.Namespace] = true
+							namespaces = append(namespaces, s.Namespace)
+						}
+					}
+					if err := b.addJSON("services.json", resp); err != nil {
+						return err
+					}
+				}
+
+				if resp, err := client.GetRuntimeStats(ctx, &api.GetRuntimeStatsRequest{GoroutineDump: true}); err != nil {
+					addProblem("runtime stats", err)
+				} else {
+					if err := b.add("goroutines.txt", []byte(resp.GoroutineDump)); err != nil {
+						return err
+					}
+					resp.GoroutineDump = ""
+					if err := b.addJSON("runtime-stats.json", resp); err != nil {
+						return err
+					}
+				}
+			}
+
+			// everything below is read from this machine, which isn't
+			// where the daemon is when using --remote
+			if c.String("remote") == "" {
+				if contents, err := hostsBlocks(c.String("hosts-file")); err != nil {
+					addProblem("hosts file", err)
+				} else if err := b.add("hosts", contents); err != nil {
+					return err
+				}
+
+				for _, path := range recentLogFiles(5) {
+					contents, err := tailFile(path, maxLogBytes)
+					if err != nil {
+						addProblem(path, err)
+						continue
+					}
+					if err := b.add(filepath.Join("logs", filepath.Base(path)), contents); err != nil {
+						return err
+					}
+				}
+
+				_, k, err := kube.GetKubeClient(log, c.String("context"))
+				if c.Bool("in-cluster") {
+					_, k, err = kube.GetInClusterKubeClient()
+				}
+				if err != nil {
+					addProblem("events", err)
+				} else if events, err := recentEvents(ctx, k, namespaces); err != nil {
+					addProblem("events", err)
+				} else if err := b.add("events.txt", []byte(strings.Join(events, "\n"))); err != nil {
+					return err
+				}
+			}
+
+			if len(problems) != 0 {
+				if err := b.add("problems.txt", []byte(strings.Join(problems, "\n"))); err != nil {
+					return err
+				}
+			}
+
+			if err := b.Close(); err != nil {
+				return errors.Wrap(err, "failed to write bundle")
+			}
+
+			log.Infof("wrote debug bundle to %s, please check it for anything sensitive before sharing it", output)
+			return nil
+		},
+	}
+}
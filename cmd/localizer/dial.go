@@ -0,0 +1,67 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func NewDialCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "dial",
+		Description: "Open a connection to a service and pipe it to stdin/stdout, like netcat",
+		Usage:       "dial <namespace/service>:<port>",
+		Action: func(c *cli.Context) error {
+			namespace, name, port, err := parseServicePort(qualifyService(c, c.Args().First()))
+			if err != nil {
+				return err
+			}
+
+			addr, closer, err := resolveServiceAddress(c.Context, log, c, namespace, name, port)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			log.WithField("address", addr).Debug("dialing service")
+			conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+			if err != nil {
+				return errors.Wrap(err, "failed to dial service")
+			}
+			defer conn.Close()
+
+			// send stdin until it's closed, then signal that we're done writing
+			go func() {
+				if _, err := io.Copy(conn, os.Stdin); err != nil {
+					log.WithError(err).Debug("failed to copy stdin")
+				}
+
+				if tcpConn, ok := conn.(*net.TCPConn); ok {
+					tcpConn.CloseWrite() //nolint:errcheck // Why: Best effort
+				}
+			}()
+
+			// the connection is done when the remote stops sending us data
+			_, err = io.Copy(os.Stdout, conn)
+			return err
+		},
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/getoutreach/localizer/pkg/proxier"
+)
+
+// writePlan writes the port-forwards the daemon would create as a table,
+// followed by a summary of them
+func writePlan(out io.Writer, plan []proxier.PlannedForward) error {
+	w := tabwriter.NewWriter(out, 10, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "NAMESPACE\tNAME\tPRIORITY\tIP ADDRESS\tPORT(S)\tHOSTNAMES\tREASON\t\n")
+
+	waiting := 0
+	for i := range plan {
+		pf := &plan[i]
+
+		ip := "none"
+		if pf.IP != nil {
+			ip = pf.IP.String()
+		}
+		if pf.Reason != "" {
+			waiting++
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", pf.Service.Namespace, pf.Service.Name, pf.Priority, ip,
+			strings.Join(pf.Ports, ","), strings.Join(pf.Hostnames, ","), pf.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(out, "\n%d service(s) would be forwarded, %d of them wouldn't be running right away\n",
+		len(plan), waiting)
+	return err
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// groupFlag is the flag of commands that can operate on a group of
+// services instead of a single one
+var groupFlag = &cli.StringFlag{
+	Name:  "group",
+	Usage: "Operate on every service in this group, from the group label or the configuration file",
+}
+
+// setGroupEnabled enables or disables forwarding the services in the group
+// given with --group
+func setGroupEnabled(c *cli.Context, log logrus.FieldLogger, enabled bool) error {
+	ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+	defer cancel()
+
+	client, closer, err := connectDaemon(ctx, c)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	resp, err := client.SetGroupEnabled(ctx, &api.SetGroupEnabledRequest{Group: c.String("group"), Enabled: enabled})
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		log.Infof("enabled forwarding %d service(s) in group %s", len(resp.Services), c.String("group"))
+	} else {
+		log.Infof("disabled forwarding %d service(s) in group %s, they will stay disabled until 'localizer enable' is ran",
+			len(resp.Services), c.String("group"))
+	}
+	return nil
+}
+
+// setServiceEnabled enables or disables forwarding the service given as the
+// first argument, or the services in the group given with --group
+func setServiceEnabled(c *cli.Context, log logrus.FieldLogger, enabled bool) error {
+	if c.String("group") != "" {
+		if c.Args().Present() {
+			return fmt.Errorf("a service can't be given with --group")
+		}
+		return setGroupEnabled(c, log, enabled)
+	}
+
+	split := strings.Split(qualifyService(c, c.Args().First()), "/")
+	if len(split) != 2 {
+		return fmt.Errorf("invalid service, expected namespace/name")
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+	defer cancel()
+
+	client, closer, err := connectDaemon(ctx, c)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	_, err = client.SetServiceEnabled(ctx, &api.SetServiceEnabledRequest{
+		Namespace: split[0],
+		Service:   split[1],
+		Enabled:   enabled,
+	})
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		log.Infof("enabled forwarding %s", c.Args().First())
+	} else {
+		log.Infof("disabled forwarding %s, it will stay disabled until 'localizer enable' is ran", c.Args().First())
+	}
+	return nil
+}
+
+func NewDisableCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "disable",
+		Aliases:     []string{"stop"},
+		Description: "Stop forwarding a service, or a group of them, until 'localizer enable' (or 'start') is ran",
+		Usage:       "disable <namespace/service>|--group <group>, kept across restarts of the daemon",
+		Flags:       []cli.Flag{groupFlag},
+		Action: func(c *cli.Context) error {
+			return setServiceEnabled(c, log, false)
+		},
+	}
+}
+
+func NewEnableCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "enable",
+		Aliases:     []string{"start"},
+		Description: "Start forwarding a service, or a group of them, that was disabled with 'localizer disable' (or 'stop')",
+		Usage:       "enable <namespace/service>|--group <group>, kept across restarts of the daemon",
+		Flags:       []cli.Flag{groupFlag},
+		Action: func(c *cli.Context) error {
+			return setServiceEnabled(c, log, true)
+		},
+	}
+}
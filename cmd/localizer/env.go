@@ -0,0 +1,138 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// redacted replaces the values of secrets in the env command's output
+const redacted = "<redacted>"
+
+// plainEnvValue matches values that don't need to be quoted in a dotenv
+// file
+var plainEnvValue = regexp.MustCompile(`^[A-Za-z0-9_./:@,+=-]*$`)
+
+// redactEnv returns a copy of env with the values of the given variables
+// replaced
+func redactEnv(env map[string]string, secretKeys []string) map[string]string {
+	redactedEnv := make(map[string]string, len(env))
+	for k, v := range env {
+		redactedEnv[k] = v
+	}
+	for _, k := range secretKeys {
+		if _, ok := redactedEnv[k]; ok {
+			redactedEnv[k] = redacted
+		}
+	}
+	return redactedEnv
+}
+
+// writeEnv writes an environment as a dotenv file, sorted by name
+func writeEnv(out io.Writer, env map[string]string) {
+	names := make([]string, 0, len(env))
+	for k := range env {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		v := env[k]
+		if !plainEnvValue.MatchString(v) {
+			v = strconv.Quote(v)
+		}
+		fmt.Fprintf(out, "%s=%s\n", k, v)
+	}
+}
+
+func NewEnvCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name: "env",
+		Description: "Print the environment of a pod backing a service as a dotenv file. Values that come from secrets " +
+			"are redacted, so the output can be shared, unless --show-secrets is passed",
+		Usage:     "env <namespace/service>",
+		ArgsUsage: "<namespace/service>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "show-secrets",
+				Usage: "Print the values of variables that come from secrets",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output format, one of: dotenv, json",
+				Value:   "dotenv",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			split := strings.Split(qualifyService(c, c.Args().First()), "/")
+			if len(split) != 2 {
+				return fmt.Errorf("invalid service, expected namespace/name")
+			}
+
+			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			resp, err := client.GetServiceEnv(ctx, &api.GetServiceEnvRequest{
+				Namespace:   split[0],
+				Service:     split[1],
+				ShowSecrets: c.Bool("show-secrets"),
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to get the environment of the service")
+			}
+
+			env := resp.Env
+			if !c.Bool("show-secrets") {
+				// the daemon redacts these itself, but older ones don't
+				env = redactEnv(env, resp.SecretKeys)
+				if len(resp.SecretKeys) > 0 {
+					log.Infof("redacted %d variable(s) from secrets, pass --show-secrets to include them", len(resp.SecretKeys))
+				}
+			}
+
+			switch c.String("output") {
+			case "dotenv":
+				writeEnv(os.Stdout, env)
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return errors.Wrap(enc.Encode(env), "failed to write environment")
+			default:
+				return fmt.Errorf("unknown output format '%s', expected one of: dotenv, json", c.String("output"))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/getoutreach/localizer/api"
+)
+
+// resolvEntry is an IP and the hostnames that resolve to it
+type resolvEntry struct {
+	IP        string
+	Hostnames []string
+}
+
+// resolvFormats are the formats export-resolv can write, keyed by name,
+// along with the name of the file they're written to with --dir
+var resolvFormats = map[string]struct {
+	file  string
+	write func(io.Writer, []resolvEntry) error
+}{
+	"hosts":   {"hosts", writeResolvHosts},
+	"coredns": {"Corefile", writeResolvCoreDNS},
+	"dnsmasq": {"dnsmasq.conf", writeResolvDnsmasq},
+}
+
+// resolvEntries returns the hostnames of every service with an IP, sorted
+// by IP
+func resolvEntries(services []*api.ListService) []resolvEntry {
+	entries := []resolvEntry{}
+	for _, s := range services {
+		if s.Ip == "" || len(s.Hostnames) == 0 {
+			continue
+		}
+		entries = append(entries, resolvEntry{IP: s.Ip, Hostnames: s.Hostnames})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].IP < entries[j].IP })
+	return entries
+}
+
+// writeResolvHosts writes entries in /etc/hosts format
+func writeResolvHosts(w io.Writer, entries []resolvEntry) error {
+	fmt.Fprintln(w, "# Generated by localizer export-resolv")
+	for _, e := range entries {
+		if _, err := fmt.Fprintf(w, "%s %s\n", e.IP, strings.Join(e.Hostnames, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeResolvCoreDNS writes a Corefile that answers for entries, and
+// forwards everything else to the upstream resolvers
+func writeResolvCoreDNS(w io.Writer, entries []resolvEntry) error {
+	fmt.Fprintln(w, "# Generated by localizer export-resolv")
+	fmt.Fprintln(w, ". {")
+	fmt.Fprintln(w, "    hosts {")
+	for _, e := range entries {
+		fmt.Fprintf(w, "        %s %s\n", e.IP, strings.Join(e.Hostnames, " "))
+	}
+	fmt.Fprintln(w, "        fallthrough")
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w, "    forward . /etc/resolv.conf")
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// writeResolvDnsmasq writes entries as dnsmasq host-records
+func writeResolvDnsmasq(w io.Writer, entries []resolvEntry) error {
+	fmt.Fprintln(w, "# Generated by localizer export-resolv")
+	for _, e := range entries {
+		if _, err := fmt.Fprintf(w, "host-record=%s,%s\n", strings.Join(e.Hostnames, ","), e.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeResolvDir writes every format to its file in dir
+func writeResolvDir(dir string, entries []resolvEntry) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "failed to create output directory")
+	}
+
+	for _, f := range resolvFormats {
+		var b strings.Builder
+		if err := f.write(&b, entries); err != nil {
+			return err
+		}
+
+		// written to a temporary file first so that containers watching
+		// the file never see a partial one
+		path := filepath.Join(dir, f.file)
+		if err := ioutil.WriteFile(path+".tmp", []byte(b.String()), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", path)
+		}
+		if err := os.Rename(path+".tmp", path); err != nil {
+			return errors.Wrapf(err, "failed to write %s", path)
+		}
+	}
+
+	return nil
+}
+
+func NewExportResolvCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name: "export-resolv",
+		Description: "Write the hostnames of forwarded services, and their IPs, in formats that containers and VMs can " +
+			"be pointed at: an /etc/hosts file, a CoreDNS Corefile, or a dnsmasq configuration",
+		Usage: "export-resolv [--format hosts|coredns|dnsmasq] [--dir <directory>]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Format to write to stdout, one of: hosts, coredns, dnsmasq",
+				Value: "hosts",
+			},
+			&cli.StringFlag{
+				Name:  "dir",
+				Usage: "Write every format to this directory (hosts, Corefile, and dnsmasq.conf) instead of stdout",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			format, ok := resolvFormats[c.String("format")]
+			if !ok {
+				return fmt.Errorf("unknown format '%s', expected one of: hosts, coredns, dnsmasq", c.String("format"))
+			}
+
+			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			resp, err := listServices(ctx, client, &api.ListRequest{})
+			if err != nil {
+				return err
+			}
+			entries := resolvEntries(resp.Services)
+
+			if dir := c.String("dir"); dir != "" {
+				if err := writeResolvDir(dir, entries); err != nil {
+					return err
+				}
+				log.Infof("wrote %d entries to %s", len(entries), dir)
+				return nil
+			}
+
+			return format.write(os.Stdout, entries)
+		},
+	}
+}
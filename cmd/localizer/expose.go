@@ -15,37 +15,70 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"io"
+	"os"
+	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/internal/expose"
+	"github.com/getoutreach/localizer/internal/kube"
 	"github.com/getoutreach/localizer/pkg/localizer"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
-	"google.golang.org/grpc"
 )
 
 func NewExposeCommand(log logrus.FieldLogger) *cli.Command { //nolint:funlen
 	return &cli.Command{
 		Name:        "expose",
 		Description: "Expose ports for a given service to Kubernetes",
-		Usage:       "expose <namespace/service>",
+		Usage:       "expose <namespace/service> [-- command...]",
 		Flags: []cli.Flag{
 			&cli.StringSliceFlag{
 				Name:  "map",
-				Usage: "Map a local port to a remote port, i.e --map 80:8080 will bind what is normally :8080 to :80 locally",
+				Usage: "Map a local port, or range, to a remote one, i.e --map 80:8080 will bind what is normally :8080 to :80 locally",
+			},
+			&cli.StringSliceFlag{
+				Name: "target-port",
+				Usage: "Use a container port for a service port whose targetPort can't be resolved, " +
+					"i.e --target-port http=8080, instead of being asked for it",
+			},
+			&cli.StringSliceFlag{
+				Name:  "annotate",
+				Usage: "Set an annotation on the service, and the controllers that are scaled down, while it's exposed, i.e --annotate key=value",
+			},
+			&cli.BoolFlag{
+				Name: "env-from",
+				Usage: "Run the given command with the environment of the service's pods once it's exposed, " +
+					"and stop exposing the service when it exits",
+			},
+			&cli.BoolFlag{
+				Name: "mirror",
+				Usage: "Send a copy of the traffic the service's pods get to the local service, without scaling them down. " +
+					"Responses are still served by the pods",
+			},
+			&cli.BoolFlag{
+				Name:  "log-connections",
+				Usage: "Log every connection the cluster makes to the service in the daemon's logs",
 			},
 			&cli.BoolFlag{
 				Name:  "stop",
 				Usage: "stop exposing a service",
 			},
+			&cli.BoolFlag{
+				Name: "repair",
+				Usage: "Undo the changes an expose of the service made to the cluster, e.g. scaling it down, " +
+					"if localizer died without undoing them. Doesn't need the daemon to be running",
+			},
 		},
 		Action: func(c *cli.Context) error {
-			split := strings.Split(c.Args().First(), "/")
+			split := strings.Split(qualifyService(c, c.Args().First()), "/")
 			if len(split) != 2 {
 				return fmt.Errorf("invalid service, expected namespace/name")
 			}
@@ -53,21 +86,100 @@ func NewExposeCommand(log logrus.FieldLogger) *cli.Command { //nolint:funlen
 			serviceNamespace := split[0]
 			serviceName := split[1]
 
-			if !localizer.IsRunning() {
-				return fmt.Errorf("localizer daemon not running (run localizer by itself?)")
+			if c.Bool("repair") {
+				return repairExpose(c, log, serviceNamespace, serviceName)
 			}
 
+			// flag parsing stops at the service, so the -- separating the
+			// command from it is still in the arguments
+			command := c.Args().Tail()
+			if len(command) > 0 && command[0] == "--" {
+				command = command[1:]
+			}
+			if c.Bool("env-from") && len(command) == 0 {
+				return fmt.Errorf("--env-from requires a command, e.g. expose --env-from %s -- make run", c.Args().First())
+			}
+
+			annotations := make(map[string]string)
+			for _, a := range c.StringSlice("annotate") {
+				spl := strings.SplitN(a, "=", 2)
+				if len(spl) != 2 || spl[0] == "" {
+					return fmt.Errorf("invalid annotation '%s', expected 'key=value'", a)
+				}
+				annotations[spl[0]] = spl[1]
+			}
+
+			targetPorts := make(map[string]uint32)
+			for _, tp := range c.StringSlice("target-port") {
+				spl := strings.SplitN(tp, "=", 2)
+				if len(spl) != 2 || spl[0] == "" {
+					return fmt.Errorf("invalid target port '%s', expected 'port=containerPort'", tp)
+				}
+				port, err := strconv.ParseUint(spl[1], 10, 16)
+				if err != nil || port == 0 {
+					return fmt.Errorf("invalid target port '%s', expected 'port=containerPort'", tp)
+				}
+				targetPorts[spl[0]] = uint32(port)
+			}
+
+			// the daemon is given 30 seconds to look up the service, and
+			// then another 30 seconds to expose it, so the time spent
+			// picking ports doesn't count against it
 			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
 			defer cancel()
 
 			log.Info("connecting to localizer daemon")
 
-			client, closer, err := localizer.Connect(ctx, grpc.WithBlock(), grpc.WithInsecure())
+			client, closer, err := connectDaemon(ctx, c)
 			if err != nil {
-				return errors.Wrap(err, "failed to connect to localizer daemon")
+				return err
 			}
 			defer closer()
 
+			// the pods are read before exposing, since they might be scaled
+			// down by it
+			var env *api.GetServiceEnvResponse
+			if c.Bool("env-from") {
+				// the command is ran with the real values of secrets
+				env, err = client.GetServiceEnv(ctx, &api.GetServiceEnvRequest{
+					Namespace:   serviceNamespace,
+					Service:     serviceName,
+					ShowSecrets: true,
+				})
+				if err != nil {
+					return errors.Wrap(err, "failed to get the environment of the service")
+				}
+				log.Infof("using the environment of pod %s", env.Pod)
+			}
+
+			if !c.Bool("stop") {
+				ports, err := client.GetExposePorts(ctx, &api.GetExposePortsRequest{
+					Namespace: serviceNamespace,
+					Service:   serviceName,
+				})
+				if err != nil {
+					return errors.Wrap(err, "failed to get the ports of the service")
+				}
+
+				if err := pickTargetPorts(os.Stdin, os.Stderr, ports.Ports, targetPorts); err != nil {
+					return err
+				}
+
+				if len(c.StringSlice("map")) == 0 {
+					for _, p := range ports.Ports {
+						local, ok := targetPorts[exposePortKey(p)]
+						if !ok {
+							local = p.Candidates[0]
+						}
+						log.Infof("port %s will be sent to 127.0.0.1:%d, use --map to change it", exposePortKey(p), local)
+					}
+				}
+			}
+
+			cancel()
+			ctx, cancel = context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+
 			var stream api.LocalizerService_ExposeServiceClient
 			if c.Bool("stop") {
 				log.Info("sending stop expose request to daemon")
@@ -78,9 +190,13 @@ func NewExposeCommand(log logrus.FieldLogger) *cli.Command { //nolint:funlen
 			} else {
 				log.Info("sending expose request to daemon")
 				stream, err = client.ExposeService(ctx, &api.ExposeServiceRequest{
-					PortMap:   c.StringSlice("map"),
-					Namespace: serviceNamespace,
-					Service:   serviceName,
+					PortMap:        c.StringSlice("map"),
+					Namespace:      serviceNamespace,
+					Service:        serviceName,
+					Annotations:    annotations,
+					TargetPorts:    targetPorts,
+					LogConnections: c.Bool("log-connections"),
+					Mirror:         c.Bool("mirror"),
 				})
 			}
 			if err != nil {
@@ -90,7 +206,7 @@ func NewExposeCommand(log logrus.FieldLogger) *cli.Command { //nolint:funlen
 			for {
 				res, err := stream.Recv()
 				if err == io.EOF {
-					return nil
+					break
 				} else if err != nil {
 					return err
 				}
@@ -106,6 +222,208 @@ func NewExposeCommand(log logrus.FieldLogger) *cli.Command { //nolint:funlen
 
 				logger(res.Message)
 			}
+
+			if env == nil {
+				return nil
+			}
+			return runExposed(c.Context, log, client, serviceNamespace, serviceName, env, command)
 		},
 	}
 }
+
+// isExposed returns if a service's mode means it's being exposed
+func isExposed(mode api.ServiceMode) bool {
+	switch mode {
+	case api.ServiceMode_SERVICE_MODE_EXPOSED, api.ServiceMode_SERVICE_MODE_INTERCEPTED, api.ServiceMode_SERVICE_MODE_MIRRORED:
+		return true
+	case api.ServiceMode_SERVICE_MODE_UNSPECIFIED, api.ServiceMode_SERVICE_MODE_FORWARDED:
+	}
+	return false
+}
+
+// repairExpose undoes the changes recorded by an expose of a service that
+// didn't clean up after itself. A running daemon that's exposing the
+// service is left alone.
+func repairExpose(c *cli.Context, log logrus.FieldLogger, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(c.Context, 2*time.Minute)
+	defer cancel()
+
+	if localizer.IsRunning() {
+		if client, closer, err := connectDaemon(ctx, c); err == nil {
+			defer closer()
+
+			resp, err := listServices(ctx, client, &api.ListRequest{})
+			if err != nil {
+				return errors.Wrap(err, "failed to check if the daemon is exposing the service")
+			}
+			for _, s := range resp.Services {
+				if s.Namespace == namespace && s.Name == name && isExposed(s.Mode) {
+					return fmt.Errorf("%s/%s is being exposed by the running daemon, use --stop to stop exposing it", namespace, name)
+				}
+			}
+		}
+	}
+
+	kconf, k, err := kube.GetKubeClient(log, c.String("context"))
+	if err != nil {
+		return errors.Wrap(err, "failed to create kube client")
+	}
+
+	repaired, err := expose.NewExposer(k, kconf, log, "").Repair(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	if !repaired {
+		log.Infof("nothing to repair, %s/%s has no changes left behind by an expose", namespace, name)
+		return nil
+	}
+
+	log.Infof("undid the changes made by exposing %s/%s", namespace, name)
+	return nil
+}
+
+// runExposed runs a command with the environment of an exposed service's
+// pods once the daemon has started exposing it, then stops exposing the
+// service when the command exits
+func runExposed(ctx context.Context, log logrus.FieldLogger, client api.LocalizerServiceClient, //nolint:funlen
+	namespace, name string, env *api.GetServiceEnvResponse, command []string) error {
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	log.Info("waiting for the service to be exposed")
+	var svc *api.ListService
+	for svc == nil {
+		resp, err := listServices(waitCtx, client, &api.ListRequest{})
+		if err != nil {
+			return errors.Wrap(err, "failed to list services")
+		}
+		for _, s := range resp.Services {
+			if s.Namespace == namespace && s.Name == name && isExposed(s.Mode) {
+				svc = s
+			}
+		}
+
+		if svc == nil {
+			select {
+			case <-waitCtx.Done():
+				return fmt.Errorf("timed out waiting for %s/%s to be exposed", namespace, name)
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+	}
+
+	// stop exposing the service however the command exits, the context of
+	// the command is canceled on interrupt so it can't be used here
+	defer func() {
+		stopCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		log.Info("stopping expose")
+		stream, err := client.StopExpose(stopCtx, &api.StopExposeRequest{Namespace: namespace, Service: name})
+		if err == nil {
+			// wait for the response, otherwise the request can be canceled
+			// before the daemon handles it
+			_, err = stream.Recv()
+		}
+		if err != nil && err != io.EOF {
+			log.WithError(err).Error("failed to stop exposing service")
+		}
+	}()
+
+	addresses := make([]string, 0, len(svc.LocalTargets))
+	for _, t := range svc.LocalTargets {
+		addresses = append(addresses, t.Address)
+	}
+
+	cmdEnv := os.Environ()
+	for k, v := range env.Env {
+		cmdEnv = append(cmdEnv, k+"="+v)
+	}
+	cmdEnv = append(cmdEnv,
+		"LOCALIZER_NAMESPACE="+namespace,
+		"LOCALIZER_SERVICE="+name,
+		"LOCALIZER_POD="+env.Pod,
+		"LOCALIZER_LOCAL_ADDRESSES="+strings.Join(addresses, ","),
+		"LOCALIZER_INTERCEPTING="+strconv.FormatBool(svc.Mode == api.ServiceMode_SERVICE_MODE_INTERCEPTED),
+	)
+
+	//nolint:gosec // Why: Running the user's command is the point
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = cmdEnv
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	log.Infof("running %s", strings.Join(command, " "))
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return cli.Exit(fmt.Sprintf("command exited with code %d", exitErr.ExitCode()), exitErr.ExitCode())
+	}
+	return errors.Wrap(err, "failed to run command")
+}
+
+// exposePortKey returns the key of a port of a service in the target ports
+// of an ExposeServiceRequest
+func exposePortKey(p *api.ExposePort) string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return strconv.Itoa(int(p.Port))
+}
+
+// pickTargetPorts asks which container port is meant for each port of a
+// service whose targetPort is ambiguous, and isn't in targetPorts already,
+// adding the answers to it
+func pickTargetPorts(in io.Reader, out io.Writer, ports []*api.ExposePort, targetPorts map[string]uint32) error {
+	r := bufio.NewReader(in)
+	for _, p := range ports {
+		key := exposePortKey(p)
+		if _, ok := targetPorts[key]; ok || len(p.Candidates) == 1 {
+			continue
+		}
+
+		if len(p.Candidates) == 0 {
+			fmt.Fprintf(out, "\nNo container declares targetPort '%s' of port %s.\n", p.TargetPort, key)
+		} else {
+			fmt.Fprintf(out, "\ntargetPort '%s' of port %s could be any of:\n", p.TargetPort, key)
+			for i, c := range p.Candidates {
+				fmt.Fprintf(out, "  %d) %d\n", i+1, c)
+			}
+		}
+
+		for {
+			fmt.Fprint(out, "Container port to use: ")
+			line, err := r.ReadString('\n')
+			line = strings.TrimSpace(line)
+
+			if port, ok := parsePortChoice(line, p.Candidates); ok {
+				targetPorts[key] = port
+				break
+			}
+			if err != nil {
+				// the input was closed, e.g. it's not a terminal
+				fmt.Fprintln(out)
+				return fmt.Errorf("couldn't tell which container port targetPort '%s' of port %s refers to, "+
+					"pass it with --target-port %s=<port>", p.TargetPort, key, key)
+			}
+			fmt.Fprintf(out, "invalid port '%s'\n", line)
+		}
+	}
+
+	return nil
+}
+
+// parsePortChoice parses the answer to which container port to use, the
+// number of one of candidates or a port
+func parsePortChoice(s string, candidates []uint32) (uint32, bool) {
+	n, err := strconv.ParseUint(s, 10, 16)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+
+	if n <= uint64(len(candidates)) {
+		return candidates[n-1], true
+	}
+	return uint32(n), true
+}
@@ -0,0 +1,143 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func NewForwardCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "forward",
+		Description: "Forward ports of a specific pod, e.g. the leader of a StatefulSet, or a host only reachable from the cluster",
+		Usage:       "forward pod/<namespace>/<name> | tcp/<host>:<port>",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "ports",
+				Usage: "Ports to forward, i.e --ports 8080 or --ports 9000:8080 to bind the pod's :8080 to :9000 locally",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Namespace to run the proxy pod in when forwarding tcp/<host>:<port>",
+				Value: "default",
+			},
+			&cli.BoolFlag{
+				Name:  "stop",
+				Usage: "stop forwarding a pod",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+
+			if strings.HasPrefix(c.Args().First(), "tcp/") {
+				return forwardTCP(ctx, c, log)
+			}
+
+			split := strings.Split(c.Args().First(), "/")
+			if len(split) != 3 || split[0] != "pod" {
+				return fmt.Errorf("invalid target, expected pod/namespace/name or tcp/host:port")
+			}
+			namespace, name := split[1], split[2]
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			if c.Bool("stop") {
+				_, err = client.StopForwardPod(ctx, &api.StopForwardPodRequest{Namespace: namespace, Name: name})
+				if err != nil {
+					return err
+				}
+
+				log.Infof("stopped forwarding %s", c.Args().First())
+				return nil
+			}
+
+			if len(c.StringSlice("ports")) == 0 {
+				return fmt.Errorf("at least one port is required, i.e --ports 8080")
+			}
+
+			_, err = client.ForwardPod(ctx, &api.ForwardPodRequest{
+				Namespace: namespace,
+				Name:      name,
+				Ports:     c.StringSlice("ports"),
+			})
+			if err != nil {
+				return err
+			}
+
+			log.Infof("forwarding %s, it's available at %s.%s.pod", c.Args().First(), name, namespace)
+			return nil
+		},
+	}
+}
+
+// forwardTCP forwards to, or stops forwarding to, the tcp/<host>:<port>
+// given as the first argument
+func forwardTCP(ctx context.Context, c *cli.Context, log logrus.FieldLogger) error {
+	host, portStr, err := net.SplitHostPort(strings.TrimPrefix(c.Args().First(), "tcp/"))
+	if err != nil {
+		return fmt.Errorf("invalid target, expected tcp/host:port")
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || host == "" {
+		return fmt.Errorf("invalid target, expected tcp/host:port")
+	}
+
+	client, closer, err := connectDaemon(ctx, c)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	if c.Bool("stop") {
+		_, err = client.StopForwardTCP(ctx, &api.StopForwardTCPRequest{
+			Namespace: c.String("namespace"),
+			Host:      host,
+			Port:      uint32(port),
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Infof("stopped forwarding %s", c.Args().First())
+		return nil
+	}
+
+	_, err = client.ForwardTCP(ctx, &api.ForwardTCPRequest{
+		Namespace: c.String("namespace"),
+		Host:      host,
+		Port:      uint32(port),
+		Ports:     c.StringSlice("ports"),
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("forwarding %s through a proxy pod in %s, it will be available once the pod is running", c.Args().First(),
+		c.String("namespace"))
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func NewGCCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name: "gc",
+		Description: "Show, or change, how a running daemon checks for forwarded services that were deleted without it " +
+			"noticing, without restarting it",
+		Usage: "gc [--enable|--disable] [--interval <duration>] [--grace-period <duration>]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "enable",
+				Usage: "Start checking for deleted services",
+			},
+			&cli.BoolFlag{
+				Name:  "disable",
+				Usage: "Stop checking for deleted services",
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Usage: "How often to check for deleted services",
+			},
+			&cli.DurationFlag{
+				Name:  "grace-period",
+				Usage: "How long a service has to be missing for before its tunnel is removed",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Bool("enable") && c.Bool("disable") {
+				return fmt.Errorf("--enable and --disable can't be used together")
+			}
+
+			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			opts, err := client.GetGCOptions(ctx, &api.Empty{})
+			if err != nil {
+				return err
+			}
+
+			changed := false
+			if c.Bool("enable") || c.Bool("disable") {
+				opts.Enabled, changed = c.Bool("enable"), true
+			}
+			if c.IsSet("interval") {
+				opts.IntervalSeconds, changed = int64(c.Duration("interval").Seconds()), true
+			}
+			if c.IsSet("grace-period") {
+				opts.GracePeriodSeconds, changed = int64(c.Duration("grace-period").Seconds()), true
+			}
+
+			if changed {
+				opts, err = client.SetGCOptions(ctx, opts)
+				if err != nil {
+					return err
+				}
+			}
+
+			state := "disabled"
+			if opts.Enabled {
+				state = "enabled"
+			}
+			log.Infof("checks for deleted services are %s, every %s with a grace period of %s", state,
+				time.Duration(opts.IntervalSeconds)*time.Second, time.Duration(opts.GracePeriodSeconds)*time.Second)
+			return nil
+		},
+	}
+}
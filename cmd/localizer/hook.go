@@ -0,0 +1,152 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// hookService is a service in the output of 'hook --json'
+type hookService struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Status    string   `json:"status"`
+	Reason    string   `json:"reason,omitempty"`
+	IP        string   `json:"ip,omitempty"`
+	Ports     []string `json:"ports,omitempty"`
+	Hostnames []string `json:"hostnames,omitempty"`
+}
+
+// hookResult is the output of 'hook --json'
+type hookResult struct {
+	Ready    bool          `json:"ready"`
+	Services []hookService `json:"services"`
+}
+
+// readHookServices reads the services given to hook, a JSON array of
+// strings if asJSON is set, otherwise whitespace separated
+func readHookServices(r io.Reader, asJSON bool) ([]string, error) {
+	if asJSON {
+		var services []string
+		if err := json.NewDecoder(r).Decode(&services); err != nil {
+			return nil, errors.Wrap(err, "failed to parse services, expected a JSON array of strings")
+		}
+		return services, nil
+	}
+
+	services := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	for scanner.Scan() {
+		services = append(services, scanner.Text())
+	}
+	return services, errors.Wrap(scanner.Err(), "failed to read services")
+}
+
+func NewHookCommand() *cli.Command {
+	return &cli.Command{
+		Name: "hook",
+		Description: "Ensure services, read from stdin, are forwarded, waiting until their port-forwards are running. " +
+			"Meant to be called by tools like Tilt or Skaffold after they deploy services. Exits non-zero if any " +
+			"service isn't forwarded in time.",
+		Usage: "hook [--json] < services",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Read services as a JSON array of strings and write the result as JSON",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "How long to wait for the port-forwards to be running",
+				Value: 2 * time.Minute,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			asJSON := c.Bool("json")
+			services, err := readHookServices(os.Stdin, asJSON)
+			if err != nil {
+				return err
+			}
+			if len(services) == 0 {
+				return fmt.Errorf("no services were given on stdin")
+			}
+			for i := range services {
+				services[i] = qualifyService(c, services[i])
+			}
+
+			timeout := c.Duration("timeout")
+			ctx, cancel := context.WithTimeout(c.Context, timeout+30*time.Second)
+			defer cancel()
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			resp, err := client.EnsureForwarded(ctx, &api.EnsureForwardedRequest{
+				Services:       services,
+				TimeoutSeconds: int64(timeout / time.Second),
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to ensure services are forwarded")
+			}
+
+			if asJSON {
+				result := hookResult{Ready: resp.Ready, Services: make([]hookService, len(resp.Services))}
+				for i, s := range resp.Services {
+					result.Services[i] = hookService{
+						Namespace: s.Namespace,
+						Name:      s.Name,
+						Status:    s.Status,
+						Reason:    s.StatusReason,
+						IP:        s.Ip,
+						Ports:     s.Ports,
+						Hostnames: s.Hostnames,
+					}
+				}
+				if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+					return errors.Wrap(err, "failed to write result")
+				}
+			} else {
+				for _, s := range resp.Services {
+					line := fmt.Sprintf("%s/%s: %s", s.Namespace, s.Name, s.Status)
+					if s.Ip != "" {
+						line += fmt.Sprintf(" %s (%s)", s.Ip, strings.Join(s.Ports, ", "))
+					}
+					if s.StatusReason != "" {
+						line += ", " + s.StatusReason
+					}
+					fmt.Println(line)
+				}
+			}
+
+			if !resp.Ready {
+				return cli.Exit("", 1)
+			}
+			return nil
+		},
+	}
+}
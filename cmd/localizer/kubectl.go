@@ -0,0 +1,101 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// kubectlPluginName is the name of the binary when it's installed as a
+// kubectl plugin, e.g. by krew, so that it's ran by kubectl localizer
+const kubectlPluginName = "kubectl-localizer"
+
+// isKubectlPlugin returns if the binary, arg0, was ran as a kubectl plugin
+func isKubectlPlugin(arg0 string) bool {
+	return strings.TrimSuffix(filepath.Base(arg0), ".exe") == kubectlPluginName
+}
+
+// kubectlPluginArgs rewrites the arguments kubectl passes to us as a
+// plugin. kubectl users put --context, --kubeconfig and --namespace (-n)
+// after the command, e.g. kubectl localizer list -n default, but they're
+// our global flags so they're moved in front of it, unless the command has
+// a flag of its own with the same name.
+func kubectlPluginArgs(app *cli.App, args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+
+	var cmd *cli.Command
+	global := []string{args[0]}
+	rest := []string{}
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		name, value, hasValue := splitFlag(arg)
+		switch name {
+		case "context", "kubeconfig", "namespace", "n":
+			if cmd != nil && !hasFlag(cmd, name) {
+				global = append(global, strings.SplitN(arg, "=", 2)[0])
+				if hasValue {
+					global = append(global, value)
+				} else if i+1 < len(args) {
+					i++
+					global = append(global, args[i])
+				}
+				continue
+			}
+		case "":
+			if cmd == nil {
+				cmd = app.Command(arg)
+			}
+		}
+
+		rest = append(rest, arg)
+	}
+
+	return append(global, rest...)
+}
+
+// splitFlag returns the name of a flag, and its value if it was given with
+// =, e.g. --namespace=default. name is empty if arg isn't a flag.
+func splitFlag(arg string) (name, value string, hasValue bool) {
+	if !strings.HasPrefix(arg, "-") || arg == "-" {
+		return "", "", false
+	}
+
+	name = strings.TrimLeft(arg, "-")
+	if idx := strings.Index(name, "="); idx != -1 {
+		return name[:idx], name[idx+1:], true
+	}
+	return name, "", false
+}
+
+// hasFlag returns if a command has a flag with the given name, or alias
+func hasFlag(cmd *cli.Command, name string) bool {
+	for _, f := range cmd.Flags {
+		for _, n := range f.Names() {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
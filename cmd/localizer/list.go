@@ -17,71 +17,530 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/getoutreach/localizer/api"
-	"github.com/getoutreach/localizer/pkg/localizer"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
-	"google.golang.org/grpc"
 )
 
-func NewListCommand(_ logrus.FieldLogger) *cli.Command { //nolint:funlen
+// modeString returns a human readable version of a service's mode
+func modeString(mode api.ServiceMode) string {
+	switch mode {
+	case api.ServiceMode_SERVICE_MODE_EXPOSED:
+		return "Exposed"
+	case api.ServiceMode_SERVICE_MODE_INTERCEPTED:
+		return "Intercepted"
+	case api.ServiceMode_SERVICE_MODE_MIRRORED:
+		return "Mirrored"
+	case api.ServiceMode_SERVICE_MODE_FORWARDED, api.ServiceMode_SERVICE_MODE_UNSPECIFIED:
+	}
+
+	return "Forwarded"
+}
+
+// protocolString returns a human readable version of a port's protocol.
+// Protocols that haven't been seen on a connection yet are marked with
+// a *, they're only what the service says the port speaks.
+func protocolString(p *api.PortProtocol) string {
+	var name string
+	switch p.Protocol {
+	case "":
+		return "?"
+	case "http/1.1":
+		name = "HTTP/1.1"
+	case "http2":
+		name = "HTTP/2"
+	case "grpc":
+		name = "gRPC"
+	default:
+		name = strings.ToUpper(p.Protocol)
+	}
+
+	if !p.Detected {
+		name += "*"
+	}
+	return name
+}
+
+// listColumn is a column that can be displayed by the list command
+type listColumn struct {
+	// Header is the name of this column in the table header
+	Header string
+
+	// Field is the name of the field this column displays, used
+	// by custom-columns
+	Field string
+
+	// Value returns the value of this column for a given service
+	Value func(s *api.ListService) string
+}
+
+// listColumns are the columns shown by default, in order
+var listColumns = []listColumn{
+	{"NAMESPACE", "namespace", func(s *api.ListService) string { return s.Namespace }},
+	{"NAME", "name", func(s *api.ListService) string { return s.Name }},
+	{"MODE", "mode", func(s *api.ListService) string { return modeString(s.Mode) }},
+	{"GROUPS", "groups", func(s *api.ListService) string { return strings.Join(s.Groups, ",") }},
+	{"STATUS", "status", func(s *api.ListService) string {
+		if s.Status == "" {
+			return ""
+		}
+		return strings.ToUpper(s.Status[:1]) + s.Status[1:]
+	}},
+	{"REASON", "statusReason", func(s *api.ListService) string { return s.StatusReason }},
+	{"ENDPOINT", "endpoint", func(s *api.ListService) string { return s.Endpoint }},
+	{"RECREATES", "recreateCount", func(s *api.ListService) string {
+		if len(s.LastRecreations) == 0 {
+			return strconv.Itoa(int(s.RecreateCount))
+		}
+
+		last := time.Unix(s.LastRecreations[len(s.LastRecreations)-1].Time, 0)
+		return fmt.Sprintf("%d (%s ago)", s.RecreateCount, time.Since(last).Round(time.Second))
+	}},
+	{"IP ADDRESS", "ip", func(s *api.ListService) string {
+		if s.Ip == "" {
+			return "None"
+		}
+		return s.Ip
+	}},
+	{"PORT(S)", "ports", func(s *api.ListService) string { return strings.Join(s.Ports, ",") }},
+	{"PROTOCOL(S)", "protocols", func(s *api.ListService) string {
+		protocols := make([]string, len(s.Protocols))
+		for i, p := range s.Protocols {
+			protocols[i] = p.Port + "=" + protocolString(p)
+		}
+		return strings.Join(protocols, ",")
+	}},
+	{"LOCAL TARGET(S)", "localTargets", func(s *api.ListService) string {
+		targets := make([]string, len(s.LocalTargets))
+		for i, t := range s.LocalTargets {
+			targets[i] = t.Address
+			if !t.Reachable {
+				targets[i] += " (unreachable)"
+			}
+		}
+		return strings.Join(targets, ",")
+	}},
+}
+
+// lastRecreationColumn shows when, and why, a service was last recreated.
+// It's only shown by default with --problems.
+var lastRecreationColumn = listColumn{"LAST RECREATE", "lastRecreation", func(s *api.ListService) string {
+	if len(s.LastRecreations) == 0 {
+		return "Never"
+	}
+
+	last := s.LastRecreations[len(s.LastRecreations)-1]
+	return fmt.Sprintf("%s ago: %s", time.Since(time.Unix(last.Time, 0)).Round(time.Second), last.Reason)
+}}
+
+// contextColumn shows the kubeconfig context a service is forwarded from.
+// It's only shown by default when services come from more than one.
+var contextColumn = listColumn{"CONTEXT", "kubeContext", func(s *api.ListService) string { return s.KubeContext }}
+
+// withContextColumn returns columns with contextColumn after the name, if
+// services come from more than one context
+func withContextColumn(columns []listColumn, services []*api.ListService) []listColumn {
+	contexts := make(map[string]bool)
+	for _, s := range services {
+		contexts[s.KubeContext] = true
+	}
+	if len(contexts) < 2 {
+		return columns
+	}
+
+	withContext := make([]listColumn, 0, len(columns)+1)
+	for _, col := range columns {
+		withContext = append(withContext, col)
+		if col.Field == "name" {
+			withContext = append(withContext, contextColumn)
+		}
+	}
+	return withContext
+}
+
+// findColumn returns the column with the given field or header
+func findColumn(field string) (listColumn, bool) {
+	for _, col := range append([]listColumn{lastRecreationColumn, contextColumn}, listColumns...) {
+		if strings.EqualFold(col.Field, field) || strings.EqualFold(col.Header, field) {
+			return col, true
+		}
+	}
+	return listColumn{}, false
+}
+
+// problemColumns are the columns shown by default with --problems
+var problemColumns = func() []listColumn {
+	columns := []listColumn{}
+	for _, field := range []string{"namespace", "name", "status", "statusReason", "lastRecreation", "localTargets"} {
+		col, _ := findColumn(field)
+		columns = append(columns, col)
+	}
+	return columns
+}()
+
+// parseCustomColumns parses a kubectl-like custom-columns spec, e.g.
+// NAME:.name,IP:.ip. The field path may be omitted, e.g. NAME,IP, in which
+// case the header is used to find the column.
+func parseCustomColumns(spec string) ([]listColumn, error) {
+	columns := []listColumn{}
+	for _, entry := range strings.Split(spec, ",") {
+		header := entry
+		field := strings.ToLower(entry)
+		if split := strings.SplitN(entry, ":", 2); len(split) == 2 {
+			header = split[0]
+			field = strings.ToLower(strings.TrimPrefix(split[1], "."))
+		}
+		field = strings.ReplaceAll(field, "_", "")
+
+		col, ok := findColumn(field)
+		if !ok {
+			return nil, fmt.Errorf("unknown column '%s'", entry)
+		}
+		columns = append(columns, listColumn{header, col.Field, col.Value})
+	}
+
+	return columns, nil
+}
+
+// writeTable writes the provided services as a table with the given columns
+func writeTable(out io.Writer, columns []listColumn, services []*api.ListService) error {
+	w := tabwriter.NewWriter(out, 10, 0, 3, ' ', 0)
+
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.Header
+	}
+	fmt.Fprintf(w, "%s\t\n", strings.Join(headers, "\t"))
+
+	for _, s := range services {
+		values := make([]string, len(columns))
+		for i, col := range columns {
+			values[i] = col.Value(s)
+		}
+		fmt.Fprintf(w, "%s\n", strings.Join(values, "\t"))
+	}
+
+	return w.Flush()
+}
+
+// defaultDotenvTemplate is the default template of the prefix of the
+// variables written by the dotenv output format. It includes the namespace
+// so that services with the same name in different namespaces don't
+// collide.
+const defaultDotenvTemplate = "{{.Namespace}}_{{.Name}}"
+
+// invalidEnvChars are characters that can't be in an environment variable
+var invalidEnvChars = regexp.MustCompile(`[^A-Z0-9_]+`)
+
+// envName turns a string into a valid environment variable name
+func envName(s string) string {
+	name := invalidEnvChars.ReplaceAllString(strings.ToUpper(s), "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// localPort returns the local port of a port from the list response, e.g.
+// 80 for 80->8080/tcp
+func localPort(port string) string {
+	port = strings.SplitN(port, "/", 2)[0]
+	return strings.SplitN(port, "->", 2)[0]
+}
+
+// writeDotenv writes <PREFIX>_HOST and <PREFIX>_PORT variables for every
+// service that has an IP, where the prefix is built from nameTemplate. When
+// a service has more than one port, each is also written as
+// <PREFIX>_PORT_<port>.
+func writeDotenv(out io.Writer, nameTemplate string, services []*api.ListService) error {
+	if nameTemplate == "" {
+		nameTemplate = defaultDotenvTemplate
+	}
+
+	tmpl, err := template.New("dotenv").Parse(nameTemplate)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse dotenv name template")
+	}
+
+	for _, s := range services {
+		if s.Ip == "" || len(s.Ports) == 0 {
+			continue
+		}
+
+		var b strings.Builder
+		//nolint:govet // Why: We're OK shadowing err
+		if err := tmpl.Execute(&b, s); err != nil {
+			return errors.Wrapf(err, "failed to render dotenv name of %s/%s", s.Namespace, s.Name)
+		}
+		prefix := envName(b.String())
+
+		fmt.Fprintf(out, "%s_HOST=%s\n", prefix, s.Ip)
+		fmt.Fprintf(out, "%s_PORT=%s\n", prefix, localPort(s.Ports[0]))
+		if len(s.Ports) > 1 {
+			for _, p := range s.Ports {
+				fmt.Fprintf(out, "%s_PORT_%s=%s\n", prefix, localPort(p), localPort(p))
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeList writes a list response in the requested output format, tables
+// have the given columns unless custom-columns were asked for
+func writeList(out io.Writer, format string, columns []listColumn, resp *api.ListResponse) error {
+	switch {
+	case format == "":
+		return writeTable(out, columns, resp.Services)
+	case strings.HasPrefix(format, "custom-columns="):
+		columns, err := parseCustomColumns(strings.TrimPrefix(format, "custom-columns="))
+		if err != nil {
+			return err
+		}
+		return writeTable(out, columns, resp.Services)
+	case strings.HasPrefix(format, "go-template="):
+		tmpl, err := template.New("list").Parse(strings.TrimPrefix(format, "go-template="))
+		if err != nil {
+			return errors.Wrap(err, "failed to parse template")
+		}
+		return tmpl.Execute(out, resp)
+	case format == "dotenv" || strings.HasPrefix(format, "dotenv="):
+		return writeDotenv(out, strings.TrimPrefix(strings.TrimPrefix(format, "dotenv"), "="), resp.Services)
+	}
+
+	return fmt.Errorf("unknown output format '%s'", format)
+}
+
+// statusRank orders statuses so that services with problems are shown first,
+// and paused or disabled services, which were done on purpose, are shown last
+func statusRank(status string) int {
+	switch status {
+	case "paused", "disabled":
+		return 3
+	case "running":
+		return 2
+	case "recreating":
+		return 1
+	}
+
+	return 0
+}
+
+// sortServices sorts services by the given field, falling back to
+// namespace and then name.
+func sortServices(services []*api.ListService, sortBy string) error {
+	byName := func(i, j int) bool {
+		if services[i].Namespace != services[j].Namespace {
+			return services[i].Namespace < services[j].Namespace
+		}
+		return services[i].Name < services[j].Name
+	}
+
+	switch sortBy {
+	case "", "namespace":
+		sort.SliceStable(services, byName)
+	case "name":
+		sort.SliceStable(services, func(i, j int) bool {
+			if services[i].Name != services[j].Name {
+				return services[i].Name < services[j].Name
+			}
+			return byName(i, j)
+		})
+	case "status":
+		sort.SliceStable(services, func(i, j int) bool {
+			ri, rj := statusRank(services[i].Status), statusRank(services[j].Status)
+			if ri != rj {
+				return ri < rj
+			}
+			return byName(i, j)
+		})
+	default:
+		return fmt.Errorf("unknown sort field '%s', expected one of: status, namespace, name", sortBy)
+	}
+
+	return nil
+}
+
+// writeGroups writes services grouped by the given field, with a summary of
+// the statuses in each group. Services in more than one group, with
+// --group-by group, are written under each of them.
+func writeGroups(out io.Writer, format, groupBy string, columns []listColumn, resp *api.ListResponse) error {
+	var keyFn func(s *api.ListService) []string
+	switch groupBy {
+	case "namespace":
+		keyFn = func(s *api.ListService) []string { return []string{s.Namespace} }
+	case "status":
+		keyFn = func(s *api.ListService) []string { return []string{s.Status} }
+	case "group":
+		keyFn = func(s *api.ListService) []string {
+			if len(s.Groups) == 0 {
+				return []string{"none"}
+			}
+			return s.Groups
+		}
+	default:
+		return fmt.Errorf("unknown group field '%s', expected one of: namespace, status, group", groupBy)
+	}
+
+	if strings.HasPrefix(format, "go-template=") || strings.HasPrefix(format, "dotenv") {
+		return fmt.Errorf("--group-by can't be used with go-template or dotenv output")
+	}
+
+	groups := make(map[string][]*api.ListService)
+	keys := []string{}
+	for _, s := range resp.Services {
+		for _, k := range keyFn(s) {
+			if _, ok := groups[k]; !ok {
+				keys = append(keys, k)
+			}
+			groups[k] = append(groups[k], s)
+		}
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		counts := make(map[string]int)
+		statuses := []string{}
+		for _, s := range groups[k] {
+			if counts[s.Status] == 0 {
+				statuses = append(statuses, s.Status)
+			}
+			counts[s.Status]++
+		}
+		sort.Slice(statuses, func(i, j int) bool { return statusRank(statuses[i]) < statusRank(statuses[j]) })
+
+		summary := make([]string, len(statuses))
+		for i, status := range statuses {
+			summary[i] = fmt.Sprintf("%d %s", counts[status], status)
+		}
+
+		if i != 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%s: %d service(s), %s\n", strings.ToUpper(groupBy), len(groups[k]), strings.Join(summary, ", "))
+		if err := writeList(out, format, columns, &api.ListResponse{Services: groups[k]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// filterGroup returns the services in group
+func filterGroup(services []*api.ListService, group string) []*api.ListService {
+	filtered := make([]*api.ListService, 0, len(services))
+	for _, s := range services {
+		for _, g := range s.Groups {
+			if g == group {
+				filtered = append(filtered, s)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// filterNamespaces returns the services in one of namespaces
+func filterNamespaces(services []*api.ListService, namespaces []string) []*api.ListService {
+	filtered := make([]*api.ListService, 0, len(services))
+	for _, s := range services {
+		for _, ns := range namespaces {
+			if s.Namespace == ns {
+				filtered = append(filtered, s)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func NewListCommand(log logrus.FieldLogger) *cli.Command { //nolint:funlen
 	return &cli.Command{
 		Name:        "list",
 		Description: "list all port-forwarded services and their status(es)",
 		Usage:       "list",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage: "Output format, one of: custom-columns=NAME:.name,IP:.ip, go-template={{range .Services}}{{.Name}}{{end}}, " +
+					"or dotenv[={{.Name}}] to write <NAMESPACE>_<NAME>_HOST and <NAMESPACE>_<NAME>_PORT variables",
+			},
+			&cli.StringFlag{
+				Name:  "sort-by",
+				Usage: "Sort services by one of: status, namespace, name",
+				Value: "namespace",
+			},
+			&cli.StringFlag{
+				Name:  "group-by",
+				Usage: "Group services by one of: namespace, status, group, showing a summary for each group",
+			},
+			&cli.StringFlag{
+				Name:  "group",
+				Usage: "Only show services in this group",
+			},
+			&cli.BoolFlag{
+				Name:  "problems",
+				Usage: "Only show services that aren't working, with when and why they were last recreated",
+			},
+		},
 		Action: func(c *cli.Context) error {
-			if !localizer.IsRunning() {
-				return fmt.Errorf("localizer daemon not running (run localizer by itself?)")
-			}
-
 			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
 			defer cancel()
 
-			client, closer, err := localizer.Connect(ctx, grpc.WithBlock(), grpc.WithInsecure())
+			client, closer, err := connectDaemon(ctx, c)
 			if err != nil {
-				return errors.Wrap(err, "failed to connect to localizer daemon")
+				return err
 			}
 			defer closer()
 
-			resp, err := client.List(ctx, &api.ListRequest{})
+			resp, err := listServices(ctx, client, &api.ListRequest{ProblemsOnly: c.Bool("problems")})
 			if err != nil {
 				return err
 			}
 
-			w := tabwriter.NewWriter(os.Stdout, 10, 0, 3, ' ', 0)
-			defer w.Flush()
+			if c.String("context") == "" && resp.CurrentKubeContext != resp.KubeContext {
+				log.Warnf("kubeconfig current-context is now %s, but services are still forwarded from %s, "+
+					"restart localizer to switch", resp.CurrentKubeContext, resp.KubeContext)
+			}
 
-			fmt.Fprintf(w, "NAMESPACE\tNAME\tSTATUS\tREASON\tENDPOINT\tIP ADDRESS\tPORT(S)\t\n")
+			if namespaces := parseList(c.String("namespace")); len(namespaces) > 0 {
+				resp.Services = filterNamespaces(resp.Services, namespaces)
+			}
+			if group := c.String("group"); group != "" {
+				resp.Services = filterGroup(resp.Services, group)
+			}
 
-			// sort by namespace and then by name
-			sort.Slice(resp.Services, func(i, j int) bool {
-				return resp.Services[i].Namespace < resp.Services[j].Namespace
-			})
-			sort.Slice(resp.Services, func(i, j int) bool {
-				return resp.Services[i].Name < resp.Services[j].Name
-			})
+			if err := sortServices(resp.Services, c.String("sort-by")); err != nil {
+				return err
+			}
 
-			for _, s := range resp.Services {
-				status := strings.ToUpper(s.Status[:1]) + s.Status[1:]
-				ip := s.Ip
-				if ip == "" {
-					ip = "None"
+			columns := listColumns
+			if c.Bool("problems") {
+				if len(resp.Services) == 0 && c.String("output") == "" {
+					log.Info("no problems found")
+					return nil
 				}
+				columns = problemColumns
+			}
+			columns = withContextColumn(columns, resp.Services)
 
-				fmt.Fprintf(w,
-					"%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-					s.Namespace, s.Name, status, s.StatusReason, s.Endpoint, ip, strings.Join(s.Ports, ","),
-				)
+			if c.String("group-by") != "" {
+				return writeGroups(os.Stdout, c.String("output"), c.String("group-by"), columns, resp)
 			}
 
-			return nil
+			return writeList(os.Stdout, c.String("output"), columns, resp)
 		},
 	}
 }
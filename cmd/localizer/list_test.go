@@ -0,0 +1,92 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/getoutreach/localizer/api"
+)
+
+func TestWriteDotenv(t *testing.T) {
+	services := []*api.ListService{
+		{Namespace: "databases", Name: "postgres", Ip: "127.0.0.2", Ports: []string{"5432/tcp"}},
+		{Namespace: "staging", Name: "postgres", Ip: "127.0.0.3", Ports: []string{"5432/tcp"}},
+		{Namespace: "default", Name: "web-api", Ip: "127.0.0.4", Ports: []string{"80->8080/tcp", "9090/tcp"}},
+		{Namespace: "default", Name: "pending", Ports: []string{"80/tcp"}},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name: "default includes the namespace",
+			expected: `DATABASES_POSTGRES_HOST=127.0.0.2
+DATABASES_POSTGRES_PORT=5432
+STAGING_POSTGRES_HOST=127.0.0.3
+STAGING_POSTGRES_PORT=5432
+DEFAULT_WEB_API_HOST=127.0.0.4
+DEFAULT_WEB_API_PORT=80
+DEFAULT_WEB_API_PORT_80=80
+DEFAULT_WEB_API_PORT_9090=9090
+`,
+		},
+		{
+			name:     "custom template",
+			template: "svc-{{.Name}}",
+			expected: `SVC_POSTGRES_HOST=127.0.0.2
+SVC_POSTGRES_PORT=5432
+SVC_POSTGRES_HOST=127.0.0.3
+SVC_POSTGRES_PORT=5432
+SVC_WEB_API_HOST=127.0.0.4
+SVC_WEB_API_PORT=80
+SVC_WEB_API_PORT_80=80
+SVC_WEB_API_PORT_9090=9090
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := writeDotenv(&b, tt.template, services); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, b.String())
+			}
+		})
+	}
+
+	if err := writeDotenv(&strings.Builder{}, "{{.Name", services); err == nil {
+		t.Error("expected an invalid template to fail")
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"default_web-api":   "DEFAULT_WEB_API",
+		"kube-system_dns.1": "KUBE_SYSTEM_DNS_1",
+		"3scale_api":        "_3SCALE_API",
+	}
+
+	for in, expected := range tests {
+		if got := envName(in); got != expected {
+			t.Errorf("envName(%q) = %q, expected %q", in, got, expected)
+		}
+	}
+}
@@ -20,15 +20,21 @@ import (
 	"io"
 	"os"
 	"os/signal"
-	"os/user"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/getoutreach/localizer/internal/config"
+	"github.com/getoutreach/localizer/internal/ippool"
 	"github.com/getoutreach/localizer/internal/kevents"
 	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/getoutreach/localizer/internal/privhelper"
 	"github.com/getoutreach/localizer/internal/server"
+	"github.com/getoutreach/localizer/internal/tcpproxy"
+	"github.com/getoutreach/localizer/pkg/localizer"
+	"github.com/getoutreach/localizer/pkg/proxier"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
@@ -39,6 +45,63 @@ import (
 
 var Version = "v0.0.0-unset"
 
+// ReleasePublicKey is the base64 encoded ed25519 public key the checksums
+// of releases are signed with, set at build time
+var ReleasePublicKey = ""
+
+// instanceNameRegex matches valid instance names, these are used in file
+// names and the hosts file so they are restricted.
+var instanceNameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// parseList parses a comma separated list, e.g. of namespaces
+func parseList(s string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// serviceArgs returns the services given as arguments to the daemon, e.g.
+// 'localizer default/api', as namespace/name
+func serviceArgs(c *cli.Context) ([]string, error) {
+	if !c.Args().Present() || c.App.Command(c.Args().First()) != nil {
+		return nil, nil
+	}
+
+	services := make([]string, 0, c.NArg())
+	for _, arg := range c.Args().Slice() {
+		key := qualifyService(c, arg)
+		if !strings.Contains(key, "/") {
+			return nil, fmt.Errorf("unknown command or service '%s', services must be given as namespace/name", arg)
+		}
+		services = append(services, key)
+	}
+	return services, nil
+}
+
+// forwardedNamespaces returns the namespaces the daemon forwards services
+// in, the ones of the services given as arguments unless --namespace was
+// passed
+func forwardedNamespaces(c *cli.Context, services []string) []string {
+	if c.IsSet("namespace") || len(services) == 0 {
+		return parseList(c.String("namespace"))
+	}
+
+	namespaces := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, key := range services {
+		namespace := strings.SplitN(key, "/", 2)[0]
+		if !seen[namespace] {
+			seen[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
 func main() { //nolint:funlen
 	ctx, cancel := context.WithCancel(context.Background())
 	log := logrus.New()
@@ -46,12 +109,16 @@ func main() { //nolint:funlen
 		ForceColors: true,
 	}
 
-	tmpFilePath := filepath.Join(os.TempDir(), "localizer-"+strings.ReplaceAll(time.Now().Format(time.RFC3339), ":", "-")+".log")
-	tmpFile, err := os.Create(tmpFilePath)
-	if err == nil {
-		defer tmpFile.Close()
+	// the privileged helper is ran for every hosts file write, so it
+	// doesn't get a log file
+	if len(os.Args) < 2 || os.Args[1] != privhelper.Command {
+		tmpFilePath := filepath.Join(os.TempDir(), "localizer-"+strings.ReplaceAll(time.Now().Format(time.RFC3339), ":", "-")+".log")
+		tmpFile, err := os.Create(tmpFilePath)
+		if err == nil {
+			defer tmpFile.Close()
 
-		log.Out = io.MultiWriter(os.Stderr, tmpFile)
+			log.Out = io.MultiWriter(os.Stderr, tmpFile)
+		}
 	}
 
 	// this prevents the CLI from clobbering context cancellation
@@ -65,14 +132,32 @@ func main() { //nolint:funlen
 		Version:              Version,
 		EnableBashCompletion: true,
 		Name:                 "localizer",
+		ArgsUsage:            "[namespace/service...]",
 		Flags: []cli.Flag{
-			// Note: KUBECONFIG is respected, but we don't allow passing a
-			// CLI argument to reduce the complexity and re-parsing of it.
 			&cli.StringFlag{
 				Name:    "context",
-				Usage:   "Specify Kubernetes context to use",
+				Usage:   "Specify Kubernetes context to use, from any of the kubeconfig files",
 				EnvVars: []string{"KUBECONTEXT"},
 			},
+			&cli.StringFlag{
+				Name: "kubeconfig",
+				Usage: "Kubeconfig files to merge, separated like KUBECONFIG, e.g. ~/.kube/staging:~/.kube/production " +
+					"(default: $KUBECONFIG, or ~/.kube/config)",
+			},
+			&cli.StringFlag{
+				Name:    "proxy-url",
+				Usage:   "Connect to the API server through this http, https or socks5 proxy (default: the kubeconfig's proxy-url)",
+				EnvVars: []string{"LOCALIZER_PROXY_URL"},
+			},
+			&cli.StringFlag{
+				Name:    "bastion",
+				Usage:   "Connect to the API server through this SSH jump host, [user@]host[:port]",
+				EnvVars: []string{"LOCALIZER_BASTION"},
+			},
+			&cli.StringFlag{
+				Name:  "bastion-key",
+				Usage: "Private key to log in to --bastion with (default: the SSH agent and keys in ~/.ssh)",
+			},
 			&cli.StringFlag{
 				Name:        "log-level",
 				Usage:       "Set the log level",
@@ -86,27 +171,235 @@ func main() { //nolint:funlen
 				EnvVars:     []string{"LOG_FORMAT"},
 				DefaultText: "TEXT",
 			},
+			&cli.StringFlag{
+				Name:    "config",
+				Usage:   "Path to the localizer configuration file (default: ~/.localizer/config.yaml)",
+				EnvVars: []string{config.EnvVar},
+			},
+			&cli.StringFlag{
+				Name:    "remote",
+				Usage:   "Control a localizer daemon running on a remote host over SSH, e.g. ssh://user@devbox",
+				EnvVars: []string{"LOCALIZER_REMOTE"},
+			},
 			&cli.StringFlag{
 				Name:  "cluster-domain",
 				Usage: "Configure the cluster domain used for service DNS endpoints",
 				Value: "cluster.local",
 			},
+			&cli.StringFlag{
+				Name:  "audit-log",
+				Usage: "Append every request made to the daemon, who made it, and its result to this file",
+			},
+			&cli.DurationFlag{
+				Name:  "drain-timeout",
+				Usage: "How long open connections are given to finish when a service stops being forwarded, or localizer stops",
+			},
+			&cli.StringFlag{
+				Name:  "dns-address",
+				Usage: "Serve DNS for forwarded services, including wildcard hostnames, on the given UDP address (e.g. 127.0.0.1:5353)",
+			},
 			&cli.StringFlag{
 				Name:  "ip-cidr",
-				Usage: "Set the IP address CIDR, must include the /",
-				Value: "127.0.0.1/8",
+				Usage: "Set the IP address CIDR, must include the / (default: the loopback range of the platform, usually 127.0.0.1/8)",
+			},
+			&cli.StringFlag{
+				Name:  "ipam-file",
+				Usage: "Keep track of allocated IPs in this file, rather than in memory, so instances sharing it never get the same IPs",
+			},
+			&cli.StringFlag{
+				Name:    "namespace",
+				Aliases: []string{"n"},
+				Usage: "Restrict forwarding to the given namespace(s), comma separated. (default: all namespaces) " +
+					"For other commands, the namespace of services given without one",
+			},
+			&cli.StringFlag{
+				Name:  "only-port-names",
+				Usage: "Only forward the service ports with these names, comma separated, e.g. http,grpc. Unnamed ports are always forwarded.",
+			},
+			&cli.BoolFlag{
+				Name:  "follow-context",
+				Usage: "Restart, forwarding services from the new context, when the kubeconfig's current-context is changed",
+			},
+			&cli.BoolFlag{
+				Name:  "include-system",
+				Usage: "Forward services in system namespaces, e.g. kube-system, which are skipped unless listed in --namespace",
+			},
+			&cli.StringFlag{
+				Name:  "node",
+				Usage: "Node to choose endpoints as if connecting from, for services with an internalTrafficPolicy of Local",
+			},
+			&cli.StringFlag{
+				Name:  "zone",
+				Usage: "Zone to choose endpoints as if connecting from, for services with topology aware hints (default: the zone of --node)",
+			},
+			&cli.BoolFlag{
+				Name:    "in-cluster",
+				Usage:   "Use the in-cluster service account instead of a kubeconfig, for running inside of a pod",
+				EnvVars: []string{"LOCALIZER_IN_CLUSTER"},
+			},
+			&cli.StringFlag{
+				Name:    "listen-address",
+				Usage:   "Also serve the daemon API on the given TCP address, clients should set LOCALIZER_ADDRESS. Must be a loopback address unless --listen-token is set",
+				EnvVars: []string{"LOCALIZER_LISTEN_ADDRESS"},
+			},
+			&cli.StringFlag{
+				Name:    "listen-token",
+				Usage:   "Token clients must present to use the API over --listen-address, clients should set LOCALIZER_TOKEN",
+				EnvVars: []string{"LOCALIZER_LISTEN_TOKEN"},
+			},
+			&cli.StringFlag{
+				Name:    "hosts-file",
+				Usage:   "Path to the hosts file to write service entries to",
+				EnvVars: []string{"LOCALIZER_HOSTS_FILE"},
+				Value:   "/etc/hosts",
+			},
+			&cli.StringFlag{
+				Name:    "instance",
+				Usage:   "Name of the localizer instance to run or talk to, allows running more than one daemon on a machine",
+				EnvVars: []string{localizer.InstanceEnvVar},
+			},
+			&cli.StringFlag{
+				Name:    "socket",
+				Usage:   "Path of the unix socket the daemon listens on (default: /var/run/localizer.sock, or the instance's socket)",
+				EnvVars: []string{localizer.SocketEnvVar},
+			},
+			&cli.BoolFlag{
+				Name:  "interactive",
+				Usage: "Pick the services to forward before starting, the selection is remembered for the next run",
+			},
+			&cli.BoolFlag{
+				Name:  "clear-selection",
+				Usage: "Forget the services picked with --interactive, and forward every service again",
+			},
+			&cli.IntFlag{
+				Name:  "max-tunnels",
+				Usage: "Maximum number of port-forwards to run at once, services past it wait for a free slot (default: unlimited)",
+			},
+			&cli.IntFlag{
+				Name:  "max-connections",
+				Usage: "Maximum number of connections to handle at once across all port-forwards (default: unlimited)",
+			},
+			&cli.IntFlag{
+				Name:  "buffer-size",
+				Usage: "Size, in bytes, of the buffers used for each direction of a connection (default: 32768)",
+			},
+			&cli.IntFlag{
+				Name:  "max-recreates",
+				Usage: "Number of times a port-forward can be recreated within --recreate-window before it's marked as failed, 0 is unlimited",
+				Value: 10,
+			},
+			&cli.DurationFlag{
+				Name:  "recreate-window",
+				Usage: "Window that --max-recreates applies to",
+				Value: 5 * time.Minute,
+			},
+			&cli.DurationFlag{
+				Name:  "operation-timeout",
+				Usage: "How long creating, or deleting, a port-forward can take before the worker gives up on it, 0 is forever",
+				Value: time.Minute,
+			},
+			&cli.Float64Flag{
+				Name:  "recreate-rate",
+				Usage: "Number of port-forwards that can be recreated per second, e.g. after the network drops, 0 is unlimited",
+				Value: 5,
+			},
+			&cli.IntFlag{
+				Name:  "recreate-burst",
+				Usage: "Number of port-forwards that can be recreated at once before --recreate-rate applies",
+				Value: 10,
+			},
+			&cli.StringFlag{
+				Name:  "pprof-address",
+				Usage: "Serve net/http/pprof on the given address (e.g. 127.0.0.1:6060), for debugging the daemon",
 			},
 			&cli.StringFlag{
-				Name:  "namespace",
-				Usage: "Restrict forwarding to the given namespace. (default: all namespaces)",
+				Name:  "metrics-address",
+				Usage: "Serve Prometheus metrics of the daemon's use of the Kubernetes API on the given address (e.g. 127.0.0.1:9090)",
+			},
+			&cli.BoolFlag{
+				Name: "dry-run",
+				Usage: "Print the services that would be forwarded, with the IPs and hostnames they'd get, " +
+					"without forwarding them or changing anything on this machine",
+			},
+			&cli.BoolFlag{
+				Name:  "random-ports",
+				Usage: "Forward services on random ports of 127.0.0.1, found with 'localizer list', instead of their own IPs. Doesn't need root.",
+			},
+			&cli.BoolFlag{
+				Name:  "redirect-cluster-ips",
+				Usage: "Redirect traffic sent to service ClusterIPs to their local forwards using nftables (Linux only)",
+			},
+			&cli.DurationFlag{
+				Name:  "resync-interval",
+				Usage: "How often every service is checked again, e.g. for tunnels to pods that have gone away, 0 disables it",
+				Value: kevents.DefaultResyncInterval,
+			},
+			&cli.DurationFlag{
+				Name:  "gc-interval",
+				Usage: "How often to check for forwarded services that were deleted without localizer noticing, 0 disables it",
+				Value: proxier.DefaultGCInterval,
+			},
+			&cli.DurationFlag{
+				Name:  "gc-grace-period",
+				Usage: "How long a forwarded service has to be missing for before its tunnel is removed",
+			},
+			&cli.BoolFlag{
+				Name:  "disable-gc",
+				Usage: "Don't check for forwarded services that were deleted, they can be enabled later with 'localizer gc --enable'",
+			},
+			&cli.StringFlag{
+				Name:  "relay-agent-image",
+				Usage: "Connect to services through a relay agent running this image (of localizer), instead of a port-forward per service",
+			},
+			&cli.StringFlag{
+				Name:  "relay-agent-namespace",
+				Usage: "Namespace to run the relay agent in",
+				Value: "default",
+			},
+			&cli.StringFlag{
+				Name: "mirror-agent-image",
+				Usage: "Image (of localizer) ran in the pods of 'localizer expose --mirror' to send the service's connections on " +
+					"to its pods from inside of the cluster, required to mirror",
+			},
+			&cli.StringFlag{
+				Name:  "tcp-proxy-image",
+				Usage: "Image of the pods used by 'localizer forward tcp/<host>:<port>', its entrypoint must be socat",
+				Value: tcpproxy.DefaultImage,
 			},
 		},
 		Commands: []*cli.Command{
 			NewListCommand(log),
 			NewExposeCommand(log),
+			NewDialCommand(log),
+			NewCurlCommand(log),
+			NewRecordCommand(log),
+			NewReplayCommand(log),
+			NewLogLevelCommand(log),
+			NewGCCommand(log),
+			NewStatsCommand(log),
+			NewDebugBundleCommand(log),
+			NewStatusCommand(log),
+			NewUpgradeCommand(log),
+			NewBenchCommand(log),
+			NewPingCommand(log),
+			NewWaitCommand(log),
+			NewHookCommand(),
+			NewConfigCommand(log),
+			NewEnvCommand(log),
+			NewPauseCommand(log),
+			NewResumeCommand(log),
+			NewDisableCommand(log),
+			NewEnableCommand(log),
+			NewRestartCommand(log),
+			NewForwardCommand(log),
+			NewExportResolvCommand(log),
+			NewRelayAgentCommand(log),
+			NewMirrorAgentCommand(log),
+			NewSetupCommand(log),
+			NewPrivilegedCommand(log),
 		},
 		Before: func(c *cli.Context) error {
-			sigC := make(chan os.Signal)
+			sigC := make(chan os.Signal, 1)
 			signal.Notify(sigC, os.Interrupt, syscall.SIGTERM)
 			go func() {
 				sig := <-sigC
@@ -130,43 +423,240 @@ func main() { //nolint:funlen
 
 			klog.SetLogger(&kube.KlogtoLogrus{Log: log.WithField("logger", "klog")})
 
+			// set it for anything we run too, e.g. kubectl, and the daemon
+			// when it re-executes itself
+			if kubeconfig := c.String("kubeconfig"); kubeconfig != "" {
+				os.Setenv("KUBECONFIG", kubeconfig) //nolint:errcheck // Why: This can't fail on a valid key
+			}
+
+			if instance := c.String("instance"); instance != "" {
+				if !instanceNameRegex.MatchString(instance) {
+					return fmt.Errorf("invalid instance name '%s', must only contain lowercase letters, numbers, and dashes", instance)
+				}
+
+				// ensure that clients in this process find the right daemon
+				os.Setenv(localizer.InstanceEnvVar, instance) //nolint:errcheck // Why: This can't fail on a valid key
+			}
+
+			if socket := c.String("socket"); socket != "" {
+				os.Setenv(localizer.SocketEnvVar, socket) //nolint:errcheck // Why: This can't fail on a valid key
+			}
+
+			// the remote daemon talks to Kubernetes, not us, the relay and
+			// mirror agents only make connections inside of the cluster, and
+			// setup, config and the privileged helper don't talk to it at all
+			if c.String("remote") != "" {
+				return nil
+			}
+			switch c.Args().First() {
+			case "relay-agent", "mirror-agent", "setup", "config", privhelper.Command:
+				return nil
+			}
+
+			if c.Duration("resync-interval") < 0 {
+				return fmt.Errorf("--resync-interval can't be negative")
+			}
+			if c.Duration("gc-grace-period") < 0 {
+				return fmt.Errorf("--gc-grace-period can't be negative")
+			}
+
+			services, err := serviceArgs(c)
+			if err != nil {
+				return err
+			}
+
+			err = kube.UseProxy(log, kube.ProxyOptions{
+				ProxyURL:   c.String("proxy-url"),
+				Bastion:    c.String("bastion"),
+				BastionKey: c.String("bastion-key"),
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to configure connecting to the API server")
+			}
+
 			// setup the global kubernetes cache interface
-			config, k, err := kube.GetKubeClient(c.String("context"))
+			kconf, k, err := kube.GetKubeClient(log, c.String("context"))
+			if c.Bool("in-cluster") {
+				kconf, k, err = kube.GetInClusterKubeClient()
+			}
 			if err != nil {
 				return err
 			}
-			log.Infof("using apiserver %s", config.Host)
-			kevents.ConfigureGlobalCache(k, c.String("namespace"))
+			log.Infof("using apiserver %s", kconf.Host)
+			kevents.ConfigureGlobalCache(k, forwardedNamespaces(c, services), c.Duration("resync-interval"))
 
 			return nil
 		},
 		Action: func(c *cli.Context) error {
-			u, err := user.Current()
+			if c.String("remote") != "" {
+				return fmt.Errorf("--remote can only be used with client commands, e.g. list or expose")
+			}
+
+			privileged, err := isPrivileged()
 			if err != nil {
-				return errors.Wrap(err, "failed to get current user")
+				return err
+			}
+
+			if c.Bool("random-ports") && c.Bool("redirect-cluster-ips") {
+				return fmt.Errorf("--redirect-cluster-ips can't be used with --random-ports")
+			}
+
+			if c.Bool("dry-run") && c.Bool("interactive") {
+				return fmt.Errorf("--interactive can't be used with --dry-run, as it saves the services picked")
 			}
 
-			if u.Uid != "0" {
-				return fmt.Errorf("must be run as root/Administrator")
+			if c.Bool("clear-selection") && c.Bool("interactive") {
+				return fmt.Errorf("--clear-selection can't be used with --interactive, picking every service clears it")
+			}
+
+			// random ports, and dry runs, don't need anything that requires
+			// root
+			var helper *privhelper.Client
+			if !privileged && !c.Bool("random-ports") && !c.Bool("dry-run") {
+				helper, err = privhelper.NewClient(ctx)
+				if err != nil {
+					log.WithError(err).Debug("privileged helper isn't usable")
+					return fmt.Errorf("must be run as root/Administrator, or run 'localizer setup' once to run without it")
+				}
+				log.Info("not running as root, using the privileged helper installed by 'localizer setup'")
+			}
+
+			conf, err := config.Load(c.String("config"))
+			if err != nil {
+				return err
+			}
+
+			services, err := serviceArgs(c)
+			if err != nil {
+				return err
+			}
+			if len(services) > 0 && c.Bool("interactive") {
+				return fmt.Errorf("--interactive can't be used when services are given as arguments")
+			}
+
+			if c.Bool("clear-selection") {
+				if err := localizer.WriteSelected(c.String("instance"), nil); err != nil {
+					return err
+				}
+				log.Info("cleared the services picked with --interactive, forwarding every service")
+			} else if len(services) == 0 && !c.Bool("interactive") {
+				selected, err := localizer.ReadSelected(c.String("instance")) //nolint:govet // Why: We're OK shadowing err
+				if err != nil {
+					return err
+				}
+				if len(selected) > 0 {
+					log.Warnf("only forwarding the %d services picked with --interactive in an earlier run, "+
+						"pass --interactive to change them or --clear-selection to forward every service", len(selected))
+				}
+			}
+
+			if c.Bool("interactive") {
+				//nolint:govet // Why: We're OK shadowing err
+				_, k, err := kube.GetKubeClient(log, c.String("context"))
+				if c.Bool("in-cluster") {
+					_, k, err = kube.GetInClusterKubeClient()
+				}
+				if err != nil {
+					return err
+				}
+
+				err = pickServicesInteractively(ctx, k, os.Stdin, os.Stderr, c.String("instance"),
+					parseList(c.String("namespace")), c.Bool("include-system"))
+				if err != nil {
+					return err
+				}
 			}
 
 			clusterDomain := c.String("cluster-domain")
+			// nothing is listened on in the pool when using random ports
 			ipCidr := c.String("ip-cidr")
+			if c.Bool("random-ports") && ipCidr == "" {
+				ipCidr = ippool.DefaultCIDR
+			} else if ipCidr, err = ippool.Select(log, ipCidr); err != nil {
+				return err
+			}
 
 			log.Infof("using cluster domain: %v", clusterDomain)
 			log.Infof("using ip cidr: %v", ipCidr)
+			log.Infof("using hosts file: %v", c.String("hosts-file"))
 
-			srv := server.NewGRPCService(&server.RunOpts{
-				ClusterDomain: clusterDomain,
-				IPCidr:        ipCidr,
-				KubeContext:   c.String("context"),
-			})
-			return srv.Run(ctx, log)
+			opts := &server.RunOpts{
+				ClusterDomain:           clusterDomain,
+				IPCidr:                  ipCidr,
+				IPAMFile:                c.String("ipam-file"),
+				KubeContext:             c.String("context"),
+				InCluster:               c.Bool("in-cluster"),
+				ListenAddress:           c.String("listen-address"),
+				ListenToken:             c.String("listen-token"),
+				HostsFile:               c.String("hosts-file"),
+				RedirectClusterIPs:      c.Bool("redirect-cluster-ips"),
+				PprofAddress:            c.String("pprof-address"),
+				MetricsAddress:          c.String("metrics-address"),
+				DNSAddress:              c.String("dns-address"),
+				AuditLog:                c.String("audit-log"),
+				Instance:                c.String("instance"),
+				Socket:                  c.String("socket"),
+				MaxTunnels:              c.Int("max-tunnels"),
+				MaxConnections:          c.Int("max-connections"),
+				BufferSize:              c.Int("buffer-size"),
+				MaxRecreates:            c.Int("max-recreates"),
+				RecreateWindow:          c.Duration("recreate-window"),
+				RecreateRate:            c.Float64("recreate-rate"),
+				RecreateBurst:           c.Int("recreate-burst"),
+				OperationTimeout:        c.Duration("operation-timeout"),
+				Namespaces:              forwardedNamespaces(c, services),
+				Services:                services,
+				PortNames:               parseList(c.String("only-port-names")),
+				IncludeSystemNamespaces: c.Bool("include-system"),
+				Node:                    c.String("node"),
+				Zone:                    c.String("zone"),
+				RelayAgentImage:         c.String("relay-agent-image"),
+				RelayAgentNamespace:     c.String("relay-agent-namespace"),
+				MirrorAgentImage:        c.String("mirror-agent-image"),
+				TCPProxyImage:           c.String("tcp-proxy-image"),
+				FollowContext:           c.Bool("follow-context"),
+				Helper:                  helper,
+				Unprivileged:            !privileged,
+				RandomPorts:             c.Bool("random-ports"),
+				DrainTimeout:            c.Duration("drain-timeout"),
+				GCInterval:              c.Duration("gc-interval"),
+				GCGracePeriod:           c.Duration("gc-grace-period"),
+				GCDisabled:              c.Bool("disable-gc"),
+				Config:                  conf,
+			}
+
+			if c.Bool("dry-run") {
+				plan, err := server.DryRun(ctx, log, opts) //nolint:govet // Why: We're OK shadowing err
+				if err != nil {
+					return err
+				}
+				return writePlan(os.Stdout, plan)
+			}
+
+			err = server.NewGRPCService(opts).Run(ctx, log)
+			if errors.Is(err, server.ErrContextChanged) {
+				// everything was created from the old context, so start
+				// over rather than rebuilding it in place
+				//nolint:govet // Why: We're OK shadowing err
+				exe, err := os.Executable()
+				if err != nil {
+					return err
+				}
+				return syscall.Exec(exe, os.Args, os.Environ())
+			}
+			return err
 		},
 	}
 
-	if err := app.Run(os.Args); err != nil {
-		log.Errorf("failed to run: %v", err)
+	args := os.Args
+	if isKubectlPlugin(args[0]) {
+		app.Name = "kubectl localizer"
+		app.HelpName = app.Name
+		args = kubectlPluginArgs(&app, args)
+	}
+
+	if err := app.Run(args); err != nil {
+		logError(log, err)
 		return
 	}
 }
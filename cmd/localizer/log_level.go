@@ -0,0 +1,54 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func NewLogLevelCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "log-level",
+		Description: "Change the log level of a running daemon, without restarting it",
+		Usage:       "log-level <level>",
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				return fmt.Errorf("expected exactly one level, e.g. debug or info")
+			}
+
+			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			resp, err := client.SetLogLevel(ctx, &api.SetLogLevelRequest{Level: c.Args().First()})
+			if err != nil {
+				return err
+			}
+
+			log.Infof("log level changed from %s to %s", resp.PreviousLevel, c.Args().First())
+			return nil
+		},
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/getoutreach/localizer/internal/mirroragent"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func NewMirrorAgentCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "mirror-agent",
+		Description: "Run the in-cluster mirror agent, this is ran next to the SSH server of 'expose --mirror' when --mirror-agent-image is set",
+		Hidden:      true,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "port",
+				Usage: "Port to serve, and the port to send copies of its connections to, in the format of target:copy",
+			},
+			&cli.StringSliceFlag{
+				Name:  "upstream",
+				Usage: "IP of a pod to send connections to, until others are sent over the control port",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			a := mirroragent.New(log, c.StringSlice("upstream"))
+
+			// the agent stops when any of its listeners does
+			ports := c.StringSlice("port")
+			errs := make(chan error, len(ports)+1)
+			for _, s := range c.StringSlice("port") {
+				port, err := mirroragent.ParsePort(s)
+				if err != nil {
+					return err
+				}
+
+				l, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", port.Target))
+				if err != nil {
+					return errors.Wrap(err, "failed to listen")
+				}
+				go func() { errs <- a.Serve(c.Context, l, port) }()
+			}
+
+			l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", mirroragent.ControlPort))
+			if err != nil {
+				return errors.Wrap(err, "failed to listen")
+			}
+			go func() { errs <- a.ServeControl(c.Context, l) }()
+
+			log.Info("mirror agent listening")
+			return <-errs
+		},
+	}
+}
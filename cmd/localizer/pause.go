@@ -0,0 +1,73 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func NewPauseCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "pause",
+		Description: "Close all tunnels, without forgetting them, until resume is ran",
+		Usage:       "pause",
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			if _, err := client.Pause(ctx, &api.Empty{}); err != nil {
+				return err
+			}
+
+			log.Info("paused all tunnels, run 'localizer resume' to recreate them")
+			return nil
+		},
+	}
+}
+
+func NewResumeCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "resume",
+		Description: "Recreate the tunnels closed by pause",
+		Usage:       "resume",
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			if _, err := client.Resume(ctx, &api.Empty{}); err != nil {
+				return err
+			}
+
+			log.Info("resuming tunnels, run 'localizer list' to see their status")
+			return nil
+		},
+	}
+}
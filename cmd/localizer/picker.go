@@ -0,0 +1,208 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/getoutreach/localizer/pkg/localizer"
+	"github.com/getoutreach/localizer/pkg/proxier"
+)
+
+// fuzzyMatch returns if every character of pattern appears in s, in order,
+// ignoring case
+func fuzzyMatch(pattern, s string) bool {
+	s = strings.ToLower(s)
+	for _, r := range strings.ToLower(pattern) {
+		idx := strings.IndexRune(s, r)
+		if idx == -1 {
+			return false
+		}
+		s = s[idx+1:]
+	}
+	return true
+}
+
+// parseSelection parses a list of numbers and ranges, e.g. 1,3-5 or 1 3,
+// returning the zero-based indexes they refer to. ok is false if s isn't
+// a selection.
+func parseSelection(s string, n int) (indexes []int, ok bool) {
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		from, to := field, field
+		if split := strings.SplitN(field, "-", 2); len(split) == 2 {
+			from, to = split[0], split[1]
+		}
+
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, false
+		}
+		end, err := strconv.Atoi(to)
+		if err != nil {
+			return nil, false
+		}
+
+		for i := start; i <= end; i++ {
+			if i >= 1 && i <= n {
+				indexes = append(indexes, i-1)
+			}
+		}
+	}
+
+	return indexes, len(indexes) > 0
+}
+
+// pickServices asks the user which of services, as namespace/name, should
+// be forwarded. Services in selected start out picked.
+func pickServices(in io.Reader, out io.Writer, services []string, selected map[string]bool) []string { //nolint:funlen
+	picked := make([]bool, len(services))
+	for i, key := range services {
+		picked[i] = selected[key]
+	}
+
+	filter := ""
+	r := bufio.NewReader(in)
+	for {
+		fmt.Fprintln(out)
+		shown := []int{}
+		lastNamespace := ""
+		for i, key := range services {
+			if filter != "" && !fuzzyMatch(filter, key) {
+				continue
+			}
+			shown = append(shown, i)
+
+			namespace, name := key[:strings.Index(key, "/")], key[strings.Index(key, "/")+1:]
+			if namespace != lastNamespace {
+				fmt.Fprintf(out, "  %s\n", namespace)
+				lastNamespace = namespace
+			}
+
+			mark := " "
+			if picked[i] {
+				mark = "x"
+			}
+			fmt.Fprintf(out, "    [%s] %3d) %s\n", mark, i+1, name)
+		}
+		if len(shown) == 0 {
+			fmt.Fprintf(out, "  no services match '%s'\n", filter)
+		}
+
+		fmt.Fprintln(out, "\nType to filter ('/' to clear), numbers or ranges (1,3-5) to toggle, "+
+			"'all' or 'none' to (un)pick what's shown, and enter when done.")
+		if filter != "" {
+			fmt.Fprintf(out, "filter: %s\n", filter)
+		}
+		fmt.Fprint(out, "> ")
+
+		line, err := r.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			// enter, or the input was closed
+			break
+		}
+
+		switch indexes, ok := parseSelection(line, len(services)); {
+		case line == "/":
+			filter = ""
+		case line == "all" || line == "none":
+			for _, i := range shown {
+				picked[i] = line == "all"
+			}
+		case ok:
+			for _, i := range indexes {
+				picked[i] = !picked[i]
+			}
+		default:
+			filter = line
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	keys := []string{}
+	for i, key := range services {
+		if picked[i] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// pickServicesInteractively lists the services in namespaces, or all of
+// them, and asks the user which should be forwarded. The selection is
+// saved for future runs, picking every service forgets it.
+func pickServicesInteractively(ctx context.Context, k kubernetes.Interface, in io.Reader, out io.Writer,
+	instance string, namespaces []string, includeSystem bool) error {
+	skipSystem := len(namespaces) == 0 && !includeSystem
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	services := []string{}
+	for _, ns := range namespaces {
+		list, err := k.CoreV1().Services(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrap(err, "failed to list services")
+		}
+
+		for i := range list.Items {
+			if skipSystem && proxier.IsSystemNamespace(list.Items[i].Namespace) {
+				continue
+			}
+			services = append(services, list.Items[i].Namespace+"/"+list.Items[i].Name)
+		}
+	}
+	sort.Strings(services)
+
+	previous, err := localizer.ReadSelected(instance)
+	if err != nil {
+		return err
+	}
+
+	// with no previous selection, everything was being forwarded
+	selected := make(map[string]bool)
+	for _, key := range previous {
+		selected[key] = true
+	}
+	if len(previous) == 0 {
+		for _, key := range services {
+			selected[key] = true
+		}
+	}
+
+	fmt.Fprintln(out, "Pick the services to forward:")
+	picked := pickServices(in, out, services, selected)
+	if len(picked) == len(services) {
+		fmt.Fprintln(out, "Forwarding every service")
+		return localizer.WriteSelected(instance, nil)
+	} else if len(picked) == 0 {
+		return fmt.Errorf("no services were picked")
+	}
+
+	fmt.Fprintf(out, "Forwarding %d of %d services\n", len(picked), len(services))
+	return localizer.WriteSelected(instance, picked)
+}
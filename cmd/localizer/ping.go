@@ -0,0 +1,186 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// pingStats are the results of the connections made by ping
+type pingStats struct {
+	sent, failed  int
+	min, max, sum time.Duration
+}
+
+// add records a successful connection that took d
+func (s *pingStats) add(d time.Duration) {
+	if s.min == 0 || d < s.min {
+		s.min = d
+	}
+	if d > s.max {
+		s.max = d
+	}
+	s.sum += d
+}
+
+// String returns a summary of the stats, like ping's
+func (s *pingStats) String() string {
+	loss := 0.0
+	if s.sent > 0 {
+		loss = float64(s.failed) / float64(s.sent) * 100
+	}
+
+	summary := fmt.Sprintf("%d connections, %d failed, %.1f%% failure rate", s.sent, s.failed, loss)
+	if ok := s.sent - s.failed; ok > 0 {
+		summary += fmt.Sprintf("\nmin/avg/max = %s/%s/%s", s.min, s.sum/time.Duration(ok), s.max)
+	}
+	return summary
+}
+
+// probe sends a request of the given kind over conn, waiting for the
+// response to start. Only getting a connection doesn't mean anything made
+// it to the pod, as the local end of a tunnel accepts it before reaching
+// the other end.
+func probe(conn net.Conn, kind, host string) error {
+	var req []byte
+	switch kind {
+	case "connect":
+		return nil
+	case "http":
+		req = []byte("HEAD / HTTP/1.1\r\nHost: " + host + "\r\nConnection: close\r\n\r\n")
+	case "echo":
+		req = []byte("localizer ping\n")
+	}
+
+	if _, err := conn.Write(req); err != nil {
+		return errors.Wrap(err, "failed to send probe")
+	}
+
+	// any response to the HTTP request will do, even an error, as it came
+	// from the service
+	resp := make([]byte, 1)
+	if kind == "echo" {
+		resp = make([]byte, len(req))
+	}
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return errors.Wrap(err, "failed to read response")
+	}
+	if kind == "echo" && !bytes.Equal(req, resp) {
+		return fmt.Errorf("expected %q to be echoed, got %q", req, resp)
+	}
+	return nil
+}
+
+func NewPingCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name: "ping",
+		Description: "Repeatedly connect to a service and send it a request, printing how long each took or why it failed. " +
+			"Useful for finding out when, and how often, a tunnel drops",
+		Usage: "ping <namespace/service>:<port>",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "interval",
+				Usage: "Time to wait between connections",
+				Value: time.Second,
+			},
+			&cli.IntFlag{
+				Name:    "count",
+				Aliases: []string{"c"},
+				Usage:   "Stop after this many connections, 0 to run until interrupted",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "How long to wait for a connection, and its response, before considering it failed",
+				Value: 5 * time.Second,
+			},
+			&cli.StringFlag{
+				Name: "probe",
+				Usage: "What to send once connected: http (a HEAD request), echo (a line that's expected back), " +
+					"or connect (nothing, only the connection is timed)",
+				Value: "http",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			namespace, name, port, err := parseServicePort(qualifyService(c, c.Args().First()))
+			if err != nil {
+				return err
+			}
+
+			kind := c.String("probe")
+			switch kind {
+			case "http", "echo", "connect":
+			default:
+				return fmt.Errorf("unknown --probe '%s', expected http, echo, or connect", kind)
+			}
+
+			addr, closer, err := resolveServiceAddress(c.Context, log, c, namespace, name, port)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			fmt.Printf("PING %s/%s:%d (%s)\n", namespace, name, port, addr)
+
+			stats := &pingStats{}
+			t := time.NewTicker(c.Duration("interval"))
+			defer t.Stop()
+			for {
+				stats.sent++
+				start := time.Now()
+				conn, err := net.DialTimeout("tcp", addr, c.Duration("timeout"))
+				connected := time.Since(start)
+				if err == nil {
+					conn.SetDeadline(start.Add(c.Duration("timeout"))) //nolint:errcheck // Why: Only fails if closed
+					err = probe(conn, kind, name+"."+namespace)
+					conn.Close()
+				}
+				took := time.Since(start)
+				if err != nil {
+					stats.failed++
+					fmt.Printf("%s seq=%d failed after %s: %v\n", start.Format(time.RFC3339), stats.sent, took, err)
+				} else if kind == "connect" {
+					stats.add(took)
+					fmt.Printf("%s seq=%d connected in %s\n", start.Format(time.RFC3339), stats.sent, took)
+				} else {
+					stats.add(took)
+					fmt.Printf("%s seq=%d connected in %s, response in %s\n",
+						start.Format(time.RFC3339), stats.sent, connected, took)
+				}
+
+				if n := c.Int("count"); n > 0 && stats.sent >= n {
+					break
+				}
+
+				select {
+				case <-c.Context.Done():
+				case <-t.C:
+				}
+				if c.Context.Err() != nil {
+					break
+				}
+			}
+
+			fmt.Printf("\n--- %s/%s:%d ping statistics ---\n%s\n", namespace, name, port, stats)
+			return nil
+		},
+	}
+}
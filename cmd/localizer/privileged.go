@@ -0,0 +1,74 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/getoutreach/localizer/internal/privhelper"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// NewPrivilegedCommand is the privileged helper installed by setup, which
+// the daemon runs with sudo when it isn't running as root
+func NewPrivilegedCommand(_ logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        privhelper.Command,
+		Description: "Do the operations the daemon needs root for, this is ran by the daemon with sudo",
+		Hidden:      true,
+		Before: func(c *cli.Context) error {
+			privileged, err := isPrivileged()
+			if err != nil {
+				return err
+			}
+			if !privileged {
+				return fmt.Errorf("must be run as root")
+			}
+			return nil
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "check",
+				Usage: "check",
+				Action: func(c *cli.Context) error {
+					return nil
+				},
+			},
+			{
+				Name:      "hosts",
+				Usage:     "Replace the entries of a localizer block of /etc/hosts with the ones read from stdin",
+				ArgsUsage: "<block>",
+				Action: func(c *cli.Context) error {
+					return privhelper.WriteHosts(c.Context, c.Args().First(), os.Stdin)
+				},
+			},
+			{
+				Name:      "alias",
+				Usage:     "Add or remove a loopback alias",
+				ArgsUsage: "add|remove <ip>",
+				Action: func(c *cli.Context) error {
+					switch c.Args().First() {
+					case "add":
+						return privhelper.LoopbackAlias(c.Args().Get(1), true)
+					case "remove":
+						return privhelper.LoopbackAlias(c.Args().Get(1), false)
+					}
+					return fmt.Errorf("expected add or remove, got '%s'", c.Args().First())
+				},
+			},
+		},
+	}
+}
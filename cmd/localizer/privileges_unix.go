@@ -0,0 +1,34 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os/user"
+
+	"github.com/pkg/errors"
+)
+
+// isPrivileged returns true if we're running as root
+func isPrivileged() (bool, error) {
+	u, err := user.Current()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get current user")
+	}
+
+	return u.Uid == "0", nil
+}
@@ -0,0 +1,409 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recordedRequest is a request in a recording, which are stored as one
+// JSON object per line
+type recordedRequest struct {
+	Time time.Time `json:"time"`
+
+	// Service is the service the request was made to, as namespace/name,
+	// and Port is the port of it
+	Service string `json:"service"`
+	Port    string `json:"port"`
+
+	Method        string      `json:"method"`
+	URI           string      `json:"uri"`
+	Host          string      `json:"host"`
+	Header        http.Header `json:"header,omitempty"`
+	Body          []byte      `json:"body,omitempty"`
+	BodyTruncated bool        `json:"bodyTruncated,omitempty"`
+}
+
+// sensitiveHeaders are the headers that usually carry credentials, their
+// values aren't recorded unless --show-secrets is passed
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+	"X-Xsrf-Token",
+	"X-Amz-Security-Token",
+}
+
+// redactHeaders replaces the values of sensitiveHeaders, and of headers
+// whose name looks like they carry a secret, in h
+func redactHeaders(h http.Header) {
+	for name := range h {
+		lower := strings.ToLower(name)
+		sensitive := strings.Contains(lower, "token") || strings.Contains(lower, "secret") ||
+			strings.Contains(lower, "password") || strings.Contains(lower, "api-key")
+		for _, s := range sensitiveHeaders {
+			if http.CanonicalHeaderKey(name) == s {
+				sensitive = true
+			}
+		}
+
+		if sensitive {
+			h[name] = []string{redacted}
+		}
+	}
+}
+
+// isRedacted returns if the values of a header were redacted when it was
+// recorded, these aren't replayed
+func isRedacted(values []string) bool {
+	return len(values) == 1 && values[0] == redacted
+}
+
+func NewRecordCommand(log logrus.FieldLogger) *cli.Command { //nolint:funlen
+	return &cli.Command{
+		Name: "record",
+		Description: "Record the HTTP requests made to a forwarded service, to replay them later with 'localizer replay'. " +
+			"Requests are only seen by forwards that relay connections, so if the service's forward doesn't, e.g. because " +
+			"--drain-timeout, --buffer-size and --max-connections aren't set, it's recreated to, which drops the " +
+			"connections that are open to it. Headers that usually carry credentials, e.g. Authorization and Cookie, are " +
+			"redacted unless --show-secrets is passed, bodies are recorded as-is",
+		Usage: "record --output <file> <namespace/service>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "output",
+				Aliases:  []string{"o"},
+				Usage:    "File to write the requests to, '-' for stdout",
+				Required: true,
+			},
+			&cli.IntFlag{
+				Name:  "count",
+				Usage: "Stop after recording this many requests",
+			},
+			&cli.DurationFlag{
+				Name:  "duration",
+				Usage: "Stop after recording for this long",
+			},
+			&cli.BoolFlag{
+				Name:  "show-secrets",
+				Usage: "Record the values of headers that usually carry credentials, e.g. Authorization and Cookie",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			split := strings.Split(qualifyService(c, c.Args().First()), "/")
+			if len(split) != 2 {
+				return fmt.Errorf("invalid service, expected namespace/name")
+			}
+
+			var out io.Writer = os.Stdout
+			if c.String("output") != "-" {
+				// recordings can have credentials in them, e.g. in bodies
+				f, err := os.OpenFile(c.String("output"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+				if err != nil {
+					return errors.Wrap(err, "failed to create output file")
+				}
+				defer f.Close()
+				out = f
+			}
+
+			ctx, cancel := context.WithCancel(c.Context)
+			defer cancel()
+			if c.Duration("duration") > 0 {
+				ctx, cancel = context.WithTimeout(ctx, c.Duration("duration"))
+				defer cancel()
+			}
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			stream, err := client.Record(ctx, &api.RecordRequest{Namespace: split[0], Service: split[1]})
+			if err != nil {
+				return err
+			}
+
+			log.Infof("recording requests to %s/%s, press Ctrl+C to stop", split[0], split[1])
+
+			enc := json.NewEncoder(out)
+			count := 0
+			for c.Int("count") == 0 || count < c.Int("count") {
+				r, err := stream.Recv()
+				if err == io.EOF || status.Code(err) == codes.Canceled || status.Code(err) == codes.DeadlineExceeded {
+					break
+				} else if err != nil {
+					return err
+				}
+
+				header := make(http.Header)
+				for _, h := range r.Headers {
+					header.Add(h.Name, h.Value)
+				}
+				if !c.Bool("show-secrets") {
+					redactHeaders(header)
+				}
+
+				err = enc.Encode(&recordedRequest{
+					Time:          time.Unix(0, r.Time),
+					Service:       split[0] + "/" + split[1],
+					Port:          r.Port,
+					Method:        r.Method,
+					URI:           r.Uri,
+					Host:          r.Host,
+					Header:        header,
+					Body:          r.Body,
+					BodyTruncated: r.BodyTruncated,
+				})
+				if err != nil {
+					return errors.Wrap(err, "failed to write request")
+				}
+				count++
+			}
+
+			log.Infof("recorded %d requests", count)
+			return nil
+		},
+	}
+}
+
+// readRecording reads the requests in a recording made by 'localizer record'
+func readRecording(r io.Reader) ([]recordedRequest, error) {
+	reqs := make([]recordedRequest, 0)
+
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var req recordedRequest
+		if err := dec.Decode(&req); err == io.EOF {
+			return reqs, nil
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse request %d", len(reqs)+1)
+		}
+		reqs = append(reqs, req)
+	}
+}
+
+// replayTarget resolves where requests are replayed to, which is either a
+// URL, or a service as namespace/name[:port]. Without a port, requests are
+// sent to the port of the service they were recorded on.
+type replayTarget struct {
+	log logrus.FieldLogger
+	c   *cli.Context
+
+	base    string
+	service string
+	port    string
+
+	// addresses are the resolved addresses of the service's ports, and
+	// closers close them
+	addresses map[string]string
+	closers   []func()
+}
+
+func newReplayTarget(log logrus.FieldLogger, c *cli.Context, target string) (*replayTarget, error) {
+	t := &replayTarget{log: log, c: c, addresses: make(map[string]string)}
+	if strings.Contains(target, "://") {
+		if _, err := url.Parse(target); err != nil {
+			return nil, errors.Wrap(err, "invalid target url")
+		}
+		t.base = strings.TrimSuffix(target, "/")
+		return t, nil
+	}
+
+	service := target
+	if i := strings.LastIndex(target, ":"); i != -1 {
+		service, t.port = target[:i], target[i+1:]
+	}
+	t.service = qualifyService(c, service)
+	if len(strings.Split(t.service, "/")) != 2 {
+		return nil, fmt.Errorf("invalid target '%s', expected a url or namespace/service[:port]", target)
+	}
+	return t, nil
+}
+
+// urlFor returns the URL to replay a request to
+func (t *replayTarget) urlFor(ctx context.Context, r *recordedRequest) (string, error) {
+	uri := r.URI
+	if u, err := url.ParseRequestURI(uri); err == nil && u.IsAbs() {
+		uri = u.RequestURI()
+	}
+
+	if t.base != "" {
+		return t.base + uri, nil
+	}
+
+	port := t.port
+	if port == "" {
+		port = r.Port
+	}
+
+	addr, ok := t.addresses[port]
+	if !ok {
+		portInt, err := strconv.Atoi(port)
+		if err != nil {
+			return "", fmt.Errorf("invalid port '%s'", port)
+		}
+
+		spl := strings.Split(t.service, "/")
+		var closer func()
+		addr, closer, err = resolveServiceAddress(ctx, t.log, t.c, spl[0], spl[1], portInt)
+		if err != nil {
+			return "", err
+		}
+		t.closers = append(t.closers, closer)
+		t.addresses[port] = addr
+	}
+
+	return "http://" + addr + uri, nil
+}
+
+// Close closes the port-forwards used to reach the target
+func (t *replayTarget) Close() {
+	for _, closer := range t.closers {
+		closer()
+	}
+}
+
+func NewReplayCommand(log logrus.FieldLogger) *cli.Command { //nolint:funlen
+	return &cli.Command{
+		Name: "replay",
+		Description: "Replay requests recorded with 'localizer record' against a URL or a service. Headers that were " +
+			"redacted when they were recorded aren't sent",
+		Usage: "replay <file> <http://host:port|namespace/service[:port]>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "keep-timing",
+				Usage: "Wait between requests for as long as was waited between them when they were recorded",
+			},
+			&cli.StringFlag{
+				Name:  "host",
+				Usage: "Host header to send, instead of the recorded one",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "How long to wait for each request",
+				Value: 30 * time.Second,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 2 {
+				return fmt.Errorf("expected a recording and a target, e.g. replay requests.jsonl http://127.0.0.1:8080")
+			}
+
+			f, err := os.Open(c.Args().Get(0))
+			if err != nil {
+				return errors.Wrap(err, "failed to open recording")
+			}
+			defer f.Close()
+
+			reqs, err := readRecording(f)
+			if err != nil {
+				return err
+			}
+
+			target, err := newReplayTarget(log, c, c.Args().Get(1))
+			if err != nil {
+				return err
+			}
+			defer target.Close()
+
+			client := &http.Client{
+				Timeout: c.Duration("timeout"),
+				CheckRedirect: func(*http.Request, []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+			}
+
+			failed := 0
+			for i := range reqs {
+				r := &reqs[i]
+				if i > 0 && c.Bool("keep-timing") {
+					select {
+					case <-time.After(r.Time.Sub(reqs[i-1].Time)):
+					case <-c.Context.Done():
+						return c.Context.Err()
+					}
+				}
+
+				if r.BodyTruncated {
+					log.Warnf("skipping %s %s, its body was too large to be recorded", r.Method, r.URI)
+					continue
+				}
+
+				u, err := target.urlFor(c.Context, r)
+				if err != nil {
+					return err
+				}
+
+				req, err := http.NewRequestWithContext(c.Context, r.Method, u, bytes.NewReader(r.Body))
+				if err != nil {
+					return errors.Wrapf(err, "failed to create request %d", i+1)
+				}
+				req.Header = r.Header.Clone()
+				if req.Header == nil {
+					req.Header = make(http.Header)
+				}
+				for name, values := range req.Header {
+					if isRedacted(values) {
+						req.Header.Del(name)
+					}
+				}
+				req.Host = r.Host
+				if c.String("host") != "" {
+					req.Host = c.String("host")
+				}
+
+				started := time.Now()
+				resp, err := client.Do(req)
+				if err != nil {
+					failed++
+					fmt.Printf("%s %s -> %v\n", r.Method, r.URI, err)
+					continue
+				}
+				io.Copy(ioutil.Discard, resp.Body) //nolint:errcheck // Why: Only the status is shown
+				resp.Body.Close()
+
+				fmt.Printf("%s %s -> %d (%s)\n", r.Method, r.URI, resp.StatusCode, time.Since(started).Round(time.Millisecond))
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d requests failed", failed, len(reqs))
+			}
+			log.Infof("replayed %d requests", len(reqs))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/getoutreach/localizer/internal/relayagent"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func NewRelayAgentCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "relay-agent",
+		Description: "Run the in-cluster relay agent, this is deployed by the daemon when --relay-agent-image is set",
+		Hidden:      true,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "address",
+				Usage: "Address to listen on",
+				Value: fmt.Sprintf("127.0.0.1:%d", relayagent.Port),
+			},
+		},
+		Action: func(c *cli.Context) error {
+			l, err := net.Listen("tcp", c.String("address"))
+			if err != nil {
+				return errors.Wrap(err, "failed to listen")
+			}
+
+			log.WithField("address", l.Addr().String()).Info("relay agent listening")
+			return relayagent.Serve(c.Context, log, l)
+		},
+	}
+}
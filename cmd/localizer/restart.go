@@ -0,0 +1,70 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func NewRestartCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "restart",
+		Description: "Recreate the port-forwards of services, or a group of them, e.g. after a tunnel got stuck",
+		Usage:       "restart [namespace/service...] [--group <group>]",
+		ArgsUsage:   "[namespace/service...]",
+		Flags:       []cli.Flag{groupFlag},
+		Action: func(c *cli.Context) error {
+			services := make([]string, 0, c.NArg())
+			for _, arg := range c.Args().Slice() {
+				key := qualifyService(c, arg)
+				if len(strings.Split(key, "/")) != 2 {
+					return fmt.Errorf("invalid service '%s', expected namespace/name", arg)
+				}
+				services = append(services, key)
+			}
+			if len(services) == 0 && c.String("group") == "" {
+				return fmt.Errorf("services, or --group, are required")
+			}
+
+			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			resp, err := client.Restart(ctx, &api.RestartRequest{Services: services, Group: c.String("group")})
+			if err != nil {
+				return err
+			}
+
+			for _, key := range resp.Services {
+				log.Infof("restarting %s", key)
+			}
+			if len(resp.Services) == 0 {
+				log.Warn("none of the services are being forwarded, nothing was restarted")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+
+	"github.com/getoutreach/localizer/internal/privhelper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// setupUser returns the user that setup should allow to run the helper,
+// when ran through sudo that's the user that invoked sudo
+func setupUser() (string, error) {
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" && os.Geteuid() == 0 {
+		return sudoUser, nil
+	}
+
+	u, err := user.Current()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get current user")
+	}
+	if u.Uid == "0" {
+		return "", fmt.Errorf("run setup as the user that will run localizer, not root")
+	}
+	return u.Username, nil
+}
+
+func NewSetupCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name: "setup",
+		Description: "Install a helper for the operations that need root, so that the daemon can be started without sudo. " +
+			"This prompts for your password once.",
+		Usage: "setup",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "uninstall",
+				Usage: "Remove the helper, after which the daemon has to be ran with sudo again",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if runtime.GOOS == "windows" {
+				return fmt.Errorf("setup isn't supported on Windows, run localizer as an Administrator instead")
+			}
+
+			if c.Bool("uninstall") {
+				if err := privhelper.Uninstall(c.Context, os.Stdin, os.Stderr); err != nil {
+					return err
+				}
+				log.Infof("removed %s and %s", privhelper.HelperPath, privhelper.SudoersPath)
+				return nil
+			}
+
+			username, err := setupUser()
+			if err != nil {
+				return err
+			}
+
+			exe, err := os.Executable()
+			if err != nil {
+				return errors.Wrap(err, "failed to find localizer binary")
+			}
+			if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+				exe = resolved
+			}
+
+			log.Infof("installing %s as %s, and allowing %s to run it with sudo without a password",
+				exe, privhelper.HelperPath, username)
+			if err := privhelper.Install(c.Context, username, exe, os.Stdin, os.Stderr); err != nil {
+				return err
+			}
+
+			if _, err := privhelper.NewClient(c.Context); err != nil {
+				return errors.Wrap(err, "installed the helper, but failed to run it")
+			}
+
+			log.Info("done, localizer can now be started without sudo")
+			return nil
+		},
+	}
+}
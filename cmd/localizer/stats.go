@@ -0,0 +1,111 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// ipPoolWarnThreshold is the fraction of the IP pool that, once in use,
+// is warned about
+const ipPoolWarnThreshold = 0.9
+
+// formatIPPool formats the usage of the daemon's IP pool, noting if it's
+// running out of addresses
+func formatIPPool(resp *api.GetRuntimeStatsResponse) string {
+	if resp.IpPoolCidr == "" {
+		return "Unknown"
+	}
+
+	s := fmt.Sprintf("%s (%d/%d in use)", resp.IpPoolCidr, resp.IpPoolAcquired, resp.IpPoolAvailable)
+	if resp.IpPoolAcquired >= resp.IpPoolAvailable {
+		s += ", exhausted! Use a larger --ip-cidr"
+	} else if float64(resp.IpPoolAcquired) >= float64(resp.IpPoolAvailable)*ipPoolWarnThreshold {
+		s += ", almost exhausted"
+	}
+	return s
+}
+
+// formatAPIRequests formats the number of requests the daemon has made to
+// the Kubernetes API, and how many of them failed
+func formatAPIRequests(resp *api.GetRuntimeStatsResponse) string {
+	total, failed := int64(0), int64(0)
+	for _, r := range resp.KubeApiRequests {
+		total += r.Count
+		if r.Code == 0 || r.Code >= 400 {
+			failed += r.Count
+		}
+	}
+
+	s := fmt.Sprintf("%d", total)
+	if resp.UptimeSeconds > 0 {
+		s += fmt.Sprintf(" (%.1f/min)", float64(total)/(float64(resp.UptimeSeconds)/60))
+	}
+	if failed > 0 {
+		s += fmt.Sprintf(", %d failed", failed)
+	}
+	return s
+}
+
+func NewStatsCommand(_ logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "stats",
+		Description: "Show runtime statistics of the daemon, such as goroutines and memory usage",
+		Usage:       "stats",
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			resp, err := client.GetRuntimeStats(ctx, &api.GetRuntimeStatsRequest{})
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 10, 0, 3, ' ', 0)
+			fmt.Fprintf(w, "Uptime:\t%s\n", time.Duration(resp.UptimeSeconds)*time.Second)
+			fmt.Fprintf(w, "Goroutines:\t%d\n", resp.Goroutines)
+			fmt.Fprintf(w, "Heap Allocated:\t%.1f MiB\n", float64(resp.HeapAllocBytes)/1024/1024)
+			fmt.Fprintf(w, "Heap Objects:\t%d\n", resp.HeapObjects)
+			fmt.Fprintf(w, "System Memory:\t%.1f MiB\n", float64(resp.SysBytes)/1024/1024)
+			fmt.Fprintf(w, "GC Cycles:\t%d\n", resp.NumGc)
+			fmt.Fprintf(w, "Forwarded Tunnels:\t%d\n", resp.ForwardedTunnels)
+			fmt.Fprintf(w, "Exposed Tunnels:\t%d\n", resp.ExposedTunnels)
+			fmt.Fprintf(w, "IP Pool:\t%s\n", formatIPPool(resp))
+			fmt.Fprintf(w, "Kube API Requests:\t%s\n", formatAPIRequests(resp))
+			fmt.Fprintf(w, "Kube API Throttled:\t%d (%.1fs total)\n", resp.KubeApiThrottled, resp.KubeApiThrottledSeconds)
+			for _, r := range resp.KubeApiRequests {
+				code := "no response"
+				if r.Code != 0 {
+					code = fmt.Sprint(r.Code)
+				}
+				fmt.Fprintf(w, "  %s %s (%s):\t%d\n", r.Verb, r.Resource, code, r.Count)
+			}
+			return w.Flush()
+		},
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/internal/updater"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// NoUpdateCheckEnvVar disables checking for new versions in status
+const NoUpdateCheckEnvVar = "LOCALIZER_NO_UPDATE_CHECK"
+
+// formatHostnameCollision returns a human readable version of a hostname
+// collision, e.g. api: auth/api (used), billing/api
+func formatHostnameCollision(c *api.HostnameCollision) string {
+	services := make([]string, len(c.Services))
+	for i, s := range c.Services {
+		services[i] = s
+		if s == c.Owner {
+			services[i] += " (used)"
+		}
+	}
+
+	summary := fmt.Sprintf("%s: %s", c.Hostname, strings.Join(services, ", "))
+	if c.Owner == "" {
+		summary += " (none used)"
+	}
+	return summary
+}
+
+func NewStatusCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "status",
+		Description: "Show the status of the daemon, and if a new version of localizer is available",
+		Usage:       "status",
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+
+			w := tabwriter.NewWriter(os.Stdout, 10, 0, 3, ' ', 0)
+			fmt.Fprintf(w, "Version:\t%s\n", Version)
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				log.WithError(err).Debug("failed to connect to daemon")
+				fmt.Fprintf(w, "Daemon:\tNot Running\n")
+			} else {
+				defer closer()
+
+				stable, err := client.Stable(ctx, &api.Empty{})
+				if err != nil {
+					return err
+				}
+
+				stats, err := client.GetRuntimeStats(ctx, &api.GetRuntimeStatsRequest{})
+				if err != nil {
+					return err
+				}
+
+				state := "Running"
+				if !stable.Stable {
+					state = "Running (starting up)"
+				}
+				fmt.Fprintf(w, "Daemon:\t%s\n", state)
+				fmt.Fprintf(w, "Uptime:\t%s\n", time.Duration(stats.UptimeSeconds)*time.Second)
+				if stats.KubeContext != "" {
+					kubeContext := stats.KubeContext
+					if stats.CurrentKubeContext != stats.KubeContext {
+						kubeContext += fmt.Sprintf(" (WARNING: kubeconfig is now using %s, restart to switch)",
+							stats.CurrentKubeContext)
+					}
+					fmt.Fprintf(w, "Context:\t%s\n", kubeContext)
+				}
+				fmt.Fprintf(w, "Forwarded:\t%d\n", stats.ForwardedTunnels)
+				fmt.Fprintf(w, "Exposed:\t%d\n", stats.ExposedTunnels)
+				fmt.Fprintf(w, "IP Pool:\t%s\n", formatIPPool(stats))
+				for i, collision := range stats.HostnameCollisions {
+					label := ""
+					if i == 0 {
+						label = "Hostname Collisions:"
+					}
+					fmt.Fprintf(w, "%s\t%s\n", label, formatHostnameCollision(collision))
+				}
+			}
+
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			if os.Getenv(NoUpdateCheckEnvVar) == "" {
+				// this is only a notice, so don't let it slow us down
+				checkCtx, checkCancel := context.WithTimeout(c.Context, 2*time.Second)
+				defer checkCancel()
+
+				latest, err := updater.CheckForUpdate(checkCtx, Version)
+				if err != nil {
+					log.WithError(err).Debug("failed to check for updates")
+				} else if latest != "" {
+					fmt.Printf("\nA new version of localizer is available: %s (current: %s), run 'localizer upgrade' to install it\n", latest, Version)
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/getoutreach/localizer/internal/updater"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func NewUpgradeCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name:        "upgrade",
+		Description: "Upgrade localizer to the latest release",
+		Usage:       "upgrade",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "Install the latest release even if it isn't newer than this version",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			// releases can't be verified without the key they're signed
+			// with, e.g. by a build from source
+			if ReleasePublicKey == "" {
+				return fmt.Errorf("this build of localizer can't verify releases, download one from GitHub instead")
+			}
+			key, err := updater.ParsePublicKey(ReleasePublicKey)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(c.Context, 5*time.Minute)
+			defer cancel()
+
+			r, err := updater.LatestRelease(ctx)
+			if err != nil {
+				return err
+			}
+
+			if !updater.IsNewer(Version, r.TagName) && !c.Bool("force") {
+				log.Infof("localizer is up to date (%s)", Version)
+				return nil
+			}
+
+			exe, err := os.Executable()
+			if err != nil {
+				return errors.Wrap(err, "failed to find current binary")
+			}
+
+			exe, err = filepath.EvalSymlinks(exe)
+			if err != nil {
+				return errors.Wrap(err, "failed to find current binary")
+			}
+
+			log.Infof("upgrading %s from %s to %s", exe, Version, r.TagName)
+			if err := updater.Install(ctx, r, exe, key); err != nil {
+				return err
+			}
+
+			log.Infof("upgraded to %s, restart the daemon to use it", r.TagName)
+			return nil
+		},
+	}
+}
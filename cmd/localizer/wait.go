@@ -0,0 +1,92 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func NewWaitCommand(log logrus.FieldLogger) *cli.Command {
+	return &cli.Command{
+		Name: "wait",
+		Description: "Wait for the daemon to finish starting up, i.e. every service has been processed and " +
+			"port-forwards have stopped changing. Useful for scripts that need services to be forwarded",
+		Usage: "wait",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "How long to wait before giving up, 0 to wait forever",
+				Value: 5 * time.Minute,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			ctx := c.Context
+			if timeout := c.Duration("timeout"); timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			client, closer, err := connectDaemon(ctx, c)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			t := time.NewTicker(time.Second)
+			defer t.Stop()
+
+			var last *api.ReadyResponse
+			for {
+				ready, err := client.Ready(ctx, &api.Empty{})
+				if err != nil {
+					if ctx.Err() != nil && last != nil {
+						return waitTimedOut(last)
+					}
+					return errors.Wrap(err, "failed to check if the daemon is ready")
+				}
+				if ready.Ready {
+					log.Infof("ready, created %d/%d port-forwards", ready.Running, ready.Total)
+					return nil
+				}
+
+				if last == nil || ready.Running != last.Running || ready.Queued != last.Queued {
+					log.WithField("queued", ready.Queued).WithField("waiting", len(ready.Waiting)).
+						Infof("created %d/%d port-forwards", ready.Running, ready.Total)
+				}
+				last = ready
+
+				select {
+				case <-ctx.Done():
+					return waitTimedOut(last)
+				case <-t.C:
+				}
+			}
+		},
+	}
+}
+
+// waitTimedOut returns the error for giving up on the daemon, with how far
+// along it got
+func waitTimedOut(last *api.ReadyResponse) error {
+	return fmt.Errorf("timed out waiting for the daemon, %d/%d port-forwards created, %d queued",
+		last.Running, last.Total, last.Queued)
+}
@@ -0,0 +1,282 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package config contains the configuration file of localizer
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/getoutreach/localizer/pkg/localizer"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// EnvVar is the environment variable that overrides the path of the
+// configuration file
+const EnvVar = "LOCALIZER_CONFIG"
+
+// Config is the configuration file of localizer. Everything in it is
+// optional.
+type Config struct {
+	// Services configures specific services
+	Services []Service `json:"services,omitempty"`
+
+	// Hooks are commands to run when events happen
+	Hooks []Hook `json:"hooks,omitempty"`
+
+	// Webhooks are URLs that events are sent to
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+
+	// Expose configures exposing services
+	Expose Expose `json:"expose,omitempty"`
+
+	// Hostnames configures the hostnames given to forwarded services
+	Hostnames Hostnames `json:"hostnames,omitempty"`
+}
+
+// Hostnames is the configuration of the hostnames given to forwarded
+// services
+type Hostnames struct {
+	// Collisions is how a hostname that more than one service would get,
+	// e.g. the name of services in different namespaces, is handled. One
+	// of first (the default), qualified, or priority.
+	Collisions string `json:"collisions,omitempty"`
+
+	// Priority are namespaces, highest priority first, whose services get
+	// a hostname when it collides. Used by the priority strategy.
+	Priority []string `json:"priority,omitempty"`
+
+	// Suffixes are extra DNS suffixes given to services, keyed by
+	// namespace, e.g. payments.test makes the api service in payments
+	// reachable at api.payments.test
+	Suffixes map[string]string `json:"suffixes,omitempty"`
+}
+
+// Expose is the configuration of exposing services
+type Expose struct {
+	// Annotations are set on a service, and the controllers that are
+	// scaled down, while it's exposed. They're removed, or set back to
+	// their previous values, when it stops. This can be used to silence
+	// alerts or monitoring that would otherwise fire.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Hook is a command that is ran when an event happens. The event is passed
+// to it as JSON on stdin, and as LOCALIZER_* environment variables.
+type Hook struct {
+	// Events are the events to run this hook on, if empty it's ran on
+	// every event
+	Events []string `json:"events,omitempty"`
+
+	// Command is the command to run, and its arguments
+	Command []string `json:"command"`
+
+	// Timeout is how long the command can run for before it's killed,
+	// defaults to 30s
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// Service is the configuration of a single service
+type Service struct {
+	// Name is the service this applies to, in the format of namespace/name
+	Name string `json:"name"`
+
+	// Priority controls the order port-forwards are created and recreated
+	// in, higher priorities go first. Overrides the priority annotation.
+	Priority int `json:"priority,omitempty"`
+
+	// Compress compresses connections to the service, which helps with
+	// chatty text protocols on slow connections. Requires the relay agent.
+	// Overrides the compress annotation.
+	Compress *bool `json:"compress,omitempty"`
+
+	// LeaderLock is the leader election lock of the service's pods, e.g.
+	// lease/my-controller, so the pod holding it is forwarded to.
+	// Overrides the leader-lock annotation.
+	LeaderLock string `json:"leaderLock,omitempty"`
+
+	// FanOut forwards every ready replica of the service on its own IP, in
+	// addition to the service. Overrides the fan-out annotation.
+	FanOut *bool `json:"fanOut,omitempty"`
+
+	// PortNames, if set, are the only named ports of the service that are
+	// forwarded. Overrides --only-port-names.
+	PortNames []string `json:"portNames,omitempty"`
+
+	// Wildcard makes subdomains of the service's hostnames, e.g.
+	// tenant.myapp.ns, resolve to it. Only works with the DNS server.
+	Wildcard bool `json:"wildcard,omitempty"`
+
+	// Groups puts the service in groups, in addition to the one from its
+	// group label, so it can be restarted or stopped along with the rest
+	// of them
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Webhook is a URL that events are POSTed to
+type Webhook struct {
+	// URL is the URL to send events to
+	URL string `json:"url"`
+
+	// Events are the events to send, if empty every event is sent
+	Events []string `json:"events,omitempty"`
+
+	// Body is a Go template of the request body, which is passed the
+	// event. Defaults to the event as JSON.
+	Body string `json:"body,omitempty"`
+
+	// Headers are extra headers to send, e.g. Authorization
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Timeout is how long a request can take, defaults to 30s
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// DefaultPath returns the default location of the configuration file,
+// ~/.localizer/config.yaml
+func DefaultPath() (string, error) {
+	dir, err := localizer.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// Load reads the configuration file at the given path. If path is empty the
+// default path is used, and it not existing results in an empty config.
+func Load(path string) (*Config, error) {
+	optional := path == ""
+	if optional {
+		var err error
+		path, err = DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && optional {
+		return &Config{}, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read config file")
+	}
+
+	conf, err := Parse(b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse config file '%s'", path)
+	}
+
+	return conf, nil
+}
+
+// Parse parses a configuration file. Keys that aren't known are an error,
+// which includes the line they're on.
+func Parse(b []byte) (*Config, error) {
+	var conf Config
+	if err := yaml.UnmarshalStrict(b, &conf); err != nil {
+		if line := lineOfError(b, err); line != 0 {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+// Priorities returns the configured priority of services, keyed by
+// namespace/name
+func (c *Config) Priorities() map[string]int {
+	priorities := make(map[string]int)
+	for _, s := range c.Services {
+		if s.Priority != 0 {
+			priorities[s.Name] = s.Priority
+		}
+	}
+	return priorities
+}
+
+// LeaderLocks returns the services that have a leader election lock
+// configured, keyed by namespace/name
+func (c *Config) LeaderLocks() map[string]string {
+	locks := make(map[string]string)
+	for _, s := range c.Services {
+		if s.LeaderLock != "" {
+			locks[s.Name] = s.LeaderLock
+		}
+	}
+	return locks
+}
+
+// FanOut returns the services that have fan-out configured, keyed by
+// namespace/name
+func (c *Config) FanOut() map[string]bool {
+	fanOut := make(map[string]bool)
+	for _, s := range c.Services {
+		if s.FanOut != nil {
+			fanOut[s.Name] = *s.FanOut
+		}
+	}
+	return fanOut
+}
+
+// Compress returns the services that have compression configured, keyed
+// by namespace/name
+func (c *Config) Compress() map[string]bool {
+	compress := make(map[string]bool)
+	for _, s := range c.Services {
+		if s.Compress != nil {
+			compress[s.Name] = *s.Compress
+		}
+	}
+	return compress
+}
+
+// Wildcards returns the services that subdomains of their hostnames
+// should resolve to, keyed by namespace/name
+func (c *Config) Wildcards() map[string]bool {
+	wildcards := make(map[string]bool)
+	for _, s := range c.Services {
+		if s.Wildcard {
+			wildcards[s.Name] = true
+		}
+	}
+	return wildcards
+}
+
+// Groups returns the services that are in groups, keyed by namespace/name
+func (c *Config) Groups() map[string][]string {
+	groups := make(map[string][]string)
+	for _, s := range c.Services {
+		if len(s.Groups) > 0 {
+			groups[s.Name] = s.Groups
+		}
+	}
+	return groups
+}
+
+// PortNames returns the services that have the names of the ports to
+// forward configured, keyed by namespace/name
+func (c *Config) PortNames() map[string][]string {
+	portNames := make(map[string][]string)
+	for _, s := range c.Services {
+		if len(s.PortNames) > 0 {
+			portNames[s.Name] = s.PortNames
+		}
+	}
+	return portNames
+}
@@ -0,0 +1,313 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/getoutreach/localizer/pkg/hostsfile"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Problem is something wrong with the configuration file
+type Problem struct {
+	// Path is where the problem is, e.g. services[0].name
+	Path string
+
+	// Line is the line of the file the problem is on, 0 if it isn't known
+	Line int
+
+	Message string
+}
+
+// Error implements error
+func (p Problem) Error() string {
+	msg := p.Message
+	if p.Path != "" {
+		msg = p.Path + ": " + msg
+	}
+	if p.Line != 0 {
+		msg = fmt.Sprintf("line %d: %s", p.Line, msg)
+	}
+	return msg
+}
+
+// Validate returns the problems with the configuration that parsing it
+// doesn't catch, e.g. services that aren't namespace/name. Hooks and
+// webhooks are only checked for what's in the configuration, their events
+// are checked by the hooks package.
+func (c *Config) Validate() []Problem { //nolint:funlen,gocyclo
+	problems := []Problem{}
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seen := make(map[string]int)
+	for i := range c.Services {
+		s := &c.Services[i]
+		path := fmt.Sprintf("services[%d]", i)
+
+		if spl := strings.Split(s.Name, "/"); len(spl) != 2 || spl[0] == "" || spl[1] == "" {
+			add(path+".name", "must be namespace/name, got '%s'", s.Name)
+		} else if j, ok := seen[s.Name]; ok {
+			add(path+".name", "'%s' is already configured by services[%d]", s.Name, j)
+		} else {
+			seen[s.Name] = i
+		}
+
+		if s.LeaderLock != "" {
+			kind := "lease"
+			if spl := strings.SplitN(s.LeaderLock, "/", 2); len(spl) == 2 {
+				kind = strings.ToLower(spl[0])
+			}
+			if kind != "lease" && kind != "configmap" && kind != "endpoints" {
+				add(path+".leaderLock", "unknown kind '%s', expected one of: lease, configmap, endpoints", kind)
+			}
+		}
+
+		for j, name := range s.PortNames {
+			if name == "" {
+				add(fmt.Sprintf("%s.portNames[%d]", path, j), "can't be empty")
+			}
+		}
+
+		// groups can also come from a label, so they're held to the same rules
+		for j, group := range s.Groups {
+			if errs := validation.IsValidLabelValue(group); group == "" || len(errs) > 0 {
+				add(fmt.Sprintf("%s.groups[%d]", path, j), "'%s' isn't a valid label value", group)
+			}
+		}
+	}
+
+	switch c.Hostnames.Collisions {
+	case "", "first", "qualified", "priority":
+	default:
+		add("hostnames.collisions", "unknown strategy '%s', expected one of: first, qualified, priority",
+			c.Hostnames.Collisions)
+	}
+	if len(c.Hostnames.Priority) > 0 && c.Hostnames.Collisions != "priority" {
+		add("hostnames.priority", "is only used when collisions is priority")
+	}
+	for namespace, suffix := range c.Hostnames.Suffixes {
+		path := "hostnames.suffixes." + namespace
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			add(path, "'%s' isn't a valid namespace: %s", namespace, strings.Join(errs, ", "))
+		}
+
+		// suffixes are normalized like hostnames, e.g. to punycode
+		suffix = strings.TrimPrefix(strings.TrimPrefix(suffix, "*"), ".")
+		normalized, err := hostsfile.NormalizeHostname(suffix)
+		if err != nil {
+			add(path, "'%s' isn't a valid DNS suffix: %v", suffix, err)
+		} else if errs := validation.IsDNS1123Subdomain(normalized); len(errs) > 0 {
+			add(path, "'%s' isn't a valid DNS suffix: %s", suffix, strings.Join(errs, ", "))
+		}
+	}
+
+	for key := range c.Expose.Annotations {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			add("expose.annotations."+key, "isn't a valid annotation: %s", strings.Join(errs, ", "))
+		}
+	}
+
+	for i := range c.Hooks {
+		h := &c.Hooks[i]
+		if len(h.Command) == 0 {
+			add(fmt.Sprintf("hooks[%d]", i), "has no command")
+		}
+		if h.Timeout.Duration < 0 {
+			add(fmt.Sprintf("hooks[%d].timeout", i), "can't be negative")
+		}
+	}
+
+	for i := range c.Webhooks {
+		w := &c.Webhooks[i]
+		if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			add(fmt.Sprintf("webhooks[%d].url", i), "must be an http or https url, got '%s'", w.URL)
+		}
+		if w.Timeout.Duration < 0 {
+			add(fmt.Sprintf("webhooks[%d].timeout", i), "can't be negative")
+		}
+	}
+
+	return problems
+}
+
+// yamlNode is a key, or an item of a list, in a YAML document
+type yamlNode struct {
+	line     int
+	indent   int
+	key      string
+	item     bool
+	children []*yamlNode
+}
+
+// yamlKey matches a key at the start of a line, e.g. name: or "name":
+var yamlKey = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s#"'][^:]*?):(\s+|$)`)
+
+// parseYAMLLines finds the keys and list items of a block style YAML
+// document, and the lines they're on. It's only used to point at where
+// problems are, so anything it doesn't understand, e.g. flow style
+// mappings, is skipped rather than being an error.
+func parseYAMLLines(b []byte) *yamlNode {
+	root := &yamlNode{indent: -1}
+	stack := []*yamlNode{root}
+	push := func(n *yamlNode) {
+		// items can be at the same indentation as the key they're in
+		for len(stack) > 1 {
+			top := stack[len(stack)-1]
+			if top.indent < n.indent || (top.indent == n.indent && n.item && !top.item) {
+				break
+			}
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, n)
+		stack = append(stack, n)
+	}
+
+	scalarIndent := -1
+	for i, line := range strings.Split(string(b), "\n") {
+		rest := strings.TrimLeft(line, " ")
+		indent := len(line) - len(rest)
+		if strings.TrimSpace(rest) == "" || strings.HasPrefix(rest, "#") || strings.HasPrefix(rest, "---") {
+			continue
+		}
+
+		// lines of a multi-line string
+		if scalarIndent != -1 && indent > scalarIndent {
+			continue
+		}
+		scalarIndent = -1
+
+		for {
+			if rest == "-" || strings.HasPrefix(rest, "- ") {
+				push(&yamlNode{line: i + 1, indent: indent, item: true})
+				trimmed := strings.TrimLeft(strings.TrimPrefix(rest, "-"), " ")
+				indent += len(rest) - len(trimmed)
+				rest = trimmed
+				continue
+			}
+
+			m := yamlKey.FindStringSubmatch(rest)
+			if m == nil {
+				break
+			}
+			push(&yamlNode{line: i + 1, indent: indent, key: strings.Trim(m[1], `"'`)})
+
+			value := strings.TrimSpace(rest[len(m[0]):])
+			if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+				scalarIndent = indent
+			}
+			break
+		}
+	}
+
+	return root
+}
+
+// splitPath splits a path, e.g. services[0].name, into the keys and list
+// indexes in it
+func splitPath(path string) []string {
+	segments := []string{}
+	for _, part := range strings.Split(path, ".") {
+		for {
+			i := strings.Index(part, "[")
+			if i == -1 {
+				break
+			}
+			if i > 0 {
+				segments = append(segments, part[:i])
+			}
+			end := strings.Index(part, "]")
+			if end < i {
+				break
+			}
+			segments = append(segments, part[i:end+1])
+			part = part[end+1:]
+		}
+		if part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return segments
+}
+
+// LineOf returns the line of a configuration file that the given path,
+// e.g. services[0].name, is on. If it can't be found, the line of the
+// closest parent of it is returned, or 0 if there isn't one.
+func LineOf(b []byte, path string) int {
+	n := parseYAMLLines(b)
+	for _, seg := range splitPath(path) {
+		var next *yamlNode
+		if strings.HasPrefix(seg, "[") {
+			index, err := strconv.Atoi(strings.Trim(seg, "[]"))
+			if err != nil {
+				break
+			}
+			for _, c := range n.children {
+				if c.item {
+					if index == 0 {
+						next = c
+						break
+					}
+					index--
+				}
+			}
+		} else {
+			for _, c := range n.children {
+				if !c.item && c.key == seg {
+					next = c
+					break
+				}
+			}
+		}
+		if next == nil {
+			break
+		}
+		n = next
+	}
+
+	return n.line
+}
+
+// unknownField matches the error for a key that isn't in the configuration
+var unknownField = regexp.MustCompile(`unknown field "([^"]+)"`)
+
+// lineOfError returns the line of a configuration file that an error
+// parsing it is about, if it can be found
+func lineOfError(b []byte, err error) int {
+	m := unknownField.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0
+	}
+
+	var find func(n *yamlNode) int
+	find = func(n *yamlNode) int {
+		for _, c := range n.children {
+			if !c.item && c.key == m[1] {
+				return c.line
+			}
+			if line := find(c); line != 0 {
+				return line
+			}
+		}
+		return 0
+	}
+	return find(parseYAMLLines(b))
+}
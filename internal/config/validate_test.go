@@ -0,0 +1,94 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const testConfig = `# services
+services:
+  - name: default/api
+    priority: 10
+  - name: bad
+    leaderLock: pod/x
+    groups: [infra, "not a label"]
+hostnames:
+  collisions: nope
+webhooks:
+- url: ftp://example.com
+  body: |
+    name: {{.Service}}
+  events: [stable]
+`
+
+func TestValidate(t *testing.T) {
+	conf, err := Parse([]byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{
+		"services[1].name":       5,
+		"services[1].leaderLock": 6,
+		"services[1].groups[1]":  7,
+		"hostnames.collisions":   9,
+		"webhooks[0].url":        11,
+	}
+
+	problems := conf.Validate()
+	if len(problems) != len(expected) {
+		t.Errorf("expected %d problems, got %v", len(expected), problems)
+	}
+	for _, p := range problems {
+		line, ok := expected[p.Path]
+		if !ok {
+			t.Errorf("unexpected problem: %v", p)
+			continue
+		}
+		if got := LineOf([]byte(testConfig), p.Path); got != line {
+			t.Errorf("expected %s on line %d, got %d", p.Path, line, got)
+		}
+	}
+}
+
+func TestLineOf(t *testing.T) {
+	tests := []struct {
+		path string
+		line int
+	}{
+		{"services", 2},
+		{"services[0]", 3},
+		{"services[0].priority", 4},
+		{"webhooks[0].events", 14},
+		// the multi-line body isn't mistaken for keys
+		{"webhooks[0].body.name", 12},
+		// missing keys fall back to their parent
+		{"services[1].portNames[0]", 5},
+		{"expose", 0},
+	}
+	for _, tt := range tests {
+		if got := LineOf([]byte(testConfig), tt.path); got != tt.line {
+			t.Errorf("%s: expected line %d, got %d", tt.path, tt.line, got)
+		}
+	}
+}
+
+func TestParseUnknownField(t *testing.T) {
+	_, err := Parse([]byte("services:\n  - name: a/b\n    prio: 1\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected an error on line 3, got %v", err)
+	}
+}
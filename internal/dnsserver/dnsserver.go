@@ -0,0 +1,127 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package dnsserver implements a small DNS server that answers for the
+// hostnames of forwarded services, including wildcards that can't be
+// written to a hosts file
+package dnsserver
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// TTL is the number of seconds answers can be cached for. It's short
+// because IPs change as services come and go.
+const TTL = 5
+
+// LookupFunc returns the IP that a hostname, without a trailing dot,
+// resolves to
+type LookupFunc func(name string) (net.IP, bool)
+
+// Server answers A queries over UDP using a LookupFunc. Every other query
+// for a known hostname gets an empty answer, and unknown hostnames get
+// NXDOMAIN.
+type Server struct {
+	log    logrus.FieldLogger
+	lookup LookupFunc
+}
+
+// NewServer creates a new DNS server that answers using lookup
+func NewServer(log logrus.FieldLogger, lookup LookupFunc) *Server {
+	return &Server{log: log, lookup: lookup}
+}
+
+// Serve answers queries received on conn until ctx is canceled
+func (s *Server) Serve(ctx context.Context, conn net.PacketConn) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "failed to read query")
+		}
+
+		resp, err := s.answer(buf[:n])
+		if err != nil {
+			s.log.WithError(err).Debug("failed to answer dns query")
+			continue
+		}
+
+		if _, err := conn.WriteTo(resp, addr); err != nil {
+			s.log.WithError(err).Debug("failed to write dns response")
+		}
+	}
+}
+
+// answer builds the response to a query
+func (s *Server) answer(query []byte) ([]byte, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse query")
+	}
+
+	q, err := p.Question()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse question")
+	}
+
+	h.Response = true
+	h.Authoritative = true
+	h.RecursionAvailable = false
+
+	ip, ok := s.lookup(strings.TrimSuffix(q.Name.String(), "."))
+	if !ok {
+		h.RCode = dnsmessage.RCodeNameError
+	}
+
+	b := dnsmessage.NewBuilder(nil, h)
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(q); err != nil {
+		return nil, err
+	}
+
+	if ip4 := ip.To4(); ok && ip4 != nil && q.Type == dnsmessage.TypeA {
+		if err := b.StartAnswers(); err != nil {
+			return nil, err
+		}
+
+		var a dnsmessage.AResource
+		copy(a.A[:], ip4)
+		if err := b.AResource(dnsmessage.ResourceHeader{
+			Name:  q.Name,
+			Class: dnsmessage.ClassINET,
+			TTL:   TTL,
+		}, a); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Finish()
+}
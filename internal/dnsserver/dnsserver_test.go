@@ -0,0 +1,112 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package dnsserver
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// query sends a query for name to the server at addr, returning its
+// response
+func query(t *testing.T, addr, name string, typ dnsmessage.Type) (dnsmessage.Header, []dnsmessage.Resource) {
+	t.Helper()
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 1, RecursionDesired: true})
+	if err := b.StartQuestions(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(name),
+		Type:  typ,
+		Class: dnsmessage.ClassINET,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	req, err := b.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second)) //nolint:errcheck // Why: Checked by the read
+
+	if _, err := conn.Write(req); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 512)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(buf[:n]); err != nil {
+		t.Fatal(err)
+	}
+	if msg.ID != 1 {
+		t.Fatalf("expected response to query 1, got %d", msg.ID)
+	}
+	return msg.Header, msg.Answers
+}
+
+func TestServer(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	go NewServer(log, func(name string) (net.IP, bool) {
+		if name == "tenant.myapp.ns" {
+			return net.ParseIP("127.0.0.5"), true
+		}
+		return nil, false
+	}).Serve(ctx, conn) //nolint:errcheck // Why: Stopped by the test finishing
+
+	addr := conn.LocalAddr().String()
+
+	h, answers := query(t, addr, "tenant.myapp.ns.", dnsmessage.TypeA)
+	if h.RCode != dnsmessage.RCodeSuccess || len(answers) != 1 {
+		t.Fatalf("expected one answer, got %v with %d answers", h.RCode, len(answers))
+	}
+	if a, ok := answers[0].Body.(*dnsmessage.AResource); !ok || net.IP(a.A[:]).String() != "127.0.0.5" {
+		t.Fatalf("expected an A record for 127.0.0.5, got %v", answers[0].Body)
+	}
+
+	h, answers = query(t, addr, "tenant.myapp.ns.", dnsmessage.TypeAAAA)
+	if h.RCode != dnsmessage.RCodeSuccess || len(answers) != 0 {
+		t.Fatalf("expected no answers for AAAA, got %v with %d answers", h.RCode, len(answers))
+	}
+
+	h, _ = query(t, addr, "unknown.ns.", dnsmessage.TypeA)
+	if h.RCode != dnsmessage.RCodeNameError {
+		t.Fatalf("expected NXDOMAIN for an unknown hostname, got %v", h.RCode)
+	}
+}
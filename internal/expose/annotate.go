@@ -0,0 +1,109 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package expose
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+// AnnotationsPodLabel is the annotation on the expose pod that stores
+// the annotations that need to be restored if localizer exits uncleanly
+const AnnotationsPodLabel = "localizer.jaredallard.github.com/annotations"
+
+// annotatedObject is an object that had annotations set on it while
+// being exposed
+type annotatedObject struct {
+	// Group is the API group of the object, empty for core
+	Group string `json:"group,omitempty"`
+
+	// Resource is the resource type (REST) of the object, e.g. services
+	Resource string `json:"resource"`
+
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// Previous are the values of the annotations before they were set,
+	// nil if the annotation didn't exist
+	Previous map[string]*string `json:"previous"`
+}
+
+// newAnnotatedObject returns an annotatedObject that records the values
+// of annotations before they were overwritten
+func newAnnotatedObject(group, resource, namespace, name string, existing, annotations map[string]string) annotatedObject {
+	previous := make(map[string]*string, len(annotations))
+	for k := range annotations {
+		if v, ok := existing[k]; ok {
+			v := v
+			previous[k] = &v
+		} else {
+			previous[k] = nil
+		}
+	}
+
+	return annotatedObject{
+		Group:     group,
+		Resource:  resource,
+		Namespace: namespace,
+		Name:      name,
+		Previous:  previous,
+	}
+}
+
+func (a *annotatedObject) restClient(c *Client) rest.Interface {
+	if a.Group == "apps" {
+		return c.k.AppsV1().RESTClient()
+	}
+	return c.k.CoreV1().RESTClient()
+}
+
+// patchAnnotations sets annotations on an object, a nil value removes the
+// annotation
+func (c *Client) patchAnnotations(ctx context.Context, obj *annotatedObject, annotations map[string]*string) error {
+	payload := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal annotations patch body")
+	}
+
+	req := obj.restClient(c).Patch(types.MergePatchType).Resource(obj.Resource).
+		Namespace(obj.Namespace).Name(obj.Name).Body(payloadBytes)
+
+	c.log.WithField("url", req.URL().String()).Debug("setting annotations")
+	return req.Do(ctx).Error()
+}
+
+// annotate sets annotations on an object
+func (c *Client) annotate(ctx context.Context, obj *annotatedObject, annotations map[string]string) error {
+	values := make(map[string]*string, len(annotations))
+	for k, v := range annotations {
+		v := v
+		values[k] = &v
+	}
+	return c.patchAnnotations(ctx, obj, values)
+}
+
+// restoreAnnotations puts an object's annotations back to how they were
+// before annotate was called
+func (c *Client) restoreAnnotations(ctx context.Context, obj *annotatedObject) error {
+	return c.patchAnnotations(ctx, obj, obj.Previous)
+}
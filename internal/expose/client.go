@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/cache"
@@ -42,21 +43,81 @@ type Client struct {
 	kconf *rest.Config
 	log   logrus.FieldLogger
 
-	podStore cache.Store
-	svcStore cache.Store
-	rm       meta.RESTMapper
+	// owner is the owner ID recorded in ledgers, only ledgers with it are
+	// repaired on Start
+	owner string
+
+	// MirrorAgentImage is the image of localizer ran next to the SSH
+	// server of mirrored exposes, see the mirroragent package. Exposes
+	// can't be mirrored without it.
+	MirrorAgentImage string
+
+	podStore  cache.Store
+	podLister corelisters.PodLister
+	svcStore  cache.Store
+	rm        meta.RESTMapper
+
+	// podsByIP are the pods in podStore, indexed by their IP
+	podsByIP cache.Indexer
 }
 
-// NewExposer returns a new client capable of exposing localports to remote locations
-func NewExposer(k kubernetes.Interface, kconf *rest.Config, log logrus.FieldLogger) *Client {
+// NewExposer returns a new client capable of exposing localports to remote
+// locations. Changes are recorded as made by owner, which may be empty when
+// the client is only used to Repair.
+func NewExposer(k kubernetes.Interface, kconf *rest.Config, log logrus.FieldLogger, owner string) *Client {
 	return &Client{
-		k,
-		kconf,
-		log,
-		nil,
-		nil,
-		nil,
+		k:     k,
+		kconf: kconf,
+		log:   log,
+		owner: owner,
+	}
+}
+
+// podIPIndex is the name of the index of pods by their IP
+const podIPIndex = "podIP"
+
+// podIPIndexFunc indexes pods by their IP. Pods using the host's network
+// are skipped since they share their IP with every other one on the node,
+// as are pods that have finished, since their IP may have been reused.
+func podIPIndexFunc(obj interface{}) ([]string, error) {
+	po, ok := obj.(*corev1.Pod)
+	if !ok || po.Spec.HostNetwork || po.Status.PodIP == "" {
+		return nil, nil
 	}
+
+	if po.Status.Phase == corev1.PodSucceeded || po.Status.Phase == corev1.PodFailed {
+		return nil, nil
+	}
+
+	return []string{po.Status.PodIP}, nil
+}
+
+// newPodIPIndexer returns an indexer of pods by their IP, that's kept up to
+// date with informer
+func newPodIPIndexer(informer cache.SharedInformer) cache.Indexer {
+	indexer := cache.NewIndexer(cache.DeletionHandlingMetaNamespaceKeyFunc, cache.Indexers{podIPIndex: podIPIndexFunc})
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { indexer.Add(obj) },       //nolint:errcheck
+		UpdateFunc: func(_, obj interface{}) { indexer.Update(obj) }, //nolint:errcheck
+		DeleteFunc: func(obj interface{}) { indexer.Delete(obj) },    //nolint:errcheck
+	})
+	return indexer
+}
+
+// podByIP returns the pod, in the format of namespace/name, that has the
+// given IP, see podIPIndexFunc
+func (c *Client) podByIP(ip string) string {
+	if c.podsByIP == nil {
+		return ""
+	}
+
+	objs, err := c.podsByIP.ByIndex(podIPIndex, ip)
+	if err != nil || len(objs) == 0 {
+		return ""
+	}
+
+	po := objs[0].(*corev1.Pod)
+	return po.Namespace + "/" + po.Name
 }
 
 // Start warms up the expose cache and enables running Expose()
@@ -66,6 +127,8 @@ func (c *Client) Start(ctx context.Context) error {
 	svcInformer := kevents.GlobalCache.Core().V1().Services().Informer()
 
 	c.podStore = podInformer.GetStore()
+	c.podsByIP = newPodIPIndexer(podInformer)
+	c.podLister = kevents.GlobalCache.Core().V1().Pods().Lister()
 	c.svcStore = svcInformer.GetStore()
 
 	groupResources, err := restmapper.GetAPIGroupResources(c.k.Discovery())
@@ -102,9 +165,31 @@ func (c *Client) Start(ctx context.Context) error {
 					continue
 				}
 			}
+
+			if p.Annotations[AnnotationsPodLabel] == "" {
+				continue
+			}
+
+			var annotated []annotatedObject
+			err = json.Unmarshal([]byte(p.Annotations[AnnotationsPodLabel]), &annotated)
+			if err != nil {
+				c.log.WithError(err).Warn("failed to ensure annotations were restored")
+				continue
+			}
+
+			for i := range annotated {
+				if err := c.restoreAnnotations(ctx, &annotated[i]); err != nil {
+					c.log.WithError(err).WithField("object", annotated[i].Namespace+"/"+annotated[i].Name).
+						Warn("failed to restore annotations")
+				}
+			}
 		}
 	}
 
+	if err := c.repairAll(ctx); err != nil {
+		c.log.WithError(err).Warn("failed to undo changes of abandoned exposes")
+	}
+
 	return nil
 }
 
@@ -208,9 +293,14 @@ func (c *Client) getServiceControllers(_ context.Context, namespace, serviceName
 	}
 
 	c.log.WithField("service", fmt.Sprintf("%s/%s", namespace, serviceName)).Debug("finding controllers")
-	objs, err := kube.FindControllersForService(c.log, svc)
+	objs, ok, err := kube.FindScaleTargets(kevents.GlobalCache, svc)
 	if err != nil {
 		return nil, err
+	} else if !ok {
+		objs, err = kube.FindControllersForService(c.log, kevents.GlobalCache, svc)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	scaledObjects := make([]scaledObjectType, 0)
@@ -250,8 +340,12 @@ func (c *Client) getServiceControllers(_ context.Context, namespace, serviceName
 }
 
 // Expose exposed a port, localPort, on the local host, and opens a remote port
-// that can be accessed via the remote service at remotePort
-func (c *Client) Expose(ctx context.Context, ports []kube.ResolvedServicePort, namespace, serviceName string) (*ServiceForward, error) {
+// that can be accessed via the remote service at remotePort. annotations are
+// set on the service, and the controllers that are scaled down, until the
+// expose stops. If mirror is set nothing is scaled down, and connections are
+// sent to the service's pods with a copy of them sent to the local host.
+func (c *Client) Expose(ctx context.Context, ports []kube.ResolvedServicePort, namespace, serviceName string,
+	annotations map[string]string, mirror bool) (*ServiceForward, error) {
 	s, err := c.k.CoreV1().Services(namespace).Get(ctx, serviceName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
@@ -261,8 +355,19 @@ func (c *Client) Expose(ctx context.Context, ports []kube.ResolvedServicePort, n
 		return nil, fmt.Errorf("headless services are not supported")
 	}
 
-	objects, err := c.getServiceControllers(ctx, namespace, serviceName)
-	if err != nil {
+	if mirror && c.MirrorAgentImage == "" {
+		return nil, fmt.Errorf("mirroring requires the daemon to be started with --mirror-agent-image")
+	}
+
+	var objects []scaledObjectType
+	if !mirror {
+		objects, err = c.getServiceControllers(ctx, namespace, serviceName)
+	}
+	if err != nil && s.Annotations[kube.ScaleTargetAnnotation] != "" {
+		// the controllers to scale down were picked, exposing without
+		// scaling them down would split traffic with them
+		return nil, errors.Wrap(err, "failed to find controllers to scale down")
+	} else if err != nil {
 		// service either had no controllers, or we failed to get them. Either way
 		// it's likely not the end of the world
 		c.log.WithError(err).Debug("failed to get controllers")
@@ -270,6 +375,11 @@ func (c *Client) Expose(ctx context.Context, ports []kube.ResolvedServicePort, n
 
 	log := c.log.WithField("service", fmt.Sprintf("%s/%s", namespace, serviceName))
 
+	annotated := []annotatedObject{newAnnotatedObject("", "services", namespace, serviceName, s.Annotations, annotations)}
+	for _, o := range objects {
+		annotated = append(annotated, newAnnotatedObject("apps", o.Resource, o.GetNamespace(), o.GetName(), o.GetAnnotations(), annotations))
+	}
+
 	return &ServiceForward{
 		c:           c,
 		log:         log,
@@ -278,5 +388,8 @@ func (c *Client) Expose(ctx context.Context, ports []kube.ResolvedServicePort, n
 		Selector:    s.Spec.Selector,
 		Ports:       ports,
 		objects:     objects,
+		annotations: annotations,
+		annotated:   annotated,
+		mirror:      mirror,
 	}, nil
 }
@@ -0,0 +1,70 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package expose
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func pod(name, ip string, phase corev1.PodPhase, hostNetwork bool) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       corev1.PodSpec{HostNetwork: hostNetwork},
+		Status:     corev1.PodStatus{Phase: phase, PodIP: ip},
+	}
+}
+
+func TestPodByIP(t *testing.T) {
+	c := &Client{podsByIP: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{podIPIndex: podIPIndexFunc})}
+	for _, po := range []*corev1.Pod{
+		pod("api", "10.0.0.1", corev1.PodRunning, false),
+		pod("old-job", "10.0.0.2", corev1.PodSucceeded, false),
+		pod("worker", "10.0.0.2", corev1.PodRunning, false),
+		pod("node-agent", "10.0.1.1", corev1.PodRunning, true),
+	} {
+		if err := c.podsByIP.Add(po); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		ip       string
+		expected string
+	}{
+		{"10.0.0.1", "default/api"},
+		{"10.0.0.2", "default/worker"},
+		{"10.0.1.1", ""},
+		{"10.0.0.3", ""},
+	}
+	for _, tt := range tests {
+		if got := c.podByIP(tt.ip); got != tt.expected {
+			t.Errorf("podByIP(%s) = %q, expected %q", tt.ip, got, tt.expected)
+		}
+	}
+
+	// the index follows pods changing IP
+	if err := c.podsByIP.Update(pod("api", "10.0.0.4", corev1.PodRunning, false)); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.podByIP("10.0.0.1"); got != "" {
+		t.Errorf("expected the old IP of api to be forgotten, got %q", got)
+	}
+	if got := c.podByIP("10.0.0.4"); got != "default/api" {
+		t.Errorf("expected the new IP of api to be found, got %q", got)
+	}
+}
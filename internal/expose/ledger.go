@@ -0,0 +1,211 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// LedgerLabel is set on the config maps that record the changes an
+	// expose made to the cluster
+	LedgerLabel = "localizer.jaredallard.github.com/ledger"
+
+	// LedgerOwnerLabel is set on ledgers to the owner ID of the localizer
+	// that wrote them
+	LedgerOwnerLabel = "localizer.jaredallard.github.com/ledger-owner"
+
+	// ledgerKey is the key of the config map that stores the ledger
+	ledgerKey = "ledger"
+)
+
+// ledger records every change an expose makes to the cluster before it's
+// made, so that it can be undone by Repair if localizer dies without
+// cleaning up after itself. It's stored in a config map in the namespace of
+// the service.
+type ledger struct {
+	// Owner is the owner ID of the localizer that made the changes
+	Owner string `json:"owner,omitempty"`
+
+	// Pods are the expose pods that were created
+	Pods []string `json:"pods,omitempty"`
+
+	// Scaled are the controllers that were scaled down, with their
+	// original replicas
+	Scaled []scaledObjectType `json:"scaled,omitempty"`
+
+	// Annotated are the objects that had annotations set on them
+	Annotated []annotatedObject `json:"annotated,omitempty"`
+}
+
+// ledgerName returns the name of the config map storing the ledger of a
+// service
+func ledgerName(serviceName string) string {
+	return fmt.Sprintf("localizer-expose-%s", serviceName)
+}
+
+// serviceLedger is the ledger of a single expose
+type serviceLedger struct {
+	mu sync.Mutex
+	l  ledger
+}
+
+// record updates the ledger of the forward and writes it to the cluster
+func (p *ServiceForward) record(ctx context.Context, update func(l *ledger)) error {
+	p.ledger.mu.Lock()
+	defer p.ledger.mu.Unlock()
+
+	update(&p.ledger.l)
+	return p.c.writeLedger(ctx, p.Namespace, p.ServiceName, &p.ledger.l)
+}
+
+// writeLedger creates, or updates, the ledger of a service
+func (c *Client) writeLedger(ctx context.Context, namespace, serviceName string, l *ledger) error {
+	l.Owner = c.owner
+	b, err := json.Marshal(l)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode ledger")
+	}
+
+	labels := map[string]string{LedgerLabel: "true"}
+	if c.owner != "" {
+		labels[LedgerOwnerLabel] = c.owner
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ledgerName(serviceName),
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{ledgerKey: string(b)},
+	}
+
+	cms := c.k.CoreV1().ConfigMaps(namespace)
+	_, err = cms.Update(ctx, cm, metav1.UpdateOptions{})
+	if kerrors.IsNotFound(err) {
+		_, err = cms.Create(ctx, cm, metav1.CreateOptions{})
+	}
+	return errors.Wrap(err, "failed to write ledger")
+}
+
+// deleteLedger removes the ledger of a service once everything in it has
+// been undone
+func (c *Client) deleteLedger(ctx context.Context, namespace, serviceName string) error {
+	err := c.k.CoreV1().ConfigMaps(namespace).Delete(ctx, ledgerName(serviceName), metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to delete ledger")
+	}
+	return nil
+}
+
+// Repair undoes the changes recorded in the ledger of a service, deleting
+// the pods an expose created, scaling controllers back up, and restoring
+// annotations, then removes the ledger. It returns false if the service
+// had no ledger.
+func (c *Client) Repair(ctx context.Context, namespace, serviceName string) (bool, error) {
+	cm, err := c.k.CoreV1().ConfigMaps(namespace).Get(ctx, ledgerName(serviceName), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, "failed to get ledger")
+	}
+
+	return true, c.undo(ctx, cm)
+}
+
+// repairAll repairs every service with a ledger written by this localizer,
+// which are left behind when it dies while exposing them. Ledgers of other
+// owners may belong to exposes that are still running, so they're only
+// repaired when asked to with Repair.
+func (c *Client) repairAll(ctx context.Context) error {
+	if c.owner == "" {
+		return nil
+	}
+
+	cms, err := c.k.CoreV1().ConfigMaps("").List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true,%s=%s", LedgerLabel, LedgerOwnerLabel, c.owner),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list ledgers")
+	}
+
+	for i := range cms.Items {
+		cm := &cms.Items[i]
+		log := c.log.WithField("ledger", cm.Namespace+"/"+cm.Name)
+		log.Warn("undoing changes of abandoned expose")
+		if err := c.undo(ctx, cm); err != nil {
+			log.WithError(err).Warn("failed to undo changes of abandoned expose")
+		}
+	}
+	return nil
+}
+
+// undo undoes the changes recorded in a ledger, removing it if every one
+// of them was undone
+func (c *Client) undo(ctx context.Context, cm *corev1.ConfigMap) error {
+	var l ledger
+	if err := json.Unmarshal([]byte(cm.Data[ledgerKey]), &l); err != nil {
+		return errors.Wrap(err, "failed to parse ledger")
+	}
+
+	namespace := cm.Namespace
+	log := c.log.WithField("ledger", namespace+"/"+cm.Name)
+
+	var lastErr error
+	for _, name := range l.Pods {
+		log.Infof("deleting pod %s", name)
+		err := c.k.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			log.WithError(err).Warnf("failed to delete pod %s", name)
+			lastErr = err
+		}
+	}
+
+	for _, o := range l.Scaled {
+		log.Infof("scaling %s back to %d", o.GetKey(), o.Replicas)
+		if err := c.scaleObject(ctx, o, o.Replicas); err != nil {
+			log.WithError(err).Warnf("failed to scale %s back up", o.GetKey())
+			lastErr = err
+		}
+	}
+
+	for i := range l.Annotated {
+		o := &l.Annotated[i]
+		log.Infof("restoring annotations on %s %s/%s", o.Resource, o.Namespace, o.Name)
+		if err := c.restoreAnnotations(ctx, o); err != nil {
+			log.WithError(err).Warnf("failed to restore annotations on %s/%s", o.Namespace, o.Name)
+			lastErr = err
+		}
+	}
+
+	// keep the ledger around so that it can be tried again
+	if lastErr != nil {
+		return errors.Wrap(lastErr, "failed to undo every change")
+	}
+
+	err := c.k.CoreV1().ConfigMaps(namespace).Delete(ctx, cm.Name, metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to delete ledger")
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package expose
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRepair(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	ctx := context.Background()
+	k := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "localizer-api-abcde"},
+	})
+	c := NewExposer(k, nil, log, "")
+
+	if repaired, err := c.Repair(ctx, "default", "api"); err != nil || repaired {
+		t.Fatalf("expected nothing to repair, got %v, %v", repaired, err)
+	}
+
+	if err := c.writeLedger(ctx, "default", "api", &ledger{Pods: []string{"localizer-api-abcde", "localizer-api-gone"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// writing it again updates it
+	if err := c.writeLedger(ctx, "default", "api", &ledger{Pods: []string{"localizer-api-abcde"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	repaired, err := c.Repair(ctx, "default", "api")
+	if err != nil || !repaired {
+		t.Fatalf("expected the expose to be repaired, got %v, %v", repaired, err)
+	}
+
+	if _, err := k.CoreV1().Pods("default").Get(ctx, "localizer-api-abcde", metav1.GetOptions{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected the expose pod to be deleted, got %v", err)
+	}
+
+	if _, err := k.CoreV1().ConfigMaps("default").Get(ctx, ledgerName("api"), metav1.GetOptions{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected the ledger to be deleted, got %v", err)
+	}
+}
+
+func TestRepairAllOnlyOwnLedgers(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	ctx := context.Background()
+	k := fake.NewSimpleClientset()
+	mine, theirs := NewExposer(k, nil, log, "0a1b2c3d"), NewExposer(k, nil, log, "4e5f6a7b")
+
+	if err := mine.writeLedger(ctx, "default", "api", &ledger{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := theirs.writeLedger(ctx, "default", "web", &ledger{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mine.repairAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := k.CoreV1().ConfigMaps("default").Get(ctx, ledgerName("api"), metav1.GetOptions{}); !kerrors.IsNotFound(err) {
+		t.Errorf("expected our ledger to be repaired, got %v", err)
+	}
+	if _, err := k.CoreV1().ConfigMaps("default").Get(ctx, ledgerName("web"), metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the ledger of another owner to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package expose
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getoutreach/localizer/internal/mirroragent"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// upstreamsInterval is how often the mirror agent is sent the pods of the
+// service, if they changed
+const upstreamsInterval = 5 * time.Second
+
+// upstreams returns the IPs of the ready pods of the service that mirrored
+// connections are sent to, sorted
+func (p *ServiceForward) upstreams() ([]string, error) {
+	pods, err := p.c.podLister.Pods(p.Namespace).List(labels.SelectorFromSet(p.Selector))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list pods")
+	}
+
+	ips := make([]string, 0, len(pods))
+	for _, po := range pods {
+		if po.Labels[ExposedPodLabel] == "true" || po.Status.PodIP == "" || !podReady(po) {
+			continue
+		}
+		ips = append(ips, po.Status.PodIP)
+	}
+
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no ready pods of %s/%s to mirror", p.Namespace, p.ServiceName)
+	}
+	sort.Strings(ips)
+
+	return ips, nil
+}
+
+// mirrorAgentContainer returns the container of the mirror agent, which
+// serves ports and sends connections on to upstreams until it's sent others
+func (p *ServiceForward) mirrorAgentContainer(ports []corev1.ContainerPort, upstreams []string) *corev1.Container {
+	args := []string{"mirror-agent"}
+	for i, port := range ports {
+		args = append(args, "--port", mirroragent.Port{Target: int(port.ContainerPort), Copy: mirroragent.CopyPort(i)}.String())
+	}
+	for _, ip := range upstreams {
+		args = append(args, "--upstream", ip)
+	}
+
+	return &corev1.Container{
+		Name:            "mirror-agent",
+		Image:           p.c.MirrorAgentImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Args:            args,
+		Ports:           ports,
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+			},
+		},
+	}
+}
+
+// sendUpstreams keeps the mirror agent up to date with the pods of the
+// service, over a control connection dialed through the tunnel, until ctx
+// is canceled. The agent keeps using the last pods it was sent while the
+// tunnel is down.
+func (p *ServiceForward) sendUpstreams(ctx context.Context, dial func(addr string) (net.Conn, error)) {
+	t := time.NewTicker(upstreamsInterval)
+	defer t.Stop()
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	sent := ""
+	for {
+		if conn == nil {
+			var err error
+			conn, err = dial(fmt.Sprintf("127.0.0.1:%d", mirroragent.ControlPort))
+			if err != nil {
+				p.log.WithError(err).Debug("failed to connect to mirror agent")
+			}
+			sent = ""
+		}
+
+		if conn != nil {
+			ips, err := p.upstreams()
+			if err != nil {
+				p.log.WithError(err).Warn("failed to find pods to mirror")
+			} else if key := strings.Join(ips, " "); key != sent {
+				if err := mirroragent.WriteUpstreams(conn, ips); err != nil {
+					p.log.WithError(err).Debug("failed to send pods to mirror agent")
+					conn.Close()
+					conn = nil
+				} else {
+					sent = key
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -17,14 +17,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/getoutreach/localizer/internal/mirroragent"
 	"github.com/getoutreach/localizer/internal/ssh"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -65,6 +70,44 @@ type ServiceForward struct {
 
 	// TODO(jaredallard): support replacing non associated pods?
 	objects []scaledObjectType
+
+	// mu protects objects, which are read by the daemon while the
+	// forward runs
+	mu sync.Mutex
+
+	// annotations are set on the service, and the controllers that are
+	// scaled down, while it's exposed. annotated are the objects that
+	// they're set on.
+	annotations map[string]string
+	annotated   []annotatedObject
+
+	// ledger records the changes made to the cluster, see Repair
+	ledger serviceLedger
+
+	// mirror sends connections to the service's pods, and a copy of them
+	// to us, instead of only to us
+	mirror bool
+
+	// OnConnection, if set, is called for every connection the cluster
+	// makes to the service that's sent to us
+	OnConnection func(Connection)
+
+	// OnReady, if set, is called whenever the tunnel from the cluster
+	// comes up, and OnFailure whenever it fails to or goes down
+	OnReady   func()
+	OnFailure func(error)
+}
+
+// Connection is a connection made from the cluster to an exposed service
+type Connection struct {
+	// Port is the port of the service the connection was made to
+	Port kube.ResolvedServicePort
+
+	// Source is the address the connection came from, and SourcePod is
+	// the pod with that address in the format of namespace/name, if
+	// one could be found
+	Source    string
+	SourcePod string
 }
 
 type scaledObjectType struct {
@@ -81,6 +124,89 @@ type scaledObjectType struct {
 	Resource string `json:"resource"`
 }
 
+// Intercepting returns true if this forward scales down the controllers
+// that normally serve the service, meaning all traffic is sent to us.
+func (p *ServiceForward) Intercepting() bool {
+	return len(p.scaledObjects()) > 0
+}
+
+// scaledObjects returns the controllers this forward scales down
+func (p *ServiceForward) scaledObjects() []scaledObjectType {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.objects
+}
+
+// Mirroring returns true if connections are sent to the service's pods,
+// with a copy of them sent to us
+func (p *ServiceForward) Mirroring() bool {
+	return p.mirror
+}
+
+// podReady returns if a pod is running and ready
+func podReady(po *corev1.Pod) bool {
+	if po.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, cond := range po.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// connection builds a Connection for a connection accepted on the given
+// remote port and calls OnConnection with it
+func (p *ServiceForward) connection(remotePort uint, source net.Addr) {
+	if p.OnConnection == nil {
+		return
+	}
+
+	conn := Connection{Source: source.String()}
+	for i, port := range p.Ports {
+		if uint(p.remotePort(i)) == remotePort {
+			conn.Port = port
+			break
+		}
+	}
+
+	if host, _, err := net.SplitHostPort(conn.Source); err == nil {
+		conn.SourcePod = p.c.podByIP(host)
+	}
+
+	p.OnConnection(conn)
+}
+
+// failed calls OnFailure, if set, unless the forward is stopping
+func (p *ServiceForward) failed(ctx context.Context, err error) {
+	if p.OnFailure == nil || ctx.Err() != nil {
+		return
+	}
+	p.OnFailure(err)
+}
+
+// remotePort returns the port the tunnel listens on in the pod for the
+// i-th port. When mirroring it's the port the mirror agent sends copies
+// of connections to, rather than the port the service sends them to.
+func (p *ServiceForward) remotePort(i int) int {
+	if p.mirror {
+		return mirroragent.CopyPort(i)
+	}
+	return int(p.Ports[i].TargetPort.IntVal)
+}
+
+// LocalAddresses returns the local addresses that traffic sent to the
+// service is forwarded to
+func (p *ServiceForward) LocalAddresses() []string {
+	addresses := make([]string, len(p.Ports))
+	for i, port := range p.Ports {
+		addresses[i] = net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port.MappedPort)))
+	}
+	return addresses
+}
+
 // GetKey() returns a unique, predictable key for the given
 // scaledObjectType capable of being used for caching
 func (s *scaledObjectType) GetKey() string {
@@ -91,7 +217,7 @@ func (p *ServiceForward) createServerPortForward(ctx context.Context, po *corev1
 	return kube.CreatePortForward(ctx, p.c.k.CoreV1().RESTClient(), p.c.kconf, po, "0.0.0.0", []string{fmt.Sprintf("%d:2222", localPort)})
 }
 
-func (p *ServiceForward) createServerPod(ctx context.Context) (func(), *corev1.Pod, error) { //nolint:funlen
+func (p *ServiceForward) createServerPod(ctx context.Context) (func(), *corev1.Pod, error) { //nolint:funlen,gocyclo
 	// map the service ports into containerPorts, using the
 	containerPorts := make([]corev1.ContainerPort, len(p.Ports))
 	for i, port := range p.Ports {
@@ -106,11 +232,22 @@ func (p *ServiceForward) createServerPod(ctx context.Context) (func(), *corev1.P
 		containerPorts[i] = cp
 	}
 
-	b, err := json.MarshalIndent(p.objects, "", "  ")
+	b, err := json.MarshalIndent(p.scaledObjects(), "", "  ")
 	if err != nil {
 		return func() {}, nil, errors.Wrap(err, "failed to encode object state")
 	}
 
+	annotations := map[string]string{
+		ObjectsPodLabel: string(b),
+	}
+	if len(p.annotations) > 0 {
+		b, err := json.Marshal(p.annotated)
+		if err != nil {
+			return func() {}, nil, errors.Wrap(err, "failed to encode annotated objects")
+		}
+		annotations[AnnotationsPodLabel] = string(b)
+	}
+
 	// add a label for localizer pods
 	labels := map[string]string{
 		ExposedPodLabel: "true",
@@ -120,14 +257,24 @@ func (p *ServiceForward) createServerPod(ctx context.Context) (func(), *corev1.P
 		labels[k] = v
 	}
 
+	// when mirroring, the agent serves the service's ports and the SSH
+	// server only listens for the copies it sends
+	var agent *corev1.Container
+	if p.mirror {
+		upstreams, err := p.upstreams()
+		if err != nil {
+			return func() {}, nil, err
+		}
+		agent = p.mirrorAgentContainer(containerPorts, upstreams)
+		containerPorts = nil
+	}
+
 	podObject := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:    p.Namespace,
 			GenerateName: fmt.Sprintf("localizer-%s-", p.ServiceName),
 			Labels:       labels,
-			Annotations: map[string]string{
-				ObjectsPodLabel: string(b),
-			},
+			Annotations:  annotations,
 		},
 		Spec: corev1.PodSpec{
 			RestartPolicy: corev1.RestartPolicyOnFailure,
@@ -176,6 +323,9 @@ func (p *ServiceForward) createServerPod(ctx context.Context) (func(), *corev1.P
 			},
 		},
 	}
+	if agent != nil {
+		podObject.Spec.Containers = append(podObject.Spec.Containers, *agent)
+	}
 	p.log.Debug(spew.Sdump(podObject))
 
 	po, err := p.c.k.CoreV1().Pods(p.Namespace).Create(ctx, podObject, metav1.CreateOptions{})
@@ -186,8 +336,27 @@ func (p *ServiceForward) createServerPod(ctx context.Context) (func(), *corev1.P
 	cleanupFn := func() {
 		p.log.Debug("cleaning up pod")
 		// cleanup the pod
-		//nolint:errcheck
-		p.c.k.CoreV1().Pods(p.Namespace).Delete(context.Background(), po.Name, metav1.DeleteOptions{})
+		err := p.c.k.CoreV1().Pods(p.Namespace).Delete(context.Background(), po.Name, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			p.log.WithError(err).Warn("failed to delete pod")
+			return
+		}
+
+		err = p.record(context.Background(), func(l *ledger) {
+			for i, name := range l.Pods {
+				if name == po.Name {
+					l.Pods = append(l.Pods[:i], l.Pods[i+1:]...)
+					break
+				}
+			}
+		})
+		if err != nil {
+			p.log.WithError(err).Warn("failed to record pod deletion")
+		}
+	}
+
+	if err := p.record(ctx, func(l *ledger) { l.Pods = append(l.Pods, po.Name) }); err != nil {
+		p.log.WithError(err).Warn("failed to record pod creation, it won't be deleted by --repair")
 	}
 
 	p.log.Infof("created pod %s", po.ObjectMeta.Name)
@@ -267,13 +436,61 @@ func (p *ServiceForward) createTransport(ctx context.Context, po *corev1.Pod,
 func (p *ServiceForward) Start(ctx context.Context) error { //nolint:funlen
 	ports := make([]string, len(p.Ports))
 	for i, port := range p.Ports {
-		prt := int(port.TargetPort.IntVal)
-		ports[i] = fmt.Sprintf("%d:%d", port.MappedPort, prt)
+		ports[i] = fmt.Sprintf("%d:%d", port.MappedPort, p.remotePort(i))
 		p.log.Debugf("tunneling port %v", ports[i])
 	}
 
+	objects := p.scaledObjects()
+
+	// every change is recorded before it's made, so that it can be undone
+	// with Repair if we die before undoing it ourselves
+	err := p.record(ctx, func(l *ledger) {
+		l.Scaled = objects
+		if len(p.annotations) > 0 {
+			l.Annotated = p.annotated
+		}
+	})
+	if err != nil {
+		p.log.WithError(err).Warn("failed to record changes, they won't be undone by --repair")
+	}
+	restored := true
+	defer func() {
+		if !restored {
+			p.log.Warn("not every change was undone, run 'localizer expose --repair' to try again")
+			return
+		}
+		if err := p.c.deleteLedger(context.Background(), p.Namespace, p.ServiceName); err != nil {
+			p.log.WithError(err).Warn("failed to delete ledger")
+		}
+	}()
+
+	// annotate before scaling down so that nothing alerts on it, and only
+	// remove them once everything has been scaled back up
+	if len(p.annotations) > 0 {
+		for i := range p.annotated {
+			o := &p.annotated[i]
+			p.log.Infof("annotating %s %s/%s", o.Resource, o.Namespace, o.Name)
+			if err := p.c.annotate(ctx, o, p.annotations); err != nil {
+				return errors.Wrap(err, "failed to annotate object")
+			}
+		}
+	}
+	defer func() {
+		if len(p.annotations) == 0 {
+			return
+		}
+		for i := range p.annotated {
+			o := &p.annotated[i]
+			p.log.Infof("restoring annotations on %s %s/%s", o.Resource, o.Namespace, o.Name)
+			if err := p.c.restoreAnnotations(context.Background(), o); err != nil {
+				p.log.WithError(err).Warn("failed to restore annotations")
+				restored = false
+			}
+		}
+	}()
+
 	// scale down the other resources that powered this service
-	for _, o := range p.objects {
+	for _, o := range objects {
 		p.log.Infof("scaling %s from %d -> 0", o.GetKey(), o.Replicas)
 		if err := p.c.scaleObject(ctx, o, 0); err != nil {
 			return errors.Wrap(err, "failed to scale down object")
@@ -281,10 +498,11 @@ func (p *ServiceForward) Start(ctx context.Context) error { //nolint:funlen
 	}
 	defer func() {
 		// scale back up the resources that powered this service
-		for _, o := range p.objects {
+		for _, o := range objects {
 			p.log.Infof("scaling %s from 0 -> %d", o.GetKey(), o.Replicas)
 			if err := p.c.scaleObject(context.Background(), o, o.Replicas); err != nil {
 				p.log.WithError(err).Warn("failed to scale back up object")
+				restored = false
 			}
 		}
 	}()
@@ -326,6 +544,7 @@ func (p *ServiceForward) Start(ctx context.Context) error { //nolint:funlen
 				cleanupFn, po, err = p.createServerPod(ctx)
 				if err != nil {
 					p.log.WithError(err).Debug("failed to create pod")
+					p.failed(ctx, err)
 					lastErr = ErrUnderlyingTransportPodDestroyed
 					continue
 				}
@@ -338,11 +557,18 @@ func (p *ServiceForward) Start(ctx context.Context) error { //nolint:funlen
 					}
 
 					p.log.WithError(err).Debug("failed to recreate transport port-forward")
+					p.failed(ctx, err)
 					lastErr = ErrUnderlyingTransportDied
 					continue
 				}
 
 				cli := ssh.NewReverseTunnelClient(p.log, "127.0.0.1", localPort, ports)
+				cli.OnConnection = p.connection
+				cli.OnReady = p.OnReady
+				if p.mirror {
+					cli.Mirror = true
+					cli.Control = p.sendUpstreams
+				}
 				go func() {
 					errorChan <- cli.Start(ctx, p.ServiceName)
 				}()
@@ -354,6 +580,10 @@ func (p *ServiceForward) Start(ctx context.Context) error { //nolint:funlen
 				case <-ctx.Done():
 				case err := <-errorChan:
 					p.log.WithError(err).Debug("transport died")
+					if err == nil {
+						err = ErrUnderlyingTransportProtocolDied
+					}
+					p.failed(ctx, err)
 					lastErr = err
 				}
 
@@ -0,0 +1,312 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package hooks runs user configured commands, and sends webhooks, when
+// events happen in the daemon, such as a port-forward being created.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getoutreach/localizer/internal/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// EventType is the type of an event
+type EventType string
+
+// This block contains the events that hooks can be ran on
+var (
+	// EventForwardCreated is sent when a port-forward has been created
+	EventForwardCreated EventType = "forward-created"
+
+	// EventForwardFailed is sent when a port-forward failed to be
+	// created, or an existing one stopped working
+	EventForwardFailed EventType = "forward-failed"
+
+	// EventStable is sent once every service has been processed after
+	// the daemon started
+	EventStable EventType = "stable"
+
+	// EventShutdown is sent when the daemon is shutting down
+	EventShutdown EventType = "shutdown"
+
+	// EventExposeStarted is sent when a local service starts being
+	// exposed to the cluster
+	EventExposeStarted EventType = "expose-started"
+
+	// EventExposeStopped is sent when a local service stops being exposed
+	EventExposeStopped EventType = "expose-stopped"
+
+	// EventExposeFailed is sent when the tunnel for an exposed service
+	// couldn't be created, or stopped working
+	EventExposeFailed EventType = "expose-failed"
+
+	// EventExposeConnection is sent for every connection made from the
+	// cluster to an exposed service
+	EventExposeConnection EventType = "expose-connection"
+)
+
+// EventTypes are all of the known events
+var EventTypes = []EventType{
+	EventForwardCreated, EventForwardFailed, EventStable, EventShutdown,
+	EventExposeStarted, EventExposeStopped, EventExposeFailed, EventExposeConnection,
+}
+
+// optInEventTypes are events that are only sent to hooks that list them,
+// since they can happen many times a second
+var optInEventTypes = []EventType{EventExposeConnection}
+
+// defaultTimeout is how long a hook can run for if it doesn't configure
+// a timeout
+const defaultTimeout = 30 * time.Second
+
+// Event is something that happened in the daemon
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	// User and Host are who is running localizer, and where
+	User string `json:"user"`
+	Host string `json:"host"`
+
+	// Namespace and Service are the service this event is about, if any
+	Namespace string `json:"namespace,omitempty"`
+	Service   string `json:"service,omitempty"`
+
+	// Endpoint is the pod backing the service, in the format of
+	// namespace/name
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// IP is the IP address allocated to the service
+	IP        string   `json:"ip,omitempty"`
+	Hostnames []string `json:"hostnames,omitempty"`
+	Ports     []string `json:"ports,omitempty"`
+
+	// Intercepting is set on expose events when traffic from the cluster
+	// is being intercepted
+	Intercepting bool `json:"intercepting,omitempty"`
+
+	// Source is the address a connection came from, and SourcePod is the
+	// pod with that address, in the format of namespace/name
+	Source    string `json:"source,omitempty"`
+	SourcePod string `json:"sourcePod,omitempty"`
+
+	// Reason is why this event happened, e.g. the error a port-forward
+	// failed with
+	Reason string `json:"reason,omitempty"`
+}
+
+// env returns the environment variables passed to hooks for this event
+func (e *Event) env() []string {
+	return []string{
+		"LOCALIZER_EVENT=" + string(e.Type),
+		"LOCALIZER_USER=" + e.User,
+		"LOCALIZER_HOST=" + e.Host,
+		"LOCALIZER_NAMESPACE=" + e.Namespace,
+		"LOCALIZER_SERVICE=" + e.Service,
+		"LOCALIZER_ENDPOINT=" + e.Endpoint,
+		"LOCALIZER_IP=" + e.IP,
+		"LOCALIZER_HOSTNAMES=" + strings.Join(e.Hostnames, ","),
+		"LOCALIZER_PORTS=" + strings.Join(e.Ports, ","),
+		"LOCALIZER_INTERCEPTING=" + strconv.FormatBool(e.Intercepting),
+		"LOCALIZER_SOURCE=" + e.Source,
+		"LOCALIZER_SOURCE_POD=" + e.SourcePod,
+		"LOCALIZER_REASON=" + e.Reason,
+	}
+}
+
+// Runner runs hooks and sends webhooks when events are fired. A nil Runner
+// is valid and does nothing.
+type Runner struct {
+	log      logrus.FieldLogger
+	hooks    []config.Hook
+	webhooks []*webhook
+
+	user string
+	host string
+
+	wg sync.WaitGroup
+}
+
+// NewRunner creates a Runner for the hooks and webhooks in the given
+// config, returning an error if any of them are invalid
+func NewRunner(log logrus.FieldLogger, conf *config.Config) (*Runner, error) {
+	for i := range conf.Hooks {
+		if len(conf.Hooks[i].Command) == 0 {
+			return nil, fmt.Errorf("hook %d has no command", i)
+		}
+
+		if err := validateEvents(conf.Hooks[i].Events); err != nil {
+			return nil, errors.Wrapf(err, "hook %d", i)
+		}
+	}
+
+	webhooks := make([]*webhook, len(conf.Webhooks))
+	for i := range conf.Webhooks {
+		if err := validateEvents(conf.Webhooks[i].Events); err != nil {
+			return nil, errors.Wrapf(err, "webhook %d", i)
+		}
+
+		w, err := newWebhook(&conf.Webhooks[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "webhook %d", i)
+		}
+		webhooks[i] = w
+	}
+
+	r := &Runner{
+		log:      log.WithField("component", "hooks"),
+		hooks:    conf.Hooks,
+		webhooks: webhooks,
+		user:     os.Getenv("SUDO_USER"),
+	}
+
+	if r.user == "" {
+		if u, err := user.Current(); err == nil {
+			r.user = u.Username
+		}
+	}
+	r.host, _ = os.Hostname() //nolint:errcheck // Why: Best effort
+
+	return r, nil
+}
+
+// validateEvents returns an error if any of the events aren't known
+func validateEvents(events []string) error {
+	for _, e := range events {
+		if !isEventType(e) {
+			return fmt.Errorf("unknown event '%s'", e)
+		}
+	}
+	return nil
+}
+
+func isEventType(e string) bool {
+	for _, t := range EventTypes {
+		if string(t) == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Fire runs the hooks for an event in the background
+func (r *Runner) Fire(ctx context.Context, e Event) {
+	if r == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.User = r.user
+	e.Host = r.host
+
+	for i := range r.hooks {
+		if !matches(r.hooks[i].Events, e.Type) {
+			continue
+		}
+
+		r.wg.Add(1)
+		go func(h *config.Hook) {
+			defer r.wg.Done()
+			r.run(ctx, h, &e)
+		}(&r.hooks[i])
+	}
+
+	for _, w := range r.webhooks {
+		if !matches(w.conf.Events, e.Type) {
+			continue
+		}
+
+		r.wg.Add(1)
+		go func(w *webhook) {
+			defer r.wg.Done()
+			if err := w.send(ctx, &e); err != nil {
+				r.log.WithField("event", e.Type).WithError(err).Warn("failed to send webhook")
+			}
+		}(w)
+	}
+}
+
+// Wait waits for all running hooks to finish
+func (r *Runner) Wait() {
+	if r == nil {
+		return
+	}
+	r.wg.Wait()
+}
+
+// matches returns if a hook or webhook with the given events should be
+// ran for an event. No events means every event that isn't opt-in.
+func matches(events []string, t EventType) bool {
+	if len(events) == 0 {
+		for _, o := range optInEventTypes {
+			if o == t {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, e := range events {
+		if e == string(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// run runs a single hook for an event
+func (r *Runner) run(ctx context.Context, h *config.Hook, e *Event) {
+	log := r.log.WithField("event", e.Type).WithField("command", h.Command[0])
+
+	timeout := h.Timeout.Duration
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		log.WithError(err).Warn("failed to encode event")
+		return
+	}
+
+	//nolint:gosec // Why: Hooks are configured by the user to run commands
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Env = append(os.Environ(), e.env()...)
+	cmd.Stdin = bytes.NewReader(b)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.WithError(err).Warnf("hook failed, output: %s", out)
+		return
+	}
+
+	log.Debug("ran hook")
+}
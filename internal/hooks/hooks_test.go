@@ -0,0 +1,159 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/getoutreach/localizer/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRunnerFire(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test require sh")
+	}
+
+	dir := t.TempDir()
+	stdinFile := filepath.Join(dir, "stdin")
+	envFile := filepath.Join(dir, "env")
+
+	r, err := NewRunner(logrus.New(), &config.Config{Hooks: []config.Hook{
+		{
+			Events:  []string{string(EventForwardCreated)},
+			Command: []string{"sh", "-c", "cat > " + stdinFile + " && echo $LOCALIZER_SERVICE > " + envFile},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// doesn't match the hook
+	r.Fire(context.Background(), Event{Type: EventShutdown})
+	r.Fire(context.Background(), Event{Type: EventForwardCreated, Namespace: "default", Service: "postgres"})
+	r.Wait()
+
+	b, err := ioutil.ReadFile(stdinFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e Event
+	if err := json.Unmarshal(b, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Type != EventForwardCreated || e.Service != "postgres" || e.Time.IsZero() {
+		t.Fatalf("unexpected event on stdin: %+v", e)
+	}
+
+	b, err = ioutil.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "postgres\n" {
+		t.Fatalf("expected LOCALIZER_SERVICE to be postgres, got %q", b)
+	}
+}
+
+func TestNewRunnerValidates(t *testing.T) {
+	if _, err := NewRunner(logrus.New(), &config.Config{Hooks: []config.Hook{{}}}); err == nil {
+		t.Fatal("expected an error for a hook without a command")
+	}
+
+	if _, err := NewRunner(logrus.New(), &config.Config{Hooks: []config.Hook{{Events: []string{"nope"}, Command: []string{"true"}}}}); err == nil {
+		t.Fatal("expected an error for a hook with an unknown event")
+	}
+
+	if _, err := NewRunner(logrus.New(), &config.Config{Webhooks: []config.Webhook{{URL: "ftp://example.com"}}}); err == nil {
+		t.Fatal("expected an error for a webhook without a http(s) url")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		events   []string
+		t        EventType
+		expected bool
+	}{
+		{nil, EventStable, true},
+		{nil, EventExposeConnection, false},
+		{nil, EventExposeFailed, true},
+		{[]string{"stable"}, EventShutdown, false},
+		{[]string{"expose-connection"}, EventExposeConnection, true},
+	}
+
+	for _, tt := range tests {
+		if got := matches(tt.events, tt.t); got != tt.expected {
+			t.Errorf("matches(%v, %s) = %v, expected %v", tt.events, tt.t, got, tt.expected)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	problems := Validate(&config.Config{
+		Hooks: []config.Hook{{Events: []string{"stable", "nope"}, Command: []string{"true"}}},
+		Webhooks: []config.Webhook{
+			{URL: "https://example.com", Body: `{"service": {{json .Service}}}`},
+			{URL: "https://example.com", Body: `{{.Servce}}`},
+			{URL: "https://example.com", Body: `{{if}}`},
+		},
+	})
+
+	paths := []string{}
+	for _, p := range problems {
+		paths = append(paths, p.Path)
+	}
+	expected := []string{"hooks[0].events[1]", "webhooks[1].body", "webhooks[2].body"}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected problems with %v, got %v", expected, problems)
+	}
+}
+
+func TestRunnerWebhook(t *testing.T) {
+	bodies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body) //nolint:errcheck // Why: Checked by the test
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("expected the configured Authorization header, got %q", r.Header.Get("Authorization"))
+		}
+		bodies <- string(b)
+	}))
+	defer srv.Close()
+
+	r, err := NewRunner(logrus.New(), &config.Config{Webhooks: []config.Webhook{{
+		URL:     srv.URL,
+		Events:  []string{string(EventExposeStarted)},
+		Body:    `{"text": {{ json (printf "%s/%s is exposed" .Namespace .Service) }}}`,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Fire(context.Background(), Event{Type: EventForwardCreated})
+	r.Fire(context.Background(), Event{Type: EventExposeStarted, Namespace: "default", Service: "web"})
+	r.Wait()
+
+	if got, want := <-bodies, `{"text": "default/web is exposed"}`; got != want {
+		t.Fatalf("expected body %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/getoutreach/localizer/internal/config"
+	"github.com/pkg/errors"
+)
+
+// templateFuncs are the functions available to webhook body templates
+var templateFuncs = template.FuncMap{
+	// json encodes a value as JSON, for safely embedding values in
+	// JSON bodies
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join": strings.Join,
+}
+
+// webhook sends events to a URL
+type webhook struct {
+	conf   *config.Webhook
+	body   *template.Template
+	client *http.Client
+}
+
+// parseBody parses the body template of a webhook
+func parseBody(body string) (*template.Template, error) {
+	tmpl, err := template.New("body").Funcs(templateFuncs).Option("missingkey=error").Parse(body)
+	return tmpl, errors.Wrap(err, "failed to parse body template")
+}
+
+// newWebhook creates a webhook from its configuration
+func newWebhook(conf *config.Webhook) (*webhook, error) {
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid url")
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("url '%s' must be http or https", conf.URL)
+	}
+
+	w := &webhook{
+		conf:   conf,
+		client: &http.Client{Timeout: conf.Timeout.Duration},
+	}
+	if w.client.Timeout == 0 {
+		w.client.Timeout = defaultTimeout
+	}
+
+	if conf.Body != "" {
+		if w.body, err = parseBody(conf.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+// sampleEvent is an event with every field set, used to check that body
+// templates render
+var sampleEvent = Event{
+	Type:      EventForwardCreated,
+	Time:      time.Unix(0, 0),
+	User:      "user",
+	Host:      "host",
+	Namespace: "default",
+	Service:   "api",
+	Endpoint:  "default/api-0",
+	IP:        "127.0.0.2",
+	Hostnames: []string{"api", "api.default"},
+	Ports:     []string{"8080/tcp"},
+	Reason:    "reason",
+}
+
+// Validate returns the problems with the hooks and webhooks of a
+// configuration that config.Validate can't check, i.e. unknown events
+// and webhook body templates that don't parse or render
+func Validate(conf *config.Config) []config.Problem {
+	problems := []config.Problem{}
+	checkEvents := func(path string, events []string) {
+		for i, e := range events {
+			if !isEventType(e) {
+				problems = append(problems, config.Problem{
+					Path:    fmt.Sprintf("%s.events[%d]", path, i),
+					Message: fmt.Sprintf("unknown event '%s'", e),
+				})
+			}
+		}
+	}
+
+	for i := range conf.Hooks {
+		checkEvents(fmt.Sprintf("hooks[%d]", i), conf.Hooks[i].Events)
+	}
+
+	for i := range conf.Webhooks {
+		path := fmt.Sprintf("webhooks[%d]", i)
+		checkEvents(path, conf.Webhooks[i].Events)
+
+		if conf.Webhooks[i].Body == "" {
+			continue
+		}
+		body, err := parseBody(conf.Webhooks[i].Body)
+		if err == nil {
+			err = body.Execute(ioutil.Discard, &sampleEvent)
+		}
+		if err != nil {
+			problems = append(problems, config.Problem{Path: path + ".body", Message: err.Error()})
+		}
+	}
+
+	return problems
+}
+
+// send POSTs an event to the webhook
+func (w *webhook) send(ctx context.Context, e *Event) error {
+	var body bytes.Buffer
+	if w.body == nil {
+		if err := json.NewEncoder(&body).Encode(e); err != nil {
+			return errors.Wrap(err, "failed to encode event")
+		}
+	} else if err := w.body.Execute(&body, e); err != nil {
+		return errors.Wrap(err, "failed to render body template")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.conf.URL, &body)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "localizer")
+	for k, v := range w.conf.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:errcheck // Why: Only used for the error
+		return fmt.Errorf("got status %d: %s", resp.StatusCode, b)
+	}
+
+	return nil
+}
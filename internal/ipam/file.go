@@ -0,0 +1,250 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ipam
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// file is an Allocator that keeps its state in a file, so it survives
+// restarts and can be shared by more than one daemon. Every change
+// re-reads the file while holding a lock on it, so daemons using the same
+// file never hand out the same IP.
+type file struct {
+	path  string
+	owner string
+	ipnet *net.IPNet
+
+	// reserved are the IPs never handed out by this allocator
+	reserved map[string]bool
+
+	// acquired and available are the usage of the CIDR the last time the
+	// file was read
+	mu                  sync.Mutex
+	acquired, available uint64
+}
+
+// fileState is what's stored in the file, the owner of every allocated
+// IP keyed by the IP
+type fileState struct {
+	IPs map[string]string `json:"ips"`
+}
+
+// NewFile creates an Allocator for cidr that keeps its state in the file
+// at path. IPs are allocated on behalf of owner, which should be unique to
+// each daemon using the file. IPs left allocated to owner, by a previous
+// run that didn't exit cleanly, are released. The reserved IPs are never
+// handed out.
+func NewFile(path, owner, cidr string, reserved ...net.IP) (Allocator, error) {
+	ipnet, err := parseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &file{path: path, owner: owner, ipnet: ipnet, reserved: make(map[string]bool)}
+	for _, ip := range reserved {
+		if ipnet.Contains(ip) {
+			f.reserved[ip.String()] = true
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create ipam directory")
+	}
+
+	err = f.update(func(s *fileState) error {
+		for ip, owner := range s.IPs {
+			if owner == f.owner {
+				delete(s.IPs, ip)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// CIDR returns the CIDR IPs are allocated from
+func (f *file) CIDR() string {
+	return f.ipnet.String()
+}
+
+// Acquire allocates a free IP
+func (f *file) Acquire() (net.IP, error) {
+	var acquired net.IP
+	err := f.update(func(s *fileState) error {
+		last := lastIP(f.ipnet)
+		for ip := nextIP(f.ipnet.IP); f.ipnet.Contains(ip); ip = nextIP(ip) {
+			// like go-ipam, the broadcast address is never handed out
+			if ip.To4() != nil && ip.Equal(last) {
+				break
+			}
+
+			key := ip.String()
+			if _, ok := s.IPs[key]; ok || f.reserved[key] {
+				continue
+			}
+
+			s.IPs[key] = f.owner
+			acquired = ip
+			return nil
+		}
+		return ErrNoIPAvailable
+	})
+	return acquired, err
+}
+
+// AcquireSpecific allocates ip, if it's free
+func (f *file) AcquireSpecific(ip net.IP) error {
+	if !f.ipnet.Contains(ip) {
+		return errors.Errorf("%s isn't in %s", ip, f.ipnet)
+	}
+
+	return f.update(func(s *fileState) error {
+		key := ip.String()
+		if _, ok := s.IPs[key]; ok || f.reserved[key] || f.unusable(ip) {
+			return ErrIPInUse
+		}
+
+		s.IPs[key] = f.owner
+		return nil
+	})
+}
+
+// Release frees ip so it can be allocated again
+func (f *file) Release(ip net.IP) error {
+	return f.update(func(s *fileState) error {
+		key := ip.String()
+		if owner, ok := s.IPs[key]; !ok || owner != f.owner {
+			return errors.Errorf("%s isn't allocated", ip)
+		}
+
+		delete(s.IPs, key)
+		return nil
+	})
+}
+
+// Usage returns how many of the IPs in the CIDR are allocated, including
+// ones allocated by other daemons using the file
+func (f *file) Usage() (acquired, available uint64) {
+	if s, err := f.read(); err == nil {
+		f.setUsage(s)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.acquired, f.available
+}
+
+// unusable returns true if ip is the network, or broadcast, address
+func (f *file) unusable(ip net.IP) bool {
+	return ip.Equal(f.ipnet.IP) || (ip.To4() != nil && ip.Equal(lastIP(f.ipnet)))
+}
+
+// setUsage updates the usage of the CIDR from s
+func (f *file) setUsage(s *fileState) {
+	// the network and broadcast addresses count as acquired, as they do
+	// with go-ipam
+	acquired := uint64(1)
+	if f.ipnet.IP.To4() != nil {
+		acquired++
+	}
+	for ip := range f.reserved {
+		if _, ok := s.IPs[ip]; !ok {
+			acquired++
+		}
+	}
+	for ip := range s.IPs {
+		if f.ipnet.Contains(net.ParseIP(ip)) && !f.unusable(net.ParseIP(ip)) {
+			acquired++
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acquired, f.available = acquired, size(f.ipnet)
+}
+
+// read reads the file, which is empty if it doesn't exist yet
+func (f *file) read() (*fileState, error) {
+	s := &fileState{IPs: make(map[string]string)}
+
+	b, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read ipam file")
+	}
+
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, errors.Wrap(err, "failed to parse ipam file")
+	}
+	if s.IPs == nil {
+		s.IPs = make(map[string]string)
+	}
+	return s, nil
+}
+
+// update calls fn with the contents of the file, writing them back if it
+// doesn't return an error. The file is locked while this happens.
+func (f *file) update(fn func(s *fileState) error) error {
+	lock, err := os.OpenFile(f.path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return errors.Wrap(err, "failed to open ipam lock file")
+	}
+	defer lock.Close()
+
+	if err := lockFile(lock); err != nil {
+		return errors.Wrap(err, "failed to lock ipam file")
+	}
+	defer unlockFile(lock) //nolint:errcheck // Why: Closing it releases the lock anyways
+
+	s, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s); err != nil {
+		f.setUsage(s)
+		return err
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	// write it somewhere else first, so that a crash while writing it
+	// doesn't lose every allocation
+	tmp := f.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return errors.Wrap(err, "failed to write ipam file")
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return errors.Wrap(err, "failed to write ipam file")
+	}
+
+	f.setUsage(s)
+	return nil
+}
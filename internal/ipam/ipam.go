@@ -0,0 +1,96 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package ipam allocates the IPs port-forwards listen on. Allocators are
+// pluggable, state can be kept in memory, or in a file that's shared by
+// every daemon using it.
+package ipam
+
+import (
+	"math"
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNoIPAvailable is returned by Acquire when every IP is allocated
+	ErrNoIPAvailable = errors.New("no IP available")
+
+	// ErrIPInUse is returned by AcquireSpecific when the IP is allocated
+	ErrIPInUse = errors.New("IP already allocated")
+)
+
+// Allocator hands out IPs from a CIDR
+type Allocator interface {
+	// CIDR returns the CIDR IPs are allocated from
+	CIDR() string
+
+	// Acquire allocates a free IP
+	Acquire() (net.IP, error)
+
+	// AcquireSpecific allocates ip, if it's free
+	AcquireSpecific(ip net.IP) error
+
+	// Release frees ip so it can be allocated again
+	Release(ip net.IP) error
+
+	// Usage returns how many of the IPs in the CIDR are allocated, and how
+	// many there are. Acquired includes IPs that are never handed out, e.g.
+	// the network address.
+	Usage() (acquired, available uint64)
+}
+
+// parseCIDR parses cidr, returning it with the host bits cleared
+func parseCIDR(cidr string) (*net.IPNet, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse cidr")
+	}
+	if ip4 := ipnet.IP.To4(); ip4 != nil {
+		ipnet.IP = ip4
+	}
+	return ipnet, nil
+}
+
+// size returns the number of IPs in ipnet, capped like go-ipam does so it
+// fits the counts we report
+func size(ipnet *net.IPNet) uint64 {
+	ones, bits := ipnet.Mask.Size()
+	if bits-ones > 31 {
+		return math.MaxInt32
+	}
+	return 1 << uint(bits-ones)
+}
+
+// lastIP returns the last IP in ipnet, the broadcast address for IPv4
+func lastIP(ipnet *net.IPNet) net.IP {
+	ip := make(net.IP, len(ipnet.IP))
+	for i := range ip {
+		ip[i] = ipnet.IP[i] | ^ipnet.Mask[i]
+	}
+	return ip
+}
+
+// nextIP returns the IP after ip
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ipam
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// backends creates an Allocator of every kind for cidr
+func backends(t *testing.T, cidr string, reserved ...net.IP) map[string]Allocator {
+	t.Helper()
+
+	mem, err := NewMemory(cidr, reserved...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewFile(filepath.Join(t.TempDir(), "ipam.json"), "default", cidr, reserved...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]Allocator{"memory": mem, "file": f}
+}
+
+func TestAllocators(t *testing.T) {
+	for name, a := range backends(t, "127.0.0.0/29", net.ParseIP("127.0.0.1")) {
+		t.Run(name, func(t *testing.T) {
+			if a.CIDR() != "127.0.0.0/29" {
+				t.Fatalf("expected cidr 127.0.0.0/29, got %s", a.CIDR())
+			}
+
+			if err := a.AcquireSpecific(net.ParseIP("127.0.0.4")); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.AcquireSpecific(net.ParseIP("127.0.0.4")); !errors.Is(err, ErrIPInUse) {
+				t.Fatalf("expected ErrIPInUse, got %v", err)
+			}
+
+			// .0, .1 and .7 are never handed out, .4 is taken
+			var got []string
+			for {
+				ip, err := a.Acquire()
+				if errors.Is(err, ErrNoIPAvailable) {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, ip.String())
+			}
+			want := []string{"127.0.0.2", "127.0.0.3", "127.0.0.5", "127.0.0.6"}
+			if len(got) != len(want) {
+				t.Fatalf("expected %v, got %v", want, got)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("expected %v, got %v", want, got)
+				}
+			}
+
+			if acquired, available := a.Usage(); acquired != 8 || available != 8 {
+				t.Fatalf("expected 8/8 in use, got %d/%d", acquired, available)
+			}
+
+			if err := a.Release(net.ParseIP("127.0.0.3")); err != nil {
+				t.Fatal(err)
+			}
+			if ip, err := a.Acquire(); err != nil || ip.String() != "127.0.0.3" {
+				t.Fatalf("expected 127.0.0.3 to be acquired again, got %v, %v", ip, err)
+			}
+		})
+	}
+}
+
+func TestFileShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipam.json")
+
+	a, err := NewFile(path, "a", "127.0.0.0/29")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewFile(path, "b", "127.0.0.0/29")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ipA, err := a.Acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipB, err := b.Acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ipA.Equal(ipB) {
+		t.Fatalf("expected different IPs, both got %s", ipA)
+	}
+
+	if err := b.Release(ipA); err == nil {
+		t.Fatal("expected releasing an IP owned by another daemon to fail")
+	}
+	if acquired, _ := b.Usage(); acquired != 4 {
+		t.Fatalf("expected 4 IPs in use, got %d", acquired)
+	}
+
+	// a restart of a releases what it had, but not what b has
+	a, err = NewFile(path, "a", "127.0.0.0/29")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AcquireSpecific(ipA); err != nil {
+		t.Fatalf("expected %s to be released on restart, got %v", ipA, err)
+	}
+	if err := a.AcquireSpecific(ipB); !errors.Is(err, ErrIPInUse) {
+		t.Fatalf("expected %s to still be in use, got %v", ipB, err)
+	}
+}
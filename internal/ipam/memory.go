@@ -0,0 +1,90 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ipam
+
+import (
+	"net"
+
+	goipam "github.com/metal-stack/go-ipam"
+	"github.com/pkg/errors"
+)
+
+// memory is an Allocator that keeps its state in memory, with go-ipam
+type memory struct {
+	ipamer goipam.Ipamer
+	cidr   string
+}
+
+// NewMemory creates an Allocator for cidr that keeps its state in memory.
+// The reserved IPs, if they're in cidr, are never handed out.
+func NewMemory(cidr string, reserved ...net.IP) (Allocator, error) {
+	ipnet, err := parseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	ipamer := goipam.New()
+	prefix, err := ipamer.NewPrefix(ipnet.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create ip pool")
+	}
+
+	m := &memory{ipamer: ipamer, cidr: prefix.Cidr}
+	for _, ip := range reserved {
+		if !ipnet.Contains(ip) {
+			continue
+		}
+		if err := m.AcquireSpecific(ip); err != nil && !errors.Is(err, ErrIPInUse) {
+			return nil, errors.Wrapf(err, "failed to reserve %s", ip)
+		}
+	}
+
+	return m, nil
+}
+
+// CIDR returns the CIDR IPs are allocated from
+func (m *memory) CIDR() string {
+	return m.cidr
+}
+
+// Acquire allocates a free IP
+func (m *memory) Acquire() (net.IP, error) {
+	ip, err := m.ipamer.AcquireIP(m.cidr)
+	if errors.Is(err, goipam.ErrNoIPAvailable) {
+		return nil, ErrNoIPAvailable
+	} else if err != nil {
+		return nil, err
+	}
+	return ip.IP.IPAddr().IP, nil
+}
+
+// AcquireSpecific allocates ip, if it's free
+func (m *memory) AcquireSpecific(ip net.IP) error {
+	_, err := m.ipamer.AcquireSpecificIP(m.cidr, ip.String())
+	if errors.Is(err, goipam.ErrAlreadyAllocated) {
+		return ErrIPInUse
+	}
+	return err
+}
+
+// Release frees ip so it can be allocated again
+func (m *memory) Release(ip net.IP) error {
+	return m.ipamer.ReleaseIPFromPrefix(m.cidr, ip.String())
+}
+
+// Usage returns how many of the IPs in the CIDR are allocated
+func (m *memory) Usage() (acquired, available uint64) {
+	usage := m.ipamer.PrefixFrom(m.cidr).Usage()
+	return usage.AcquiredIPs, usage.AvailableIPs
+}
@@ -0,0 +1,239 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package ippool picks, and validates, the range of IPs that port-forwards
+// are allocated from for the platform localizer is running on
+package ippool
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Loopback is the range of IPs reserved for loopback, port-forwards are
+// always allocated from inside of it
+var Loopback = &net.IPNet{IP: net.IPv4(127, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)}
+
+// DefaultCIDR is used when the platform's loopback range can't be found
+const DefaultCIDR = "127.0.0.1/8"
+
+// iface is a network interface and the IPv4 networks assigned to it
+type iface struct {
+	name     string
+	loopback bool
+	nets     []*net.IPNet
+}
+
+// interfaces returns the network interfaces of this machine
+func interfaces() ([]iface, error) {
+	netIfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list network interfaces")
+	}
+
+	ifaces := make([]iface, 0, len(netIfaces))
+	for i := range netIfaces {
+		addrs, err := netIfaces[i].Addrs()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list addresses of interface %s", netIfaces[i].Name)
+		}
+
+		ifc := iface{name: netIfaces[i].Name, loopback: netIfaces[i].Flags&net.FlagLoopback != 0}
+		for _, addr := range addrs {
+			if n, ok := addr.(*net.IPNet); ok && n.IP.To4() != nil {
+				ifc.nets = append(ifc.nets, n)
+			}
+		}
+		ifaces = append(ifaces, ifc)
+	}
+
+	return ifaces, nil
+}
+
+// Select returns cidr, or the default for this platform if it's empty,
+// after checking that the IPs in it can be listened on and that traffic
+// for them isn't routed elsewhere. The errors name the interface, or
+// route, that's in the way, rather than leaving it to fail when binding,
+// or to blackhole traffic, later. When the default is in the way, part of
+// it that isn't is used instead.
+func Select(log logrus.FieldLogger, cidr string) (string, error) {
+	ifaces, err := interfaces()
+	if err != nil {
+		return "", err
+	}
+
+	rts, err := routes()
+	if err != nil {
+		log.WithError(err).Warn("failed to read the routing table, not checking the ip cidr against it")
+	}
+
+	return selectCIDR(log, runtime.GOOS, cidr, ifaces, rts)
+}
+
+func selectCIDR(log logrus.FieldLogger, goos, cidr string, ifaces []iface, rts []route) (string, error) {
+	auto := cidr == ""
+	if auto {
+		cidr = defaultCIDR(goos, ifaces)
+	}
+
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid ip cidr")
+	}
+
+	if !contains(Loopback, n) {
+		return "", fmt.Errorf("ip cidr %s isn't a loopback range, it must be inside of %s", cidr, Loopback)
+	}
+
+	if err := checkConflicts(n, ifaces, rts); err != nil {
+		if !auto {
+			return "", err
+		}
+
+		free := freeSubnet(n, ifaces, rts)
+		if free == nil {
+			return "", err
+		}
+		log.WithError(err).Warnf("using ip cidr %s instead", free)
+		cidr, n = free.String(), free
+	}
+
+	// Linux only routes IPs assigned to the loopback interface to it, macOS
+	// aliases each IP as it's allocated and Windows routes all of 127.0.0.0/8
+	if goos == "linux" {
+		var lo *iface
+		for i := range ifaces {
+			if !ifaces[i].loopback {
+				continue
+			}
+			lo = &ifaces[i]
+			for _, in := range lo.nets {
+				if contains(in, n) {
+					return cidr, nil
+				}
+			}
+		}
+		if lo == nil {
+			return "", fmt.Errorf("no loopback interface was found")
+		}
+		return "", fmt.Errorf("ip cidr %s isn't assigned to the loopback interface %s, so it can't be listened on. "+
+			"Add it with 'ip addr add %s dev %s', or pass an --ip-cidr inside of %v", cidr, lo.name, n, lo.name, lo.nets)
+	}
+
+	return cidr, nil
+}
+
+// checkConflicts returns an error naming the interface address, or route,
+// that takes traffic for IPs in n away from the loopback interface. VPN
+// clients sometimes claim part of 127.0.0.0/8, and traffic for those IPs
+// would go to the VPN rather than to us.
+func checkConflicts(n *net.IPNet, ifaces []iface, rts []route) error {
+	loopbacks := make(map[string]bool)
+	for _, ifc := range ifaces {
+		if ifc.loopback {
+			loopbacks[ifc.name] = true
+			continue
+		}
+		for _, in := range ifc.nets {
+			if overlaps(n, in) {
+				return fmt.Errorf("ip cidr %s overlaps with %s on interface %s, pass an --ip-cidr outside of it",
+					n, in, ifc.name)
+			}
+		}
+	}
+
+	for _, r := range rts {
+		// the default route overlaps with everything, but more specific
+		// routes, like the loopback range, take precedence over it
+		if loopbacks[r.iface] || maskSize(r.dest) == 0 {
+			continue
+		}
+		if overlaps(n, r.dest) {
+			return fmt.Errorf("ip cidr %s overlaps with the route to %s via interface %s, pass an --ip-cidr outside of it",
+				n, r.dest, r.iface)
+		}
+	}
+
+	return nil
+}
+
+// freeSubnet returns the first /16 of n that doesn't conflict with any
+// interface or route, or nil if there isn't one
+func freeSubnet(n *net.IPNet, ifaces []iface, rts []route) *net.IPNet {
+	if maskSize(n) >= 16 {
+		return nil
+	}
+
+	base := n.IP.Mask(n.Mask).To4()
+	count := 1 << uint(16-maskSize(n))
+	for i := 0; i < count; i++ {
+		ip := make(net.IP, 4)
+		copy(ip, base)
+		ip[0] |= byte(i >> 8)
+		ip[1] |= byte(i)
+
+		sub := &net.IPNet{IP: ip, Mask: net.CIDRMask(16, 32)}
+		if checkConflicts(sub, ifaces, rts) == nil {
+			return sub
+		}
+	}
+
+	return nil
+}
+
+// defaultCIDR returns the largest range assigned to the loopback interface
+// on Linux, and DefaultCIDR elsewhere
+func defaultCIDR(goos string, ifaces []iface) string {
+	if goos != "linux" {
+		return DefaultCIDR
+	}
+
+	var largest *net.IPNet
+	for _, ifc := range ifaces {
+		if !ifc.loopback {
+			continue
+		}
+		for _, n := range ifc.nets {
+			if !contains(Loopback, n) {
+				continue
+			}
+			if largest == nil || maskSize(n) < maskSize(largest) {
+				largest = n
+			}
+		}
+	}
+	if largest == nil {
+		return DefaultCIDR
+	}
+	return largest.String()
+}
+
+// maskSize returns the number of leading ones in the mask of n
+func maskSize(n *net.IPNet) int {
+	ones, _ := n.Mask.Size()
+	return ones
+}
+
+// contains returns if all of inner is inside of outer
+func contains(outer, inner *net.IPNet) bool {
+	return outer.Contains(inner.IP) && maskSize(outer) <= maskSize(inner)
+}
+
+// overlaps returns if a and b share any IPs
+func overlaps(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ippool
+
+import (
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func mustCIDR(t *testing.T, s string) *net.IPNet {
+	t.Helper()
+
+	ip, n, err := net.ParseCIDR(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.IP = ip
+	return n
+}
+
+func TestSelectCIDR(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+
+	lo := iface{name: "lo", loopback: true, nets: []*net.IPNet{mustCIDR(t, "127.0.0.1/8")}}
+	eth := iface{name: "eth0", nets: []*net.IPNet{mustCIDR(t, "192.168.1.10/24")}}
+	vpn := iface{name: "utun3", nets: []*net.IPNet{mustCIDR(t, "127.10.0.1/16")}}
+	vpnRoute := route{dest: mustCIDR(t, "127.0.0.0/16"), iface: "tun0"}
+	loRoute := route{dest: mustCIDR(t, "127.0.0.0/8"), iface: "lo"}
+	defaultRoute := route{dest: mustCIDR(t, "0.0.0.0/0"), iface: "eth0"}
+	smallLo := iface{name: "lo", loopback: true, nets: []*net.IPNet{mustCIDR(t, "127.0.0.1/32")}}
+
+	tests := []struct {
+		name   string
+		goos   string
+		cidr   string
+		ifaces []iface
+		routes []route
+		want   string
+		err    string
+	}{
+		{name: "linux default", goos: "linux", ifaces: []iface{lo, eth}, want: "127.0.0.1/8"},
+		{name: "darwin default", goos: "darwin", ifaces: []iface{lo, eth}, want: DefaultCIDR},
+		{name: "linux subset", goos: "linux", cidr: "127.1.0.0/16", ifaces: []iface{lo}, want: "127.1.0.0/16"},
+		{name: "invalid", goos: "linux", cidr: "127.1.0.0", ifaces: []iface{lo}, err: "invalid ip cidr"},
+		{name: "not loopback", goos: "darwin", cidr: "10.0.0.0/24", ifaces: []iface{lo}, err: "isn't a loopback range"},
+		{name: "vpn conflict", goos: "darwin", cidr: "127.0.0.1/8", ifaces: []iface{lo, vpn}, err: "overlaps with 127.10.0.1/16 on interface utun3"},
+		{name: "vpn adjusted", goos: "linux", ifaces: []iface{lo, vpn}, want: "127.0.0.0/16"},
+		{name: "route conflict", goos: "linux", cidr: "127.0.0.1/8", ifaces: []iface{lo}, routes: []route{vpnRoute, defaultRoute},
+			err: "overlaps with the route to 127.0.0.0/16 via interface tun0"},
+		{name: "route adjusted", goos: "linux", ifaces: []iface{lo}, routes: []route{vpnRoute, defaultRoute}, want: "127.1.0.0/16"},
+		{name: "loopback route", goos: "linux", ifaces: []iface{lo}, routes: []route{loRoute, defaultRoute}, want: "127.0.0.1/8"},
+		{name: "vpn avoided", goos: "darwin", cidr: "127.20.0.0/16", ifaces: []iface{lo, vpn}, want: "127.20.0.0/16"},
+		{name: "linux unassigned", goos: "linux", cidr: "127.1.0.0/16", ifaces: []iface{smallLo}, err: "ip addr add 127.1.0.0/16 dev lo"},
+		{name: "darwin unassigned", goos: "darwin", cidr: "127.1.0.0/16", ifaces: []iface{smallLo}, want: "127.1.0.0/16"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectCIDR(log, tt.goos, tt.cidr, tt.ifaces, tt.routes)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseProcRoutes(t *testing.T) {
+	table := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0101A8C0	0003	0	0	100	00000000	0	0	0
+eth0	0001A8C0	00000000	0001	0	0	100	00FFFFFF	0	0	0
+tun0	0000007F	00000000	0001	0	0	0	0000FFFF	0	0	0
+`
+	rts, err := parseProcRoutes(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"eth0 0.0.0.0/0", "eth0 192.168.1.0/24", "tun0 127.0.0.0/16"}
+	if len(rts) != len(want) {
+		t.Fatalf("expected %d routes, got %d", len(want), len(rts))
+	}
+	for i, r := range rts {
+		if got := r.iface + " " + r.dest.String(); got != want[i] {
+			t.Fatalf("expected route %q, got %q", want[i], got)
+		}
+	}
+}
+
+func TestParseNetstatRoutes(t *testing.T) {
+	table := `Routing tables
+
+Internet:
+Destination        Gateway            Flags           Netif Expire
+default            192.168.1.1        UGScg             en0
+127                127.0.0.1          UCS               lo0
+127.0.0.1          127.0.0.1          UH                lo0
+127.10/16          link#20            UCS             utun3
+169.254            link#6             UCS               en0      !
+192.168.1.1%en0    link#6             UHLWIir           en0   1175
+`
+	rts, err := parseNetstatRoutes(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"lo0 127.0.0.0/8", "lo0 127.0.0.1/32", "utun3 127.10.0.0/16", "en0 169.254.0.0/16"}
+	if len(rts) != len(want) {
+		t.Fatalf("expected %d routes, got %d", len(want), len(rts))
+	}
+	for i, r := range rts {
+		if got := r.iface + " " + r.dest.String(); got != want[i] {
+			t.Fatalf("expected route %q, got %q", want[i], got)
+		}
+	}
+}
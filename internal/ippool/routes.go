@@ -0,0 +1,136 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ippool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// route is an entry of the routing table, traffic for dest is sent to
+// iface
+type route struct {
+	dest  *net.IPNet
+	iface string
+}
+
+// parseProcRoutes parses the IPv4 routing table of Linux, in the format of
+// /proc/net/route
+func parseProcRoutes(r io.Reader) ([]route, error) {
+	routes := []route{}
+	scanner := bufio.NewScanner(r)
+	for first := true; scanner.Scan(); first = false {
+		fields := strings.Fields(scanner.Text())
+		if first || len(fields) < 8 {
+			continue
+		}
+
+		dest, err := parseProcAddr(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		mask, err := parseProcAddr(fields[7])
+		if err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, route{dest: &net.IPNet{IP: dest, Mask: net.IPMask(mask)}, iface: fields[0]})
+	}
+
+	return routes, errors.Wrap(scanner.Err(), "failed to read routes")
+}
+
+// parseProcAddr parses an address from /proc/net/route, which is hex in
+// the byte order of the host
+func parseProcAddr(s string) (net.IP, error) {
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid address '%s'", s)
+	}
+
+	ip := make(net.IP, 4)
+	binary.LittleEndian.PutUint32(ip, uint32(v))
+	return ip, nil
+}
+
+// parseNetstatRoutes parses the output of 'netstat -rn -f inet' on macOS
+func parseNetstatRoutes(r io.Reader) ([]route, error) {
+	routes := []route{}
+	netif := -1
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		// the columns differ between versions of macOS
+		if fields[0] == "Destination" {
+			for i, f := range fields {
+				if f == "Netif" {
+					netif = i
+				}
+			}
+			continue
+		}
+		if netif == -1 || len(fields) <= netif || fields[0] == "default" {
+			continue
+		}
+
+		dest := parseNetstatDest(fields[0])
+		if dest == nil {
+			continue
+		}
+		routes = append(routes, route{dest: dest, iface: fields[netif]})
+	}
+
+	return routes, errors.Wrap(scanner.Err(), "failed to read routes")
+}
+
+// parseNetstatDest parses a destination printed by netstat, which leaves
+// off trailing zero octets, e.g. 127 is 127.0.0.0/8 and 10.1/16 is
+// 10.1.0.0/16. Link-local destinations, like 192.168.1.1%en0, are nil.
+func parseNetstatDest(s string) *net.IPNet {
+	addr, bits := s, -1
+	if i := strings.Index(s, "/"); i != -1 {
+		var err error
+		if bits, err = strconv.Atoi(s[i+1:]); err != nil {
+			return nil
+		}
+		addr = s[:i]
+	}
+
+	octets := strings.Split(addr, ".")
+	if len(octets) > 4 {
+		return nil
+	}
+	if bits == -1 {
+		bits = 8 * len(octets)
+	}
+	for len(octets) < 4 {
+		octets = append(octets, "0")
+	}
+
+	ip := net.ParseIP(strings.Join(octets, ".")).To4()
+	if ip == nil || bits > 32 {
+		return nil
+	}
+	return &net.IPNet{IP: ip.Mask(net.CIDRMask(bits, 32)), Mask: net.CIDRMask(bits, 32)}
+}
@@ -10,7 +10,23 @@ import (
 // GlobalCache is an optional global cache that can be initialized
 var GlobalCache informers.SharedInformerFactory
 
-// ConfigureGlobalCache sets up package wide global cache
-func ConfigureGlobalCache(k kubernetes.Interface, namespace string) {
-	GlobalCache = informers.NewSharedInformerFactoryWithOptions(k, 10*time.Minute, informers.WithNamespace(namespace))
+// DefaultResyncInterval is how often every object in the global cache is
+// handled again, even if it hasn't changed
+const DefaultResyncInterval = 10 * time.Minute
+
+// ConfigureGlobalCache sets up package wide global cache, only watching
+// the given namespaces, or all of them if none are given. Every object is
+// resynced at the given interval, 0 disables resyncing.
+func ConfigureGlobalCache(k kubernetes.Interface, namespaces []string, resync time.Duration) {
+	if len(namespaces) > 1 {
+		GlobalCache = informers.NewSharedInformerFactoryWithOptions(k, resync)
+		namespacedInformers(GlobalCache, k, namespaces)
+		return
+	}
+
+	namespace := ""
+	if len(namespaces) == 1 {
+		namespace = namespaces[0]
+	}
+	GlobalCache = informers.NewSharedInformerFactoryWithOptions(k, resync, informers.WithNamespace(namespace))
 }
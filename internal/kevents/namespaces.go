@@ -0,0 +1,221 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kevents
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// listFunc lists objects of a type in a namespace
+type listFunc func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error)
+
+// watchFunc watches objects of a type in a namespace
+type watchFunc func(ctx context.Context, namespace string, opts metav1.ListOptions) (watch.Interface, error)
+
+// namespacesListWatch is a cache.ListerWatcher that lists, and watches,
+// each of a set of namespaces on its own. This lets an informer cover
+// more than one namespace without watching the entire cluster.
+type namespacesListWatch struct {
+	namespaces []string
+	list       listFunc
+	watch      watchFunc
+
+	// mu protects versions
+	mu sync.Mutex
+
+	// versions is the last resource version seen in each namespace, a
+	// resource version is only valid for the request it came from, so the
+	// merged one given to Watch can't be used
+	versions map[string]string
+}
+
+// newNamespacesListWatch creates a namespacesListWatch for namespaces
+func newNamespacesListWatch(namespaces []string, list listFunc, w watchFunc) *namespacesListWatch {
+	return &namespacesListWatch{
+		namespaces: namespaces,
+		list:       list,
+		watch:      w,
+		versions:   make(map[string]string),
+	}
+}
+
+// List lists every namespace, merging them into a single list
+func (lw *namespacesListWatch) List(opts metav1.ListOptions) (runtime.Object, error) {
+	// every namespace is listed in full, so it can't be paged
+	opts.Limit = 0
+	opts.Continue = ""
+
+	var merged runtime.Object
+	items := make([]runtime.Object, 0)
+	versions := make(map[string]string, len(lw.namespaces))
+	for _, ns := range lw.namespaces {
+		obj, err := lw.list(context.TODO(), ns, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		nsItems, err := meta.ExtractList(obj)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, nsItems...)
+
+		listMeta, err := meta.ListAccessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		versions[ns] = listMeta.GetResourceVersion()
+
+		if merged == nil {
+			merged = obj
+		}
+	}
+
+	if err := meta.SetList(merged, items); err != nil {
+		return nil, err
+	}
+
+	lw.mu.Lock()
+	lw.versions = versions
+	lw.mu.Unlock()
+
+	return merged, nil
+}
+
+// Watch watches every namespace from the last resource version seen in it
+func (lw *namespacesListWatch) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	result := make(chan watch.Event)
+	w := watch.NewProxyWatcher(result)
+
+	var wg sync.WaitGroup
+	for _, ns := range lw.namespaces {
+		nsOpts := opts
+		lw.mu.Lock()
+		nsOpts.ResourceVersion = lw.versions[ns]
+		lw.mu.Unlock()
+
+		nsWatch, err := lw.watch(ctx, ns, nsOpts)
+		if err != nil {
+			cancel()
+			wg.Wait()
+			return nil, err
+		}
+
+		wg.Add(1)
+		go func(ns string, nsWatch watch.Interface) {
+			defer wg.Done()
+			defer nsWatch.Stop()
+
+			// stopping any namespace's watch stops all of them, so the
+			// reflector starts watching again from where each left off
+			defer cancel()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-w.StopChan():
+					return
+				case e, ok := <-nsWatch.ResultChan():
+					if !ok {
+						return
+					}
+
+					if m, err := meta.Accessor(e.Object); err == nil && e.Type != watch.Error {
+						lw.mu.Lock()
+						lw.versions[ns] = m.GetResourceVersion()
+						lw.mu.Unlock()
+					}
+
+					select {
+					case result <- e:
+					case <-ctx.Done():
+						return
+					case <-w.StopChan():
+						return
+					}
+				}
+			}
+		}(ns, nsWatch)
+	}
+
+	go func() {
+		wg.Wait()
+		w.Stop()
+		close(result)
+	}()
+
+	return w, nil
+}
+
+// namespacedInformers registers informers with factory for the namespaced
+// types localizer watches, that only cover namespaces. Other types are
+// watched as the factory normally would.
+func namespacedInformers(factory informers.SharedInformerFactory, k kubernetes.Interface, namespaces []string) {
+	register := func(obj runtime.Object, list listFunc, w watchFunc) {
+		factory.InformerFor(obj, func(_ kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+			return cache.NewSharedIndexInformer(newNamespacesListWatch(namespaces, list, w), obj, resync,
+				cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		})
+	}
+
+	register(&corev1.Service{},
+		func(ctx context.Context, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return k.CoreV1().Services(ns).List(ctx, opts)
+		},
+		func(ctx context.Context, ns string, opts metav1.ListOptions) (watch.Interface, error) {
+			return k.CoreV1().Services(ns).Watch(ctx, opts)
+		})
+	register(&corev1.Endpoints{},
+		func(ctx context.Context, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return k.CoreV1().Endpoints(ns).List(ctx, opts)
+		},
+		func(ctx context.Context, ns string, opts metav1.ListOptions) (watch.Interface, error) {
+			return k.CoreV1().Endpoints(ns).Watch(ctx, opts)
+		})
+	register(&corev1.Pod{},
+		func(ctx context.Context, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return k.CoreV1().Pods(ns).List(ctx, opts)
+		},
+		func(ctx context.Context, ns string, opts metav1.ListOptions) (watch.Interface, error) {
+			return k.CoreV1().Pods(ns).Watch(ctx, opts)
+		})
+	register(&appsv1.Deployment{},
+		func(ctx context.Context, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return k.AppsV1().Deployments(ns).List(ctx, opts)
+		},
+		func(ctx context.Context, ns string, opts metav1.ListOptions) (watch.Interface, error) {
+			return k.AppsV1().Deployments(ns).Watch(ctx, opts)
+		})
+	register(&appsv1.StatefulSet{},
+		func(ctx context.Context, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return k.AppsV1().StatefulSets(ns).List(ctx, opts)
+		},
+		func(ctx context.Context, ns string, opts metav1.ListOptions) (watch.Interface, error) {
+			return k.AppsV1().StatefulSets(ns).Watch(ctx, opts)
+		})
+}
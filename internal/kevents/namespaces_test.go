@@ -0,0 +1,70 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kevents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func service(namespace, name string) *corev1.Service {
+	return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
+
+func TestConfigureGlobalCacheNamespaces(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	k := fake.NewSimpleClientset(service("a", "api"), service("b", "web"), service("c", "db"))
+	ConfigureGlobalCache(k, []string{"a", "b"}, 0)
+
+	lister := GlobalCache.Core().V1().Services().Lister()
+	GlobalCache.Start(ctx.Done())
+	GlobalCache.WaitForCacheSync(ctx.Done())
+
+	svcs, err := lister.List(labels.Everything())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(svcs) != 2 {
+		t.Fatalf("expected only the services in a and b, got %d", len(svcs))
+	}
+
+	// created after the informer started, to ensure every namespace is
+	// being watched
+	for _, svc := range []*corev1.Service{service("b", "worker"), service("c", "cache")} {
+		if _, err := k.CoreV1().Services(svc.Namespace).Create(ctx, svc, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = wait.PollImmediateUntil(10*time.Millisecond, func() (bool, error) {
+		_, err := lister.Services("b").Get("worker")
+		return err == nil, nil
+	}, ctx.Done())
+	if err != nil {
+		t.Fatal("expected b/worker to be seen by the informer")
+	}
+
+	if _, err := lister.Services("c").Get("cache"); err == nil {
+		t.Fatal("expected c/cache to not be seen by the informer")
+	}
+}
@@ -17,17 +17,17 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
-	"net/http"
+	"strings"
 
-	"github.com/getoutreach/localizer/internal/kevents"
 	"github.com/getoutreach/localizer/internal/reflectconversions"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/conversion"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/transport/spdy"
+	"k8s.io/client-go/tools/cache"
 
 	// Needed for external authenticators
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -38,46 +38,63 @@ import (
 	"k8s.io/client-go/rest"
 )
 
-// GetKubeClient returns a kubernetes client, and the config used by it, based on
-// a given context. If no context is provided then the default will be used
-func GetKubeClient(contextName string) (*rest.Config, kubernetes.Interface, error) {
-	// attempt to use in cluster config first
+// GetInClusterKubeClient returns a kubernetes client, and the config used by it,
+// using the service account of the pod we're running in.
+func GetInClusterKubeClient() (*rest.Config, kubernetes.Interface, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
-		lr := clientcmd.NewDefaultClientConfigLoadingRules()
+		return nil, nil, errors.Wrap(err, "failed to get in-cluster config, are we running in a pod?")
+	}
 
-		overrides := &clientcmd.ConfigOverrides{}
-		if contextName != "" {
-			overrides.CurrentContext = contextName
-		}
+	client, err := kubernetes.NewForConfig(instrument(config))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create kubernetes client")
+	}
 
-		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(lr, overrides).ClientConfig()
-		if err != nil {
-			return nil, nil, errors.Wrap(err, "failed to get kubernetes client config")
-		}
+	return config, client, nil
+}
+
+// GetKubeClient returns a kubernetes client, and the config used by it, based on
+// a given context. If no context is provided then the default will be used.
+// Outside of a cluster, the client, and port-forwards made with the config,
+// pick up changes to the kubeconfig and the client certificates it uses.
+func GetKubeClient(log logrus.FieldLogger, contextName string) (*rest.Config, kubernetes.Interface, error) {
+	// attempt to use in cluster config first
+	if _, err := rest.InClusterConfig(); err == nil {
+		return GetInClusterKubeClient()
+	}
+
+	lr := loadingRules()
+
+	overrides := &clientcmd.ConfigOverrides{}
+	if contextName != "" {
+		overrides.CurrentContext = contextName
 	}
 
-	client, err := kubernetes.NewForConfig(config)
+	creds, err := newCredentials(log, lr, overrides)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get kubernetes client config")
+	}
+
+	client, err := kubernetes.NewForConfig(instrument(creds.clientConfig()))
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to create kubernetes client")
 	}
 
-	return config, client, nil
+	credentialsMu.Lock()
+	reloadable[creds.rc] = creds
+	credentialsMu.Unlock()
+
+	return creds.rc, client, nil
 }
 
 func CreatePortForward(ctx context.Context, r rest.Interface, rc *rest.Config,
 	p *corev1.Pod, ip string, ports []string) (*portforward.PortForwarder, error) {
-	req := r.Post().
-		Resource("pods").
-		Namespace(p.Namespace).
-		Name(p.Name).
-		SubResource("portforward")
-
-	transport, upgrader, err := spdy.RoundTripperFor(rc)
+	t, err := TransportFor(rc)
 	if err != nil {
 		return nil, err
 	}
-	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
+	dialer := t.DialerFor(r, p.Namespace, p.Name)
 
 	return portforward.NewOnAddresses(dialer, []string{ip}, ports, ctx.Done(), nil, ioutil.Discard, ioutil.Discard)
 }
@@ -95,10 +112,10 @@ type ResolvedServicePort struct {
 }
 
 // ResolveServicePorts converts named ports into their true
-// format. TargetPort's that have are named become their integer equivalents
-func ResolveServicePorts(log logrus.FieldLogger, s *corev1.Service) ([]ResolvedServicePort, error) { //nolint:funlen
-	store := kevents.GlobalCache.Core().V1().Endpoints().Informer().GetStore()
-
+// format. TargetPort's that have are named become their integer equivalents.
+// The informers of the given factory are used to look up endpoints and
+// controllers.
+func ResolveServicePorts(log logrus.FieldLogger, factory informers.SharedInformerFactory, s *corev1.Service) ([]ResolvedServicePort, error) { //nolint:funlen,lll
 	hasNamedPorts := false
 	for _, p := range s.Spec.Ports {
 		if p.TargetPort.Type == intstr.String {
@@ -120,10 +137,11 @@ func ResolveServicePorts(log logrus.FieldLogger, s *corev1.Service) ([]ResolvedS
 		return servicePorts, nil
 	}
 
+	store := factory.Core().V1().Endpoints().Informer().GetStore()
 	obj, _, err := store.GetByKey(s.Namespace + "/" + s.Name)
 	e, ok := obj.(*corev1.Endpoints)
 	if !ok || len(e.Subsets) == 0 || err != nil {
-		return ResolveServicePortsFromControllers(log, s)
+		return ResolveServicePortsFromControllers(log, factory, s)
 	}
 
 	servicePorts := make([]ResolvedServicePort, len(s.Spec.Ports))
@@ -207,8 +225,8 @@ func satisfiesSelector(obj interface{}, matches map[string]string) (bool, error)
 
 // ResolveServicePortsFromControllers looks up the controllers of a given service
 // and uses their containerPort declarations to resolve named endpoints of a service
-func ResolveServicePortsFromControllers(log logrus.FieldLogger, s *corev1.Service) ([]ResolvedServicePort, error) { //nolint:funlen
-	controllers, err := FindControllersForService(log, s)
+func ResolveServicePortsFromControllers(log logrus.FieldLogger, factory informers.SharedInformerFactory, s *corev1.Service) ([]ResolvedServicePort, error) { //nolint:funlen,lll
+	controllers, err := FindControllersForService(log, factory, s)
 	if err != nil {
 		return nil, err
 	}
@@ -307,11 +325,11 @@ func ResolveServicePortsFromControllers(log logrus.FieldLogger, s *corev1.Servic
 // FindControllersForService returns the controllers for a given service.
 // Controllers are deployments/statefulsets that match the service's selector in their
 // pod templates.
-func FindControllersForService(log logrus.FieldLogger, s *corev1.Service) ([]interface{}, error) {
+func FindControllersForService(log logrus.FieldLogger, factory informers.SharedInformerFactory, s *corev1.Service) ([]interface{}, error) { //nolint:lll
 	// TODO: Search all types? Not sure how to handle this.
 	items := []interface{}{}
-	items = append(items, kevents.GlobalCache.Apps().V1().StatefulSets().Informer().GetStore().List()...)
-	items = append(items, kevents.GlobalCache.Apps().V1().Deployments().Informer().GetStore().List()...)
+	items = append(items, factory.Apps().V1().StatefulSets().Informer().GetStore().List()...)
+	items = append(items, factory.Apps().V1().Deployments().Informer().GetStore().List()...)
 
 	log.WithField("len", len(items)).Debug("processing controllers")
 
@@ -332,3 +350,65 @@ func FindControllersForService(log logrus.FieldLogger, s *corev1.Service) ([]int
 
 	return controllers, nil
 }
+
+// ScaleTargetAnnotation is an annotation on a service naming the
+// controllers that are scaled down when it's exposed, as a comma separated
+// list of deployment/name, statefulset/name, or name. This is used when
+// its selector matches controllers that shouldn't be scaled down.
+const ScaleTargetAnnotation = "localizer.jaredallard.github.com/scale-target"
+
+// FindScaleTargets returns the controllers named by the
+// ScaleTargetAnnotation of a service, ok is false if it doesn't have one.
+// Names without a kind can be a deployment or a statefulset, but not both.
+func FindScaleTargets(factory informers.SharedInformerFactory, s *corev1.Service) (controllers []interface{}, ok bool, err error) { //nolint:lll
+	targets := strings.TrimSpace(s.Annotations[ScaleTargetAnnotation])
+	if targets == "" {
+		return nil, false, nil
+	}
+
+	stores := map[string]cache.Store{
+		"deployment":  factory.Apps().V1().Deployments().Informer().GetStore(),
+		"statefulset": factory.Apps().V1().StatefulSets().Informer().GetStore(),
+	}
+
+	controllers = make([]interface{}, 0)
+	for _, target := range strings.Split(targets, ",") {
+		target = strings.TrimSpace(target)
+		if target == "" {
+			continue
+		}
+
+		kinds := []string{"deployment", "statefulset"}
+		name := target
+		if spl := strings.SplitN(target, "/", 2); len(spl) == 2 {
+			kind := strings.TrimSuffix(strings.ToLower(spl[0]), "s")
+			if _, ok := stores[kind]; !ok {
+				return nil, true, fmt.Errorf("%s: unsupported kind %q, expected deployment or statefulset", ScaleTargetAnnotation, spl[0])
+			}
+			kinds, name = []string{kind}, spl[1]
+		}
+
+		found := []interface{}{}
+		for _, kind := range kinds {
+			obj, exists, err := stores[kind].GetByKey(s.Namespace + "/" + name)
+			if err != nil {
+				return nil, true, err
+			}
+			if exists {
+				found = append(found, obj)
+			}
+		}
+
+		switch len(found) {
+		case 0:
+			return nil, true, fmt.Errorf("%s: %q wasn't found in namespace %s", ScaleTargetAnnotation, target, s.Namespace)
+		case 1:
+			controllers = append(controllers, found[0])
+		default:
+			return nil, true, fmt.Errorf("%s: %q is both a deployment and a statefulset, use deployment/%s or statefulset/%s",
+				ScaleTargetAnnotation, target, name, name)
+		}
+	}
+
+	return controllers, true, nil
+}
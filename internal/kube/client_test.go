@@ -0,0 +1,88 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFindScaleTargets(t *testing.T) {
+	factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	deployments := factory.Apps().V1().Deployments().Informer().GetStore()
+	statefulSets := factory.Apps().V1().StatefulSets().Informer().GetStore()
+	for _, name := range []string{"api", "api-canary", "both"} {
+		//nolint:errcheck // Why: Adding to a store can't fail
+		deployments.Add(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}})
+	}
+	for _, name := range []string{"db", "both"} {
+		//nolint:errcheck // Why: Adding to a store can't fail
+		statefulSets.Add(&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}})
+	}
+
+	tests := []struct {
+		annotation string
+		want       []string
+		ok         bool
+		err        string
+	}{
+		{annotation: "", ok: false},
+		{annotation: "api", want: []string{"Deployment/api"}, ok: true},
+		{annotation: "deployment/api, statefulsets/db", want: []string{"Deployment/api", "StatefulSet/db"}, ok: true},
+		{annotation: "statefulset/both", want: []string{"StatefulSet/both"}, ok: true},
+		{annotation: "both", ok: true, err: "is both a deployment and a statefulset"},
+		{annotation: "missing", ok: true, err: "wasn't found"},
+		{annotation: "daemonset/api", ok: true, err: "unsupported kind"},
+	}
+	for _, tt := range tests {
+		svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "api",
+			Annotations: map[string]string{ScaleTargetAnnotation: tt.annotation},
+		}}
+
+		controllers, ok, err := FindScaleTargets(factory, svc)
+		if ok != tt.ok {
+			t.Errorf("%q: expected ok to be %v", tt.annotation, tt.ok)
+		}
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: expected error containing %q, got %v", tt.annotation, tt.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.annotation, err)
+			continue
+		}
+
+		got := []string{}
+		for _, c := range controllers {
+			switch c := c.(type) {
+			case *appsv1.Deployment:
+				got = append(got, "Deployment/"+c.Name)
+			case *appsv1.StatefulSet:
+				got = append(got, "StatefulSet/"+c.Name)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%q: expected %v, got %v", tt.annotation, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// CredentialCheckInterval is how often the kubeconfig, and the files it
+// references, are checked for changes
+var CredentialCheckInterval = 10 * time.Second
+
+var (
+	credentialsMu sync.Mutex
+	reloadable    = make(map[*rest.Config]*credentials)
+)
+
+// credentials is a rest config that's reloaded when the kubeconfig, or the
+// client certificates it references, change on disk. Clusters with short
+// lived client certificates rotate them by rewriting these files, which
+// would otherwise leave us failing with TLS errors until restarted.
+//
+// Files are only checked when a request is made, at most once every
+// CredentialCheckInterval. Connections that are already established, e.g.
+// running port-forwards, keep using the credentials they were made with.
+type credentials struct {
+	log logrus.FieldLogger
+
+	// load loads the config from the kubeconfig files, and loadCurrent
+	// their current-context
+	load        func() (*rest.Config, error)
+	loadCurrent func() (string, error)
+
+	// kubeconfigs are the kubeconfig files the config is loaded from
+	kubeconfigs []string
+
+	// context is the kubeconfig context the config is loaded from. When
+	// one wasn't chosen, it's the current-context when we started, and
+	// followsCurrent is set.
+	context        string
+	followsCurrent bool
+
+	mu sync.Mutex
+
+	// current is the current-context of the kubeconfig when it was last
+	// loaded
+	current string
+
+	rc      *rest.Config
+	rt      http.RoundTripper
+	sum     string
+	checked time.Time
+}
+
+// newCredentials loads a rest config from kubeconfig files, using lr, and
+// the given overrides
+func newCredentials(log logrus.FieldLogger, lr *clientcmd.ClientConfigLoadingRules,
+	overrides *clientcmd.ConfigOverrides) (*credentials, error) {
+	// pin the context, so that changing the current-context is noticed
+	// rather than silently switching clusters on the next reload
+	pinned := *overrides
+	if pinned.CurrentContext == "" {
+		current, err := currentContext(lr)
+		if err != nil {
+			return nil, err
+		}
+		pinned.CurrentContext = current
+	}
+
+	c := &credentials{
+		log: log,
+		// the deferred loading config caches what it loaded, so a new one
+		// is needed every time
+		load: func() (*rest.Config, error) {
+			rc, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(lr, &pinned).ClientConfig()
+			if err != nil {
+				return nil, err
+			}
+			return rc, applyProxy(log, rc)
+		},
+		loadCurrent:    func() (string, error) { return currentContext(lr) },
+		kubeconfigs:    lr.GetLoadingPrecedence(),
+		context:        pinned.CurrentContext,
+		followsCurrent: overrides.CurrentContext == "",
+		current:        pinned.CurrentContext,
+		checked:        time.Now(),
+	}
+
+	rc, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	rt, err := rest.TransportFor(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	c.rc, c.rt, c.sum = rc, rt, c.fingerprint(rc)
+	return c, nil
+}
+
+// currentContext returns the current-context of the kubeconfig files lr
+// loads
+func currentContext(lr *clientcmd.ClientConfigLoadingRules) (string, error) {
+	raw, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(lr, &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return "", err
+	}
+	return raw.CurrentContext, nil
+}
+
+// files returns the files the credentials of rc are loaded from
+func (c *credentials) files(rc *rest.Config) []string {
+	files := append([]string{}, c.kubeconfigs...)
+	for _, f := range []string{rc.CAFile, rc.CertFile, rc.KeyFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// fingerprint returns a hash of the contents of the files the credentials
+// of rc are loaded from
+func (c *credentials) fingerprint(rc *rest.Config) string {
+	h := sha256.New()
+	for _, f := range c.files(rc) {
+		h.Write([]byte(f)) //nolint:errcheck // Why: hash writes never fail
+
+		b, err := ioutil.ReadFile(f)
+		if os.IsNotExist(err) {
+			b = []byte("missing")
+		}
+		h.Write(b) //nolint:errcheck // Why: hash writes never fail
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// reload loads the config again if any of the files it came from changed,
+// c.mu must be held
+func (c *credentials) reload() error {
+	sum := c.fingerprint(c.rc)
+	if sum == c.sum {
+		return nil
+	}
+	// don't retry until they change again if they can't be loaded
+	c.sum = sum
+
+	rc, err := c.load()
+	if err != nil {
+		return errors.Wrap(err, "failed to load kubeconfig")
+	}
+
+	rt, err := rest.TransportFor(rc)
+	if err != nil {
+		return errors.Wrap(err, "failed to create transport")
+	}
+
+	if rc.Host != c.rc.Host {
+		c.log.Warnf("kubeconfig now points to %s, restart to switch from %s", rc.Host, c.rc.Host)
+	}
+
+	c.rc, c.rt, c.sum = rc, rt, c.fingerprint(rc)
+	c.log.Info("kubeconfig or client certificate changed, reloaded kubernetes credentials")
+
+	if c.followsCurrent {
+		current, err := c.loadCurrent()
+		if err != nil {
+			return errors.Wrap(err, "failed to load kubeconfig current-context")
+		}
+		if current != c.current && current != c.context {
+			c.log.Warnf("kubeconfig current-context changed to %s, still using %s", current, c.context)
+		}
+		c.current = current
+	}
+
+	return nil
+}
+
+// config returns the current rest config, reloading it if it's time to
+// check for changes
+func (c *credentials) config() (*rest.Config, http.RoundTripper) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checked) >= CredentialCheckInterval {
+		c.checked = time.Now()
+		if err := c.reload(); err != nil {
+			c.log.WithError(err).Warn("failed to reload kubernetes credentials, using the previous ones")
+		}
+	}
+
+	return c.rc, c.rt
+}
+
+// RoundTrip implements http.RoundTripper using the current credentials
+func (c *credentials) RoundTrip(req *http.Request) (*http.Response, error) {
+	_, rt := c.config()
+	return rt.RoundTrip(req)
+}
+
+// clientConfig returns a rest config for creating clients that use the
+// current credentials. TLS and authentication are done by the transport,
+// so they're removed from it.
+func (c *credentials) clientConfig() *rest.Config {
+	rc := rest.AnonymousClientConfig(c.rc)
+	rc.TLSClientConfig = rest.TLSClientConfig{}
+	rc.Transport = c
+	return rc
+}
+
+// credentialsFor returns the reloadable credentials of a rest config
+// returned by GetKubeClient, if it has any
+func credentialsFor(rc *rest.Config) *credentials {
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+
+	return reloadable[rc]
+}
+
+// Context returns the kubeconfig context a rest config returned by
+// GetKubeClient was loaded from, and the current-context of the kubeconfig
+// now. They only differ if no context was chosen, and the current-context
+// was changed after we started, e.g. with kubectl config use-context. ok is
+// false if the config wasn't loaded from a kubeconfig.
+func Context(rc *rest.Config) (using, current string, ok bool) {
+	c := credentialsFor(rc)
+	if c == nil {
+		return "", "", false
+	}
+
+	// check for changes if it's time to
+	c.config()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.context, c.current, true
+}
+
+// CheckContext returns an error if a kubeconfig context, or the
+// current-context if name is empty, doesn't exist or references a cluster
+// or user that doesn't. Nothing is sent to the API server.
+func CheckContext(name string) error {
+	lr := loadingRules()
+	raw, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(lr, &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to load kubeconfig")
+	}
+
+	if name == "" {
+		if raw.CurrentContext == "" {
+			return fmt.Errorf("kubeconfig has no current-context, pass --context")
+		}
+		name = raw.CurrentContext
+	}
+
+	kctx, ok := raw.Contexts[name]
+	if !ok {
+		names := make([]string, 0, len(raw.Contexts))
+		for n := range raw.Contexts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("context '%s' doesn't exist, expected one of: %s", name, strings.Join(names, ", "))
+	}
+	if _, ok := raw.Clusters[kctx.Cluster]; !ok {
+		return fmt.Errorf("cluster '%s' of context '%s' doesn't exist", kctx.Cluster, name)
+	}
+	if _, ok := raw.AuthInfos[kctx.AuthInfo]; !ok && kctx.AuthInfo != "" {
+		return fmt.Errorf("user '%s' of context '%s' doesn't exist", kctx.AuthInfo, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// writeKubeconfig writes a kubeconfig with a context, using token, for
+// each of the given servers. The contexts are named after their index.
+func writeKubeconfig(t *testing.T, path, current, token string, servers ...string) {
+	t.Helper()
+
+	conf := "apiVersion: v1\nkind: Config\ncurrent-context: \"" + current + "\"\nclusters:\n"
+	for i, server := range servers {
+		conf += fmt.Sprintf("- name: \"%d\"\n  cluster:\n    server: %s\n    insecure-skip-tls-verify: true\n", i, server)
+	}
+	conf += "users:\n- name: test\n  user:\n    token: " + token + "\ncontexts:\n"
+	for i := range servers {
+		conf += fmt.Sprintf("- name: \"%d\"\n  context:\n    cluster: \"%d\"\n    user: test\n", i, i)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(conf), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestCredentials returns credentials loaded from path, that are
+// checked for changes on every request
+func newTestCredentials(t *testing.T, path string) *credentials {
+	t.Helper()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	c, err := newCredentials(log, &clientcmd.ClientConfigLoadingRules{ExplicitPath: path}, &clientcmd.ConfigOverrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interval := CredentialCheckInterval
+	CredentialCheckInterval = 0
+	t.Cleanup(func() { CredentialCheckInterval = interval })
+
+	return c
+}
+
+func TestCredentialsReload(t *testing.T) {
+	tokens := make(chan string, 2)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens <- r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	writeKubeconfig(t, path, "0", "first", srv.URL)
+	c := newTestCredentials(t, path)
+
+	client := &http.Client{Transport: c}
+	for _, token := range []string{"first", "second"} {
+		writeKubeconfig(t, path, "0", token, srv.URL)
+
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if got := <-tokens; got != "Bearer "+token {
+			t.Fatalf("expected the request to use token %q, got %q", token, got)
+		}
+	}
+}
+
+func TestCredentialsContextChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	writeKubeconfig(t, path, "0", "token", "https://127.0.0.1:1", "https://127.0.0.1:2")
+	c := newTestCredentials(t, path)
+
+	writeKubeconfig(t, path, "1", "token", "https://127.0.0.1:1", "https://127.0.0.1:2")
+	rc, _ := c.config()
+
+	if rc.Host != "https://127.0.0.1:1" {
+		t.Fatalf("expected to keep using the context we started with, got host %s", rc.Host)
+	}
+	if c.context != "0" || c.current != "1" {
+		t.Fatalf("expected context 0 and current-context 1, got %s and %s", c.context, c.current)
+	}
+}
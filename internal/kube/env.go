@@ -0,0 +1,192 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ServiceEnv returns the environment of the first ready endpoint of a
+// service, see PodEnv, and the name of the pod it was read from
+func ServiceEnv(ctx context.Context, k kubernetes.Interface,
+	namespace, name string) (pod string, env map[string]string, secret map[string]bool, err error) {
+	e, err := k.CoreV1().Endpoints(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, nil, errors.Wrap(err, "failed to get endpoints")
+	}
+
+	for _, subset := range e.Subsets {
+		for _, addr := range subset.Addresses {
+			if addr.TargetRef == nil || addr.TargetRef.Kind != "Pod" {
+				continue
+			}
+
+			p, err := k.CoreV1().Pods(namespace).Get(ctx, addr.TargetRef.Name, metav1.GetOptions{})
+			if err != nil {
+				return "", nil, nil, errors.Wrap(err, "failed to get pod")
+			}
+
+			env, secret, err := PodEnv(ctx, k, p)
+			return p.Name, env, secret, err
+		}
+	}
+
+	return "", nil, nil, fmt.Errorf("no endpoints were found for service %s/%s", namespace, name)
+}
+
+// PodEnv returns the environment of the first container of a pod, and the
+// variables in it whose values came from secrets. Values from config maps,
+// secrets, and fields of the pod are resolved, resource fields are skipped
+// since they're only known inside of the container.
+func PodEnv(ctx context.Context, k kubernetes.Interface, //nolint:funlen,gocyclo
+	pod *corev1.Pod) (env map[string]string, secret map[string]bool, err error) {
+	env = make(map[string]string)
+	secret = make(map[string]bool)
+	if len(pod.Spec.Containers) == 0 {
+		return env, secret, nil
+	}
+	cont := &pod.Spec.Containers[0]
+
+	// cache the objects referenced, they're usually referenced more than once
+	configMaps := make(map[string]*corev1.ConfigMap)
+	secrets := make(map[string]*corev1.Secret)
+	getConfigMap := func(name string, optional *bool) (*corev1.ConfigMap, error) {
+		if cm, ok := configMaps[name]; ok {
+			return cm, nil
+		}
+		cm, err := k.CoreV1().ConfigMaps(pod.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if optional != nil && *optional {
+				cm = &corev1.ConfigMap{}
+			} else {
+				return nil, errors.Wrapf(err, "failed to get config map '%s'", name)
+			}
+		}
+		configMaps[name] = cm
+		return cm, nil
+	}
+	getSecret := func(name string, optional *bool) (*corev1.Secret, error) {
+		if s, ok := secrets[name]; ok {
+			return s, nil
+		}
+		s, err := k.CoreV1().Secrets(pod.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if optional != nil && *optional {
+				s = &corev1.Secret{}
+			} else {
+				return nil, errors.Wrapf(err, "failed to get secret '%s'", name)
+			}
+		}
+		secrets[name] = s
+		return s, nil
+	}
+
+	// envFrom is applied first, env overrides it, like the kubelet does
+	for _, from := range cont.EnvFrom {
+		switch {
+		case from.ConfigMapRef != nil:
+			cm, err := getConfigMap(from.ConfigMapRef.Name, from.ConfigMapRef.Optional)
+			if err != nil {
+				return nil, nil, err
+			}
+			for k, v := range cm.Data {
+				env[from.Prefix+k] = v
+				delete(secret, from.Prefix+k)
+			}
+		case from.SecretRef != nil:
+			s, err := getSecret(from.SecretRef.Name, from.SecretRef.Optional)
+			if err != nil {
+				return nil, nil, err
+			}
+			for k, v := range s.Data {
+				env[from.Prefix+k] = string(v)
+				secret[from.Prefix+k] = true
+			}
+		}
+	}
+
+	for _, e := range cont.Env {
+		delete(secret, e.Name)
+		if e.ValueFrom == nil {
+			env[e.Name] = e.Value
+			continue
+		}
+
+		switch from := e.ValueFrom; {
+		case from.ConfigMapKeyRef != nil:
+			cm, err := getConfigMap(from.ConfigMapKeyRef.Name, from.ConfigMapKeyRef.Optional)
+			if err != nil {
+				return nil, nil, err
+			}
+			if v, ok := cm.Data[from.ConfigMapKeyRef.Key]; ok {
+				env[e.Name] = v
+			}
+		case from.SecretKeyRef != nil:
+			s, err := getSecret(from.SecretKeyRef.Name, from.SecretKeyRef.Optional)
+			if err != nil {
+				return nil, nil, err
+			}
+			if v, ok := s.Data[from.SecretKeyRef.Key]; ok {
+				env[e.Name] = string(v)
+				secret[e.Name] = true
+			}
+		case from.FieldRef != nil:
+			if v, ok := podField(pod, from.FieldRef.FieldPath); ok {
+				env[e.Name] = v
+			}
+		}
+	}
+
+	return env, secret, nil
+}
+
+// podField returns the value of a field of a pod that can be referenced
+// by the downward API
+func podField(pod *corev1.Pod, path string) (string, bool) {
+	switch path {
+	case "metadata.name":
+		return pod.Name, true
+	case "metadata.namespace":
+		return pod.Namespace, true
+	case "metadata.uid":
+		return string(pod.UID), true
+	case "spec.nodeName":
+		return pod.Spec.NodeName, true
+	case "spec.serviceAccountName":
+		return pod.Spec.ServiceAccountName, true
+	case "status.hostIP":
+		return pod.Status.HostIP, true
+	case "status.podIP":
+		return pod.Status.PodIP, true
+	}
+
+	for prefix, m := range map[string]map[string]string{
+		"metadata.labels['":      pod.Labels,
+		"metadata.annotations['": pod.Annotations,
+	} {
+		if strings.HasPrefix(path, prefix) && strings.HasSuffix(path, "']") {
+			v, ok := m[strings.TrimSuffix(strings.TrimPrefix(path, prefix), "']")]
+			return v, ok
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestServiceEnv(t *testing.T) {
+	optional := true
+	k := fake.NewSimpleClientset(
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api"},
+			Subsets: []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{
+				IP:        "10.0.0.1",
+				TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "api-1"},
+			}}}},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "config"},
+			Data:       map[string]string{"LOG_LEVEL": "debug", "REGION": "us"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "creds"},
+			Data:       map[string][]byte{"password": []byte("hunter2")},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api-1", Labels: map[string]string{"app": "api"}},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "api",
+				EnvFrom: []corev1.EnvFromSource{
+					{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "config"}}},
+					{
+						Prefix:    "MISSING_",
+						SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "missing"}, Optional: &optional},
+					},
+				},
+				Env: []corev1.EnvVar{
+					{Name: "REGION", Value: "eu"},
+					{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "creds"}, Key: "password",
+					}}},
+					{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}}},
+					{Name: "APP", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels['app']"}}},
+				},
+			}}},
+		},
+	)
+
+	pod, env, secret, err := ServiceEnv(context.Background(), k, "default", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod != "api-1" {
+		t.Fatalf("expected env to be read from api-1, got %q", pod)
+	}
+
+	want := map[string]string{
+		"LOG_LEVEL": "debug",
+		"REGION":    "eu",
+		"PASSWORD":  "hunter2",
+		"POD_NAME":  "api-1",
+		"APP":       "api",
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Fatalf("expected %v, got %v", want, env)
+	}
+	if want := map[string]bool{"PASSWORD": true}; !reflect.DeepEqual(secret, want) {
+		t.Fatalf("expected secret variables %v, got %v", want, secret)
+	}
+}
+
+func TestServiceEnvNoEndpoints(t *testing.T) {
+	k := fake.NewSimpleClientset(&corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api"}})
+	if _, _, _, err := ServiceEnv(context.Background(), k, "default", "api"); err == nil {
+		t.Fatal("expected an error for a service without endpoints")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ForwardServicePort creates a one-off port-forward to the first ready endpoint
+// of a service, on a random port on 127.0.0.1. This is used by commands that need
+// to reach a service that localizer isn't forwarding (yet). The returned function
+// closes the port-forward.
+func ForwardServicePort(ctx context.Context, k kubernetes.Interface, rc *rest.Config,
+	namespace, name string, port int) (int, func(), error) {
+	svc, err := k.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "failed to get service")
+	}
+
+	var sp *corev1.ServicePort
+	for i := range svc.Spec.Ports {
+		if int(svc.Spec.Ports[i].Port) == port {
+			sp = &svc.Spec.Ports[i]
+		}
+	}
+	if sp == nil {
+		return 0, nil, fmt.Errorf("service %s/%s has no port %d", namespace, name, port)
+	}
+
+	e, err := k.CoreV1().Endpoints(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "failed to get endpoints")
+	}
+
+	var pod *corev1.Pod
+	targetPort := sp.TargetPort.IntValue()
+loop:
+	for _, subset := range e.Subsets {
+		for _, addr := range subset.Addresses {
+			if addr.TargetRef == nil || addr.TargetRef.Kind != "Pod" {
+				continue
+			}
+
+			// named ports are resolved using the endpoint's ports
+			if sp.TargetPort.Type == intstr.String {
+				for _, ep := range subset.Ports {
+					if ep.Name == sp.Name {
+						targetPort = int(ep.Port)
+					}
+				}
+			}
+
+			pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: addr.TargetRef.Namespace, Name: addr.TargetRef.Name}}
+			break loop
+		}
+	}
+	if pod == nil {
+		return 0, nil, fmt.Errorf("no endpoints were found for service %s/%s", namespace, name)
+	}
+
+	fw, err := CreatePortForward(ctx, k.CoreV1().RESTClient(), rc, pod, "127.0.0.1", []string{fmt.Sprintf("0:%d", targetPort)})
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "failed to create port-forward")
+	}
+
+	fw.Ready = make(chan struct{})
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- fw.ForwardPorts()
+	}()
+
+	select {
+	case <-fw.Ready:
+	case err := <-errChan:
+		return 0, nil, errors.Wrap(err, "port-forward failed")
+	case <-time.After(30 * time.Second):
+		fw.Close()
+		return 0, nil, fmt.Errorf("timed out waiting for port-forward to become ready")
+	}
+
+	ports, err := fw.GetPorts()
+	if err != nil || len(ports) == 0 {
+		fw.Close()
+		return 0, nil, errors.Wrap(err, "failed to get local port")
+	}
+
+	return int(ports[0].Local), fw.Close, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/getoutreach/localizer/pkg/localizer"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// loadingRules returns the rules kubeconfigs are loaded with: the files in
+// KUBECONFIG, merged in order, or ~/.kube/config if it isn't set. Contexts
+// can come from any of the files, so kubeconfigs split into a file per
+// cluster don't need to be merged first.
+//
+// ~ in KUBECONFIG is expanded, since shells only do so at the start of it
+// or right after a separator, and not at all when it's set elsewhere, e.g.
+// in an env file. Through sudo, ~ is the home of the user that invoked it,
+// like for the rest of our state.
+func loadingRules() *clientcmd.ClientConfigLoadingRules {
+	lr := clientcmd.NewDefaultClientConfigLoadingRules()
+
+	home, err := localizer.HomeDir()
+	if err != nil {
+		return lr
+	}
+
+	precedence := make([]string, 0, len(lr.Precedence))
+	seen := make(map[string]bool)
+	for _, path := range lr.Precedence {
+		switch {
+		case path == clientcmd.RecommendedHomeFile:
+			path = filepath.Join(home, clientcmd.RecommendedHomeDir, clientcmd.RecommendedFileName)
+		case path == "~":
+			path = home
+		case strings.HasPrefix(path, "~/"):
+			path = filepath.Join(home, path[2:])
+		}
+
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		precedence = append(precedence, path)
+	}
+	lr.Precedence = precedence
+
+	return lr
+}
+
+// Kubeconfigs returns the kubeconfig files that are loaded, in the order
+// they're merged
+func Kubeconfigs() []string {
+	return loadingRules().GetLoadingPrecedence()
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// setenv sets an environment variable until the test finishes
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+
+	prev, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, prev) //nolint:errcheck // Why: Best effort
+		} else {
+			os.Unsetenv(key) //nolint:errcheck // Why: Best effort
+		}
+	})
+}
+
+func TestKubeconfigs(t *testing.T) {
+	home := t.TempDir()
+	setenv(t, "HOME", home)
+	setenv(t, "SUDO_USER", "")
+
+	setenv(t, "KUBECONFIG", "")
+	if got, want := Kubeconfigs(), []string{filepath.Join(home, ".kube", "config")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v without KUBECONFIG, got %v", want, got)
+	}
+
+	setenv(t, "KUBECONFIG", "~/.kube/a"+string(os.PathListSeparator)+"/etc/b"+string(os.PathListSeparator)+"~/.kube/a")
+	if got, want := Kubeconfigs(), []string{filepath.Join(home, ".kube", "a"), "/etc/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestCheckContextMerged(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"staging", "production"} {
+		conf := "apiVersion: v1\nkind: Config\nclusters:\n- name: " + name + "\n  cluster:\n    server: https://" + name +
+			"\nusers:\n- name: " + name + "\n  user:\n    token: abc\ncontexts:\n- name: " + name +
+			"\n  context:\n    cluster: " + name + "\n    user: " + name + "\n"
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(conf), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setenv(t, "KUBECONFIG", filepath.Join(dir, "staging")+string(os.PathListSeparator)+filepath.Join(dir, "production"))
+
+	for _, name := range []string{"staging", "production"} {
+		if err := CheckContext(name); err != nil {
+			t.Errorf("expected context %s from the merged kubeconfigs to be valid, got %v", name, err)
+		}
+	}
+	if err := CheckContext("missing"); err == nil {
+		t.Error("expected a context that isn't in any kubeconfig to be invalid")
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// APIRequest is a kind of request made to the Kubernetes API
+type APIRequest struct {
+	// Verb is what the request did, e.g. get, list, watch, or create
+	Verb string
+
+	// Resource is what was requested, with its subresource if it has one,
+	// e.g. endpoints or pods/portforward
+	Resource string
+
+	// Code is the status code of the response, 0 if there wasn't one
+	Code int
+}
+
+// APIUsage is how much the Kubernetes API has been used by this process
+type APIUsage struct {
+	// Requests are the number of requests made, by kind
+	Requests map[APIRequest]int64
+
+	// Throttled is the number of requests that were delayed by the client
+	// side rate limit, and ThrottledTime is how long they were delayed
+	// for in total
+	Throttled     int64
+	ThrottledTime time.Duration
+}
+
+var (
+	usageMu sync.Mutex
+	usage   = APIUsage{Requests: make(map[APIRequest]int64)}
+)
+
+// Usage returns how much the Kubernetes API has been used by the clients,
+// and port-forwards, created by this package
+func Usage() APIUsage {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	u := usage
+	u.Requests = make(map[APIRequest]int64, len(usage.Requests))
+	for req, n := range usage.Requests {
+		u.Requests[req] = n
+	}
+	return u
+}
+
+// countingTransport counts the requests made through it
+type countingTransport struct {
+	rt http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.rt.RoundTrip(req)
+
+	r := APIRequest{Verb: verbOf(req), Resource: resourceOf(req.URL.Path)}
+	if resp != nil {
+		r.Code = resp.StatusCode
+	}
+
+	usageMu.Lock()
+	usage.Requests[r]++
+	usageMu.Unlock()
+
+	return resp, err
+}
+
+// countingRateLimiter counts the requests that had to wait for the rate
+// limiter it wraps
+type countingRateLimiter struct {
+	flowcontrol.RateLimiter
+}
+
+// Accept implements flowcontrol.RateLimiter
+func (l countingRateLimiter) Accept() {
+	if l.TryAccept() {
+		return
+	}
+
+	start := time.Now()
+	l.RateLimiter.Accept()
+	recordThrottle(time.Since(start))
+}
+
+// Wait implements flowcontrol.RateLimiter
+func (l countingRateLimiter) Wait(ctx context.Context) error {
+	if l.TryAccept() {
+		return nil
+	}
+
+	start := time.Now()
+	err := l.RateLimiter.Wait(ctx)
+	recordThrottle(time.Since(start))
+	return err
+}
+
+// recordThrottle records that a request was delayed by the rate limiter
+func recordThrottle(d time.Duration) {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	usage.Throttled++
+	usage.ThrottledTime += d
+}
+
+// instrument returns a copy of rc that counts the requests made with it
+// in Usage. The rate limit client-go would use for it is shared between
+// every client made with it, like it is when QPS is set.
+func instrument(rc *rest.Config) *rest.Config {
+	rc = rest.CopyConfig(rc)
+	rc.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &countingTransport{rt}
+	})
+
+	if rc.RateLimiter == nil {
+		qps, burst := rc.QPS, rc.Burst
+		if qps == 0 {
+			qps = rest.DefaultQPS
+		}
+		if burst == 0 {
+			burst = rest.DefaultBurst
+		}
+		rc.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+	}
+	rc.RateLimiter = countingRateLimiter{rc.RateLimiter}
+
+	return rc
+}
+
+// verbOf returns the Kubernetes verb of a request, e.g. list instead of
+// GET for a collection
+func verbOf(req *http.Request) string {
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("watch") == "true" || req.URL.Query().Get("watch") == "1" {
+			return "watch"
+		}
+		if resource, name, _ := splitAPIPath(req.URL.Path); resource != "" && name == "" {
+			return "list"
+		}
+		return "get"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	}
+	return strings.ToLower(req.Method)
+}
+
+// resourceOf returns the resource, and subresource, of the path of a
+// request to the Kubernetes API, e.g. pods/portforward for
+// /api/v1/namespaces/default/pods/mypod/portforward
+func resourceOf(path string) string {
+	resource, _, subresource := splitAPIPath(path)
+	if resource == "" {
+		// e.g. /version, or discovery of /apis
+		return "other"
+	}
+	if subresource != "" {
+		return resource + "/" + subresource
+	}
+	return resource
+}
+
+// splitAPIPath splits the path of a request to the Kubernetes API into
+// the resource, name, and subresource it's for
+func splitAPIPath(path string) (resource, name, subresource string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	// strip /api/v1, or /apis/group/version
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		parts = parts[3:]
+	default:
+		return "", "", ""
+	}
+
+	// namespaced resources are under /namespaces/name/, but namespaces
+	// themselves aren't
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		parts = parts[2:]
+	}
+
+	switch len(parts) {
+	case 0:
+		return "", "", ""
+	case 1:
+		return parts[0], "", ""
+	case 2:
+		return parts[0], parts[1], ""
+	}
+	return parts[0], parts[1], parts[2]
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestVerbAndResourceOf(t *testing.T) {
+	tests := []struct {
+		method   string
+		url      string
+		verb     string
+		resource string
+	}{
+		{http.MethodGet, "/api/v1/namespaces/default/endpoints/api", "get", "endpoints"},
+		{http.MethodGet, "/api/v1/namespaces/default/endpoints", "list", "endpoints"},
+		{http.MethodGet, "/api/v1/services?watch=true&resourceVersion=1", "watch", "services"},
+		{http.MethodGet, "/api/v1/namespaces", "list", "namespaces"},
+		{http.MethodGet, "/api/v1/namespaces/default", "get", "namespaces"},
+		{http.MethodPost, "/api/v1/namespaces/default/pods/api-0/portforward", "create", "pods/portforward"},
+		{http.MethodPatch, "/apis/apps/v1/namespaces/default/deployments/api/scale", "patch", "deployments/scale"},
+		{http.MethodGet, "/apis/discovery.k8s.io/v1beta1/namespaces/default/endpointslices", "list", "endpointslices"},
+		{http.MethodGet, "/version", "get", "other"},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := &http.Request{Method: tt.method, URL: u}
+
+		if verb := verbOf(req); verb != tt.verb {
+			t.Errorf("%s %s: expected verb %s, got %s", tt.method, tt.url, tt.verb, verb)
+		}
+		if resource := resourceOf(u.Path); resource != tt.resource {
+			t.Errorf("%s %s: expected resource %s, got %s", tt.method, tt.url, tt.resource, resource)
+		}
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	// one request per second, so the second is throttled
+	k, err := kubernetes.NewForConfig(instrument(&rest.Config{Host: srv.URL, QPS: 1, Burst: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notFound := APIRequest{Verb: "get", Resource: "endpoints", Code: http.StatusNotFound}
+	before := Usage()
+	for i := 0; i < 2; i++ {
+		//nolint:errcheck // Why: The server always 404s
+		k.CoreV1().Endpoints("default").Get(context.Background(), "api", metav1.GetOptions{})
+	}
+	after := Usage()
+
+	if n := after.Requests[notFound] - before.Requests[notFound]; n != 2 {
+		t.Fatalf("expected 2 requests to be counted, got %d", n)
+	}
+	if n := after.Throttled - before.Throttled; n != 1 {
+		t.Fatalf("expected 1 request to be throttled, got %d", n)
+	}
+	if after.ThrottledTime <= before.ThrottledTime {
+		t.Fatal("expected the time spent throttled to be counted")
+	}
+}
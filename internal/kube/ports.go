@@ -0,0 +1,117 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/informers"
+)
+
+// TargetPortCandidates returns the container ports that the targetPort of
+// a port of a service may refer to. A numeric targetPort is the only one,
+// while a named one is looked up in the service's endpoints, then in the
+// containers of its controllers. If no container declares the name, every
+// port they declare is returned, so it's up to the caller to pick one
+// unless there's only one.
+func TargetPortCandidates(log logrus.FieldLogger, factory informers.SharedInformerFactory, s *corev1.Service,
+	sp *corev1.ServicePort) []int32 {
+	if sp.TargetPort.Type == intstr.Int {
+		if sp.TargetPort.IntVal == 0 {
+			// an unset targetPort is the same as the port
+			return []int32{sp.Port}
+		}
+		return []int32{sp.TargetPort.IntVal}
+	}
+
+	obj, exists, err := factory.Core().V1().Endpoints().Informer().GetStore().GetByKey(s.Namespace + "/" + s.Name)
+	if e, ok := obj.(*corev1.Endpoints); ok && exists && err == nil {
+		found := make(map[int32]bool)
+		for i := range e.Subsets {
+			for _, p := range e.Subsets[i].Ports {
+				if p.Name == sp.Name {
+					found[p.Port] = true
+				}
+			}
+		}
+		if len(found) > 0 {
+			return sortedPorts(found)
+		}
+	}
+
+	// a service without a selector would match every controller
+	if len(s.Spec.Selector) == 0 {
+		return nil
+	}
+
+	controllers, err := FindControllersForService(log, factory, s)
+	if err != nil {
+		log.WithError(err).Warn("failed to find controllers of service")
+		return nil
+	}
+
+	named := make(map[int32]bool)
+	declared := make(map[int32]bool)
+	for _, c := range controllers {
+		for _, p := range containerPorts(c) {
+			if p.Protocol != "" && sp.Protocol != "" && p.Protocol != sp.Protocol {
+				continue
+			}
+
+			declared[p.ContainerPort] = true
+			if p.Name == sp.TargetPort.StrVal {
+				named[p.ContainerPort] = true
+			}
+		}
+	}
+	if len(named) > 0 {
+		return sortedPorts(named)
+	}
+
+	return sortedPorts(declared)
+}
+
+// containerPorts returns the ports declared by the containers in the pod
+// template of a controller
+func containerPorts(controller interface{}) []corev1.ContainerPort {
+	var spec *corev1.PodSpec
+	switch c := controller.(type) {
+	case *appsv1.Deployment:
+		spec = &c.Spec.Template.Spec
+	case *appsv1.StatefulSet:
+		spec = &c.Spec.Template.Spec
+	default:
+		return nil
+	}
+
+	ports := []corev1.ContainerPort{}
+	for i := range spec.Containers {
+		ports = append(ports, spec.Containers[i].Ports...)
+	}
+	return ports
+}
+
+// sortedPorts returns the ports in a set, in order
+func sortedPorts(set map[int32]bool) []int32 {
+	ports := make([]int32, 0, len(set))
+	for p := range set {
+		ports = append(ports, p)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	return ports
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTargetPortCandidates(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	deployment := func(name string, ports ...corev1.ContainerPort) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Ports: ports}}},
+			}},
+		}
+	}
+	for _, d := range []*appsv1.Deployment{
+		deployment("api", corev1.ContainerPort{Name: "http", ContainerPort: 8080}, corev1.ContainerPort{Name: "grpc", ContainerPort: 5000}),
+		deployment("single", corev1.ContainerPort{ContainerPort: 3000}),
+	} {
+		//nolint:errcheck // Why: Adding to a store can't fail
+		factory.Apps().V1().Deployments().Informer().GetStore().Add(d)
+	}
+	//nolint:errcheck // Why: Adding to a store can't fail
+	factory.Core().V1().Endpoints().Informer().GetStore().Add(&corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "running"},
+		Subsets: []corev1.EndpointSubset{{
+			Ports: []corev1.EndpointPort{{Name: "web", Port: 9090}},
+		}},
+	})
+
+	tests := []struct {
+		name       string
+		service    string
+		selector   string
+		targetPort intstr.IntOrString
+		want       []int32
+	}{
+		{name: "numeric", service: "api", selector: "api", targetPort: intstr.FromInt(8443), want: []int32{8443}},
+		{name: "unset", service: "api", selector: "api", want: []int32{80}},
+		{name: "named", service: "api", selector: "api", targetPort: intstr.FromString("http"), want: []int32{8080}},
+		{name: "undeclared", service: "api", selector: "api", targetPort: intstr.FromString("metrics"), want: []int32{5000, 8080}},
+		{name: "only port", service: "single", selector: "single", targetPort: intstr.FromString("http"), want: []int32{3000}},
+		{name: "endpoints", service: "running", selector: "none", targetPort: intstr.FromString("http"), want: []int32{9090}},
+		{name: "no selector", service: "api", targetPort: intstr.FromString("http"), want: nil},
+	}
+	for _, tt := range tests {
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: tt.service},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "web", Port: 80, TargetPort: tt.targetPort}}},
+		}
+		if tt.selector != "" {
+			svc.Spec.Selector = map[string]string{"app": tt.selector}
+		}
+
+		got := TargetPortCandidates(log, factory, svc, &svc.Spec.Ports[0])
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/net/proxy"
+	"k8s.io/client-go/rest"
+)
+
+// ProxyOptions are ways of reaching the API server other than connecting
+// to it directly, or through the kubeconfig's proxy-url
+type ProxyOptions struct {
+	// ProxyURL is an http, https or socks5 proxy to connect through
+	ProxyURL string
+
+	// Bastion is an SSH jump host, as [user@]host[:port], to connect
+	// through
+	Bastion string
+
+	// BastionKey is the private key to log in to the bastion with. The SSH
+	// agent and the default keys in ~/.ssh are used otherwise.
+	BastionKey string
+}
+
+// dialFunc dials an address, like net.Dialer.DialContext
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+var (
+	proxyMu sync.Mutex
+
+	// proxyOverride replaces the proxy of every rest config loaded from a
+	// kubeconfig, when set
+	proxyOverride func(*http.Request) (*url.URL, error)
+
+	// socksTunnels are the tunnels started for SOCKS proxies found in
+	// kubeconfigs, by the proxy's URL
+	socksTunnels = make(map[string]*url.URL)
+)
+
+// UseProxy makes kubernetes clients created after it's called connect to
+// the API server as configured by opts
+func UseProxy(log logrus.FieldLogger, opts ProxyOptions) error {
+	if opts.ProxyURL != "" && opts.Bastion != "" {
+		return fmt.Errorf("a proxy and a bastion can't be used together")
+	}
+
+	var dial dialFunc
+	switch {
+	case opts.Bastion != "":
+		b, err := newBastion(opts.Bastion, opts.BastionKey)
+		if err != nil {
+			return err
+		}
+		dial = b.DialContext
+	case opts.ProxyURL != "":
+		u, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse proxy url")
+		}
+
+		switch u.Scheme {
+		case "http", "https":
+			proxyMu.Lock()
+			proxyOverride = http.ProxyURL(u)
+			proxyMu.Unlock()
+			return nil
+		case "socks5", "socks5h":
+			if dial, err = socksDialer(u); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported proxy scheme %q, must be http, https, socks5 or socks5h", u.Scheme)
+		}
+	default:
+		return nil
+	}
+
+	tunnel, err := startTunnel(log, dial)
+	if err != nil {
+		return err
+	}
+
+	proxyMu.Lock()
+	proxyOverride = http.ProxyURL(tunnel)
+	proxyMu.Unlock()
+	return nil
+}
+
+// applyProxy sets the proxy of a rest config loaded from a kubeconfig.
+// Port-forwards can only be made through HTTP proxies, so a SOCKS proxy
+// from the kubeconfig's proxy-url is used through a local tunnel instead.
+func applyProxy(log logrus.FieldLogger, rc *rest.Config) error {
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+
+	if proxyOverride != nil {
+		rc.Proxy = proxyOverride
+		return nil
+	}
+	if rc.Proxy == nil {
+		return nil
+	}
+
+	host := rc.Host
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse API server address")
+	}
+
+	u, err := rc.Proxy(&http.Request{URL: hostURL})
+	if err != nil || u == nil || (u.Scheme != "socks5" && u.Scheme != "socks5h") {
+		return err
+	}
+
+	tunnel, ok := socksTunnels[u.String()]
+	if !ok {
+		dial, err := socksDialer(u)
+		if err != nil {
+			return err
+		}
+		if tunnel, err = startTunnel(log, dial); err != nil {
+			return err
+		}
+		socksTunnels[u.String()] = tunnel
+	}
+
+	rc.Proxy = http.ProxyURL(tunnel)
+	return nil
+}
+
+// socksDialer returns a dialer that connects through a SOCKS proxy
+func socksDialer(u *url.URL) (dialFunc, error) {
+	d, err := proxy.FromURL(u, proxy.Direct)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create SOCKS dialer")
+	}
+
+	if cd, ok := d.(proxy.ContextDialer); ok {
+		return cd.DialContext, nil
+	}
+	return func(_ context.Context, network, addr string) (net.Conn, error) {
+		return d.Dial(network, addr)
+	}, nil
+}
+
+// tunnel is an HTTP CONNECT proxy that makes connections with dial. Only
+// requests with the password it was started with are allowed, so other
+// users of the machine can't use it to get into the network behind it.
+type tunnel struct {
+	log      logrus.FieldLogger
+	dial     dialFunc
+	password string
+}
+
+// startTunnel serves a tunnel on a random port of localhost, until the
+// process exits, and returns the URL to use it as a proxy
+func startTunnel(log logrus.FieldLogger, dial dialFunc) (*url.URL, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "failed to generate tunnel password")
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to listen for tunnel")
+	}
+
+	t := &tunnel{log: log, dial: dial, password: hex.EncodeToString(b)}
+	go http.Serve(l, t) //nolint:errcheck // Why: the listener is never closed
+
+	return &url.URL{Scheme: "http", User: url.UserPassword("localizer", t.password), Host: l.Addr().String()}, nil
+}
+
+// ServeHTTP implements http.Handler
+func (t *tunnel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		http.Error(w, "only CONNECT is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Header.Set("Authorization", r.Header.Get("Proxy-Authorization"))
+	if _, password, ok := r.BasicAuth(); !ok || password != t.password {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="localizer"`)
+		http.Error(w, "invalid credentials", http.StatusProxyAuthRequired)
+		return
+	}
+
+	conn, err := t.dial(r.Context(), "tcp", r.Host)
+	if err != nil {
+		t.log.WithError(err).Warnf("failed to connect to %s", r.Host)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		conn.Close()
+		http.Error(w, "connection can't be hijacked", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hj.Hijack()
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		client.Close()
+		conn.Close()
+		return
+	}
+
+	go func() {
+		defer conn.Close()
+
+		// the client may have sent more than the request already
+		io.Copy(conn, buf) //nolint:errcheck // Why: best effort
+	}()
+	go func() {
+		defer client.Close()
+		io.Copy(client, conn) //nolint:errcheck // Why: best effort
+	}()
+}
+
+// bastion dials addresses through an SSH jump host, sharing one SSH
+// connection that's made again if it breaks
+type bastion struct {
+	addr   string
+	config *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// newBastion returns a bastion for [user@]host[:port], authenticating with
+// keyFile, or the SSH agent and default keys if it's empty. The host key
+// is checked against ~/.ssh/known_hosts.
+func newBastion(spec, keyFile string) (*bastion, error) {
+	username := ""
+	if i := strings.LastIndex(spec, "@"); i != -1 {
+		username, spec = spec[:i], spec[i+1:]
+	}
+	if username == "" {
+		u, err := user.Current()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get current user for bastion")
+		}
+		username = u.Username
+	}
+	if _, _, err := net.SplitHostPort(spec); err != nil {
+		spec = net.JoinHostPort(spec, "22")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find home directory")
+	}
+
+	hostKeys, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read known_hosts, connect to the bastion with ssh once to add it")
+	}
+
+	auth, err := bastionAuth(home, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bastion{
+		addr: spec,
+		config: &ssh.ClientConfig{
+			User:            username,
+			Auth:            auth,
+			HostKeyCallback: hostKeys,
+		},
+	}, nil
+}
+
+// bastionAuth returns the ways of authenticating to a bastion
+func bastionAuth(home, keyFile string) ([]ssh.AuthMethod, error) {
+	auth := make([]ssh.AuthMethod, 0)
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" && keyFile == "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	files := []string{keyFile}
+	if keyFile == "" {
+		files = []string{
+			filepath.Join(home, ".ssh", "id_ed25519"),
+			filepath.Join(home, ".ssh", "id_ecdsa"),
+			filepath.Join(home, ".ssh", "id_rsa"),
+		}
+	}
+
+	signers := make([]ssh.Signer, 0)
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if os.IsNotExist(err) && keyFile == "" {
+			continue
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to read bastion key")
+		}
+
+		signer, err := ssh.ParsePrivateKey(b)
+		if err != nil {
+			// encrypted keys are left to the agent
+			if _, ok := err.(*ssh.PassphraseMissingError); ok && keyFile == "" {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to parse bastion key %s", f)
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) != 0 {
+		auth = append(auth, ssh.PublicKeys(signers...))
+	}
+
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("no SSH agent or keys found to log in to the bastion with")
+	}
+	return auth, nil
+}
+
+// connect returns the SSH connection to the bastion, making it if needed
+func (b *bastion) connect(ctx context.Context) (*ssh.Client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.client != nil {
+		return b.client, nil
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", b.addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to bastion")
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, b.addr, b.config)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "failed to log in to bastion")
+	}
+
+	b.client = ssh.NewClient(c, chans, reqs)
+	return b.client, nil
+}
+
+// DialContext dials addr from the bastion
+func (b *bastion) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := b.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.Dial(network, addr)
+	if _, refused := err.(*ssh.OpenChannelError); err == nil || refused {
+		return conn, err
+	}
+
+	// anything but the bastion refusing the connection means the SSH
+	// connection broke, try again once with a new one
+	b.mu.Lock()
+	if b.client == client {
+		b.client = nil
+	}
+	b.mu.Unlock()
+	client.Close()
+
+	if client, err = b.connect(ctx); err != nil {
+		return nil, err
+	}
+	return client.Dial(network, addr)
+}
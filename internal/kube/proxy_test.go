@@ -0,0 +1,80 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+)
+
+func TestTunnel(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok")) //nolint:errcheck // Why: test
+	}))
+	defer srv.Close()
+
+	tunnel, err := startTunnel(logrus.New(), (&net.Dialer{}).DialContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(proxyURL *url.URL) (*http.Response, error) {
+		rt := srv.Client().Transport.(*http.Transport).Clone()
+		rt.Proxy = http.ProxyURL(proxyURL)
+		return (&http.Client{Transport: rt}).Get(srv.URL)
+	}
+
+	resp, err := get(tunnel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if b, _ := ioutil.ReadAll(resp.Body); string(b) != "ok" {
+		t.Errorf("expected ok through the tunnel, got %q", b)
+	}
+
+	noPassword := *tunnel
+	noPassword.User = nil
+	if resp, err := get(&noPassword); err == nil {
+		resp.Body.Close()
+		t.Error("expected the tunnel to refuse connections without its password")
+	}
+}
+
+func TestApplyProxySOCKS(t *testing.T) {
+	socks, err := url.Parse("socks5://127.0.0.1:1080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := &rest.Config{Host: "https://10.0.0.1:6443", Proxy: http.ProxyURL(socks)}
+
+	if err := applyProxy(logrus.New(), rc); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := rc.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "10.0.0.1:6443"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "http" || u.Hostname() != "127.0.0.1" || u.User == nil {
+		t.Errorf("expected the SOCKS proxy to be replaced by a local tunnel, got %s", u)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	spdystream "k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// SPDYTransport creates dialers for port-forwards that share a single TLS
+// configuration, TLS session cache, and round tripper, instead of building
+// them from the rest config every time. Dialers are also shared between all
+// port-forwards to the same pod.
+type SPDYTransport struct {
+	// creds are the reloadable credentials of the rest config, if it has
+	// any. The TLS configuration is rebuilt when they're reloaded.
+	creds *credentials
+
+	mu        sync.Mutex
+	rc        *rest.Config
+	tlsConfig *tls.Config
+	proxy     func(*http.Request) (*url.URL, error)
+	client    *http.Client
+	dialers   map[string]httpstream.Dialer
+}
+
+var (
+	transportsMu sync.Mutex
+	transports   = make(map[string]*SPDYTransport)
+)
+
+// transportKey returns the key of the transport for a rest config. Configs
+// for the same host, with the same credentials, share a transport.
+func transportKey(rc *rest.Config) string {
+	parts := []string{
+		rc.Host, rc.APIPath, rc.Username, rc.Password, rc.BearerToken, rc.BearerTokenFile,
+		rc.Impersonate.UserName, strings.Join(rc.Impersonate.Groups, ","),
+		rc.CAFile, rc.CertFile, rc.KeyFile, string(rc.CAData), string(rc.CertData), string(rc.KeyData),
+		rc.ServerName, strconv.FormatBool(rc.Insecure),
+	}
+	if rc.AuthProvider != nil {
+		parts = append(parts, fmt.Sprintf("%+v", *rc.AuthProvider))
+	}
+	if rc.ExecProvider != nil {
+		parts = append(parts, fmt.Sprintf("%+v", *rc.ExecProvider))
+	}
+	if rc.Transport != nil {
+		// does its own authentication, e.g. reloadable credentials
+		parts = append(parts, fmt.Sprintf("%p", rc.Transport))
+	}
+
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p)) //nolint:errcheck // Why: hash writes never fail
+		h.Write([]byte{0}) //nolint:errcheck // Why: hash writes never fail
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// TransportFor returns the shared SPDYTransport for a rest config
+func TransportFor(rc *rest.Config) (*SPDYTransport, error) {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	key := transportKey(rc)
+	if t, ok := transports[key]; ok {
+		return t, nil
+	}
+
+	t := &SPDYTransport{
+		creds:   credentialsFor(rc),
+		dialers: make(map[string]httpstream.Dialer),
+	}
+	if err := t.load(rc); err != nil {
+		return nil, err
+	}
+	transports[key] = t
+
+	return t, nil
+}
+
+// load builds the TLS configuration, and round tripper, from a rest config,
+// t.mu must be held if t is in use
+func (t *SPDYTransport) load(rc *rest.Config) error {
+	tlsConfig, err := rest.TLSConfigFor(rc)
+	if err != nil {
+		return err
+	}
+
+	// allow connections to resume TLS sessions, skipping full handshakes
+	if tlsConfig != nil && tlsConfig.ClientSessionCache == nil {
+		tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if rc.Proxy != nil {
+		proxy = rc.Proxy
+	}
+
+	wrapper, err := rest.HTTPWrappersForConfig(rc, upgradingTransport{})
+	if err != nil {
+		return err
+	}
+
+	t.rc, t.tlsConfig, t.proxy = rc, tlsConfig, proxy
+	t.client = &http.Client{Transport: &countingTransport{wrapper}}
+	return nil
+}
+
+// upgraderKey is the request context key of the SPDY round tripper that
+// upgrades a dial's connection
+type upgraderKey struct{}
+
+// upgradingTransport sends requests through the SPDY round tripper in their
+// context. SPDY round trippers hold onto the connection they upgraded, so
+// one is needed for every dial, but what wraps them, e.g. authentication,
+// can be shared.
+type upgradingTransport struct{}
+
+// RoundTrip implements http.RoundTripper
+func (upgradingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt, ok := req.Context().Value(upgraderKey{}).(http.RoundTripper)
+	if !ok {
+		return nil, fmt.Errorf("request to %s has no SPDY round tripper", req.URL)
+	}
+	return rt.RoundTrip(req)
+}
+
+// upgrader returns the shared client, and a SPDY round tripper for a single
+// connection, which must be put in the context of the request made with it
+func (t *SPDYTransport) upgrader() (*http.Client, *spdystream.SpdyRoundTripper, error) {
+	t.mu.Lock()
+	if t.creds != nil {
+		if rc, _ := t.creds.config(); rc != t.rc {
+			if err := t.load(rc); err != nil {
+				t.mu.Unlock()
+				return nil, nil, err
+			}
+		}
+	}
+	client, tlsConfig, proxy := t.client, t.tlsConfig, t.proxy
+	t.mu.Unlock()
+
+	return client, spdystream.NewRoundTripperWithConfig(spdystream.RoundTripperConfig{
+		TLS:                      tlsConfig,
+		FollowRedirects:          true,
+		RequireSameHostRedirects: false,
+		Proxier:                  proxy,
+		PingPeriod:               time.Second * 5,
+	}), nil
+}
+
+// podDialer dials the portforward subresource of a pod
+type podDialer struct {
+	t   *SPDYTransport
+	url *url.URL
+}
+
+// Dial implements httpstream.Dialer
+func (d *podDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	client, upgrader, err := d.t.upgrader()
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx := context.WithValue(context.Background(), upgraderKey{}, upgrader)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return spdy.Negotiate(upgrader, client, req, protocols...)
+}
+
+// DialerFor returns the dialer for port-forwarding to a pod
+func (t *SPDYTransport) DialerFor(r rest.Interface, namespace, name string) httpstream.Dialer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := namespace + "/" + name
+	if d, ok := t.dialers[key]; ok {
+		return d
+	}
+
+	d := &podDialer{t, r.Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(name).
+		SubResource("portforward").URL()}
+	t.dialers[key] = d
+
+	return d
+}
+
+// Forget removes the dialer for a pod, this should be called once it's no
+// longer being port-forwarded to.
+func (t *SPDYTransport) Forget(namespace, name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.dialers, namespace+"/"+name)
+}
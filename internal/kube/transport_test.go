@@ -0,0 +1,50 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package kube
+
+import (
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestTransportForSharing(t *testing.T) {
+	newConfig := func(host, token string) *rest.Config {
+		return &rest.Config{Host: host, BearerToken: token}
+	}
+
+	a, err := TransportFor(newConfig("https://a.example.com", "token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a copy of the same config, e.g. from loading the kubeconfig again
+	same, err := TransportFor(newConfig("https://a.example.com", "token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != same {
+		t.Error("expected configs with the same host and credentials to share a transport")
+	}
+
+	for _, rc := range []*rest.Config{newConfig("https://b.example.com", "token"), newConfig("https://a.example.com", "other")} {
+		other, err := TransportFor(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a == other {
+			t.Errorf("expected %s with a different host or credentials to not share a transport", rc.Host)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package mirroragent implements the agent that runs next to the SSH server
+// in the pod of a mirrored expose. It serves the connections the service
+// sends to the pod from inside of the cluster, by sending them on to the
+// service's other pods, and only sends a copy of what clients send over the
+// tunnel to the local service. Clients never depend on the tunnel, so they
+// aren't affected when the local machine sleeps or goes offline.
+package mirroragent
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/function61/gokit/io/bidipipe"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ControlPort is the port the agent accepts upstream updates on inside of
+// its pod
+const ControlPort = 8676
+
+// copyPortBase is the first port copies of connections are sent to inside
+// of the pod, see CopyPort
+const copyPortBase = 47000
+
+// The control protocol is line based, the daemon sends
+// "UPSTREAMS [ip...]\n" whenever the ready pods of the service change.
+//
+// Copies of connections start with "SOURCE <host:port>\n", the address of
+// the client, followed by everything the client sends.
+const (
+	upstreamsPrefix = "UPSTREAMS"
+	sourcePrefix    = "SOURCE "
+)
+
+// CopyPort returns the port, inside of the pod, that copies of connections
+// to the i-th port of the service are sent to. The SSH tunnel listens on it.
+func CopyPort(i int) int {
+	return copyPortBase + i
+}
+
+// Port is a port of the service that the agent serves
+type Port struct {
+	// Target is the port the service sends connections to, which is the
+	// port they're sent on to upstreams with
+	Target int
+
+	// Copy is the port copies of the connections are sent to
+	Copy int
+}
+
+// ParsePort parses a port in the format of target:copy
+func ParsePort(s string) (Port, error) {
+	spl := strings.Split(s, ":")
+	if len(spl) != 2 {
+		return Port{}, fmt.Errorf("invalid port '%s', expected target:copy", s)
+	}
+
+	target, err := strconv.Atoi(spl[0])
+	if err != nil {
+		return Port{}, fmt.Errorf("invalid target port '%s'", spl[0])
+	}
+	cp, err := strconv.Atoi(spl[1])
+	if err != nil {
+		return Port{}, fmt.Errorf("invalid copy port '%s'", spl[1])
+	}
+
+	return Port{Target: target, Copy: cp}, nil
+}
+
+// String returns the port in the format of target:copy
+func (p Port) String() string {
+	return fmt.Sprintf("%d:%d", p.Target, p.Copy)
+}
+
+// ReadSource reads the address of the client a copy of a connection came
+// from, returning a reader of the rest of the copy
+func ReadSource(conn net.Conn) (string, io.Reader, error) {
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to read source")
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	if !strings.HasPrefix(line, sourcePrefix) {
+		return "", nil, fmt.Errorf("invalid copy header '%s'", line)
+	}
+	return strings.TrimPrefix(line, sourcePrefix), r, nil
+}
+
+// WriteUpstreams sends the IPs of the pods connections should be sent to
+// over a control connection
+func WriteUpstreams(w io.Writer, ips []string) error {
+	_, err := fmt.Fprintf(w, "%s %s\n", upstreamsPrefix, strings.Join(ips, " "))
+	return err
+}
+
+// Agent serves the connections to a mirrored service
+type Agent struct {
+	log logrus.FieldLogger
+
+	// mu protects upstreams, the IPs of the pods connections are sent to.
+	// next is used to spread connections across them.
+	mu        sync.Mutex
+	upstreams []string
+	next      uint32
+}
+
+// New returns an agent that sends connections to upstreams until it's told
+// about others
+func New(log logrus.FieldLogger, upstreams []string) *Agent {
+	return &Agent{log: log, upstreams: upstreams}
+}
+
+// SetUpstreams replaces the IPs of the pods connections are sent to
+func (a *Agent) SetUpstreams(ips []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.upstreams = ips
+}
+
+// upstream returns the IP of the next pod a connection should be sent to,
+// or an empty string if there isn't one
+func (a *Agent) upstream() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.upstreams) == 0 {
+		return ""
+	}
+	a.next++
+	return a.upstreams[a.next%uint32(len(a.upstreams))]
+}
+
+// ServeControl accepts control connections on l, updating the upstreams
+// with what's sent over them, until ctx is canceled
+func (a *Agent) ServeControl(ctx context.Context, l net.Listener) error {
+	return serve(ctx, l, a.handleControl)
+}
+
+// handleControl reads upstream updates from a control connection
+func (a *Agent) handleControl(c net.Conn) {
+	defer c.Close()
+
+	scanner := bufio.NewScanner(c)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != upstreamsPrefix {
+			a.log.Warnf("ignoring unknown control message '%s'", scanner.Text())
+			continue
+		}
+
+		a.SetUpstreams(fields[1:])
+		a.log.Infof("sending connections to %d pod(s)", len(fields)-1)
+	}
+}
+
+// Serve accepts connections for port on l, until ctx is canceled
+func (a *Agent) Serve(ctx context.Context, l net.Listener, port Port) error {
+	return serve(ctx, l, func(c net.Conn) { a.handle(c, port) })
+}
+
+// handle sends a connection to the next upstream, and a copy of what the
+// client sends to the copy port. Only the upstream's responses are sent
+// back, and the copy is dropped if it can't keep up.
+func (a *Agent) handle(client net.Conn, port Port) {
+	defer client.Close()
+
+	ip := a.upstream()
+	if ip == "" {
+		a.log.Warn("no pods to send connection to")
+		return
+	}
+
+	upstreamAddr := net.JoinHostPort(ip, strconv.Itoa(port.Target))
+	upstream, err := net.DialTimeout("tcp", upstreamAddr, 10*time.Second)
+	if err != nil {
+		a.log.WithError(err).Errorf("failed to dial upstream %s", upstreamAddr)
+		return
+	}
+	defer upstream.Close()
+
+	// the tunnel only listens on the copy port while it's up, so this
+	// fails right away when it isn't
+	var mirror io.Writer = ioutil.Discard
+	if local, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port.Copy), time.Second); err != nil {
+		a.log.WithError(err).Debug("tunnel isn't up, not mirroring connection")
+	} else {
+		w := newMirrorWriter(local)
+		defer w.Finish()
+		w.Write([]byte(sourcePrefix + client.RemoteAddr().String() + "\n")) //nolint:errcheck // Why: mirrorWriter never fails
+		mirror = w
+	}
+
+	tee := &teeConn{Conn: client, w: mirror}
+	if err := bidipipe.Pipe(bidipipe.WithName("client", tee), bidipipe.WithName("upstream", upstream)); err != nil {
+		a.log.WithError(err).Warn("failed to send data to upstream")
+	}
+}
+
+// serve accepts connections on l and handles each of them in a goroutine,
+// until ctx is canceled
+func serve(ctx context.Context, l net.Listener, handle func(net.Conn)) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "failed to accept connection")
+		}
+
+		go handle(c)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package mirroragent
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// listenPort listens on a free port of 127.0.0.1, returning the listener
+// and its port
+func listenPort(t *testing.T) (net.Listener, int) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	return l, l.Addr().(*net.TCPAddr).Port
+}
+
+func TestAgentMirrorsToCopyPort(t *testing.T) {
+	upstream, target := listenPort(t)
+	copies, copyPort := listenPort(t)
+	l, _ := listenPort(t)
+
+	// the upstream responds to what it's sent
+	go func() {
+		c, err := upstream.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		b := make([]byte, len("hello"))
+		if _, err := io.ReadFull(c, b); err != nil {
+			return
+		}
+		c.Write(append([]byte("upstream: "), b...)) //nolint:errcheck // Why: Test
+	}()
+
+	type copied struct {
+		source string
+		data   []byte
+	}
+	received := make(chan copied, 1)
+	go func() {
+		c, err := copies.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		// responses to the copy are never sent to the client
+		c.Write([]byte("local")) //nolint:errcheck // Why: Test
+
+		source, r, err := ReadSource(c)
+		if err != nil {
+			close(received)
+			return
+		}
+		b, _ := ioutil.ReadAll(r) //nolint:errcheck // Why: Compared below
+		received <- copied{source, b}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	a := New(log, []string{"127.0.0.1"})
+	go a.Serve(ctx, l, Port{Target: target, Copy: copyPort}) //nolint:errcheck // Why: Stopped by the test finishing
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := c.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	c.(*net.TCPConn).CloseWrite() //nolint:errcheck // Why: Test
+
+	resp, err := ioutil.ReadAll(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp) != "upstream: hello" {
+		t.Fatalf("expected the response of the upstream, got %q", resp)
+	}
+
+	got, ok := <-received
+	if !ok {
+		t.Fatal("failed to read copy")
+	}
+	if got.source != c.LocalAddr().String() || !bytes.Equal(got.data, []byte("hello")) {
+		t.Fatalf("expected a copy of 'hello' from %s, got %q from %s", c.LocalAddr(), got.data, got.source)
+	}
+}
+
+func TestParsePort(t *testing.T) {
+	p, err := ParsePort("8080:47000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Target != 8080 || p.Copy != 47000 || p.String() != "8080:47000" {
+		t.Fatalf("unexpected port %+v", p)
+	}
+
+	for _, s := range []string{"8080", "a:1", "1:b", "1:2:3"} {
+		if _, err := ParsePort(s); err == nil {
+			t.Fatalf("expected %q to be invalid", s)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 Jared Allard
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package mirroragent
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+)
+
+// mirrorBuffer is how many writes can be queued for the copy connection
+// before it's considered too slow to keep up, and stops being mirrored to
+const mirrorBuffer = 256
+
+// mirrorLinger is how long the local service has to finish responding once
+// the client is done, before its connection is closed
+const mirrorLinger = 30 * time.Second
+
+// mirrorWriter copies what's written to it to a connection in the
+// background, so a slow or broken connection never holds up the writer.
+// If it can't keep up the connection is closed, rather than sending it a
+// stream with gaps in it.
+type mirrorWriter struct {
+	conn net.Conn
+	ch   chan []byte
+
+	// aborted is closed when the connection stops being written to
+	aborted   chan struct{}
+	abortOnce sync.Once
+}
+
+// newMirrorWriter creates a mirrorWriter for conn, discarding anything
+// conn sends back
+func newMirrorWriter(conn net.Conn) *mirrorWriter {
+	w := &mirrorWriter{conn: conn, ch: make(chan []byte, mirrorBuffer), aborted: make(chan struct{})}
+
+	go func() {
+		for {
+			select {
+			case <-w.aborted:
+				return
+			case b, ok := <-w.ch:
+				if !ok {
+					w.closeWrite()
+					return
+				}
+				if _, err := conn.Write(b); err != nil {
+					w.abort()
+					return
+				}
+			}
+		}
+	}()
+
+	// responses from the mirror are thrown away, the client only ever sees
+	// the ones from the upstream
+	go func() {
+		io.Copy(ioutil.Discard, conn) //nolint:errcheck // Why: Responses are ignored
+		w.abort()
+	}()
+
+	return w
+}
+
+// Write queues p to be written to the connection. It never fails so that
+// mirroring can't break the connection being mirrored.
+func (w *mirrorWriter) Write(p []byte) (int, error) {
+	b := make([]byte, len(p))
+	copy(b, p)
+
+	select {
+	case <-w.aborted:
+	case w.ch <- b:
+	default:
+		w.abort()
+	}
+	return len(p), nil
+}
+
+// Finish writes anything still queued, then closes the connection once it
+// stops responding. Write must not be called after it.
+func (w *mirrorWriter) Finish() {
+	close(w.ch)
+}
+
+// closeWrite lets the local service know the client is done, giving it
+// mirrorLinger to finish responding before it's closed
+func (w *mirrorWriter) closeWrite() {
+	tcp, ok := w.conn.(*net.TCPConn)
+	if !ok {
+		w.abort()
+		return
+	}
+
+	if err := tcp.CloseWrite(); err != nil {
+		w.abort()
+		return
+	}
+	if err := tcp.SetReadDeadline(time.Now().Add(mirrorLinger)); err != nil {
+		w.abort()
+	}
+}
+
+// abort stops writing to the connection and closes it
+func (w *mirrorWriter) abort() {
+	w.abortOnce.Do(func() {
+		close(w.aborted)
+		w.conn.Close()
+	})
+}
+
+// teeConn is a connection whose reads are also written to w
+type teeConn struct {
+	net.Conn
+	w io.Writer
+}
+
+func (c *teeConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.w.Write(p[:n]) //nolint:errcheck // Why: mirrorWriter never fails
+	}
+	return n, err
+}
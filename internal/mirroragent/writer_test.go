@@ -0,0 +1,61 @@
+// Copyright 2020 Jared Allard
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package mirroragent
+
+import (
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+func TestMirrorWriter(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	received := make(chan string)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		defer conn.Close()
+
+		// responses are discarded by the writer
+		conn.Write([]byte("ignored")) //nolint:errcheck // Why: Test
+
+		b, _ := ioutil.ReadAll(conn) //nolint:errcheck // Why: Compared below
+		received <- string(b)
+	}()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := newMirrorWriter(conn)
+	for _, s := range []string{"GET / HTTP/1.1\r\n", "Host: api\r\n", "\r\n"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Finish()
+
+	if got := <-received; got != "GET / HTTP/1.1\r\nHost: api\r\n\r\n" {
+		t.Fatalf("unexpected mirrored data %q", got)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nftables implements transparent redirection of traffic destined
+// for Kubernetes ClusterIPs to the local listeners created by localizer. This
+// allows applications to use unmodified in-cluster configuration.
+package nftables
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultTableName is the name of the nftables table managed by localizer
+	// when no other name is provided
+	DefaultTableName = "localizer"
+
+	// mapName is the name of the map that stores clusterIP.port -> localIP.port
+	mapName = "redirects"
+
+	// routeLocalnetPath is the sysctl that needs to be enabled in order for
+	// locally generated traffic to be DNAT'd to a loopback address.
+	routeLocalnetPath = "/proc/sys/net/ipv4/conf/all/route_localnet"
+)
+
+// Redirector manages a nftables table that DNATs traffic sent to a ClusterIP
+// to a local address.
+type Redirector struct {
+	log logrus.FieldLogger
+
+	// table is the name of the nftables table we manage
+	table string
+
+	mu sync.Mutex
+
+	// elements is a map of clusterIP to the map elements that were added
+	// for it, used to remove them later on.
+	elements map[string][]string
+
+	// routeLocalnet is the value route_localnet had before we enabled it,
+	// restored on Close
+	routeLocalnet []byte
+}
+
+// NewRedirector creates a nftables table with the given name, replacing any
+// existing one left over from a previous instance. If table is empty,
+// DefaultTableName is used.
+func NewRedirector(log logrus.FieldLogger, table string) (*Redirector, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("clusterIP redirection is only supported on linux")
+	}
+
+	if _, err := exec.LookPath("nft"); err != nil {
+		return nil, errors.Wrap(err, "failed to find nft binary, is nftables installed?")
+	}
+
+	r := &Redirector{
+		log:      log.WithField("component", "nftables"),
+		elements: make(map[string][]string),
+		table:    table,
+	}
+	if r.table == "" {
+		r.table = DefaultTableName
+	}
+
+	// remove any table left over from an unclean exit, this is allowed to fail
+	// when one doesn't exist
+	_ = r.nft("delete", "table", "ip", r.table) //nolint:errcheck // Why: best effort
+
+	script := strings.Join([]string{
+		fmt.Sprintf("add table ip %s", r.table),
+		fmt.Sprintf("add map ip %s %s { type ipv4_addr . inet_service : ipv4_addr . inet_service; }", r.table, mapName),
+		fmt.Sprintf("add chain ip %s output { type nat hook output priority -100; }", r.table),
+		fmt.Sprintf("add rule ip %s output dnat to ip daddr . tcp dport map @%s", r.table, mapName),
+	}, "\n")
+
+	if err := r.nftScript(script); err != nil {
+		return nil, errors.Wrap(err, "failed to create nftables table")
+	}
+
+	// route_localnet is only enabled once the table exists, so that nothing
+	// is left changed on the host if it couldn't be created. Nothing is
+	// redirected until Add is called, so this can come after.
+	previous, err := ioutil.ReadFile(routeLocalnetPath)
+	if err != nil {
+		_ = r.nft("delete", "table", "ip", r.table) //nolint:errcheck // Why: best effort
+		return nil, errors.Wrap(err, "failed to read route_localnet")
+	}
+
+	//nolint:gosec // Why: This is a constant path
+	if err := ioutil.WriteFile(routeLocalnetPath, []byte("1"), 0644); err != nil {
+		_ = r.nft("delete", "table", "ip", r.table) //nolint:errcheck // Why: best effort
+		return nil, errors.Wrap(err, "failed to enable route_localnet")
+	}
+	r.routeLocalnet = previous
+
+	return r, nil
+}
+
+// nft runs the nft binary with the provided arguments
+func (r *Redirector) nft(args ...string) error {
+	b, err := exec.Command("nft", args...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "nft %s: %s", strings.Join(args, " "), strings.TrimSpace(string(b)))
+	}
+
+	return nil
+}
+
+// nftScript runs the provided nft script atomically
+func (r *Redirector) nftScript(script string) error {
+	cmd := exec.Command("nft", "-f", "-")
+	cmd.Stdin = strings.NewReader(script)
+
+	b, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "nft: %s", strings.TrimSpace(string(b)))
+	}
+
+	return nil
+}
+
+// Add redirects traffic sent to clusterIP on the given ports to localIP. Ports
+// are in the format of port:targetPort, the same as port-forwards, of which
+// only the service port is used.
+func (r *Redirector) Add(clusterIP, localIP string, ports []string) error {
+	if net.ParseIP(clusterIP).To4() == nil {
+		return fmt.Errorf("invalid clusterIP '%s', only IPv4 is supported", clusterIP)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// replace any existing rules for this clusterIP
+	if err := r.remove(clusterIP); err != nil {
+		return err
+	}
+
+	elements := make([]string, 0, len(ports))
+	for _, p := range ports {
+		port := strings.Split(p, ":")[0]
+		elements = append(elements, fmt.Sprintf("%s . %s : %s . %s", clusterIP, port, localIP, port))
+	}
+	if len(elements) == 0 {
+		return nil
+	}
+
+	if err := r.nft("add", "element", "ip", r.table, mapName, "{ "+strings.Join(elements, ", ")+" }"); err != nil {
+		return errors.Wrap(err, "failed to add redirect")
+	}
+	r.elements[clusterIP] = elements
+
+	r.log.WithField("clusterIP", clusterIP).Debugf("redirecting %d port(s) to %s", len(elements), localIP)
+
+	return nil
+}
+
+// Remove removes all redirects for a given clusterIP
+func (r *Redirector) Remove(clusterIP string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.remove(clusterIP)
+}
+
+func (r *Redirector) remove(clusterIP string) error {
+	elements, ok := r.elements[clusterIP]
+	if !ok {
+		return nil
+	}
+
+	// we only need the keys of the elements to delete them
+	keys := make([]string, len(elements))
+	for i, e := range elements {
+		keys[i] = strings.TrimSpace(strings.Split(e, ":")[0])
+	}
+
+	if err := r.nft("delete", "element", "ip", r.table, mapName, "{ "+strings.Join(keys, ", ")+" }"); err != nil {
+		return errors.Wrap(err, "failed to remove redirect")
+	}
+	delete(r.elements, clusterIP)
+
+	return nil
+}
+
+// Close removes the localizer nftables table, and restores route_localnet
+// to what it was before the table was created
+func (r *Redirector) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.elements = make(map[string][]string)
+	err := r.nft("delete", "table", "ip", r.table)
+
+	// restored even if the table couldn't be removed, nothing else is
+	// going to do it
+	if r.routeLocalnet != nil && strings.TrimSpace(string(r.routeLocalnet)) != "1" {
+		//nolint:gosec // Why: This is a constant path
+		if werr := ioutil.WriteFile(routeLocalnetPath, r.routeLocalnet, 0644); werr != nil && err == nil {
+			err = errors.Wrap(werr, "failed to restore route_localnet")
+		}
+		r.routeLocalnet = nil
+	}
+
+	return err
+}
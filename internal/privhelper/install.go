@@ -0,0 +1,90 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package privhelper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// userRegex matches user names that are safe to put into a sudoers rule
+var userRegex = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]*$`)
+
+// Sudoers returns the sudoers rule that allows user to run the helper
+// without a password
+func Sudoers(user string) string {
+	return fmt.Sprintf("# Written by 'localizer setup', allows the localizer daemon to run without sudo\n"+
+		"%s ALL=(root) NOPASSWD: %s %s *\n", user, HelperPath, Command)
+}
+
+// sudo runs a command with sudo, prompting for a password if needed
+func sudo(ctx context.Context, in io.Reader, out io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, "sudo", args...)
+	cmd.Stdin = in
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return errors.Wrapf(cmd.Run(), "failed to run sudo %v", args)
+}
+
+// Install installs exe, a localizer binary, as the helper and allows user
+// to run it without a password. sudo is used to do so, which prompts for
+// a password once.
+func Install(ctx context.Context, user, exe string, in io.Reader, out io.Writer) error {
+	if !userRegex.MatchString(user) {
+		return fmt.Errorf("invalid user name '%s'", user)
+	}
+
+	tmp, err := ioutil.TempFile("", "localizer-sudoers")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(Sudoers(user)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	steps := [][]string{
+		{"install", "-d", "-m", "0755", filepath.Dir(HelperPath)},
+		{"install", "-m", "0755", "-o", "0", "-g", "0", exe, HelperPath},
+		// a broken sudoers file can lock everyone out of sudo, so it's
+		// checked before it's installed
+		{"visudo", "-cf", tmp.Name()},
+		{"install", "-m", "0440", "-o", "0", "-g", "0", tmp.Name(), SudoersPath},
+	}
+	for _, args := range steps {
+		if err := sudo(ctx, in, out, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Uninstall removes the helper and its sudoers rule
+func Uninstall(ctx context.Context, in io.Reader, out io.Writer) error {
+	return sudo(ctx, in, out, "rm", "-f", SudoersPath, HelperPath)
+}
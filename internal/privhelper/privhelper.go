@@ -0,0 +1,196 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package privhelper lets the daemon run without root. The few operations
+// that need it, writing to the hosts file and creating loopback aliases,
+// are done by a copy of localizer installed by `localizer setup`, which the
+// daemon runs with sudo. A sudoers rule allows running only that copy
+// without a password, and it only accepts a narrow set of commands.
+package privhelper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/getoutreach/localizer/pkg/hostsfile"
+)
+
+const (
+	// HelperPath is where the helper is installed. It's owned by root so
+	// that only root can replace what sudo runs.
+	HelperPath = "/usr/local/libexec/localizer-helper"
+
+	// SudoersPath is the sudoers rule allowing the helper to be ran
+	// without a password
+	SudoersPath = "/etc/sudoers.d/localizer"
+
+	// Command is the command of the helper, e.g. localizer-helper privileged
+	Command = "privileged"
+
+	// HostsFile is the only hosts file the helper writes to
+	HostsFile = "/etc/hosts"
+)
+
+// blockNameRegex matches the names of the hosts file blocks written by
+// localizer instances, the helper refuses to write to any other block
+var blockNameRegex = regexp.MustCompile(`^localizer(-[a-z0-9]([a-z0-9-]*[a-z0-9])?)?$`)
+
+// loopback is the only network the helper creates aliases in
+var loopback = &net.IPNet{IP: net.IPv4(127, 0, 0, 0), Mask: net.CIDRMask(8, 32)}
+
+// Client runs the helper with sudo
+type Client struct{}
+
+// NewClient returns a client for the installed helper, or an error if it
+// isn't installed or can't be ran without a password
+func NewClient(ctx context.Context) (*Client, error) {
+	c := &Client{}
+	if err := c.run(ctx, nil, "check"); err != nil {
+		return nil, errors.Wrap(err, "privileged helper isn't installed, run 'localizer setup'")
+	}
+	return c, nil
+}
+
+// run runs a command of the helper
+func (c *Client) run(ctx context.Context, stdin io.Reader, args ...string) error {
+	//nolint:gosec // Why: HelperPath is a constant, and args are validated by the helper
+	cmd := exec.CommandContext(ctx, "sudo", append([]string{"-n", HelperPath, Command}, args...)...)
+	cmd.Stdin = stdin
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "privileged helper failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// WriteHosts replaces the entries of a localizer block of the hosts file
+func (c *Client) WriteHosts(ctx context.Context, block string, hosts map[string][]string) error {
+	ips := make([]string, 0, len(hosts))
+	for ip := range hosts {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+
+	var buf bytes.Buffer
+	for _, ip := range ips {
+		fmt.Fprintf(&buf, "%s %s\n", ip, strings.Join(hosts[ip], " "))
+	}
+
+	return c.run(ctx, &buf, "hosts", block)
+}
+
+// AddLoopbackAlias adds an alias for ip to the loopback interface
+func (c *Client) AddLoopbackAlias(ctx context.Context, ip string) error {
+	return c.run(ctx, nil, "alias", "add", ip)
+}
+
+// RemoveLoopbackAlias removes the alias for ip from the loopback interface
+func (c *Client) RemoveLoopbackAlias(ctx context.Context, ip string) error {
+	return c.run(ctx, nil, "alias", "remove", ip)
+}
+
+// WriteHosts replaces the entries of a localizer block of HostsFile with
+// the "<ip> <host>..." lines read from r. Since anyone can run the helper,
+// only loopback addresses and valid DNS names are accepted, so it can't be
+// used to point names at an address outside of this machine.
+func WriteHosts(ctx context.Context, block string, r io.Reader) error {
+	if !blockNameRegex.MatchString(block) {
+		return fmt.Errorf("'%s' isn't a localizer hosts block", block)
+	}
+
+	f, err := hostsfile.New(HostsFile, block)
+	if err != nil {
+		return errors.Wrap(err, "failed to open hosts file")
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		ip := net.ParseIP(fields[0])
+		if ip == nil || len(fields) < 2 {
+			return fmt.Errorf("invalid hosts entry '%s'", scanner.Text())
+		}
+		if !loopback.Contains(ip) {
+			return fmt.Errorf("'%s' isn't a loopback address", fields[0])
+		}
+
+		hosts, err := validHostnames(fields[1:])
+		if err != nil {
+			return err
+		}
+		if err := f.AddHosts(ip.String(), hosts); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return f.Save(ctx)
+}
+
+// validHostnames normalizes hosts with hostsfile.NormalizeHostname and
+// returns an error if any of them isn't a DNS-1123 subdomain
+func validHostnames(hosts []string) ([]string, error) {
+	normalized, err := hostsfile.NormalizeHostnames(hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range normalized {
+		if errs := validation.IsDNS1123Subdomain(h); len(errs) > 0 {
+			return nil, fmt.Errorf("'%s' isn't a valid hostname: %s", h, strings.Join(errs, ", "))
+		}
+	}
+	return normalized, nil
+}
+
+// LoopbackAlias adds, or removes, an alias for ip to the loopback
+// interface. This is only needed on macOS, elsewhere all of 127.0.0.0/8 is
+// routed to it.
+func LoopbackAlias(ip string, add bool) error {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || !loopback.Contains(parsed) {
+		return fmt.Errorf("'%s' isn't a loopback address", ip)
+	}
+
+	if runtime.GOOS != "darwin" {
+		return nil
+	}
+
+	args := []string{"lo0", "alias", parsed.String(), "up"}
+	if !add {
+		args = []string{"lo0", "-alias", parsed.String()}
+	}
+
+	if out, err := exec.Command("ifconfig", args...).CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "ifconfig failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
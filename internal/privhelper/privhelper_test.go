@@ -0,0 +1,76 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package privhelper
+
+import (
+	"context"
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWriteHostsOnlyLocalizerBlocks(t *testing.T) {
+	for _, block := range []string{"", "other", "localizer-", "localizer-UPPER", "localizer-a b"} {
+		err := WriteHosts(context.Background(), block, strings.NewReader(""))
+		if err == nil || !strings.Contains(err.Error(), "isn't a localizer hosts block") {
+			t.Fatalf("expected block %q to be refused, got %v", block, err)
+		}
+	}
+}
+
+func TestValidHostnames(t *testing.T) {
+	got, err := validHostnames([]string{"API.default", "api.default.", "Bücher.default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "api.default xn--bcher-kva.default"; strings.Join(got, " ") != want {
+		t.Fatalf("expected %q, got %q", want, strings.Join(got, " "))
+	}
+
+	for _, h := range []string{"under_score.default", "a..b", "-api.default"} {
+		if _, err := validHostnames([]string{h}); err == nil {
+			t.Fatalf("expected hostname %q to be refused", h)
+		}
+	}
+}
+
+func TestLoopbackAliasOnlyLoopback(t *testing.T) {
+	for _, ip := range []string{"", "10.0.0.1", "::1", "lo0"} {
+		if err := LoopbackAlias(ip, true); err == nil {
+			t.Fatalf("expected %q to be refused", ip)
+		}
+	}
+
+	// only macOS needs aliases, everywhere else this does nothing
+	if runtime.GOOS != "darwin" {
+		if err := LoopbackAlias("127.0.0.2", true); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInstallRejectsUser(t *testing.T) {
+	err := Install(context.Background(), "me ALL=(ALL) NOPASSWD: ALL\n#", "/bin/true", nil, ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), "invalid user name") {
+		t.Fatalf("expected the user name to be refused, got %v", err)
+	}
+}
+
+func TestSudoers(t *testing.T) {
+	want := "me ALL=(root) NOPASSWD: " + HelperPath + " privileged *\n"
+	if got := Sudoers("me"); !strings.HasSuffix(got, want) {
+		t.Fatalf("expected sudoers rule %q, got %q", want, got)
+	}
+}
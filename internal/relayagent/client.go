@@ -0,0 +1,148 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package relayagent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+)
+
+// Client connects to services through the relay agent, deploying it and
+// creating a port-forward to it as needed. The port-forward is recreated
+// if it breaks.
+type Client struct {
+	ctx   context.Context
+	k     kubernetes.Interface
+	rc    *rest.Config
+	log   logrus.FieldLogger
+	image string
+
+	namespace string
+
+	mu sync.Mutex
+
+	// fw is the port-forward to the agent, and addr is the local address
+	// it's listening on. Both are nil/empty when not connected.
+	fw   *portforward.PortForwarder
+	addr string
+}
+
+// NewClient creates a client that uses a relay agent running the given
+// image in namespace. The agent isn't deployed until the first Dial. Its
+// port-forward is closed when ctx is canceled.
+func NewClient(ctx context.Context, k kubernetes.Interface, rc *rest.Config, log logrus.FieldLogger,
+	namespace, image string) *Client {
+	return &Client{
+		ctx:       ctx,
+		k:         k,
+		rc:        rc,
+		log:       log.WithField("component", "relay-agent"),
+		image:     image,
+		namespace: namespace,
+	}
+}
+
+// Dial connects to address, a host:port, from inside of the cluster. If
+// compress is set the connection to the agent is compressed.
+func (c *Client) Dial(ctx context.Context, address string, compress bool) (net.Conn, error) {
+	addr, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	d := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to relay agent port-forward")
+	}
+
+	// the handshake includes the agent dialing the target
+	conn.SetDeadline(time.Now().Add(30 * time.Second)) //nolint:errcheck // Why: Best effort
+	tconn, err := Handshake(conn, address, compress)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{}) //nolint:errcheck // Why: Best effort
+
+	return tconn, nil
+}
+
+// connect returns the local address of the port-forward to the agent,
+// creating it if needed
+func (c *Client) connect(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.addr != "" {
+		return c.addr, nil
+	}
+
+	c.log.Info("connecting to relay agent")
+	pod, err := Ensure(ctx, c.k, c.namespace, c.image)
+	if err != nil {
+		return "", err
+	}
+
+	fw, err := kube.CreatePortForward(c.ctx, c.k.CoreV1().RESTClient(), c.rc, pod, "127.0.0.1", []string{fmt.Sprintf("0:%d", Port)})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create port-forward to relay agent")
+	}
+	fw.Ready = make(chan struct{})
+
+	errChan := make(chan error, 1)
+	go func() {
+		err := fw.ForwardPorts()
+		errChan <- err
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.fw == fw {
+			c.log.WithError(err).Warn("lost connection to relay agent")
+			c.fw = nil
+			c.addr = ""
+		}
+	}()
+
+	select {
+	case <-fw.Ready:
+	case err := <-errChan:
+		return "", errors.Wrap(err, "port-forward to relay agent failed")
+	case <-time.After(30 * time.Second):
+		fw.Close()
+		return "", fmt.Errorf("timed out waiting for port-forward to relay agent")
+	}
+
+	ports, err := fw.GetPorts()
+	if err != nil || len(ports) == 0 {
+		fw.Close()
+		return "", errors.Wrap(err, "failed to get relay agent port-forward port")
+	}
+
+	c.fw = fw
+	c.addr = fmt.Sprintf("127.0.0.1:%d", ports[0].Local)
+	c.log.WithField("pod", pod.Name).Info("connected to relay agent")
+
+	return c.addr, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package relayagent
+
+import (
+	"compress/flate"
+	"io"
+	"net"
+)
+
+// compressOption is sent after the target in a CONNECT request to
+// compress the connection with DEFLATE once it's established
+const compressOption = "deflate"
+
+// compressedConn compresses writes to, and decompresses reads from, a
+// connection. Every write is flushed, so interactive protocols don't wait
+// on a full compression block.
+type compressedConn struct {
+	net.Conn
+	r io.ReadCloser
+	w *flate.Writer
+}
+
+// newCompressedConn wraps c, which must support CloseWrite
+func newCompressedConn(c net.Conn) net.Conn {
+	// BestSpeed, this is meant to help on slow connections without the
+	// CPU becoming the bottleneck on fast ones
+	w, _ := flate.NewWriter(c, flate.BestSpeed) //nolint:errcheck // Why: Only errors on an invalid level
+	return &compressedConn{Conn: c, r: flate.NewReader(c), w: w}
+}
+
+func (c *compressedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func (c *compressedConn) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, c.w.Flush()
+}
+
+// CloseWrite finishes the compressed stream and closes the write side of
+// the underlying connection
+func (c *compressedConn) CloseWrite() error {
+	if err := c.w.Close(); err != nil {
+		return err
+	}
+	return c.Conn.(closeWriter).CloseWrite()
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package relayagent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Name is the name of the relay agent's deployment, and the value of its
+// app label
+const Name = "localizer-relay-agent"
+
+// deployment returns the deployment that runs the agent
+func deployment(namespace, image string) *appsv1.Deployment {
+	labels := map[string]string{"app": Name}
+	replicas := int32(1)
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      Name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "relay-agent",
+							Image:           image,
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							// Only listen on localhost, the agent is reached through a
+							// port-forward and shouldn't be an open proxy in the cluster
+							Args: []string{"relay-agent", "--address", fmt.Sprintf("127.0.0.1:%d", Port)},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("50m"),
+									corev1.ResourceMemory: resource.MustParse("64Mi"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Ensure creates the relay agent's deployment if it doesn't exist, or
+// updates its image, and waits for a pod of it to be ready. The ready pod
+// is returned.
+func Ensure(ctx context.Context, k kubernetes.Interface, namespace, image string) (*corev1.Pod, error) {
+	want := deployment(namespace, image)
+
+	existing, err := k.AppsV1().Deployments(namespace).Get(ctx, Name, metav1.GetOptions{})
+	switch {
+	case kerrors.IsNotFound(err):
+		if _, err := k.AppsV1().Deployments(namespace).Create(ctx, want, metav1.CreateOptions{}); err != nil { //nolint:govet // Why: We're OK shadowing err
+			return nil, errors.Wrap(err, "failed to create relay agent")
+		}
+	case err != nil:
+		return nil, errors.Wrap(err, "failed to get relay agent")
+	case existing.Spec.Template.Spec.Containers[0].Image != image:
+		existing.Spec.Template = want.Spec.Template
+		if _, err := k.AppsV1().Deployments(namespace).Update(ctx, existing, metav1.UpdateOptions{}); err != nil { //nolint:govet // Why: We're OK shadowing err
+			return nil, errors.Wrap(err, "failed to update relay agent")
+		}
+	}
+
+	var pod *corev1.Pod
+	err = wait.PollImmediate(2*time.Second, 2*time.Minute, func() (bool, error) {
+		pods, err := k.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: "app=" + Name})
+		if err != nil {
+			return false, err
+		}
+
+		for i := range pods.Items {
+			if isReady(&pods.Items[i], image) {
+				pod = &pods.Items[i]
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wait for relay agent to be ready")
+	}
+
+	return pod, nil
+}
+
+// isReady returns if a pod is running the given image and is ready
+func isReady(pod *corev1.Pod, image string) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+
+	if len(pod.Spec.Containers) == 0 || pod.Spec.Containers[0].Image != image {
+		return false
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
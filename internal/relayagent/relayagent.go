@@ -0,0 +1,238 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package relayagent implements an agent that runs inside of the cluster
+// and relays connections to services. A single port-forward to the agent
+// carries the connections for every service, instead of one port-forward
+// per service.
+package relayagent
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Port is the port the agent listens on inside of its pod
+const Port = 8675
+
+// EchoTarget is a target the agent handles itself by echoing everything
+// sent to it, used to measure the performance of the agent's tunnel
+// without depending on a service
+const EchoTarget = "localizer-echo:7"
+
+// maxLineLength is the maximum length of a line in the protocol
+const maxLineLength = 1024
+
+// The protocol is line based. Clients send "CONNECT <host:port> [deflate]\n",
+// and the agent responds with "OK\n" once it's connected, or
+// "ERROR <message>\n". After OK, the connection carries the data of the
+// target connection, compressed if deflate was requested.
+const (
+	connectPrefix = "CONNECT "
+	okResponse    = "OK"
+	errorPrefix   = "ERROR "
+)
+
+// readLine reads a single protocol line
+func readLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		part, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		line = append(line, part...)
+		if len(line) > maxLineLength {
+			return "", fmt.Errorf("line too long")
+		}
+		if !isPrefix {
+			return string(line), nil
+		}
+	}
+}
+
+// closeWriter is a connection whose write side can be closed on its own
+type closeWriter interface {
+	io.Writer
+	CloseWrite() error
+}
+
+// conn is a connection that has completed the handshake. Reads go through
+// the bufio.Reader used for the handshake so no data is lost.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *conn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// CloseWrite closes the write side of the connection, if supported
+func (c *conn) CloseWrite() error {
+	if cw, ok := c.Conn.(closeWriter); ok {
+		return cw.CloseWrite()
+	}
+	return c.Conn.Close()
+}
+
+// Handshake asks the agent on the other end of c to connect to target,
+// returning a connection to it. If compress is set the connection to the
+// agent is compressed, the target sees the original data.
+func Handshake(c net.Conn, target string, compress bool) (net.Conn, error) {
+	req := connectPrefix + target
+	if compress {
+		req += " " + compressOption
+	}
+
+	if _, err := fmt.Fprintf(c, "%s\n", req); err != nil {
+		return nil, errors.Wrap(err, "failed to send connect request")
+	}
+
+	r := bufio.NewReader(c)
+	resp, err := readLine(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read connect response")
+	}
+
+	if resp != okResponse {
+		return nil, fmt.Errorf("relay agent failed to connect to %s: %s", target, strings.TrimPrefix(resp, errorPrefix))
+	}
+
+	if compress {
+		return newCompressedConn(&conn{Conn: c, r: r}), nil
+	}
+	return &conn{Conn: c, r: r}, nil
+}
+
+// Serve accepts connections on l and relays them to the targets clients
+// request, until ctx is canceled.
+func Serve(ctx context.Context, log logrus.FieldLogger, l net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "failed to accept connection")
+		}
+
+		go handle(log, c)
+	}
+}
+
+// handle handles a single client connection
+func handle(log logrus.FieldLogger, c net.Conn) {
+	defer c.Close()
+
+	// clients have to send their request quickly
+	c.SetReadDeadline(time.Now().Add(10 * time.Second)) //nolint:errcheck // Why: Best effort
+	r := bufio.NewReader(c)
+	req, err := readLine(r)
+	if err != nil {
+		log.WithError(err).Debug("failed to read request")
+		return
+	}
+	c.SetReadDeadline(time.Time{}) //nolint:errcheck // Why: Best effort
+
+	if !strings.HasPrefix(req, connectPrefix) {
+		fmt.Fprintf(c, "%sunknown request\n", errorPrefix)
+		return
+	}
+	args := strings.Fields(strings.TrimPrefix(req, connectPrefix))
+	if len(args) == 0 {
+		fmt.Fprintf(c, "%smissing target\n", errorPrefix)
+		return
+	}
+	target := args[0]
+
+	compress := false
+	for _, opt := range args[1:] {
+		if opt != compressOption {
+			fmt.Fprintf(c, "%sunsupported option '%s'\n", errorPrefix, opt)
+			return
+		}
+		compress = true
+	}
+
+	//nolint:govet // Why: We're OK shadowing err
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		fmt.Fprintf(c, "%sinvalid target '%s'\n", errorPrefix, target)
+		return
+	}
+
+	if target == EchoTarget {
+		echo(c, r, compress)
+		return
+	}
+
+	log = log.WithField("target", target)
+	t, err := net.DialTimeout("tcp", target, 10*time.Second)
+	if err != nil {
+		log.WithError(err).Warn("failed to connect to target")
+		fmt.Fprintf(c, "%s%v\n", errorPrefix, err)
+		return
+	}
+	defer t.Close()
+
+	if _, err := fmt.Fprintf(c, "%s\n", okResponse); err != nil {
+		return
+	}
+
+	var client net.Conn = &conn{Conn: c, r: r}
+	if compress {
+		client = newCompressedConn(client)
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	pipe := func(dst closeWriter, src io.Reader) {
+		defer wg.Done()
+		io.Copy(dst, src) //nolint:errcheck // Why: Either side closing ends the copy
+		dst.CloseWrite()  //nolint:errcheck // Why: Best effort
+	}
+
+	go pipe(t.(*net.TCPConn), client)
+	go pipe(client.(closeWriter), t)
+	wg.Wait()
+}
+
+// echo handles a connection to the EchoTarget
+func echo(c net.Conn, r *bufio.Reader, compress bool) {
+	if _, err := fmt.Fprintf(c, "%s\n", okResponse); err != nil {
+		return
+	}
+
+	var client net.Conn = &conn{Conn: c, r: r}
+	if compress {
+		client = newCompressedConn(client)
+	}
+
+	io.Copy(client, client)           //nolint:errcheck // Why: Either side closing ends the copy
+	client.(closeWriter).CloseWrite() //nolint:errcheck // Why: Best effort
+}
@@ -0,0 +1,145 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package relayagent
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// startAgent starts an agent on a local listener, returning its address
+func startAgent(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	go Serve(ctx, log, l) //nolint:errcheck // Why: Stopped by the test finishing
+
+	return l.Addr().String()
+}
+
+func TestHandshake(t *testing.T) {
+	// an echo server for the agent to connect to
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	go func() {
+		for {
+			c, err := target.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				io.Copy(c, c) //nolint:errcheck // Why: Test echo server
+			}()
+		}
+	}()
+
+	addr := startAgent(t)
+	for _, compress := range []bool{false, true} {
+		c, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		tconn, err := Handshake(c, target.Addr().String(), compress)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// large enough to take more than one compressed write
+		want := strings.Repeat("hello ", 32*1024)
+		go func() {
+			io.WriteString(tconn, want)      //nolint:errcheck // Why: Checked by the read
+			tconn.(closeWriter).CloseWrite() //nolint:errcheck // Why: Checked by the read
+		}()
+
+		b, err := ioutil.ReadAll(tconn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Fatalf("compress=%v: expected %d bytes echoed, got %d", compress, len(want), len(b))
+		}
+	}
+}
+
+func TestHandshakeDialFailure(t *testing.T) {
+	// find a port with nothing listening on it
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	c, err := net.Dial("tcp", startAgent(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	_, err = Handshake(c, addr, false)
+	if err == nil || !strings.Contains(err.Error(), "relay agent failed to connect") {
+		t.Fatalf("expected connect failure, got %v", err)
+	}
+}
+
+func TestHandshakeEcho(t *testing.T) {
+	addr := startAgent(t)
+	for _, compress := range []bool{false, true} {
+		c, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		tconn, err := Handshake(c, EchoTarget, compress)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := strings.Repeat("echo ", 32*1024)
+		go func() {
+			io.WriteString(tconn, want)      //nolint:errcheck // Why: Checked by the read
+			tconn.(closeWriter).CloseWrite() //nolint:errcheck // Why: Checked by the read
+		}()
+
+		b, err := ioutil.ReadAll(tconn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Fatalf("compress=%v: expected %d bytes echoed, got %d", compress, len(want), len(b))
+		}
+	}
+}
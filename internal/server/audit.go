@@ -0,0 +1,142 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// peerAddr is the address of a client connected to the unix socket, which
+// is who it is rather than where
+type peerAddr struct {
+	uid int
+}
+
+func (a peerAddr) Network() string { return "unix" }
+func (a peerAddr) String() string  { return "uid:" + strconv.Itoa(a.uid) }
+
+// peerConn is a connection from a known user
+type peerConn struct {
+	net.Conn
+	addr peerAddr
+}
+
+// RemoteAddr returns who is on the other end of the connection
+func (c *peerConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// auditor writes every RPC, who made it, and its result, to the audit log
+type auditor struct {
+	log *logrus.Logger
+	f   *os.File
+}
+
+// newAuditor appends the audit log to the file at path
+func newAuditor(path string) (*auditor, error) {
+	//nolint:gosec // Why: The path is provided by the user running the daemon
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open audit log")
+	}
+
+	log := logrus.New()
+	log.Out = f
+	log.Formatter = &logrus.JSONFormatter{}
+	return &auditor{log: log, f: f}, nil
+}
+
+// Close closes the audit log
+func (a *auditor) Close() error {
+	return a.f.Close()
+}
+
+// record writes an RPC to the audit log
+func (a *auditor) record(ctx context.Context, method string, req interface{}, started time.Time, err error) {
+	fields := logrus.Fields{
+		"method":   method,
+		"code":     status.Code(err).String(),
+		"duration": time.Since(started).String(),
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields["peer"] = p.Addr.String()
+		if addr, ok := p.Addr.(peerAddr); ok {
+			fields["uid"] = addr.uid
+			if u, err := user.LookupId(strconv.Itoa(addr.uid)); err == nil {
+				fields["user"] = u.Username
+			}
+		}
+	}
+
+	if msg, ok := req.(proto.Message); ok {
+		if b, err := protojson.Marshal(msg); err == nil {
+			fields["request"] = string(b)
+		}
+	}
+
+	entry := a.log.WithFields(fields)
+	if err != nil {
+		entry.WithError(err).Warn("rpc failed")
+		return
+	}
+	entry.Info("rpc")
+}
+
+// unary records unary RPCs
+func (a *auditor) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	started := time.Now()
+	resp, err := handler(ctx, req)
+	a.record(ctx, info.FullMethod, req, started, err)
+	return resp, err
+}
+
+// stream records streaming RPCs, once they're done
+func (a *auditor) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	started := time.Now()
+	rs := &recordingStream{ServerStream: ss}
+	err := handler(srv, rs)
+	a.record(ss.Context(), info.FullMethod, rs.req, started, err)
+	return err
+}
+
+// recordingStream keeps the first message received on a stream, which is
+// the request of a server streaming RPC
+type recordingStream struct {
+	grpc.ServerStream
+	req interface{}
+}
+
+func (s *recordingStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && s.req == nil {
+		s.req = m
+	}
+	return err
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/internal/config"
+	"github.com/getoutreach/localizer/internal/kube"
+)
+
+// redactConfig returns a copy of conf with the values of webhook headers,
+// and everything but the host of webhook URLs, which are usually
+// credentials, redacted
+func redactConfig(conf *config.Config) *config.Config {
+	redacted := *conf
+	redacted.Webhooks = make([]config.Webhook, len(conf.Webhooks))
+	for i := range conf.Webhooks {
+		redacted.Webhooks[i] = conf.Webhooks[i]
+		redacted.Webhooks[i].URL = redactURL(conf.Webhooks[i].URL)
+		if len(conf.Webhooks[i].Headers) == 0 {
+			continue
+		}
+
+		redacted.Webhooks[i].Headers = make(map[string]string, len(conf.Webhooks[i].Headers))
+		for k := range conf.Webhooks[i].Headers {
+			redacted.Webhooks[i].Headers[k] = "<redacted>"
+		}
+	}
+	return &redacted
+}
+
+// redactURL redacts the userinfo, path, and query of a URL, e.g. Slack
+// webhooks have their token in the path
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "<redacted>"
+	}
+
+	redacted := u.Scheme + "://"
+	if u.User != nil {
+		redacted += "<redacted>@"
+	}
+	redacted += u.Host
+	if u.Path != "" && u.Path != "/" {
+		redacted += "/<redacted>"
+	}
+	if u.RawQuery != "" {
+		redacted += "?<redacted>"
+	}
+	return redacted
+}
+
+// GetConfig returns the configuration the daemon was started with. The
+// values of webhook headers, and webhook URLs, are redacted.
+func (h *GRPCServiceHandler) GetConfig(ctx context.Context, _ *api.Empty) (*api.GetConfigResponse, error) {
+	opts := h.opts
+
+	conf := ""
+	if opts.Config != nil {
+		b, err := yaml.Marshal(redactConfig(opts.Config))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal configuration file")
+		}
+		conf = string(b)
+	}
+
+	// the context actually in use, which opts only has if it was passed
+	kubeContext, _, _ := kube.Context(h.kconf)
+	if kubeContext == "" {
+		kubeContext = opts.KubeContext
+	}
+
+	var kubeconfigs []string
+	if !opts.InCluster {
+		kubeconfigs = kube.Kubeconfigs()
+	}
+
+	return &api.GetConfigResponse{
+		KubeContext:             kubeContext,
+		InCluster:               opts.InCluster,
+		Kubeconfigs:             kubeconfigs,
+		ClusterDomain:           opts.ClusterDomain,
+		IpCidr:                  opts.IPCidr,
+		IpamFile:                opts.IPAMFile,
+		Instance:                opts.Instance,
+		Socket:                  h.socket,
+		Namespaces:              opts.Namespaces,
+		Services:                h.services,
+		IncludeSystemNamespaces: opts.IncludeSystemNamespaces,
+		PortNames:               opts.PortNames,
+		HostsFile:               opts.HostsFile,
+		RedirectClusterIps:      opts.RedirectClusterIPs,
+		RandomPorts:             opts.RandomPorts,
+		FollowContext:           opts.FollowContext,
+		Unprivileged:            opts.Unprivileged,
+		MaxTunnels:              int64(opts.MaxTunnels),
+		MaxConnections:          int64(opts.MaxConnections),
+		DrainTimeoutSeconds:     int64(opts.DrainTimeout.Seconds()),
+		GcIntervalSeconds:       int64(opts.GCInterval.Seconds()),
+		OperationTimeoutSeconds: int64(opts.OperationTimeout.Seconds()),
+		Node:                    opts.Node,
+		Zone:                    opts.Zone,
+		RelayAgentImage:         opts.RelayAgentImage,
+		DnsAddress:              opts.DNSAddress,
+		MetricsAddress:          opts.MetricsAddress,
+		AuditLog:                opts.AuditLog,
+		Config:                  conf,
+	}, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+
+	"github.com/getoutreach/localizer/internal/kube"
+)
+
+// ErrContextChanged is returned by Run when it stopped because the
+// kubeconfig's current-context changed, and RunOpts.FollowContext is set.
+// Starting again will forward services from the new context.
+var ErrContextChanged = errors.New("kubeconfig current-context changed")
+
+// watchContext checks if the kubeconfig's current-context has been changed
+// from the context we're using. Unless follow is set, which calls stop, we
+// keep forwarding from the old context, so it's warned about in case that
+// wasn't expected.
+func watchContext(ctx context.Context, log logrus.FieldLogger, rc *rest.Config, follow bool, stop func()) {
+	t := time.NewTicker(kube.CredentialCheckInterval)
+	defer t.Stop()
+
+	warned := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		using, current, ok := kube.Context(rc)
+		if !ok {
+			return
+		}
+		if current == using || current == warned {
+			continue
+		}
+
+		if follow {
+			log.Warnf("kubeconfig current-context changed from %s to %s, restarting to forward services from it",
+				using, current)
+			stop()
+			return
+		}
+
+		log.Warnf("kubeconfig current-context changed from %s to %s, still forwarding services from %s. "+
+			"Restart localizer, or run it with --follow-context, to switch", using, current, using)
+		warned = current
+	}
+}
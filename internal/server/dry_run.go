@@ -0,0 +1,60 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/getoutreach/localizer/internal/kevents"
+	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/getoutreach/localizer/pkg/proxier"
+)
+
+// DryRun returns the port-forwards the daemon would create if it was
+// started with opts, with the IPs and hostnames services would get. Only
+// the API server is talked to, nothing is forwarded and nothing on this
+// machine is changed.
+func DryRun(ctx context.Context, log logrus.FieldLogger, opts *RunOpts) ([]proxier.PlannedForward, error) {
+	kconf, k, err := kube.GetKubeClient(log, opts.KubeContext)
+	if opts.InCluster {
+		kconf, k, err = kube.GetInClusterKubeClient()
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create kube client")
+	}
+
+	// hooks aren't ran, so there's no runner
+	popts, _, err := proxyOpts(log, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := proxier.NewProxier(ctx, k, kconf, log, popts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create proxier")
+	}
+
+	kevents.GlobalCache.Start(ctx.Done())
+	log.Info("Waiting for caches to sync...")
+	for informer, synced := range kevents.GlobalCache.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return nil, errors.Errorf("failed to sync cache of %v", informer)
+		}
+	}
+
+	return p.Plan()
+}
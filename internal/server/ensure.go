@@ -0,0 +1,122 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/pkg/proxier"
+	"k8s.io/client-go/tools/cache"
+)
+
+// defaultEnsureTimeout is how long EnsureForwarded waits when a request
+// doesn't set a timeout
+const defaultEnsureTimeout = 2 * time.Minute
+
+// settled returns if a service's status won't change without something
+// else happening first, e.g. it being enabled again
+func settled(s *api.ListService) bool {
+	return s.Status == string(proxier.PortForwardStatusRunning) ||
+		s.Status == string(proxier.PortForwardStatusFailed) || s.Status == "disabled"
+}
+
+// EnsureForwarded implements the EnsureForwarded RPC. The services are
+// reconciled right away, instead of when the daemon notices them, so
+// tools that just deployed a service don't race the daemon's discovery,
+// then it waits for every port-forward to be running.
+func (h *GRPCServiceHandler) EnsureForwarded(ctx context.Context,
+	req *api.EnsureForwardedRequest) (*api.EnsureForwardedResponse, error) {
+	if len(req.Services) == 0 {
+		return nil, invalidRequest("", "at least one service is required")
+	}
+	for _, key := range req.Services {
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil || namespace == "" || name == "" || strings.HasPrefix(key, "pod/") {
+			return nil, invalidRequest(key, "services must be given as namespace/name")
+		}
+	}
+
+	timeout := time.Duration(req.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultEnsureTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	events := h.p.Subscribe(ctx)
+	for _, key := range req.Services {
+		h.p.Sync(key)
+	}
+
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+
+	for {
+		resp, err := h.ensured(req.Services)
+		if err != nil {
+			return nil, err
+		}
+
+		done := true
+		for _, s := range resp.Services {
+			done = done && settled(s)
+		}
+		if done {
+			return resp, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return resp, nil
+		case <-events:
+		case <-t.C:
+		}
+	}
+}
+
+// ensured returns the status of the given services, by namespace/name
+func (h *GRPCServiceHandler) ensured(keys []string) (*api.EnsureForwardedResponse, error) {
+	// not the request's context, so that the statuses can still be
+	// reported once the wait has timed out
+	list, err := h.List(context.Background(), &api.ListRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*api.ListService, len(list.Services))
+	for _, s := range list.Services {
+		byKey[s.Namespace+"/"+s.Name] = s
+	}
+
+	resp := &api.EnsureForwardedResponse{Ready: true}
+	for _, key := range keys {
+		s, ok := byKey[key]
+		if !ok {
+			namespace, name, _ := cache.SplitMetaNamespaceKey(key) //nolint:errcheck // Why: validated by the caller
+			s = &api.ListService{
+				Namespace:    namespace,
+				Name:         name,
+				Status:       "unknown",
+				StatusReason: "Not found, it may not exist yet or be excluded by the daemon's --namespace or --pick",
+			}
+		}
+
+		resp.Ready = resp.Ready && s.Status == string(proxier.PortForwardStatusRunning)
+		resp.Services = append(resp.Services, s)
+	}
+	return resp, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/getoutreach/localizer/api"
+)
+
+// rpcError returns err as a gRPC status error, with details clients can
+// use to show a targeted message
+func rpcError(code codes.Code, category api.ErrorCategory, service, hint string, err error) error {
+	st := status.New(code, err.Error())
+	if withDetails, detailsErr := st.WithDetails(&api.ErrorDetails{
+		Service:  service,
+		Category: category,
+		Hint:     hint,
+	}); detailsErr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// invalidRequest returns an error for a request that's missing fields, or
+// has invalid ones
+func invalidRequest(service, format string, args ...interface{}) error {
+	return rpcError(codes.InvalidArgument, api.ErrorCategory_ERROR_CATEGORY_INVALID_REQUEST, service, "",
+		fmt.Errorf(format, args...))
+}
+
+// notActive returns an error for trying to stop something that isn't
+// running
+func notActive(service, hint string, err error) error {
+	return rpcError(codes.NotFound, api.ErrorCategory_ERROR_CATEGORY_NOT_ACTIVE, service, hint, err)
+}
+
+// kubeError categorizes an error returned by the Kubernetes API while
+// working on service
+func kubeError(service string, err error) error {
+	switch {
+	case kerrors.IsNotFound(err):
+		return rpcError(codes.NotFound, api.ErrorCategory_ERROR_CATEGORY_NOT_FOUND, service,
+			"check the namespace and name, e.g. with kubectl get services --all-namespaces", err)
+	case kerrors.IsForbidden(err), kerrors.IsUnauthorized(err):
+		return rpcError(codes.PermissionDenied, api.ErrorCategory_ERROR_CATEGORY_FORBIDDEN, service,
+			"the daemon's kubeconfig user isn't allowed to do this, check its RBAC permissions", err)
+	}
+
+	return rpcError(codes.Unavailable, api.ErrorCategory_ERROR_CATEGORY_CLUSTER_UNAVAILABLE, service,
+		"check that the cluster is reachable, e.g. with kubectl get nodes", err)
+}
@@ -21,9 +21,11 @@ import (
 	"sync"
 
 	"github.com/getoutreach/localizer/internal/expose"
+	"github.com/getoutreach/localizer/internal/hooks"
 	"github.com/getoutreach/localizer/internal/kube"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
@@ -31,6 +33,32 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// parsePortRange parses a port, or an inclusive range of ports in the
+// format of start-end
+func parsePortRange(s string) (start, end uint64, err error) {
+	spl := strings.SplitN(s, "-", 2)
+	start, err = strconv.ParseUint(spl[0], 10, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end = start
+	if len(spl) == 2 {
+		end, err = strconv.ParseUint(spl[1], 10, 16)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if end < start {
+		return 0, 0, fmt.Errorf("range %s ends before it starts", s)
+	}
+	return start, end, nil
+}
+
+// mapPorts sets the local ports that service ports are exposed from, using
+// port maps in the format of local:remote. Either side can be a range, e.g.
+// 9000-9010:9000-9010, as long as both are the same size.
 func mapPorts(portMap []string, log logrus.FieldLogger, servicePorts []kube.ResolvedServicePort) error {
 	for _, portOverride := range portMap {
 		spl := strings.Split(portOverride, ":")
@@ -38,20 +66,26 @@ func mapPorts(portMap []string, log logrus.FieldLogger, servicePorts []kube.Reso
 			return fmt.Errorf("invalid port map '%s', expected 'local:remote'", portOverride)
 		}
 
-		local, err := strconv.ParseUint(spl[0], 10, 0)
+		localStart, localEnd, err := parsePortRange(spl[0])
 		if err != nil {
 			return errors.Wrapf(err, "failed to parse port map '%s'", portOverride)
 		}
 
-		rem, err := strconv.ParseUint(spl[1], 10, 0)
+		remStart, remEnd, err := parsePortRange(spl[1])
 		if err != nil {
 			return errors.Wrapf(err, "failed to parse port map '%s'", portOverride)
 		}
 
+		if localEnd-localStart != remEnd-remStart {
+			return fmt.Errorf("invalid port map '%s', local and remote ranges are different sizes", portOverride)
+		}
+
 		// TODO: this is slow...
 		for i, sp := range servicePorts {
-			log.Debugf("checking if we need to map %s, using %d:%d", sp.TargetPort.String(), rem, local)
-			if uint(servicePorts[i].TargetPort.IntValue()) == uint(rem) {
+			rem := uint64(sp.TargetPort.IntValue())
+			log.Debugf("checking if we need to map %s, using %s", sp.TargetPort.String(), portOverride)
+			if rem >= remStart && rem <= remEnd {
+				local := localStart + (rem - remStart)
 				log.Debugf("mapping remote port %d -> %d locally", rem, local)
 				servicePorts[i].MappedPort = uint(local)
 			}
@@ -65,6 +99,14 @@ type newExpose struct {
 	ports       []kube.ResolvedServicePort
 	namespace   string
 	serviceName string
+	annotations map[string]string
+
+	// logConnections logs connections to the service at info level
+	logConnections bool
+
+	// req is the request the expose was made from, so that it can be
+	// made again when the daemon restarts
+	req *api.ExposeServiceRequest
 }
 
 type Exposer struct {
@@ -73,29 +115,51 @@ type Exposer struct {
 	e     *expose.Client
 	log   logrus.FieldLogger
 
+	// hooks are ran when exposes start and stop
+	hooks *hooks.Runner
+
+	// annotations are the configured annotations to set while a service
+	// is exposed
+	annotations map[string]string
+
 	// parentCtx shuts down all exposers when canceled
 	parentCtx context.Context
 
 	portForwards map[string]context.CancelFunc
 	pfMutex      sync.Mutex
 
+	// active are the exposes that are currently running, and requests are
+	// the requests they were made from, protected by pfMutex
+	active   map[string]*expose.ServiceForward
+	requests map[string]*api.ExposeServiceRequest
+
+	// changed is sent to, without blocking, when an expose starts or stops
+	changed chan struct{}
+
 	workerChan chan newExpose
 	doneChan   chan struct{}
 }
 
 // NewExposer creates a service that can maintain multiple expose instances
-func NewExposer(parentCtx context.Context, k kubernetes.Interface, kconf *rest.Config, log logrus.FieldLogger) (*Exposer, error) {
+func NewExposer(parentCtx context.Context, k kubernetes.Interface, kconf *rest.Config, log logrus.FieldLogger,
+	hookRunner *hooks.Runner, annotations map[string]string, owner, mirrorAgentImage string) (*Exposer, error) {
 	log = log.WithField("component", "exposer")
 
-	e := expose.NewExposer(k, kconf, log)
+	e := expose.NewExposer(k, kconf, log, owner)
+	e.MirrorAgentImage = mirrorAgentImage
 
 	exp := &Exposer{
 		e:            e,
 		k:            k,
 		kconf:        kconf,
 		log:          log,
+		hooks:        hookRunner,
+		annotations:  annotations,
 		parentCtx:    parentCtx,
 		portForwards: make(map[string]context.CancelFunc),
+		active:       make(map[string]*expose.ServiceForward),
+		requests:     make(map[string]*api.ExposeServiceRequest),
+		changed:      make(chan struct{}, 1),
 		workerChan:   make(chan newExpose),
 		doneChan:     make(chan struct{}),
 	}
@@ -132,13 +196,16 @@ func (e *Exposer) worker() { //nolint:lostcancel,funlen
 				continue
 			}
 
-			exp, err := e.e.Expose(e.parentCtx, expMsg.ports, expMsg.namespace, expMsg.serviceName)
+			exp, err := e.e.Expose(e.parentCtx, expMsg.ports, expMsg.namespace, expMsg.serviceName, expMsg.annotations, expMsg.req.Mirror)
 			if err != nil {
 				// TODO: send this error back
 				e.log.WithError(err).Error("failed to create expose")
 				continue
 			}
 
+			exp.OnConnection = e.onConnection(expMsg)
+			started := e.onTunnelChange(expMsg, exp)
+
 			workerCtx, cancel := context.WithCancel(e.parentCtx)
 
 			// take lock so we can start the expose
@@ -147,26 +214,130 @@ func (e *Exposer) worker() { //nolint:lostcancel,funlen
 			// spin up goroutine that'll terminate itself later
 			go func(ctx context.Context) {
 				err := exp.Start(ctx)
+				ev := hooks.Event{Type: hooks.EventExposeStopped, Namespace: expMsg.namespace, Service: expMsg.serviceName}
 				if err != nil {
 					e.log.WithError(err).Error("expose exited with an error")
+					ev.Reason = err.Error()
 				}
 
+				// an expose that never came up didn't start, so it failed
+				// rather than stopped
+				if err != nil && !started() {
+					ev.Type = hooks.EventExposeFailed
+				}
+
+				// exposes stop when the daemon is shutting down, so the
+				// parent context can't be used here
+				e.hooks.Fire(context.Background(), ev)
+
 				// if we exited we need to signify that we're now not taken
 				e.pfMutex.Lock()
 				defer e.pfMutex.Unlock()
 
 				e.portForwards[key] = nil
+				delete(e.active, key)
+				delete(e.requests, key)
+				e.notify()
 
 				wg.Done()
 			}(workerCtx)
 
 			wg.Add(1)
 			e.portForwards[key] = cancel
+			e.active[key] = exp
+			e.requests[key] = expMsg.req
 			e.pfMutex.Unlock()
+			e.notify()
+		}
+	}
+}
+
+// onTunnelChange sets exp to fire hooks when its tunnel comes up, or fails
+// to, and returns a function that reports if it ever came up. Retries
+// of a tunnel that's already down don't fire the failure again.
+func (e *Exposer) onTunnelChange(expMsg newExpose, exp *expose.ServiceForward) func() bool {
+	var mu sync.Mutex
+	started, failed := false, false
+
+	exp.OnReady = func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		started, failed = true, false
+		e.hooks.Fire(e.parentCtx, hooks.Event{
+			Type:         hooks.EventExposeStarted,
+			Namespace:    expMsg.namespace,
+			Service:      expMsg.serviceName,
+			Intercepting: exp.Intercepting(),
+		})
+	}
+	exp.OnFailure = func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if failed {
+			return
 		}
+		failed = true
+
+		e.log.WithError(err).WithField("service", getKey(expMsg.namespace, expMsg.serviceName)).
+			Warn("tunnel to the cluster is down, retrying")
+		e.hooks.Fire(e.parentCtx, hooks.Event{
+			Type:      hooks.EventExposeFailed,
+			Namespace: expMsg.namespace,
+			Service:   expMsg.serviceName,
+			Reason:    err.Error(),
+		})
+	}
+
+	return func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return started
+	}
+}
+
+// onConnection returns a function that logs, and fires hooks for,
+// connections made to an exposed service
+func (e *Exposer) onConnection(expMsg newExpose) func(expose.Connection) {
+	log := e.log.WithField("service", getKey(expMsg.namespace, expMsg.serviceName))
+	logger := log.Debugf
+	if expMsg.logConnections {
+		logger = log.Infof
+	}
+
+	return func(conn expose.Connection) {
+		source := conn.Source
+		if conn.SourcePod != "" {
+			source = fmt.Sprintf("%s (%s)", conn.SourcePod, conn.Source)
+		}
+		logger("connection to port %d from %s", conn.Port.TargetPort.IntValue(), source)
+
+		e.hooks.Fire(e.parentCtx, hooks.Event{
+			Type:      hooks.EventExposeConnection,
+			Namespace: expMsg.namespace,
+			Service:   expMsg.serviceName,
+			Ports:     []string{fmt.Sprintf("%d:%d", conn.Port.MappedPort, conn.Port.TargetPort.IntValue())},
+			Source:    conn.Source,
+			SourcePod: conn.SourcePod,
+		})
 	}
 }
 
+// notify signals that the running exposes have changed
+func (e *Exposer) notify() {
+	select {
+	case e.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Changed returns a channel that is sent to when an expose starts or
+// stops
+func (e *Exposer) Changed() <-chan struct{} {
+	return e.changed
+}
+
 func (e *Exposer) Close(namespace, serviceName string) error {
 	k := getKey(namespace, serviceName)
 	if e.portForwards[k] == nil {
@@ -179,6 +350,37 @@ func (e *Exposer) Close(namespace, serviceName string) error {
 	return nil
 }
 
+// exposedService is a service that is being exposed
+type exposedService struct {
+	mode api.ServiceMode
+
+	// localAddresses are where traffic to the service is sent
+	localAddresses []string
+
+	// request is the request the expose was made from
+	request *api.ExposeServiceRequest
+}
+
+// List returns all services currently being exposed, keyed by
+// namespace/name
+func (e *Exposer) List() map[string]exposedService {
+	e.pfMutex.Lock()
+	defer e.pfMutex.Unlock()
+
+	services := make(map[string]exposedService, len(e.active))
+	for k, exp := range e.active {
+		mode := api.ServiceMode_SERVICE_MODE_EXPOSED
+		if exp.Intercepting() {
+			mode = api.ServiceMode_SERVICE_MODE_INTERCEPTED
+		} else if exp.Mirroring() {
+			mode = api.ServiceMode_SERVICE_MODE_MIRRORED
+		}
+		services[k] = exposedService{mode: mode, localAddresses: exp.LocalAddresses(), request: e.requests[k]}
+	}
+
+	return services
+}
+
 // Wait waits for all exposes to be shut down
 func (e *Exposer) Wait() {
 	<-e.doneChan
@@ -186,11 +388,24 @@ func (e *Exposer) Wait() {
 	e.log.Info("exposes cleaned up")
 }
 
-func (e *Exposer) Start(ports []kube.ResolvedServicePort, namespace, serviceName string) error {
+// Start exposes the service of a request on the given ports, the
+// request's annotations are merged over the configured annotations
+func (e *Exposer) Start(ports []kube.ResolvedServicePort, req *api.ExposeServiceRequest) error {
+	merged := make(map[string]string, len(e.annotations)+len(req.Annotations))
+	for k, v := range e.annotations {
+		merged[k] = v
+	}
+	for k, v := range req.Annotations {
+		merged[k] = v
+	}
+
 	e.workerChan <- newExpose{
-		ports:       ports,
-		namespace:   namespace,
-		serviceName: serviceName,
+		ports:          ports,
+		namespace:      req.Namespace,
+		serviceName:    req.Service,
+		annotations:    merged,
+		logConnections: req.LogConnections,
+		req:            req,
 	}
 
 	// TODO: propregate error
@@ -198,33 +413,42 @@ func (e *Exposer) Start(ports []kube.ResolvedServicePort, namespace, serviceName
 }
 
 func (h *GRPCServiceHandler) StopExpose(req *api.StopExposeRequest, res api.LocalizerService_StopExposeServer) error {
-	return h.exp.Close(req.Namespace, req.Service)
+	if err := h.exp.Close(req.Namespace, req.Service); err != nil {
+		return notActive(getKey(req.Namespace, req.Service), "'localizer list' shows the services being exposed", err)
+	}
+	return nil
 }
 
 func (h *GRPCServiceHandler) ExposeService(req *api.ExposeServiceRequest, res api.LocalizerService_ExposeServiceServer) error {
+	return h.expose(h.ctx, req)
+}
+
+// expose resolves the ports of the service in req and starts exposing it
+func (h *GRPCServiceHandler) expose(ctx context.Context, req *api.ExposeServiceRequest) error {
 	log := h.log
-	ctx := h.ctx
 
 	// discover the service's ports
 	key := fmt.Sprintf("%s/%s", req.Namespace, req.Service)
 	s, err := h.k.CoreV1().Services(req.Namespace).Get(ctx, req.Service, metav1.GetOptions{})
 	if err != nil {
-		return errors.Wrapf(err, "failed to get service '%s'", key)
+		return kubeError(key, errors.Wrapf(err, "failed to get service '%s'", key))
 	}
 
 	if len(s.Spec.Ports) == 0 {
-		return fmt.Errorf("service had no defined ports")
+		return rpcError(codes.FailedPrecondition, api.ErrorCategory_ERROR_CATEGORY_NO_PORTS, key,
+			"add a port to the service's spec.ports", fmt.Errorf("service had no defined ports"))
 	}
 
-	servicePorts, err := kube.ResolveServicePorts(log, s)
+	servicePorts, err := resolveExposePorts(log, s, req.TargetPorts)
 	if err != nil {
-		return errors.Wrap(err, "failed to resolve service ports")
+		return rpcError(codes.FailedPrecondition, api.ErrorCategory_ERROR_CATEGORY_INVALID_REQUEST, key,
+			"pass the container port with --target-port, or run 'localizer expose' in a terminal to pick it", err)
 	}
 
 	// handle mapped ports
 	if err := mapPorts(req.PortMap, log, servicePorts); err != nil {
-		return err
+		return invalidRequest(key, "%v", err)
 	}
 
-	return h.exp.Start(servicePorts, req.Namespace, req.Service)
+	return h.exp.Start(servicePorts, req)
 }
@@ -0,0 +1,121 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/internal/kevents"
+	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// portKey is the key of a service port in the target ports of an
+// ExposeServiceRequest, its name or its port if it has none
+func portKey(sp *corev1.ServicePort) string {
+	if sp.Name != "" {
+		return sp.Name
+	}
+	return strconv.Itoa(int(sp.Port))
+}
+
+// exposePorts returns the ports of a service, and the container ports that
+// each of their targetPorts may refer to
+func exposePorts(log logrus.FieldLogger, s *corev1.Service) []*api.ExposePort {
+	ports := make([]*api.ExposePort, len(s.Spec.Ports))
+	for i := range s.Spec.Ports {
+		sp := &s.Spec.Ports[i]
+		p := &api.ExposePort{Name: sp.Name, Port: uint32(sp.Port), TargetPort: sp.TargetPort.String()}
+		for _, c := range kube.TargetPortCandidates(log, kevents.GlobalCache, s, sp) {
+			p.Candidates = append(p.Candidates, uint32(c))
+		}
+		ports[i] = p
+	}
+	return ports
+}
+
+// resolveExposePorts resolves the targetPorts of a service to the
+// container ports they refer to, using targetPorts, keyed by portKey, for
+// ones that can't be. Each is exposed from the same port locally, since
+// that's what the service listens on when ran locally, unless it's mapped.
+func resolveExposePorts(log logrus.FieldLogger, s *corev1.Service,
+	targetPorts map[string]uint32) ([]kube.ResolvedServicePort, error) {
+	exposed := exposePorts(log, s)
+
+	resolved := make([]kube.ResolvedServicePort, len(s.Spec.Ports))
+	for i, sp := range s.Spec.Ports {
+		p := exposed[i]
+
+		var port uint32
+		switch override, ok := targetPorts[portKey(&sp)]; {
+		case ok:
+			if override == 0 || override > 65535 {
+				return nil, fmt.Errorf("invalid target port %d for port %s", override, portKey(&sp))
+			}
+			port = override
+		case len(p.Candidates) == 1:
+			port = p.Candidates[0]
+		case len(p.Candidates) == 0:
+			return nil, fmt.Errorf("no container declares the port targetPort '%s' of port %s refers to",
+				p.TargetPort, portKey(&sp))
+		default:
+			return nil, fmt.Errorf("targetPort '%s' of port %s could be any of %v",
+				p.TargetPort, portKey(&sp), p.Candidates)
+		}
+
+		original := ""
+		if sp.TargetPort.Type == intstr.String {
+			original = sp.TargetPort.StrVal
+		}
+		sp.TargetPort = intstr.FromInt(int(port))
+
+		resolved[i] = kube.ResolvedServicePort{
+			ServicePort:        sp,
+			OriginalTargetPort: original,
+			MappedPort:         uint(port),
+		}
+	}
+
+	return resolved, nil
+}
+
+// GetExposePorts returns the ports of a service that'd be exposed, so that
+// clients can ask which container port is meant when it's ambiguous
+func (h *GRPCServiceHandler) GetExposePorts(ctx context.Context,
+	req *api.GetExposePortsRequest) (*api.GetExposePortsResponse, error) {
+	if req.Namespace == "" || req.Service == "" {
+		return nil, invalidRequest("", "namespace and service are required")
+	}
+
+	key := req.Namespace + "/" + req.Service
+	s, err := h.k.CoreV1().Services(req.Namespace).Get(ctx, req.Service, metav1.GetOptions{})
+	if err != nil {
+		return nil, kubeError(key, errors.Wrapf(err, "failed to get service '%s'", key))
+	}
+
+	if len(s.Spec.Ports) == 0 {
+		return nil, rpcError(codes.FailedPrecondition, api.ErrorCategory_ERROR_CATEGORY_NO_PORTS, key,
+			"add a port to the service's spec.ports", fmt.Errorf("service had no defined ports"))
+	}
+
+	return &api.GetExposePortsResponse{Ports: exposePorts(h.log, s)}, nil
+}
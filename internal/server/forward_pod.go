@@ -0,0 +1,94 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// normalizePorts turns ports into local:remote pairs, a single port is
+// forwarded to the same port locally
+func normalizePorts(ports []string) []string {
+	normalized := make([]string, len(ports))
+	for i, port := range ports {
+		if !strings.Contains(port, ":") {
+			port = port + ":" + port
+		}
+		normalized[i] = port
+	}
+	return normalized
+}
+
+// podPhase describes the phase of pod, for errors
+func podPhase(pod *corev1.Pod) string {
+	if pod.DeletionTimestamp != nil {
+		return "terminating"
+	}
+	if pod.Status.Phase == "" {
+		return "pending"
+	}
+	return strings.ToLower(string(pod.Status.Phase))
+}
+
+// ForwardPod port-forwards directly to a pod
+func (h *GRPCServiceHandler) ForwardPod(ctx context.Context, req *api.ForwardPodRequest) (*api.Empty, error) {
+	if req.Namespace == "" || req.Name == "" {
+		return nil, invalidRequest("", "namespace and name are required")
+	}
+
+	key := req.Namespace + "/" + req.Name
+	pod, err := h.k.CoreV1().Pods(req.Namespace).Get(ctx, req.Name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil, rpcError(codes.NotFound, api.ErrorCategory_ERROR_CATEGORY_NOT_FOUND, key,
+			"check the namespace and name, e.g. with kubectl get pods --all-namespaces",
+			fmt.Errorf("pod '%s' doesn't exist", key))
+	} else if err != nil {
+		return nil, kubeError(key, errors.Wrapf(err, "failed to get pod '%s'", key))
+	}
+
+	// port-forwards to a pod that isn't running fail, there's nothing
+	// listening on the other end
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return nil, rpcError(codes.FailedPrecondition, api.ErrorCategory_ERROR_CATEGORY_NO_ENDPOINTS, key,
+			"wait for the pod to start, e.g. with kubectl wait --for=condition=Ready",
+			fmt.Errorf("pod '%s' isn't running, it's %s", key, podPhase(pod)))
+	}
+
+	ports := normalizePorts(req.Ports)
+	if err := h.p.ForwardPod(req.Namespace, req.Name, ports); err != nil {
+		return nil, invalidRequest(key, "%v", err)
+	}
+
+	h.log.WithField("pod", req.Namespace+"/"+req.Name).Infof("forwarding pod ports %v", ports)
+	return &api.Empty{}, nil
+}
+
+// StopForwardPod stops port-forwarding to a pod
+func (h *GRPCServiceHandler) StopForwardPod(ctx context.Context, req *api.StopForwardPodRequest) (*api.Empty, error) {
+	if err := h.p.StopForwardPod(req.Namespace, req.Name); err != nil {
+		return nil, notActive(req.Namespace+"/"+req.Name, "'localizer list' shows the pods being forwarded", err)
+	}
+
+	h.log.WithField("pod", req.Namespace+"/"+req.Name).Info("stopped forwarding pod")
+	return &api.Empty{}, nil
+}
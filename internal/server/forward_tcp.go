@@ -0,0 +1,76 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/getoutreach/localizer/api"
+)
+
+// ForwardTCP forwards to a host that's only reachable from inside of the
+// cluster, through a proxy pod
+func (h *GRPCServiceHandler) ForwardTCP(ctx context.Context, req *api.ForwardTCPRequest) (*api.Empty, error) {
+	if req.Namespace == "" || req.Host == "" || req.Port == 0 || req.Port > 65535 {
+		return nil, invalidRequest("", "namespace, host, and a valid port are required")
+	}
+	port := int(req.Port)
+
+	ports := normalizePorts(req.Ports)
+	if len(ports) == 0 {
+		ports = []string{fmt.Sprintf("%d:%d", port, port)}
+	}
+
+	// hostnames are added as-is so that things connecting to the host,
+	// e.g. with TLS, don't need to be changed
+	var hostnames []string
+	if net.ParseIP(req.Host) == nil {
+		hostnames = append(hostnames, req.Host)
+	}
+
+	key := req.Namespace + "/" + h.tcp.PodName(req.Host, port)
+	name, err := h.tcp.Create(ctx, req.Namespace, req.Host, port)
+	if err != nil {
+		return nil, kubeError(key, err)
+	}
+
+	if err := h.p.ForwardPod(req.Namespace, name, ports, hostnames...); err != nil {
+		//nolint:errcheck // Why: Best effort, the forward failing is the error we care about
+		h.tcp.Delete(context.Background(), req.Namespace, req.Host, port)
+		return nil, invalidRequest(key, "%v", err)
+	}
+
+	h.log.WithField("pod", req.Namespace+"/"+name).
+		Infof("forwarding %s through proxy pod", net.JoinHostPort(req.Host, strconv.Itoa(port)))
+	return &api.Empty{}, nil
+}
+
+// StopForwardTCP stops forwarding to a host, deleting its proxy pod
+func (h *GRPCServiceHandler) StopForwardTCP(ctx context.Context, req *api.StopForwardTCPRequest) (*api.Empty, error) {
+	port := int(req.Port)
+	key := req.Namespace + "/" + h.tcp.PodName(req.Host, port)
+	if err := h.p.StopForwardPod(req.Namespace, h.tcp.PodName(req.Host, port)); err != nil {
+		return nil, notActive(key, "'localizer list' shows the hosts being forwarded", err)
+	}
+
+	if err := h.tcp.Delete(ctx, req.Namespace, req.Host, port); err != nil {
+		return nil, kubeError(key, err)
+	}
+
+	h.log.Infof("stopped forwarding %s", net.JoinHostPort(req.Host, strconv.Itoa(port)))
+	return &api.Empty{}, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/pkg/proxier"
+)
+
+// gcOptions converts the options of the checks for deleted services to
+// their API form
+func gcOptions(opts proxier.GCOptions) *api.GCOptions {
+	return &api.GCOptions{
+		Enabled:            opts.Enabled,
+		IntervalSeconds:    int64(opts.Interval.Seconds()),
+		GracePeriodSeconds: int64(opts.GracePeriod.Seconds()),
+	}
+}
+
+// GetGCOptions returns the options of the checks for forwarded services that
+// were deleted without the daemon noticing
+func (h *GRPCServiceHandler) GetGCOptions(ctx context.Context, _ *api.Empty) (*api.GCOptions, error) {
+	return gcOptions(h.p.GCOptions()), nil
+}
+
+// SetGCOptions changes the options of the checks for forwarded services
+// that were deleted without the daemon noticing, at runtime
+func (h *GRPCServiceHandler) SetGCOptions(ctx context.Context, req *api.GCOptions) (*api.GCOptions, error) {
+	opts := proxier.GCOptions{
+		Enabled:     req.Enabled,
+		Interval:    time.Duration(req.IntervalSeconds) * time.Second,
+		GracePeriod: time.Duration(req.GracePeriodSeconds) * time.Second,
+	}
+	if err := h.p.SetGCOptions(opts); err != nil {
+		return nil, invalidRequest("", "%v", err)
+	}
+
+	return gcOptions(h.p.GCOptions()), nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/pkg/localizer"
+	"github.com/getoutreach/localizer/pkg/proxier"
+	"github.com/pkg/errors"
+)
+
+// groupHint is the hint given when a group has no services in it
+const groupHint = "services are put in groups with the " + proxier.GroupLabel +
+	" label, or groups in the configuration file"
+
+// SetGroupEnabled enables or disables forwarding every service in a group,
+// like SetServiceEnabled does for one
+func (h *GRPCServiceHandler) SetGroupEnabled(ctx context.Context,
+	req *api.SetGroupEnabledRequest) (*api.SetGroupEnabledResponse, error) {
+	if req.Group == "" {
+		return nil, invalidRequest("", "group is required")
+	}
+
+	members := h.p.GroupMembers(req.Group)
+	if len(members) == 0 {
+		return nil, notActive("", groupHint, fmt.Errorf("no services are in group '%s'", req.Group))
+	}
+
+	h.disabledMu.Lock()
+	defer h.disabledMu.Unlock()
+
+	for _, key := range members {
+		h.p.SetEnabled(key, req.Enabled)
+	}
+	if err := localizer.WriteDisabled(h.instance, h.p.Disabled()); err != nil {
+		return nil, errors.Wrap(err, "failed to persist disabled services")
+	}
+
+	h.log.WithField("group", req.Group).Infof("set %d service(s) enabled to %v", len(members), req.Enabled)
+	return &api.SetGroupEnabledResponse{Services: members}, nil
+}
+
+// Restart recreates the port-forwards of the requested services, and the
+// ones in the requested group
+func (h *GRPCServiceHandler) Restart(ctx context.Context, req *api.RestartRequest) (*api.RestartResponse, error) {
+	if len(req.Services) == 0 && req.Group == "" {
+		return nil, invalidRequest("", "services or a group are required")
+	}
+
+	keys := append([]string{}, req.Services...)
+	if req.Group != "" {
+		members := h.p.GroupMembers(req.Group)
+		if len(members) == 0 {
+			return nil, notActive("", groupHint, fmt.Errorf("no services are in group '%s'", req.Group))
+		}
+		keys = append(keys, members...)
+	}
+
+	restarted, err := h.p.Restart(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	h.log.Infof("restarted %d port-forward(s)", len(restarted))
+	return &api.RestartResponse{Services: restarted}, nil
+}
@@ -16,8 +16,13 @@ package server
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"net/http"
+	"net/http/pprof"
 	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
@@ -26,7 +31,11 @@ import (
 	"google.golang.org/grpc/reflection"
 
 	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/internal/config"
+	"github.com/getoutreach/localizer/internal/dnsserver"
+	"github.com/getoutreach/localizer/internal/hooks"
 	"github.com/getoutreach/localizer/internal/kevents"
+	"github.com/getoutreach/localizer/internal/privhelper"
 	"github.com/getoutreach/localizer/pkg/localizer"
 )
 
@@ -34,6 +43,9 @@ type GRPCService struct {
 	lis net.Listener
 	srv *grpc.Server
 
+	// socket is the path of the unix socket we listen on
+	socket string
+
 	opts *RunOpts
 }
 
@@ -41,11 +53,175 @@ type RunOpts struct {
 	ClusterDomain string
 	IPCidr        string
 	KubeContext   string
+
+	// InCluster requires the use of the in-cluster service account config
+	InCluster bool
+
+	// ListenAddress, if set, is a TCP address to also serve the gRPC
+	// API on. This is used when running in-cluster. Unless ListenToken is
+	// set, it must be a loopback address.
+	ListenAddress string
+
+	// ListenToken, if set, is the token clients must present to use the API
+	// over ListenAddress
+	ListenToken string
+
+	// HostsFile is the hosts file to write entries to
+	HostsFile string
+
+	// RedirectClusterIPs enables redirecting ClusterIP traffic to the
+	// local port-forwards via nftables.
+	RedirectClusterIPs bool
+
+	// Socket is the path of the unix socket to listen on, defaults to
+	// the socket of the instance.
+	Socket string
+
+	// Instance is the name of this instance of localizer, used to allow
+	// more than one to run on the same machine. Empty is the default instance.
+	Instance string
+
+	// IPAMFile, if set, is a file IPs are allocated from, rather than
+	// memory. Instances using the same file never get the same IPs.
+	IPAMFile string
+
+	// MaxTunnels, MaxConnections, and BufferSize limit the resources used
+	// by port-forwards, see proxier.ProxyOpts.
+	MaxTunnels     int
+	MaxConnections int
+	BufferSize     int
+
+	// MaxRecreates and RecreateWindow are the budget of recreations of a
+	// port-forward before it's failed, see proxier.ProxyOpts.
+	MaxRecreates   int
+	RecreateWindow time.Duration
+
+	// OperationTimeout is how long creating, or deleting, a port-forward
+	// can take before it's given up on
+	OperationTimeout time.Duration
+
+	// RecreateRate and RecreateBurst limit how quickly port-forwards are
+	// recreated
+	RecreateRate  float64
+	RecreateBurst int
+
+	// AuditLog, if set, is a file every RPC, who made it, and its result
+	// are appended to
+	AuditLog string
+
+	// DNSAddress, if set, is a UDP address to serve DNS for the hostnames
+	// of forwarded services on, including wildcards
+	DNSAddress string
+
+	// PprofAddress, if set, is a TCP address to serve net/http/pprof
+	// on. This should only be used for debugging.
+	PprofAddress string
+
+	// MetricsAddress, if set, is a TCP address to serve metrics of the
+	// daemon's use of the Kubernetes API on, in the Prometheus format
+	MetricsAddress string
+
+	// Namespaces, if set, are the only namespaces services are forwarded
+	// from
+	Namespaces []string
+
+	// PortNames, if set, are the only named service ports that are
+	// forwarded
+	PortNames []string
+
+	// IncludeSystemNamespaces forwards services in kube-system and other
+	// system namespaces when Namespaces isn't set
+	IncludeSystemNamespaces bool
+
+	// Node and Zone are where endpoints are chosen as if connections came
+	// from, see proxier.ProxyOpts
+	Node string
+	Zone string
+
+	// RelayAgentImage, if set, enables connecting to services through a
+	// single relay agent deployed in RelayAgentNamespace, rather than a
+	// port-forward per service
+	RelayAgentImage     string
+	RelayAgentNamespace string
+
+	// MirrorAgentImage is the image ran next to the SSH server of a
+	// mirrored expose, mirroring isn't possible without it
+	MirrorAgentImage string
+
+	// FollowContext stops the daemon with ErrContextChanged when the
+	// kubeconfig's current-context is changed, so it can be started again
+	// against it, instead of only warning about it
+	FollowContext bool
+
+	// Helper, if set, is used to do what needs root because we aren't
+	// running as root
+	Helper *privhelper.Client
+
+	// Unprivileged is set when we aren't running as root. The socket and
+	// pid file are kept in the user's ~/.localizer/run instead of /var/run.
+	Unprivileged bool
+
+	// DrainTimeout is how long connections are given to finish when a
+	// port-forward is deleted, see proxier.ProxyOpts
+	DrainTimeout time.Duration
+
+	// Services, if set, are the only services forwarded, by
+	// namespace/name, instead of the ones picked with --interactive
+	Services []string
+
+	// GCInterval is how often forwarded services are checked for having
+	// been deleted, GCGracePeriod how long they have to be missing for, and
+	// GCDisabled turns the checks off, see proxier.ProxyOpts
+	GCInterval    time.Duration
+	GCGracePeriod time.Duration
+	GCDisabled    bool
+
+	// RandomPorts forwards services on random ports of 127.0.0.1, see
+	// proxier.ProxyOpts
+	RandomPorts bool
+
+	// TCPProxyImage is the image of the pods used to forward to hosts
+	// outside of the cluster, defaults to tcpproxy.DefaultImage
+	TCPProxyImage string
+
+	// Config is the configuration file localizer was started with
+	Config *config.Config
+}
+
+// socketPath returns the unix socket the daemon should listen on
+func (o *RunOpts) socketPath() string {
+	if o.Socket != "" {
+		return o.Socket
+	}
+
+	if o.Unprivileged {
+		if socket, err := localizer.UnprivilegedSocketPath(o.Instance); err == nil {
+			return socket
+		}
+	}
+
+	return localizer.SocketPath(o.Instance)
+}
+
+// pidFile returns the file the daemon should write its pid to
+func (o *RunOpts) pidFile() string {
+	if o.Unprivileged {
+		if pidFile, err := localizer.UnprivilegedPidFile(o.Instance); err == nil {
+			return pidFile
+		}
+	}
+
+	return localizer.PidFile(o.Instance)
 }
 
 func NewGRPCService(opts *RunOpts) *GRPCService {
+	if opts.Config == nil {
+		opts.Config = &config.Config{}
+	}
+
 	return &GRPCService{
-		opts: opts,
+		opts:   opts,
+		socket: opts.socketPath(),
 	}
 }
 
@@ -56,7 +232,7 @@ func (g *GRPCService) CleanupPreviousInstance(ctx context.Context, log logrus.Fi
 	defer cancel()
 
 	log.Info("checking if an instance of localizer is already running")
-	client, closer, err := localizer.Connect(ctx, grpc.WithBlock(), grpc.WithInsecure())
+	client, closer, err := localizer.ConnectAddress(ctx, localizer.AddressFor(g.socket), grpc.WithBlock(), grpc.WithInsecure())
 
 	// if we made a connection, see if it's responding to pings
 	// eventually we can expose useful information here?
@@ -69,28 +245,43 @@ func (g *GRPCService) CleanupPreviousInstance(ctx context.Context, log logrus.Fi
 
 	log.Warn("failed to contact existing instance, cleaning up socket")
 
-	return errors.Wrap(os.Remove(localizer.Socket), "failed to cleanup socket from old localizer instance")
+	return errors.Wrap(os.Remove(g.socket), "failed to cleanup socket from old localizer instance")
 }
 
 // Run starts a grpc server with the internal server handler
 func (g *GRPCService) Run(ctx context.Context, log logrus.FieldLogger) error { //nolint:funlen
-	if _, err := os.Stat(localizer.Socket); err == nil {
-		// if we found an existing instance, attempt to cleanup after it
-		if err := g.CleanupPreviousInstance(ctx, log); err != nil {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if g.opts.ListenAddress != "" {
+		if err := checkListenAddress(g.opts.ListenAddress, g.opts.ListenToken); err != nil {
 			return err
 		}
 	}
 
-	l, err := net.Listen("unix", localizer.Socket)
-	if err != nil {
-		return errors.Wrap(err, "failed to listen on socket")
+	if g.opts.Unprivileged {
+		if err := os.MkdirAll(filepath.Dir(g.socket), 0700); err != nil {
+			return errors.Wrap(err, "failed to create runtime directory")
+		}
 	}
-	defer os.Remove(localizer.Socket)
 
-	err = os.Chmod(localizer.Socket, 0777)
+	l, cleanup, err := g.listen(ctx, log)
 	if err != nil {
 		return err
 	}
+	defer cleanup()
+
+	pidFile := g.opts.pidFile()
+	if err := ioutil.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return errors.Wrap(err, "failed to write pidfile")
+	}
+	defer os.Remove(pidFile)
+
+	// allow clients to find us if we're not on the default socket
+	if err := localizer.WriteState(g.opts.Instance, &localizer.State{Socket: g.socket, Pid: os.Getpid()}); err != nil {
+		log.WithError(err).Warn("failed to write state file, clients may not be able to find the daemon")
+	}
+	defer localizer.RemoveState(g.opts.Instance) //nolint:errcheck // Why: Best effort
 
 	g.lis = l
 
@@ -106,7 +297,19 @@ func (g *GRPCService) Run(ctx context.Context, log logrus.FieldLogger) error { /
 		return err
 	}
 
-	g.srv = grpc.NewServer()
+	serverOpts := []grpc.ServerOption{}
+	if g.opts.AuditLog != "" {
+		audit, err := newAuditor(g.opts.AuditLog)
+		if err != nil {
+			return err
+		}
+		defer audit.Close()
+
+		log.Infof("writing audit log to %s", g.opts.AuditLog)
+		serverOpts = append(serverOpts, grpc.UnaryInterceptor(audit.unary), grpc.StreamInterceptor(audit.stream))
+	}
+
+	g.srv = grpc.NewServer(serverOpts...)
 	reflection.Register(g.srv)
 	api.RegisterLocalizerServiceServer(g.srv, h)
 
@@ -118,7 +321,7 @@ func (g *GRPCService) Run(ctx context.Context, log logrus.FieldLogger) error { /
 	}()
 
 	// One day Serve() will accept a context?
-	log.Infof("starting GRPC server on unix://%s", localizer.Socket)
+	log.Infof("starting GRPC server on %s", localizer.AddressFor(g.socket))
 	go func() {
 		err := g.srv.Serve(g.lis)
 		if err != nil {
@@ -126,6 +329,96 @@ func (g *GRPCService) Run(ctx context.Context, log logrus.FieldLogger) error { /
 		}
 	}()
 
+	if g.opts.ListenAddress != "" {
+		tcpLis, err := net.Listen("tcp", g.opts.ListenAddress)
+		if err != nil {
+			return errors.Wrap(err, "failed to listen on tcp address")
+		}
+
+		// the TCP listener has its own server so that only it requires the
+		// token, clients of the socket are authenticated by their peer
+		tcpOpts := serverOpts
+		if g.opts.ListenToken != "" {
+			auth := &tokenAuth{token: g.opts.ListenToken}
+			tcpOpts = append(tcpOpts[:len(tcpOpts):len(tcpOpts)],
+				grpc.ChainUnaryInterceptor(auth.unary), grpc.ChainStreamInterceptor(auth.stream))
+		}
+		tcpSrv := grpc.NewServer(tcpOpts...)
+		reflection.Register(tcpSrv)
+		api.RegisterLocalizerServiceServer(tcpSrv, h)
+
+		go func() {
+			<-ctx.Done()
+			tcpSrv.GracefulStop()
+		}()
+
+		if g.opts.ListenToken == "" {
+			log.Warnf("starting GRPC server on tcp://%s, this is unauthenticated", g.opts.ListenAddress)
+		} else {
+			log.Infof("starting GRPC server on tcp://%s", g.opts.ListenAddress)
+		}
+		go func() {
+			err := tcpSrv.Serve(tcpLis)
+			if err != nil {
+				log.WithError(err).Error("grpc tcp server exited")
+			}
+		}()
+	}
+
+	if g.opts.DNSAddress != "" {
+		conn, err := net.ListenPacket("udp", g.opts.DNSAddress)
+		if err != nil {
+			return errors.Wrap(err, "failed to listen on dns address")
+		}
+
+		log.Infof("serving dns on udp://%s", g.opts.DNSAddress)
+		go func() {
+			if err := dnsserver.NewServer(log, h.p.Resolve).Serve(ctx, conn); err != nil {
+				log.WithError(err).Error("dns server exited")
+			}
+		}()
+	}
+
+	if g.opts.PprofAddress != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+		pprofSrv := &http.Server{Addr: g.opts.PprofAddress, Handler: mux}
+		go func() {
+			<-ctx.Done()
+			pprofSrv.Close()
+		}()
+
+		log.Warnf("serving pprof on http://%s/debug/pprof/", g.opts.PprofAddress)
+		go func() {
+			if err := pprofSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.WithError(err).Error("pprof server exited")
+			}
+		}()
+	}
+
+	if g.opts.MetricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/metrics", serveMetrics)
+
+		metricsSrv := &http.Server{Addr: g.opts.MetricsAddress, Handler: mux}
+		go func() {
+			<-ctx.Done()
+			metricsSrv.Close()
+		}()
+
+		log.Infof("serving metrics on http://%s/metrics", g.opts.MetricsAddress)
+		go func() {
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.WithError(err).Error("metrics server exited")
+			}
+		}()
+	}
+
 	//start the informers
 	kevents.GlobalCache.Start(ctx.Done())
 	log.Info("Waiting for caches to sync...")
@@ -134,13 +427,52 @@ func (g *GRPCService) Run(ctx context.Context, log logrus.FieldLogger) error { /
 
 	if err := h.exp.e.Start(ctx); err != nil {
 		log.WithError(err).Error("failed to start exposer")
+	} else {
+		// exposing waits on the cluster, which shouldn't hold up
+		// forwarding
+		go h.restoreExposes(ctx)
+	}
+
+	if err := h.tcp.CleanupAbandoned(ctx); err != nil {
+		log.WithError(err).Warn("failed to cleanup abandoned proxy pods")
 	}
 
+	go reportProgress(ctx, log, h.p)
+
+	snapshotsDone := make(chan struct{})
+	go func() {
+		defer close(snapshotsDone)
+		h.writeSnapshots(ctx)
+	}()
+	defer func() { <-snapshotsDone }()
+
+	contextChanged := make(chan struct{})
+	go watchContext(ctx, log, h.kconf, g.opts.FollowContext, func() {
+		close(contextChanged)
+		cancel()
+	})
+
 	if err := h.p.Start(ctx); err != nil {
 		log.WithError(err).Error("failed to start proxy informers")
 	}
 
 	h.exp.Wait()
 
-	return nil
+	// ctx is done, so proxy pods need their own to be deleted
+	tcpCtx, tcpCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer tcpCancel()
+	h.tcp.Close(tcpCtx)
+
+	// ctx is done at this point, so shutdown hooks get their own
+	hookCtx, hookCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer hookCancel()
+	h.hooks.Fire(hookCtx, hooks.Event{Type: hooks.EventShutdown})
+	h.hooks.Wait()
+
+	select {
+	case <-contextChanged:
+		return ErrContextChanged
+	default:
+		return nil
+	}
 }
@@ -15,6 +15,9 @@ package server
 
 import (
 	"context"
+	"net"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -23,8 +26,14 @@ import (
 
 	///StartBlock(imports)
 	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/internal/hooks"
+	"github.com/getoutreach/localizer/internal/ipam"
+	"github.com/getoutreach/localizer/internal/kevents"
 	"github.com/getoutreach/localizer/internal/kube"
-	"github.com/getoutreach/localizer/internal/proxier"
+	"github.com/getoutreach/localizer/internal/relayagent"
+	"github.com/getoutreach/localizer/internal/tcpproxy"
+	"github.com/getoutreach/localizer/pkg/localizer"
+	"github.com/getoutreach/localizer/pkg/proxier"
 	///EndBlock(imports)
 )
 
@@ -41,10 +50,143 @@ type GRPCServiceHandler struct {
 	ctx   context.Context
 	exp   *Exposer
 	p     *proxier.Proxier
+
+	// started is when this handler was created
+	started time.Time
+
+	// socket is the unix socket the daemon is listening on
+	socket string
+
+	// hooks are the user's configured hooks
+	hooks *hooks.Runner
+
+	// tcp runs the proxy pods used to forward to hosts outside of the
+	// cluster
+	tcp *tcpproxy.Manager
+
+	// instance is the name of this instance, used to persist the services
+	// that have been disabled. disabledMu serializes writing them.
+	instance   string
+	disabledMu sync.Mutex
+
+	// previous is the snapshot of the previous run of this instance
+	previous *localizer.Snapshot
+
+	// opts are the options the daemon was started with, and services are
+	// the only services being forwarded, if they were restricted
+	opts     *RunOpts
+	services []string
 	///EndBlock(grpcConfig)
 }
 
 ///StartBlock(global)
+
+// proxyOpts returns the options of the proxier for opts, and the
+// snapshot of the previous run of the instance they were made from
+func proxyOpts(log logrus.FieldLogger, opts *RunOpts, hookRunner *hooks.Runner) (*proxier.ProxyOpts, *localizer.Snapshot, error) { //nolint:lll
+	disabled, err := localizer.ReadDisabled(opts.Instance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selected, err := localizer.ReadSelected(opts.Instance)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(opts.Services) > 0 {
+		selected = opts.Services
+		log.Infof("only forwarding the %d services given as arguments", len(selected))
+	} else if len(selected) > 0 {
+		log.Infof("only forwarding the %d services picked with --interactive, --clear-selection forgets them", len(selected))
+	}
+
+	snapshot, err := localizer.ReadSnapshot(opts.Instance)
+	if err != nil {
+		log.WithError(err).Warn("failed to read snapshot of the previous run, starting from scratch")
+	}
+	if snapshot == nil {
+		snapshot = &localizer.Snapshot{Clean: true}
+	}
+
+	var newAllocator proxier.IPAllocatorFunc
+	if opts.IPAMFile != "" {
+		owner := opts.Instance
+		if owner == "" {
+			owner = "default"
+		}
+		newAllocator = func(cidr string, reserved ...net.IP) (proxier.IPAllocator, error) {
+			return ipam.NewFile(opts.IPAMFile, owner, cidr, reserved...)
+		}
+	}
+
+	popts := &proxier.ProxyOpts{
+		ClusterDomain:      opts.ClusterDomain,
+		IPCidr:             opts.IPCidr,
+		IPAllocator:        newAllocator,
+		HostsFile:          opts.HostsFile,
+		RedirectClusterIPs: opts.RedirectClusterIPs,
+		Instance:           opts.Instance,
+		MaxTunnels:         opts.MaxTunnels,
+		MaxConnections:     opts.MaxConnections,
+		BufferSize:         opts.BufferSize,
+		MaxRecreates:       opts.MaxRecreates,
+		RecreateWindow:     opts.RecreateWindow,
+		RecreateRate:       opts.RecreateRate,
+		RecreateBurst:      opts.RecreateBurst,
+		OperationTimeout:   opts.OperationTimeout,
+		Priorities:         opts.Config.Priorities(),
+		Compress:           opts.Config.Compress(),
+		LeaderLocks:        opts.Config.LeaderLocks(),
+		FanOut:             opts.Config.FanOut(),
+		HostnameCollisions: opts.Config.Hostnames.Collisions,
+		HostnamePriority:   opts.Config.Hostnames.Priority,
+		HostnameSuffixes:   opts.Config.Hostnames.Suffixes,
+		Wildcards:          opts.Config.Wildcards(),
+		Groups:             opts.Config.Groups(),
+		RandomPorts:        opts.RandomPorts,
+		DrainTimeout:       opts.DrainTimeout,
+		GCInterval:         opts.GCInterval,
+		GCGracePeriod:      opts.GCGracePeriod,
+		GCDisabled:         opts.GCDisabled,
+		PreviousIPs:        snapshot.IPs(),
+		CleanupPrevious:    !snapshot.Clean,
+		Hooks:              proxierHooks(hookRunner),
+		Informers:          kevents.GlobalCache,
+		Namespaces:         opts.Namespaces,
+		PortNames:          opts.PortNames,
+		ServicePortNames:   opts.Config.PortNames(),
+		Disabled:           disabled,
+		Services:           selected,
+
+		IncludeSystemNamespaces: opts.IncludeSystemNamespaces,
+		Node:                    opts.Node,
+		Zone:                    opts.Zone,
+	}
+
+	// a nil *privhelper.Client isn't a nil proxier.Helper
+	if opts.Helper != nil {
+		popts.Helper = opts.Helper
+	}
+
+	return popts, snapshot, nil
+}
+
+// proxierHooks returns a proxier.HookFunc that runs the hooks of r
+func proxierHooks(r *hooks.Runner) proxier.HookFunc {
+	return func(ctx context.Context, e proxier.HookEvent) {
+		r.Fire(ctx, hooks.Event{
+			Type:      hooks.EventType(e.Type),
+			Namespace: e.Namespace,
+			Service:   e.Service,
+			Endpoint:  e.Endpoint,
+			IP:        e.IP,
+			Hostnames: e.Hostnames,
+			Ports:     e.Ports,
+			Reason:    e.Reason,
+		})
+	}
+}
+
 ///EndBlock(global)
 
 func NewServiceHandler(ctx context.Context, log logrus.FieldLogger, opts *RunOpts) (*GRPCServiceHandler, error) {
@@ -52,20 +194,38 @@ func NewServiceHandler(ctx context.Context, log logrus.FieldLogger, opts *RunOpt
 	log = log.WithField("service", "*api.GRPCServiceHandler")
 
 	// TODO: pass context
-	kconf, k, err := kube.GetKubeClient(opts.KubeContext)
+	kconf, k, err := kube.GetKubeClient(log, opts.KubeContext)
+	if opts.InCluster {
+		kconf, k, err = kube.GetInClusterKubeClient()
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create kube client")
 	}
 
-	exp, err := NewExposer(ctx, k, kconf, log)
+	hookRunner, err := hooks.NewRunner(log, opts.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load hooks")
+	}
+
+	owner, err := localizer.OwnerID(opts.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	exp, err := NewExposer(ctx, k, kconf, log, hookRunner, opts.Config.Expose.Annotations, owner, opts.MirrorAgentImage)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to start expose container")
 	}
 
-	p, err := proxier.NewProxier(ctx, k, kconf, log, &proxier.ProxyOpts{
-		ClusterDomain: opts.ClusterDomain,
-		IPCidr:        opts.IPCidr,
-	})
+	popts, snapshot, err := proxyOpts(log, opts, hookRunner)
+	if err != nil {
+		return nil, err
+	}
+	if opts.RelayAgentImage != "" {
+		popts.ServiceDialer = relayagent.NewClient(ctx, k, kconf, log, opts.RelayAgentNamespace, opts.RelayAgentImage).Dial
+	}
+
+	p, err := proxier.NewProxier(ctx, k, kconf, log, popts)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to create proxier")
 	}
@@ -79,6 +239,17 @@ func NewServiceHandler(ctx context.Context, log logrus.FieldLogger, opts *RunOpt
 		ctx:   ctx,
 		exp:   exp,
 		p:     p,
+
+		started: time.Now(),
+		socket:  opts.socketPath(),
+		hooks:   hookRunner,
+		tcp:     tcpproxy.NewManager(k, log, opts.TCPProxyImage, owner),
+
+		instance: opts.Instance,
+		previous: snapshot,
+
+		opts:     opts,
+		services: popts.Services,
 		///EndBlock(grpcConfigInit)
 	}, nil
 }
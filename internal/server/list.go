@@ -15,21 +15,105 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"net"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/getoutreach/localizer/pkg/proxier"
+	"k8s.io/client-go/tools/cache"
 )
 
+// probeLocalTargets checks if something is listening on each of the given
+// addresses
+func probeLocalTargets(addresses []string) []*api.LocalTarget {
+	targets := make([]*api.LocalTarget, len(addresses))
+
+	wg := sync.WaitGroup{}
+	for i, addr := range addresses {
+		targets[i] = &api.LocalTarget{Address: addr}
+
+		wg.Add(1)
+		go func(t *api.LocalTarget) {
+			defer wg.Done()
+
+			conn, err := net.DialTimeout("tcp", t.Address, 500*time.Millisecond)
+			if err == nil {
+				conn.Close()
+				t.Reachable = true
+			}
+		}(targets[i])
+	}
+	wg.Wait()
+
+	return targets
+}
+
+// hasProblem returns if a service isn't working, and it wasn't stopped on
+// purpose
+func hasProblem(s *api.ListService) bool {
+	switch s.Status {
+	case string(proxier.PortForwardStatusRunning):
+		for _, t := range s.LocalTargets {
+			if !t.Reachable {
+				return true
+			}
+		}
+		return false
+	case string(proxier.PortForwardStatusPaused), "disabled":
+		return false
+	}
+	return true
+}
+
+// listKey returns the key services are ordered by when paginating
+func listKey(s *api.ListService) string {
+	return s.Namespace + "/" + s.Name
+}
+
+// paginate returns the page of services after the one the token was
+// returned for, and the token of the next page. Pages are split on service
+// keys, rather than offsets, so services being added or removed between
+// requests don't cause any to be skipped or returned twice.
+func paginate(services []*api.ListService, size uint32, token string) ([]*api.ListService, string, error) {
+	sort.Slice(services, func(i, j int) bool { return listKey(services[i]) < listKey(services[j]) })
+
+	if token != "" {
+		after, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid page token")
+		}
+
+		start := sort.Search(len(services), func(i int) bool { return listKey(services[i]) > string(after) })
+		services = services[start:]
+	}
+
+	if size == 0 || len(services) <= int(size) {
+		return services, "", nil
+	}
+
+	services = services[:size]
+	return services, base64.RawURLEncoding.EncodeToString([]byte(listKey(services[size-1]))), nil
+}
+
 func (h *GRPCServiceHandler) List(ctx context.Context, req *api.ListRequest) (*api.ListResponse, error) {
 	statuses, err := h.p.List(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	exposed := h.exp.List()
+
+	listed := make(map[string]bool)
 	services := make([]*api.ListService, len(statuses))
 	for i := range statuses {
 		s := &statuses[i]
+		listed[s.ServiceInfo.Key()] = true
 
 		ports := make([]string, len(s.Ports))
 		for i, p := range s.Ports {
@@ -47,16 +131,113 @@ func (h *GRPCServiceHandler) List(ctx context.Context, req *api.ListRequest) (*a
 			}
 		}
 
+		mode := api.ServiceMode_SERVICE_MODE_FORWARDED
+		var localTargets []*api.LocalTarget
+		if exp, ok := exposed[s.ServiceInfo.Key()]; ok {
+			mode = exp.mode
+			localTargets = probeLocalTargets(exp.localAddresses)
+			delete(exposed, s.ServiceInfo.Key())
+		}
+
+		// pods that are forwarded directly are shown like kubectl does
+		name := s.ServiceInfo.Name
+		if s.ServiceInfo.Kind == proxier.PodKind {
+			name = "pod/" + name
+		}
+
+		// only the most recent few reasons are useful
+		recreations := s.Recreations
+		if len(recreations) > 3 {
+			recreations = recreations[len(recreations)-3:]
+		}
+		lastRecreations := make([]*api.Recreation, len(recreations))
+		for i, r := range recreations {
+			lastRecreations[i] = &api.Recreation{Time: r.Time.Unix(), Reason: r.Reason}
+		}
+
+		protocols := make([]*api.PortProtocol, len(s.Protocols))
+		for i, p := range s.Protocols {
+			protocols[i] = &api.PortProtocol{Port: p.Port, Protocol: string(p.Protocol), Detected: p.Detected}
+		}
+
 		services[i] = &api.ListService{
 			Namespace:    s.ServiceInfo.Namespace,
-			Name:         s.ServiceInfo.Name,
+			Name:         name,
 			Endpoint:     s.Endpoint.Name,
 			StatusReason: s.Reason,
 			Status:       string(s.Statuses[0]),
 			Ip:           s.IP,
 			Ports:        ports,
+			Mode:         mode,
+			LocalTargets: localTargets,
+
+			RecreateCount:   uint32(s.RecreateCount),
+			LastRecreations: lastRecreations,
+			Hostnames:       s.Hostnames,
+			Groups:          s.Groups,
+			Protocols:       protocols,
+		}
+	}
+
+	// include exposed services that we aren't forwarding
+	for key, exp := range exposed {
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			continue
+		}
+		listed[key] = true
+
+		services = append(services, &api.ListService{
+			Namespace:    namespace,
+			Name:         name,
+			Status:       string(proxier.PortForwardStatusRunning),
+			Mode:         exp.mode,
+			LocalTargets: probeLocalTargets(exp.localAddresses),
+		})
+	}
+
+	// and services that have been disabled
+	for _, key := range h.p.Disabled() {
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil || listed[key] {
+			continue
 		}
+
+		services = append(services, &api.ListService{
+			Namespace:    namespace,
+			Name:         name,
+			Status:       "disabled",
+			StatusReason: "Disabled with 'localizer disable'.",
+			Mode:         api.ServiceMode_SERVICE_MODE_FORWARDED,
+			Groups:       h.p.GroupsOf(key),
+		})
+	}
+
+	if req.ProblemsOnly {
+		filtered := make([]*api.ListService, 0)
+		for _, s := range services {
+			if hasProblem(s) {
+				filtered = append(filtered, s)
+			}
+		}
+		services = filtered
+	}
+
+	services, next, err := paginate(services, req.PageSize, req.PageToken)
+	if err != nil {
+		return nil, invalidRequest("", "%v", err)
+	}
+
+	// every service comes from the same context, for now
+	kubeContext, currentKubeContext, _ := kube.Context(h.kconf)
+	for _, s := range services {
+		s.KubeContext = kubeContext
 	}
 
-	return &api.ListResponse{Services: services}, nil
+	return &api.ListResponse{
+		Services:           services,
+		KubeContext:        kubeContext,
+		CurrentKubeContext: currentKubeContext,
+		NextPageToken:      next,
+	}, nil
 }
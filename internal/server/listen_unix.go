@@ -0,0 +1,85 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// listen creates the unix socket the daemon is served on, cleaning up after
+// a previous instance if needed. The returned function removes the socket.
+func (g *GRPCService) listen(ctx context.Context, log logrus.FieldLogger) (net.Listener, func(), error) {
+	if _, err := os.Stat(g.socket); err == nil {
+		// if we found an existing instance, attempt to cleanup after it
+		if err := g.CleanupPreviousInstance(ctx, log); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// only the user that started us (and root) should be able to talk to us,
+	// when ran through sudo that's the user that invoked sudo. The socket is
+	// created in a private directory, and only moved into place once its
+	// permissions are set, so that there's no window where anyone else can
+	// connect to it.
+	dir, err := ioutil.TempDir(filepath.Dir(g.socket), ".localizer-")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create socket directory")
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck // Why: Best effort
+
+	tmpSocket := filepath.Join(dir, filepath.Base(g.socket))
+	l, err := net.Listen("unix", tmpSocket)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to listen on socket")
+	}
+
+	// the socket is moved, so it's removed by cleanup instead of on close
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	if err := os.Chmod(tmpSocket, 0600); err != nil {
+		l.Close()
+		return nil, nil, errors.Wrap(err, "failed to change permissions of socket")
+	}
+
+	uid, uidErr := strconv.Atoi(os.Getenv("SUDO_UID"))
+	gid, gidErr := strconv.Atoi(os.Getenv("SUDO_GID"))
+	if uidErr == nil && gidErr == nil {
+		if err := os.Chown(tmpSocket, uid, gid); err != nil {
+			l.Close()
+			return nil, nil, errors.Wrap(err, "failed to change owner of socket")
+		}
+	}
+
+	if err := os.Rename(tmpSocket, g.socket); err != nil {
+		l.Close()
+		return nil, nil, errors.Wrap(err, "failed to move socket into place")
+	}
+	cleanup := func() {
+		os.Remove(g.socket) //nolint:errcheck // Why: Best effort
+	}
+
+	return newPeerCredListener(log, l, allowedPeerUIDs()), cleanup, nil
+}
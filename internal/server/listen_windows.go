@@ -0,0 +1,46 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/user"
+
+	"github.com/Microsoft/go-winio"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// listen creates the named pipe the daemon is served on. Only SYSTEM,
+// Administrators, and the user running the daemon are allowed to open it.
+// Named pipes are removed by Windows when we exit, so there's nothing to
+// cleanup.
+func (g *GRPCService) listen(_ context.Context, _ logrus.FieldLogger) (net.Listener, func(), error) {
+	u, err := user.Current()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get current user")
+	}
+
+	l, err := winio.ListenPipe(g.socket, &winio.PipeConfig{
+		// Uid is the user's SID on Windows
+		SecurityDescriptor: fmt.Sprintf("D:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;GA;;;%s)", u.Uid),
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to listen on named pipe, is localizer already running?")
+	}
+
+	return l, func() {}, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/getoutreach/localizer/api"
+)
+
+// rootLogger returns the logger that backs a FieldLogger, if there is one
+func rootLogger(log logrus.FieldLogger) *logrus.Logger {
+	switch l := log.(type) {
+	case *logrus.Logger:
+		return l
+	case *logrus.Entry:
+		return l.Logger
+	}
+
+	return nil
+}
+
+// SetLogLevel changes the level of the daemon's logger at runtime
+func (h *GRPCServiceHandler) SetLogLevel(ctx context.Context, req *api.SetLogLevelRequest) (*api.SetLogLevelResponse, error) {
+	level, err := logrus.ParseLevel(req.Level)
+	if err != nil {
+		return nil, invalidRequest("", "invalid log level '%s', expected one of: trace, debug, info, warn, error",
+			req.Level)
+	}
+
+	l := rootLogger(h.log)
+	if l == nil {
+		return nil, fmt.Errorf("logger doesn't support changing levels")
+	}
+
+	previous := l.GetLevel()
+	l.SetLevel(level)
+	h.log.WithField("previous", previous.String()).Infof("log level set to %s", level)
+
+	return &api.SetLogLevelResponse{
+		PreviousLevel: previous.String(),
+	}, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/getoutreach/localizer/internal/kube"
+)
+
+// serveMetrics writes the daemon's use of the Kubernetes API in the
+// Prometheus text format
+func serveMetrics(w http.ResponseWriter, _ *http.Request) {
+	usage := kube.Usage()
+
+	requests := make([]kube.APIRequest, 0, len(usage.Requests))
+	for r := range usage.Requests {
+		requests = append(requests, r)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		if a.Verb != b.Verb {
+			return a.Verb < b.Verb
+		}
+		return a.Code < b.Code
+	})
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP localizer_kube_api_requests_total Requests made to the Kubernetes API.")
+	fmt.Fprintln(w, "# TYPE localizer_kube_api_requests_total counter")
+	for _, r := range requests {
+		fmt.Fprintf(w, "localizer_kube_api_requests_total{verb=%q,resource=%q,code=\"%d\"} %d\n",
+			r.Verb, r.Resource, r.Code, usage.Requests[r])
+	}
+
+	fmt.Fprintln(w, "# HELP localizer_kube_api_throttled_requests_total Requests delayed by the client side rate limit.")
+	fmt.Fprintln(w, "# TYPE localizer_kube_api_throttled_requests_total counter")
+	fmt.Fprintf(w, "localizer_kube_api_throttled_requests_total %d\n", usage.Throttled)
+
+	fmt.Fprintln(w, "# HELP localizer_kube_api_throttled_seconds_total Time requests were delayed by the client side rate limit.")
+	fmt.Fprintln(w, "# TYPE localizer_kube_api_throttled_seconds_total counter")
+	fmt.Fprintf(w, "localizer_kube_api_throttled_seconds_total %g\n", usage.ThrottledTime.Seconds())
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+
+	"github.com/getoutreach/localizer/api"
+)
+
+// Pause closes the tunnels of every port-forward, until Resume is called
+func (h *GRPCServiceHandler) Pause(ctx context.Context, _ *api.Empty) (*api.Empty, error) {
+	if err := h.p.Pause(ctx); err != nil {
+		return nil, err
+	}
+
+	h.log.Info("paused all port-forwards")
+	return &api.Empty{}, nil
+}
+
+// Resume recreates the port-forwards closed by Pause
+func (h *GRPCServiceHandler) Resume(ctx context.Context, _ *api.Empty) (*api.Empty, error) {
+	if err := h.p.Resume(ctx); err != nil {
+		return nil, err
+	}
+
+	h.log.Info("resuming all port-forwards")
+	return &api.Empty{}, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package server
+
+import (
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// allowedPeerUIDs returns the UIDs that are allowed to talk to the daemon
+// over its unix socket: root, the user running the daemon, and the user that
+// invoked sudo to start it.
+func allowedPeerUIDs() map[int]bool {
+	uids := map[int]bool{0: true, os.Getuid(): true}
+	if uid, err := strconv.Atoi(os.Getenv("SUDO_UID")); err == nil {
+		uids[uid] = true
+	}
+
+	return uids
+}
+
+// peerCredListener is a unix socket listener that rejects connections
+// from users that aren't allowed to talk to the daemon
+type peerCredListener struct {
+	net.Listener
+
+	log     logrus.FieldLogger
+	allowed map[int]bool
+}
+
+// newPeerCredListener wraps l, only accepting connections from allowed
+func newPeerCredListener(log logrus.FieldLogger, l net.Listener, allowed map[int]bool) net.Listener {
+	return &peerCredListener{l, log, allowed}
+}
+
+// Accept waits for a connection from an allowed peer
+func (l *peerCredListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		uc, ok := conn.(*net.UnixConn)
+		if !ok {
+			return conn, nil
+		}
+
+		uid, err := peerUID(uc)
+		if err != nil {
+			l.log.WithError(err).Warn("rejecting connection, failed to get peer credentials")
+			conn.Close()
+			continue
+		}
+
+		// -1 means this platform can't tell us, rely on the socket's permissions
+		if uid == -1 {
+			return conn, nil
+		}
+
+		if !l.allowed[uid] {
+			l.log.WithField("uid", uid).Warn("rejecting connection from unauthorized user")
+			conn.Close()
+			continue
+		}
+
+		// make who connected available to the audit log
+		return &peerConn{conn, peerAddr{uid}}, nil
+	}
+}
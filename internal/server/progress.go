@@ -0,0 +1,62 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/getoutreach/localizer/pkg/proxier"
+)
+
+// maxWaitingShown is the number of waiting services listed in a progress
+// report, past this only the number of them is shown
+const maxWaitingShown = 5
+
+// reportProgress logs how many port-forwards have been created until the
+// proxier becomes stable, rather than leaving the user to guess from the
+// log lines of each service
+func reportProgress(ctx context.Context, log logrus.FieldLogger, p *proxier.Proxier) {
+	t := time.NewTicker(2 * time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		prog := p.Progress()
+		if p.IsStable() && prog.Queued == 0 {
+			log.Infof("created %d/%d port-forwards, %d waiting for endpoints or a free slot",
+				prog.Running, prog.Total, len(prog.Waiting))
+			return
+		}
+
+		waiting := prog.Waiting
+		if len(waiting) > maxWaitingShown {
+			waiting = append(waiting[:maxWaitingShown:maxWaitingShown], "...")
+		}
+
+		l := log.WithField("queued", prog.Queued)
+		if len(waiting) > 0 {
+			l = l.WithField("waiting", strings.Join(waiting, ","))
+		}
+		l.Infof("created %d/%d port-forwards", prog.Running, prog.Total)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"sort"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/pkg/proxier"
+)
+
+// Record streams the HTTP requests made through the port-forward of a
+// service until the client goes away
+func (h *GRPCServiceHandler) Record(req *api.RecordRequest, res api.LocalizerService_RecordServer) error {
+	if req.Namespace == "" || req.Service == "" {
+		return invalidRequest("", "namespace and service are required")
+	}
+
+	key := req.Namespace + "/" + req.Service
+	if !recordable(h.p.Protocols(key)) {
+		return invalidRequest(key, "none of the service's ports speak HTTP/1.1, only HTTP/1.1 requests can be recorded")
+	}
+
+	requests, err := h.p.Record(res.Context(), key)
+	if err != nil {
+		return notActive(key, "'localizer list' shows the services being forwarded", err)
+	}
+
+	h.log.WithField("service", key).Info("recording requests")
+	defer h.log.WithField("service", key).Info("stopped recording requests")
+
+	for r := range requests {
+		names := make([]string, 0, len(r.Header))
+		for name := range r.Header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		headers := make([]*api.RecordedHeader, 0, len(r.Header))
+		for _, name := range names {
+			for _, v := range r.Header[name] {
+				headers = append(headers, &api.RecordedHeader{Name: name, Value: v})
+			}
+		}
+
+		err := res.Send(&api.RecordedRequest{
+			Time:          r.Time.UnixNano(),
+			Port:          r.Port,
+			Method:        r.Method,
+			Uri:           r.URI,
+			Host:          r.Host,
+			Headers:       headers,
+			Body:          r.Body,
+			BodyTruncated: r.BodyTruncated,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordable returns if any of protocols could be HTTP/1.1, ports that
+// haven't been used yet might be
+func recordable(protocols []proxier.PortProtocol) bool {
+	if len(protocols) == 0 {
+		return true
+	}
+
+	for _, p := range protocols {
+		if p.Protocol == proxier.ProtocolHTTP || !p.Detected {
+			return true
+		}
+	}
+	return false
+}
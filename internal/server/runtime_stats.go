@@ -0,0 +1,100 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/getoutreach/localizer/pkg/proxier"
+)
+
+// GetRuntimeStats returns information about the daemon process, for
+// diagnosing memory growth and goroutine leaks.
+func (h *GRPCServiceHandler) GetRuntimeStats(ctx context.Context,
+	req *api.GetRuntimeStatsRequest) (*api.GetRuntimeStatsResponse, error) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	forwarded := 0
+	if statuses, err := h.p.List(ctx); err == nil {
+		for i := range statuses {
+			for _, status := range statuses[i].Statuses {
+				if status == proxier.PortForwardStatusRunning {
+					forwarded++
+					break
+				}
+			}
+		}
+	}
+
+	// the proxier may not be running yet
+	ipPool, _ := h.p.IPPoolUsage() //nolint:errcheck // Why: Reported as empty
+
+	collisions := []*api.HostnameCollision{}
+	for _, c := range h.p.HostnameCollisions() {
+		collisions = append(collisions, &api.HostnameCollision{Hostname: c.Hostname, Services: c.Services, Owner: c.Owner})
+	}
+
+	kubeContext, currentKubeContext, _ := kube.Context(h.kconf)
+
+	usage := kube.Usage()
+	apiRequests := make([]*api.KubeAPIRequests, 0, len(usage.Requests))
+	for r, n := range usage.Requests {
+		apiRequests = append(apiRequests, &api.KubeAPIRequests{Verb: r.Verb, Resource: r.Resource, Code: int32(r.Code), Count: n})
+	}
+	sort.Slice(apiRequests, func(i, j int) bool {
+		return apiRequests[i].Count > apiRequests[j].Count
+	})
+
+	goroutineDump := ""
+	if req.GoroutineDump {
+		var buf bytes.Buffer
+		if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+			return nil, errors.Wrap(err, "failed to dump goroutines")
+		}
+		goroutineDump = buf.String()
+	}
+
+	return &api.GetRuntimeStatsResponse{
+		Goroutines:       int64(runtime.NumGoroutine()),
+		HeapAllocBytes:   mem.HeapAlloc,
+		HeapObjects:      mem.HeapObjects,
+		SysBytes:         mem.Sys,
+		NumGc:            mem.NumGC,
+		ForwardedTunnels: int64(forwarded),
+		ExposedTunnels:   int64(len(h.exp.List())),
+		UptimeSeconds:    int64(time.Since(h.started).Seconds()),
+		GoroutineDump:    goroutineDump,
+		IpPoolCidr:       ipPool.CIDR,
+		IpPoolAcquired:   ipPool.Acquired,
+		IpPoolAvailable:  ipPool.Available,
+
+		HostnameCollisions: collisions,
+		KubeContext:        kubeContext,
+		CurrentKubeContext: currentKubeContext,
+
+		KubeApiRequests:         apiRequests,
+		KubeApiThrottled:        usage.Throttled,
+		KubeApiThrottledSeconds: usage.ThrottledTime.Seconds(),
+	}, nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/pkg/localizer"
+	"github.com/pkg/errors"
+)
+
+// SetServiceEnabled enables or disables forwarding a service. Disabled
+// services stay disabled across restarts of the daemon.
+func (h *GRPCServiceHandler) SetServiceEnabled(ctx context.Context,
+	req *api.SetServiceEnabledRequest) (*api.Empty, error) {
+	if req.Namespace == "" || req.Service == "" {
+		return nil, invalidRequest("", "namespace and service are required")
+	}
+
+	h.disabledMu.Lock()
+	defer h.disabledMu.Unlock()
+
+	h.p.SetEnabled(req.Namespace+"/"+req.Service, req.Enabled)
+	if err := localizer.WriteDisabled(h.instance, h.p.Disabled()); err != nil {
+		return nil, errors.Wrap(err, "failed to persist disabled services")
+	}
+
+	h.log.WithField("service", req.Namespace+"/"+req.Service).Infof("set service enabled to %v", req.Enabled)
+	return &api.Empty{}, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"sort"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/internal/kube"
+)
+
+// redactedValue replaces the values of variables from secrets, unless they
+// were asked for
+const redactedValue = "<redacted>"
+
+// GetServiceEnv returns the environment of a pod backing a service, used
+// to run a local process like the service's pods are. The values of
+// variables from secrets are redacted unless req.ShowSecrets is set.
+func (h *GRPCServiceHandler) GetServiceEnv(ctx context.Context,
+	req *api.GetServiceEnvRequest) (*api.GetServiceEnvResponse, error) {
+	if req.Namespace == "" || req.Service == "" {
+		return nil, invalidRequest("", "namespace and service are required")
+	}
+
+	key := req.Namespace + "/" + req.Service
+	pod, env, secret, err := kube.ServiceEnv(ctx, h.k, req.Namespace, req.Service)
+	if err != nil {
+		return nil, kubeError(key, err)
+	}
+
+	secretKeys := make([]string, 0, len(secret))
+	for name := range secret {
+		secretKeys = append(secretKeys, name)
+		if _, ok := env[name]; ok && !req.ShowSecrets {
+			env[name] = redactedValue
+		}
+	}
+	sort.Strings(secretKeys)
+
+	return &api.GetServiceEnvResponse{Pod: pod, Env: env, SecretKeys: secretKeys}, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getoutreach/localizer/api"
+	"github.com/getoutreach/localizer/pkg/localizer"
+)
+
+// snapshotDelay is how long changes are batched up for before a snapshot
+// is written, so starting up doesn't write one per service
+const snapshotDelay = time.Second
+
+// snapshot returns what we're currently forwarding
+func (h *GRPCServiceHandler) snapshot(ctx context.Context) (*localizer.Snapshot, error) {
+	statuses, err := h.p.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exposed := h.exp.List()
+
+	s := &localizer.Snapshot{Forwards: make([]localizer.SnapshotForward, 0, len(statuses))}
+	for i := range statuses {
+		status := &statuses[i]
+		key := status.ServiceInfo.Key()
+
+		mode := api.ServiceMode_SERVICE_MODE_FORWARDED
+		if exp, ok := exposed[key]; ok {
+			mode = exp.mode
+		}
+
+		s.Forwards = append(s.Forwards, localizer.SnapshotForward{
+			Service:   key,
+			IP:        status.IP,
+			Ports:     status.Ports,
+			Hostnames: status.Hostnames,
+			Mode:      strings.ToLower(strings.TrimPrefix(mode.String(), "SERVICE_MODE_")),
+		})
+	}
+
+	for key, exp := range exposed {
+		if exp.request == nil {
+			continue
+		}
+		s.Exposes = append(s.Exposes, localizer.SnapshotExpose{
+			Service:        key,
+			PortMap:        exp.request.PortMap,
+			TargetPorts:    exp.request.TargetPorts,
+			Annotations:    exp.request.Annotations,
+			LogConnections: exp.request.LogConnections,
+			Mirror:         exp.request.Mirror,
+		})
+	}
+	sort.Slice(s.Exposes, func(i, j int) bool { return s.Exposes[i].Service < s.Exposes[j].Service })
+
+	return s, nil
+}
+
+// restoreExposes exposes the services that were being exposed when the
+// previous run of this instance died without cleaning up. Controllers that
+// were left scaled down by it have already been scaled back up by the
+// exposer, so they're scaled down again here. Nothing is restored after a
+// clean exit, as the exposes were stopped on purpose.
+func (h *GRPCServiceHandler) restoreExposes(ctx context.Context) {
+	if h.previous.Clean || len(h.previous.Exposes) == 0 {
+		return
+	}
+
+	h.log.Infof("exposing the %d services that were exposed before the daemon restarted", len(h.previous.Exposes))
+	for i := range h.previous.Exposes {
+		e := &h.previous.Exposes[i]
+		log := h.log.WithField("service", e.Service)
+
+		spl := strings.SplitN(e.Service, "/", 2)
+		if len(spl) != 2 {
+			log.Warn("skipping invalid exposed service in snapshot")
+			continue
+		}
+
+		err := h.expose(ctx, &api.ExposeServiceRequest{
+			Namespace:      spl[0],
+			Service:        spl[1],
+			PortMap:        e.PortMap,
+			TargetPorts:    e.TargetPorts,
+			Annotations:    e.Annotations,
+			LogConnections: e.LogConnections,
+			Mirror:         e.Mirror,
+		})
+		if err != nil {
+			log.WithError(err).Warn("failed to expose service again")
+		}
+	}
+}
+
+// writeSnapshots writes a snapshot whenever a port-forward changes, until
+// ctx is canceled. The last one is then written again, marked as clean,
+// as port-forwards being deleted while shutting down isn't a change the
+// next run should see. Exposes are stopped when shutting down, so they're
+// dropped from it.
+func (h *GRPCServiceHandler) writeSnapshots(ctx context.Context) {
+	events := h.p.Subscribe(ctx)
+
+	// until the first change, what the previous run had is what's left
+	// behind if we don't exit cleanly
+	last := &localizer.Snapshot{Forwards: h.previous.Forwards, Exposes: h.previous.Exposes}
+	if err := localizer.WriteSnapshot(h.instance, last); err != nil {
+		h.log.WithError(err).Warn("failed to write snapshot")
+	}
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			last.Clean = true
+			last.Exposes = nil
+			if err := localizer.WriteSnapshot(h.instance, last); err != nil {
+				h.log.WithError(err).Warn("failed to write snapshot")
+			}
+			return
+		case <-events:
+			if pending == nil {
+				pending = time.After(snapshotDelay)
+			}
+		case <-h.exp.Changed():
+			if pending == nil {
+				pending = time.After(snapshotDelay)
+			}
+		case <-pending:
+			pending = nil
+
+			s, err := h.snapshot(ctx)
+			if err != nil {
+				h.log.WithError(err).Debug("failed to take snapshot")
+				continue
+			}
+			if err := localizer.WriteSnapshot(h.instance, s); err != nil {
+				h.log.WithError(err).Warn("failed to write snapshot")
+				continue
+			}
+			last = s
+		}
+	}
+}
@@ -12,3 +12,22 @@ func (g *GRPCServiceHandler) Stable(ctx context.Context, _ *api.Empty) (*api.Sta
 		Stable: g.p.IsStable(),
 	}, nil
 }
+
+// Ready implements the Ready RPC, reporting if the daemon has finished
+// starting up and how far along it is
+func (g *GRPCServiceHandler) Ready(ctx context.Context, _ *api.Empty) (*api.ReadyResponse, error) {
+	// checked first, so that a port-forward changing in between can only
+	// make it not ready
+	stable := g.p.IsStable()
+	prog := g.p.Progress()
+
+	return &api.ReadyResponse{
+		Ready:    stable && prog.Queued == 0,
+		Stable:   stable,
+		Queued:   int64(prog.Queued),
+		Creating: int64(prog.Creating),
+		Running:  int64(prog.Running),
+		Total:    int64(prog.Total),
+		Waiting:  prog.Waiting,
+	}, nil
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"strings"
 	"sync"
@@ -25,6 +26,7 @@ import (
 	"strconv"
 
 	"github.com/function61/gokit/io/bidipipe"
+	"github.com/getoutreach/localizer/internal/mirroragent"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
@@ -43,8 +45,29 @@ type Client struct {
 	// ports is the ports this client currently hosts
 	// with the format being remotePort localPort
 	ports map[uint]uint
+
+	// OnConnection, if set, is called with the remote port and source
+	// address of every connection accepted from the remote server
+	OnConnection func(remotePort uint, source net.Addr)
+
+	// OnReady, if set, is called once the remote server is listening on
+	// every port
+	OnReady func()
+
+	// Mirror is set when connections are copies sent by a mirror agent,
+	// see the mirroragent package. The remote server only listens for them
+	// on its loopback address, and responses to them are thrown away.
+	Mirror bool
+
+	// Control, if set, is ran once the tunnel is up with a function that
+	// dials addresses from the remote server, until the tunnel goes down
+	Control func(ctx context.Context, dial func(addr string) (net.Conn, error))
 }
 
+// mirrorLinger is how long the local service has to finish responding to a
+// mirrored connection once the client is done, before it's closed
+const mirrorLinger = 30 * time.Second
+
 // NewReverseTunnelClient creates a new ssh powered reverse
 // tunnel client
 func NewReverseTunnelClient(l logrus.FieldLogger, host string, port int, ports []string) *Client {
@@ -84,7 +107,7 @@ func NewReverseTunnelClient(l logrus.FieldLogger, host string, port int, ports [
 
 		portMap[uint(remotePort)] = uint(localPort)
 	}
-	return &Client{l, host, port, portMap}
+	return &Client{log: l, host: host, port: port, ports: portMap}
 }
 
 // Start starts the ssh tunnel. This blocks until
@@ -147,6 +170,9 @@ func (c *Client) Start(ctx context.Context, serviceKey string) error { //nolint:
 	for remotePort, localPort := range c.ports {
 		// reverse listen on remote server port
 		remoteAddr := fmt.Sprintf("0.0.0.0:%d", remotePort)
+		if c.Mirror {
+			remoteAddr = fmt.Sprintf("127.0.0.1:%d", remotePort)
+		}
 		localAddr := fmt.Sprintf("127.0.0.1:%d", localPort)
 		listener, err := sshClient.Listen("tcp", remoteAddr)
 		if err != nil {
@@ -177,11 +203,29 @@ func (c *Client) Start(ctx context.Context, serviceKey string) error { //nolint:
 
 				// handle the connection in another goroutine, so we can support multiple concurrent
 				// connections on the same port
+				if c.Mirror {
+					go c.handleMirroredConn(client, remotePort, localAddr)
+					continue
+				}
+
+				if c.OnConnection != nil {
+					c.OnConnection(remotePort, client.RemoteAddr())
+				}
 				go c.handleReverseForwardConn(client, localAddr)
 			}
 		}(remotePort)
 	}
 
+	if c.OnReady != nil {
+		c.OnReady()
+	}
+
+	if c.Control != nil {
+		go c.Control(ctx, func(addr string) (net.Conn, error) {
+			return sshClient.Dial("tcp", addr)
+		})
+	}
+
 	wg.Wait()
 
 	return nil
@@ -208,3 +252,42 @@ func (c *Client) handleReverseForwardConn(client net.Conn, localAddr string) {
 		c.log.WithError(err).Warnf("failed to send data over tunnel")
 	}
 }
+
+// handleMirroredConn sends a copy of a connection, sent by a mirror agent,
+// to the local service. The client is served by the agent, so the local
+// service's responses are thrown away.
+func (c *Client) handleMirroredConn(client net.Conn, remotePort uint, localAddr string) {
+	defer client.Close()
+
+	source, r, err := mirroragent.ReadSource(client)
+	if err != nil {
+		c.log.WithError(err).Warn("failed to read mirrored connection")
+		return
+	}
+	if addr, err := net.ResolveTCPAddr("tcp", source); err == nil && c.OnConnection != nil {
+		c.OnConnection(remotePort, addr)
+	}
+
+	local, err := net.Dial("tcp", localAddr)
+	if err != nil {
+		c.log.WithError(err).Warn("failed to dial local service, not mirroring connection")
+		return
+	}
+	defer local.Close()
+
+	done := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, local) //nolint:errcheck // Why: Responses are ignored
+		close(done)
+	}()
+
+	io.Copy(local, r) //nolint:errcheck // Why: Either side closing ends the copy
+	if tcp, ok := local.(*net.TCPConn); ok {
+		tcp.CloseWrite() //nolint:errcheck // Why: Best effort
+	}
+
+	select {
+	case <-done:
+	case <-time.After(mirrorLinger):
+	}
+}
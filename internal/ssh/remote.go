@@ -0,0 +1,137 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// RemoteDialer dials unix sockets on a remote host over SSH. This is used
+// to talk to a localizer daemon running on another machine.
+type RemoteDialer struct {
+	client *ssh.Client
+}
+
+// defaultKeyFiles are the private keys, relative to ~/.ssh, that are tried
+// when connecting to a remote host, much like OpenSSH does.
+var defaultKeyFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// authMethods returns the available auth methods, preferring the ssh-agent
+func authMethods(homeDir string) []ssh.AuthMethod {
+	methods := []ssh.AuthMethod{}
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	signers := []ssh.Signer{}
+	for _, name := range defaultKeyFiles {
+		b, err := ioutil.ReadFile(filepath.Join(homeDir, ".ssh", name))
+		if err != nil {
+			continue
+		}
+
+		// encrypted keys aren't supported, use an agent for those
+		signer, err := ssh.ParsePrivateKey(b)
+		if err != nil {
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	return methods
+}
+
+// NewRemoteDialer connects to the host described by remote, which is in the
+// format of ssh://[user@]host[:port]. Host keys are verified against
+// ~/.ssh/known_hosts.
+func NewRemoteDialer(ctx context.Context, remote string) (*RemoteDialer, error) {
+	u, err := url.Parse(remote)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse remote")
+	}
+
+	if u.Scheme != "ssh" {
+		return nil, fmt.Errorf("unsupported remote scheme '%s', expected ssh://", u.Scheme)
+	}
+
+	cu, err := user.Current()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get current user")
+	}
+
+	username := cu.Username
+	if u.User != nil && u.User.Username() != "" {
+		username = u.User.Username()
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "22"
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+
+	hostKeyCallback, err := knownhosts.New(filepath.Join(cu.HomeDir, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load known_hosts")
+	}
+
+	dialer := net.Dialer{
+		Timeout: 10 * time.Second,
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	sconn, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            username,
+		Auth:            authMethods(cu.HomeDir),
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "failed to connect to %s", addr)
+	}
+
+	return &RemoteDialer{ssh.NewClient(sconn, chans, reqs)}, nil
+}
+
+// Dial opens a connection to the unix socket at path on the remote host
+func (d *RemoteDialer) Dial(_ context.Context, path string) (net.Conn, error) {
+	return d.client.Dial("unix", path)
+}
+
+// Close closes the underlying SSH connection
+func (d *RemoteDialer) Close() error {
+	return d.client.Close()
+}
@@ -0,0 +1,206 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package tcpproxy runs pods that relay TCP connections to hosts that are
+// only reachable from inside of the cluster, e.g. a database in the
+// cluster's VPC, so they can be port-forwarded to like any other pod.
+package tcpproxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PodLabel is the label on proxy pods
+const PodLabel = "localizer.jaredallard.github.com/tcp-proxy"
+
+// OwnerLabel is the label on proxy pods with the owner ID of the localizer
+// that created them
+const OwnerLabel = "localizer.jaredallard.github.com/tcp-proxy-owner"
+
+// DefaultImage is the default image of proxy pods, it must have socat as its
+// entrypoint
+const DefaultImage = "alpine/socat"
+
+// invalidNameChars are characters that can't be in a pod name
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// PodName returns the name of the proxy pod owner creates for a target.
+// The owner is part of the name so that two localizers forwarding to the
+// same target don't share, and delete, each other's pod.
+func PodName(owner, host string, port int) string {
+	name := fmt.Sprintf("localizer-tcp-%s-%s-%d", owner,
+		strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(host), "-"), "-"), port)
+	if len(name) <= 63 {
+		return name
+	}
+
+	// keep it a valid DNS label, while staying unique
+	sum := sha256.Sum256([]byte(owner + "/" + net.JoinHostPort(host, strconv.Itoa(port))))
+	return strings.TrimRight(name[:52], "-") + fmt.Sprintf("-%x", sum[:5])
+}
+
+// pod returns the proxy pod owner creates for a target
+func pod(namespace, image, owner, host string, port int) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PodName(owner, host, port),
+			Namespace: namespace,
+			Labels:    map[string]string{PodLabel: "true", OwnerLabel: owner},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyAlways,
+			Containers: []corev1.Container{
+				{
+					Name:            "socat",
+					Image:           image,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Args: []string{
+						fmt.Sprintf("TCP-LISTEN:%d,fork,reuseaddr", port),
+						"TCP:" + net.JoinHostPort(host, strconv.Itoa(port)),
+					},
+					Ports: []corev1.ContainerPort{{ContainerPort: int32(port), Protocol: corev1.ProtocolTCP}},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("10m"),
+							corev1.ResourceMemory: resource.MustParse("16Mi"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Manager creates and deletes proxy pods
+type Manager struct {
+	k     kubernetes.Interface
+	log   logrus.FieldLogger
+	image string
+
+	// owner is the owner ID of this localizer, only pods with it are
+	// touched
+	owner string
+
+	// mu protects pods, the proxy pods that have been created, keyed by
+	// namespace/name
+	mu   sync.Mutex
+	pods map[string]bool
+}
+
+// NewManager returns a manager that creates proxy pods owned by owner using
+// image, or DefaultImage if it's empty
+func NewManager(k kubernetes.Interface, log logrus.FieldLogger, image, owner string) *Manager {
+	if image == "" {
+		image = DefaultImage
+	}
+
+	return &Manager{
+		k:     k,
+		log:   log.WithField("component", "tcpproxy"),
+		image: image,
+		owner: owner,
+		pods:  make(map[string]bool),
+	}
+}
+
+// PodName returns the name of the proxy pod for a target
+func (m *Manager) PodName(host string, port int) string {
+	return PodName(m.owner, host, port)
+}
+
+// Create creates a pod in namespace that relays connections on port to
+// host:port, returning its name. An existing pod for the target is reused.
+func (m *Manager) Create(ctx context.Context, namespace, host string, port int) (string, error) {
+	po := pod(namespace, m.image, m.owner, host, port)
+
+	_, err := m.k.CoreV1().Pods(namespace).Create(ctx, po, metav1.CreateOptions{})
+	if err != nil && !kerrors.IsAlreadyExists(err) {
+		return "", errors.Wrap(err, "failed to create proxy pod")
+	}
+
+	m.mu.Lock()
+	m.pods[namespace+"/"+po.Name] = true
+	m.mu.Unlock()
+
+	m.log.WithField("pod", namespace+"/"+po.Name).Infof("created proxy pod for %s", net.JoinHostPort(host, strconv.Itoa(port)))
+	return po.Name, nil
+}
+
+// Delete deletes the proxy pod for a target
+func (m *Manager) Delete(ctx context.Context, namespace, host string, port int) error {
+	name := m.PodName(host, port)
+
+	m.mu.Lock()
+	delete(m.pods, namespace+"/"+name)
+	m.mu.Unlock()
+
+	err := m.k.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to delete proxy pod")
+	}
+	return nil
+}
+
+// Close deletes every proxy pod that was created by this manager
+func (m *Manager) Close(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.pods {
+		spl := strings.SplitN(key, "/", 2)
+		err := m.k.CoreV1().Pods(spl[0]).Delete(ctx, spl[1], metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			m.log.WithError(err).WithField("pod", key).Warn("failed to delete proxy pod")
+		}
+		delete(m.pods, key)
+	}
+}
+
+// CleanupAbandoned deletes proxy pods left behind by a previous run of this
+// localizer that didn't exit cleanly. Pods of other owners are left alone,
+// they may still be in use.
+func (m *Manager) CleanupAbandoned(ctx context.Context) error {
+	pods, err := m.k.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true,%s=%s", PodLabel, OwnerLabel, m.owner),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list proxy pods")
+	}
+
+	for i := range pods.Items {
+		p := &pods.Items[i]
+		log := m.log.WithField("pod", p.Namespace+"/"+p.Name)
+		log.Warn("removing abandoned proxy pod")
+		err := m.k.CoreV1().Pods(p.Namespace).Delete(ctx, p.Name, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			log.WithError(err).Warn("failed to remove abandoned proxy pod")
+		}
+	}
+
+	return nil
+}
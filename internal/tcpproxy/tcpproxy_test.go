@@ -0,0 +1,37 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package tcpproxy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPodName(t *testing.T) {
+	if got, want := PodName("0a1b2c3d", "MyDB.us-east-1.rds.amazonaws.com", 5432), "localizer-tcp-0a1b2c3d-mydb-us-east-1-rds-amazonaws-com-5432"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	long := strings.Repeat("a", 60) + ".example.com"
+	a, b := PodName("0a1b2c3d", long, 5432), PodName("0a1b2c3d", long, 5433)
+	if len(a) > 63 || len(b) > 63 {
+		t.Fatalf("expected names to be valid DNS labels, got %q and %q", a, b)
+	}
+	if a == b {
+		t.Fatalf("expected different targets to have different names, both got %q", a)
+	}
+	if c := PodName("4e5f6a7b", long, 5432); c == a {
+		t.Fatalf("expected different owners to have different names, both got %q", a)
+	}
+}
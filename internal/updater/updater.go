@@ -0,0 +1,344 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package updater implements checking for, and installing, new releases
+// of localizer from GitHub.
+package updater
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ReleasesURL is the GitHub API endpoint for the latest release
+const ReleasesURL = "https://api.github.com/repos/getoutreach/localizer/releases/latest"
+
+// checkInterval is how often CheckForUpdate will contact GitHub, results
+// are cached in between.
+const checkInterval = 24 * time.Hour
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Release is a GitHub release
+type Release struct {
+	TagName string  `json:"tag_name"`
+	Assets  []Asset `json:"assets"`
+}
+
+// asset returns the asset with the given name
+func (r *Release) asset(name string) (*Asset, error) {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("release %s has no asset %s", r.TagName, name)
+}
+
+// ArchiveName returns the name of the release archive for this platform,
+// e.g. localizer_1.9.0_linux_amd64.tar.gz. This must match the archive
+// name_template in .goreleaser.yml.
+func (r *Release) ArchiveName() string {
+	return fmt.Sprintf("localizer_%s_%s_%s.tar.gz",
+		strings.TrimPrefix(r.TagName, "v"), runtime.GOOS, runtime.GOARCH)
+}
+
+// get performs a GET request and returns the body
+func get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got unexpected status %s from %s", resp.Status, url)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// LatestRelease returns the latest release of localizer
+func LatestRelease(ctx context.Context) (*Release, error) {
+	b, err := get(ctx, ReleasesURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get latest release")
+	}
+
+	var r Release
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, errors.Wrap(err, "failed to parse release")
+	}
+
+	return &r, nil
+}
+
+// parseVersion parses a version in the format of vX.Y.Z into its parts,
+// pre-release and build information is ignored.
+func parseVersion(v string) ([3]int, error) {
+	parts := [3]int{}
+
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i != -1 {
+		v = v[:i]
+	}
+
+	split := strings.Split(v, ".")
+	if len(split) != 3 {
+		return parts, fmt.Errorf("invalid version '%s'", v)
+	}
+
+	for i, s := range split {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return parts, fmt.Errorf("invalid version '%s'", v)
+		}
+		parts[i] = n
+	}
+
+	return parts, nil
+}
+
+// IsNewer returns true if latest is a newer version than current
+func IsNewer(current, latest string) bool {
+	c, err := parseVersion(current)
+	if err != nil {
+		return false
+	}
+
+	l, err := parseVersion(latest)
+	if err != nil {
+		return false
+	}
+
+	for i := range c {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+
+	return false
+}
+
+// cachedCheck is the last result of CheckForUpdate
+type cachedCheck struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+// CheckForUpdate returns the latest version if it's newer than current.
+// GitHub is only contacted once a day, with results being cached in the
+// user's cache directory.
+func CheckForUpdate(ctx context.Context, current string) (string, error) {
+	cacheFile := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheFile = filepath.Join(dir, "localizer", "update-check.json")
+	}
+
+	var cached cachedCheck
+	if b, err := ioutil.ReadFile(cacheFile); err == nil && json.Unmarshal(b, &cached) == nil &&
+		time.Since(cached.CheckedAt) < checkInterval {
+		if IsNewer(current, cached.Latest) {
+			return cached.Latest, nil
+		}
+		return "", nil
+	}
+
+	r, err := LatestRelease(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if cacheFile != "" {
+		if b, err := json.Marshal(cachedCheck{time.Now(), r.TagName}); err == nil {
+			// a failure to cache just means we check again next time
+			if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err == nil {
+				ioutil.WriteFile(cacheFile, b, 0644) //nolint:errcheck // Why: Best effort
+			}
+		}
+	}
+
+	if IsNewer(current, r.TagName) {
+		return r.TagName, nil
+	}
+	return "", nil
+}
+
+// ParsePublicKey parses a base64 encoded ed25519 public key, the key the
+// checksums of releases are signed with
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode public key")
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key, expected %d bytes, got %d", ed25519.PublicKeySize, len(b))
+	}
+
+	return ed25519.PublicKey(b), nil
+}
+
+// verifySignature checks that sig is a signature of checksums by key
+func verifySignature(key ed25519.PublicKey, checksums, sig []byte) error {
+	if !ed25519.Verify(key, checksums, sig) {
+		return fmt.Errorf("checksums.txt isn't signed by the release key")
+	}
+	return nil
+}
+
+// checksumFor finds the checksum of name in a goreleaser checksums.txt
+func checksumFor(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return fields[0], nil
+		}
+	}
+
+	return "", fmt.Errorf("no checksum found for %s", name)
+}
+
+// extractBinary returns the localizer binary from a release archive
+func extractBinary(archive []byte) ([]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read archive")
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read archive")
+		}
+
+		if h.Typeflag == tar.TypeReg && filepath.Base(h.Name) == "localizer" {
+			return ioutil.ReadAll(tr)
+		}
+	}
+
+	return nil, fmt.Errorf("archive didn't contain a localizer binary")
+}
+
+// Install downloads the given release for this platform, verifies it
+// against the release's checksums, whose signature is verified with key,
+// and replaces the binary at path with it. The checksums come from the
+// same place as the release, so on their own they only catch corrupted
+// downloads.
+func Install(ctx context.Context, r *Release, path string, key ed25519.PublicKey) error {
+	archiveAsset, err := r.asset(r.ArchiveName())
+	if err != nil {
+		return err
+	}
+
+	checksumsAsset, err := r.asset("checksums.txt")
+	if err != nil {
+		return err
+	}
+
+	signatureAsset, err := r.asset("checksums.txt.sig")
+	if err != nil {
+		return err
+	}
+
+	checksums, err := get(ctx, checksumsAsset.URL)
+	if err != nil {
+		return errors.Wrap(err, "failed to download checksums")
+	}
+
+	sig, err := get(ctx, signatureAsset.URL)
+	if err != nil {
+		return errors.Wrap(err, "failed to download signature of checksums")
+	}
+
+	if err := verifySignature(key, checksums, sig); err != nil {
+		return err
+	}
+
+	expected, err := checksumFor(checksums, archiveAsset.Name)
+	if err != nil {
+		return err
+	}
+
+	archive, err := get(ctx, archiveAsset.URL)
+	if err != nil {
+		return errors.Wrap(err, "failed to download release")
+	}
+
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); got != expected {
+		return fmt.Errorf("checksum mismatch for %s, expected %s got %s", archiveAsset.Name, expected, got)
+	}
+
+	bin, err := extractBinary(archive)
+	if err != nil {
+		return err
+	}
+
+	// write next to the existing binary so that the rename is atomic
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".localizer-upgrade-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(bin); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to write new binary")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to write new binary")
+	}
+
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return errors.Wrap(err, "failed to make new binary executable")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), path), "failed to replace binary")
+}
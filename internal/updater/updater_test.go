@@ -0,0 +1,85 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package updater
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"io/ioutil"
+	"regexp"
+	"runtime"
+	"testing"
+	"text/template"
+)
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ParsePublicKey(base64.StdEncoding.EncodeToString(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checksums := []byte("abc123  localizer_1.9.0_linux_amd64.tar.gz\n")
+	sig := ed25519.Sign(priv, checksums)
+	if err := verifySignature(key, checksums, sig); err != nil {
+		t.Fatalf("expected signature to verify, got %v", err)
+	}
+
+	tampered := []byte("def456  localizer_1.9.0_linux_amd64.tar.gz\n")
+	if err := verifySignature(key, tampered, sig); err == nil {
+		t.Fatal("expected signature of modified checksums to not verify")
+	}
+
+	if _, err := ParsePublicKey(base64.StdEncoding.EncodeToString(pub[:16])); err == nil {
+		t.Fatal("expected a short public key to be rejected")
+	}
+}
+
+func TestArchiveNameMatchesGoreleaser(t *testing.T) {
+	b, err := ioutil.ReadFile("../../.goreleaser.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := regexp.MustCompile(`(?m)^archives:\n(?:[ -].*\n)*?\s+name_template: "(.*)"$`).FindSubmatch(b)
+	if m == nil {
+		t.Fatal("failed to find the archive name_template in .goreleaser.yml")
+	}
+
+	tmpl, err := template.New("archive").Parse(string(m[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var name bytes.Buffer
+	err = tmpl.Execute(&name, map[string]string{
+		"ProjectName": "localizer",
+		"Version":     "1.9.0",
+		"Os":          runtime.GOOS,
+		"Arch":        runtime.GOARCH,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Release{TagName: "v1.9.0"}
+	if got, want := r.ArchiveName(), name.String()+".tar.gz"; got != want {
+		t.Fatalf("expected ArchiveName to be %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package hostsfile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+	"golang.org/x/net/idna"
+)
+
+// NormalizeHostname returns the form of a hostname that's written to the
+// hosts file: lowercase, without a trailing dot, and with internationalized
+// labels in punycode, e.g. Bücher.Default becomes xn--bcher-kva.default.
+// Resolvers compare hostnames case-insensitively and only look up the
+// punycode form, so two hostnames that normalize the same are the same.
+func NormalizeHostname(h string) (string, error) {
+	normalized, err := idna.Lookup.ToASCII(strings.TrimSuffix(strings.TrimSpace(h), "."))
+	if err != nil {
+		return "", fmt.Errorf("'%s' is not a valid dns name: %v", h, err)
+	}
+	normalized = strings.ToLower(normalized)
+
+	if !govalidator.IsDNSName(normalized) {
+		return "", fmt.Errorf("'%s' is not a valid dns name", h)
+	}
+	return normalized, nil
+}
+
+// NormalizeHostnames normalizes hostnames with NormalizeHostname, removing
+// the ones that are the same as one before them
+func NormalizeHostnames(hostnames []string) ([]string, error) {
+	normalized := make([]string, 0, len(hostnames))
+	seen := make(map[string]bool, len(hostnames))
+	for _, h := range hostnames {
+		n, err := NormalizeHostname(h)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		normalized = append(normalized, n)
+	}
+	return normalized, nil
+}
@@ -17,20 +17,52 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/asaskevich/govalidator"
 	"github.com/benbjohnson/clock"
 	"github.com/pkg/errors"
 )
 
+const (
+	// BlockVersion is the version of the format blocks are written in.
+	// Version 1 blocks, written by older versions of localizer, used the
+	// ###start-hostfile and ###end-hostfile markers and are rewritten in
+	// this format when saved.
+	BlockVersion = 2
+
+	// beginMarker and endMarker, followed by the name of the block, wrap
+	// a block
+	beginMarker = "# BEGIN LOCALIZER BLOCK "
+	endMarker   = "# END LOCALIZER BLOCK "
+
+	// MaxBackups is the number of backups of the previous contents of the
+	// hosts file that are kept next to it, not counting the original
+	MaxBackups = 5
+
+	// backupSuffix, followed by when it was written, is appended to the
+	// path of the hosts file to name a backup
+	backupSuffix = ".localizer-backup-"
+
+	// originalSuffix is appended to the path of the hosts file to name the
+	// backup of what it was before we first wrote to it, which is never
+	// replaced or rotated out
+	originalSuffix = ".localizer.orig"
+
+	legacyBeginMarker = "###start-hostfile"
+	legacyEndMarker   = "###end-hostfile"
+)
+
 type File struct {
 	clock clock.Clock
 
@@ -47,11 +79,28 @@ type File struct {
 	// we're managing our own block, we can safely group
 	// these entries together.
 	hostsFile map[string]*HostLine
+
+	// repaired contains the lines that were found inside of our block
+	// that were not written by us during the last Marshal.
+	repaired []string
+
+	// written contains every entry line we've written to, or loaded from,
+	// our block, so that entries that have since been removed aren't
+	// mistaken for ones added by another tool
+	written map[string]bool
 }
 
 type Metadata struct {
+	// Version is the version of the format the block was written in, zero
+	// for blocks written before it was recorded
+	Version int `json:"version,omitempty"`
+
 	BlockName    string    `json:"blockName"`
 	LastModified time.Time `json:"last_modified_at"`
+
+	// Checksum is a checksum of the entries in this block, used to detect
+	// if the block has been modified by something other than us.
+	Checksum string `json:"checksum,omitempty"`
 }
 
 type HostLine struct {
@@ -69,6 +118,7 @@ func NewWithContents(sectionName string, contents []byte) *File {
 		blockName: sectionName,
 
 		hostsFile: make(map[string]*HostLine),
+		written:   make(map[string]bool),
 	}
 }
 
@@ -90,7 +140,7 @@ func New(fileLocation, sectionName string) (*File, error) {
 	return f, nil
 }
 
-func (f *File) parseMetadata(line string) (*Metadata, error) {
+func parseMetadata(line string) (*Metadata, error) {
 	// strip the comment block
 	metadataStr := strings.Replace(line, "###", "", 1)
 
@@ -103,15 +153,34 @@ func (f *File) parseMetadata(line string) (*Metadata, error) {
 	return metadata, nil
 }
 
-// Load loads the hosts file into memory, and parses it.
-func (f *File) Load(ctx context.Context) error { //nolint:funlen
-	f.lock.Lock()
-	defer f.lock.Unlock()
+// block is a block of hosts entries found in a hosts file
+type block struct {
+	meta *Metadata
 
-	scanner := bufio.NewScanner(bytes.NewReader(f.contents))
+	// lines are the lines between the metadata and the end marker, raw
+	// are all of the lines of the block, including the markers
+	lines []string
+	raw   []string
+}
 
-	foundBlock := false
+// blockEnd returns a function that reports if a line ends the block that
+// line starts, or nil if line doesn't start a block
+func blockEnd(line string) func(string) bool {
+	switch {
+	case strings.HasPrefix(line, beginMarker):
+		end := endMarker + strings.TrimPrefix(line, beginMarker)
+		return func(l string) bool { return l == end }
+	case line == legacyBeginMarker || strings.HasPrefix(line, legacyBeginMarker+" "):
+		return func(l string) bool { return strings.HasPrefix(l, legacyEndMarker) }
+	}
+	return nil
+}
 
+// scanBlocks calls fn with each line outside of a block, and each block,
+// of the given hosts file contents in order. Exactly one of line or b is
+// set.
+func scanBlocks(ctx context.Context, contents []byte, fn func(line string, b *block) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
 	for scanner.Scan() {
 		select {
 		case <-ctx.Done():
@@ -120,69 +189,113 @@ func (f *File) Load(ctx context.Context) error { //nolint:funlen
 		}
 
 		line := scanner.Text()
-		chunks := strings.Split(line, " ")
-		if len(chunks) == 0 {
+		end := blockEnd(line)
+		if end == nil {
+			if err := fn(line, nil); err != nil {
+				return err
+			}
 			continue
 		}
 
-		// process the block start
-		switch chunks[0] {
-		case "###start-hostfile":
-			foundBlock = true
-
-			// fetch the metadata
-			scanner.Scan()
-
-			m, err := f.parseMetadata(scanner.Text())
-			if err != nil {
-				return err
-			}
+		// the metadata always follows the begin marker
+		scanner.Scan()
+		m, err := parseMetadata(scanner.Text())
+		if err != nil {
+			return err
+		}
 
-			// if the block doesn't match the one we're looking for, ignore it
-			if m.BlockName != f.blockName {
-				continue
+		b := &block{meta: m, raw: []string{line, scanner.Text()}}
+		for scanner.Scan() {
+			b.raw = append(b.raw, scanner.Text())
+			if end(scanner.Text()) {
+				break
 			}
-		case "###end-hostfile":
-			foundBlock = false
+			b.lines = append(b.lines, scanner.Text())
 		}
 
-		if !foundBlock {
-			continue
+		if err := fn("", b); err != nil {
+			return err
 		}
+	}
 
-		// skip lines that don't have at least an ip address and one host
-		if len(chunks) < 2 {
-			continue
-		}
+	return scanner.Err()
+}
+
+// Blocks returns the lines of every block managed by localizer in the given
+// hosts file contents, including the markers, without parsing them
+func Blocks(contents []byte) []string {
+	lines := []string{}
 
-		// ensure we have a valid ip address
-		ip := net.ParseIP(chunks[0])
-		if ip == nil {
+	var end func(string) bool
+	for _, line := range strings.Split(string(contents), "\n") {
+		if end == nil {
+			end = blockEnd(line)
+		}
+		if end == nil {
 			continue
 		}
 
-		f.hostsFile[ip.String()] = &HostLine{
-			Addresses: chunks[1:],
+		lines = append(lines, line)
+		if end(line) {
+			end = nil
 		}
 	}
-	if scanner.Err() != nil {
-		return scanner.Err()
-	}
 
-	return nil
+	return lines
 }
 
-func (f *File) generateBlock() (string, error) {
-	contents := []string{}
+// Load loads the hosts file into memory, and parses it.
+func (f *File) Load(ctx context.Context) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
 
-	m, err := json.Marshal(&Metadata{
-		BlockName:    f.blockName,
-		LastModified: f.clock.Now().UTC(),
+	return scanBlocks(ctx, f.contents, func(_ string, b *block) error {
+		if b == nil || b.meta.BlockName != f.blockName {
+			return nil
+		}
+
+		// only a block that we last wrote is known to contain our entries
+		ours := b.meta.Checksum != "" && b.meta.Checksum == checksum(b.lines)
+
+		for _, line := range b.lines {
+			if ours {
+				f.written[line] = true
+			}
+
+			// skip lines that don't have at least an ip address and one host
+			chunks := strings.Split(line, " ")
+			if len(chunks) < 2 {
+				continue
+			}
+
+			// ensure we have a valid ip address
+			ip := net.ParseIP(chunks[0])
+			if ip == nil {
+				continue
+			}
+
+			// entries written by older versions may not be normalized
+			addresses := chunks[1:]
+			if normalized, err := NormalizeHostnames(addresses); err == nil {
+				addresses = normalized
+			}
+
+			f.hostsFile[ip.String()] = &HostLine{
+				Addresses: addresses,
+			}
+		}
+		return nil
 	})
-	if err != nil {
-		return "", err
-	}
+}
+
+// checksum returns a checksum of the provided block lines
+func checksum(lines []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
 
+// entryLines returns the lines of our block
+func (f *File) entryLines() []string {
 	// ensure the output is stable, convert the keys
 	// into a sorted slice
 	ipAddresses := make([]string, len(f.hostsFile))
@@ -197,77 +310,132 @@ func (f *File) generateBlock() (string, error) {
 		return bytes.Compare(net.ParseIP(ipAddresses[i]), net.ParseIP(ipAddresses[j])) < 0
 	})
 
-	contents = append(contents, "###start-hostfile", fmt.Sprintf("###%s", m))
-	for _, ip := range ipAddresses {
-		contents = append(
-			contents,
-			fmt.Sprintf("%s %s", ip, strings.Join(f.hostsFile[ip].Addresses, " ")),
-		)
+	lines := make([]string, len(ipAddresses))
+	for i, ip := range ipAddresses {
+		lines[i] = fmt.Sprintf("%s %s", ip, strings.Join(f.hostsFile[ip].Addresses, " "))
 	}
-	contents = append(contents, "###end-hostfile")
+
+	return lines
+}
+
+func (f *File) generateBlock() (string, error) {
+	lines := f.entryLines()
+	for _, line := range lines {
+		f.written[line] = true
+	}
+
+	m, err := json.Marshal(&Metadata{
+		Version:      BlockVersion,
+		BlockName:    f.blockName,
+		LastModified: f.clock.Now().UTC(),
+		Checksum:     checksum(lines),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	contents := append([]string{beginMarker + f.blockName, fmt.Sprintf("###%s", m)}, lines...)
+	contents = append(contents, endMarker+f.blockName)
 
 	return strings.Join(contents, "\n"), nil
 }
 
-// Marshal renders a hosts file from memory.
-func (f *File) Marshal(ctx context.Context) ([]byte, error) { //nolint:funlen
+// foreignLines returns the lines in a block that were not written by us, based
+// on the checksum stored in the block's metadata. If the checksum matches, or
+// the block predates checksums, no lines are returned. Lines we've written
+// before are never foreign, even if their address has since been removed.
+func (f *File) foreignLines(m *Metadata, lines []string) []string {
+	if m.Checksum == "" || m.Checksum == checksum(lines) {
+		return nil
+	}
+
+	foreign := []string{}
+	for _, line := range lines {
+		chunks := strings.Fields(line)
+
+		// entries for addresses we manage are rewritten by us, so only keep
+		// lines that reference an address we don't know about
+		if len(chunks) >= 2 {
+			if ip := net.ParseIP(chunks[0]); ip != nil {
+				if _, ok := f.hostsFile[ip.String()]; ok {
+					continue
+				}
+			}
+		}
+
+		if strings.TrimSpace(line) == "" || f.written[line] {
+			continue
+		}
+
+		foreign = append(foreign, line)
+	}
+
+	return foreign
+}
+
+// Repaired returns the lines that were found to be added to our block by
+// another tool during the last Marshal or Save. These lines are moved outside
+// of our block, rather than being removed, so that they are not lost.
+func (f *File) Repaired() []string {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
-	scanner := bufio.NewScanner(bytes.NewReader(f.contents))
+	return f.repaired
+}
+
+// Marshal renders a hosts file from memory. Our block is replaced as a
+// whole, in the current format, and any duplicates of it, e.g. left behind
+// by older versions, are removed.
+func (f *File) Marshal(ctx context.Context) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
 
 	contents := []string{}
 	wroteBlock := false
+	f.repaired = nil
 
-	copyLines := true
-	for scanner.Scan() {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
+	err := scanBlocks(ctx, f.contents, func(line string, b *block) error {
+		if b == nil {
+			contents = append(contents, line)
+			return nil
 		}
 
-		line := scanner.Text()
-		chunks := strings.Split(line, " ")
-
-		// process the block start
-		if len(chunks) != 0 {
-			switch chunks[0] {
-			case "###start-hostfile":
-				scanner.Scan()
-				m, err := f.parseMetadata(scanner.Text())
-				if err != nil {
-					return nil, err
-				}
+		// keep blocks that aren't ours as-is
+		if b.meta.BlockName != f.blockName {
+			contents = append(contents, b.raw...)
+			return nil
+		}
 
-				if m.BlockName != f.blockName {
-					continue
-				}
+		// if something else modified our block, keep what it added
+		// outside of our block so we don't clobber it
+		foreign := f.foreignLines(b.meta, b.lines)
+		contents = append(contents, foreign...)
+		f.repaired = append(f.repaired, foreign...)
 
-				// write the blocks' contents
-				wroteBlock = true
-				b, err := f.generateBlock()
-				if err != nil {
-					return nil, errors.Wrap(err, "failed to generate hosts entries")
-				}
-				contents = append(contents, b)
-
-				// discard lines until end block is found
-				copyLines = false
-			case "###end-hostfile":
-				copyLines = true
-				continue
-			}
+		if wroteBlock {
+			return nil
 		}
-
-		if !copyLines {
-			continue
+		wroteBlock = true
+
+		// keep the block, and when it was last modified, if nothing
+		// changed so saving it is a no-op. raw has the markers, and the
+		// metadata, if it was ended.
+		unchanged := b.meta.Version == BlockVersion && b.meta.Checksum == checksum(f.entryLines())
+		if unchanged && len(foreign) == 0 && len(b.raw) == len(b.lines)+3 {
+			contents = append(contents, b.raw...)
+			return nil
 		}
 
-		contents = append(contents, scanner.Text())
-	}
-	if scanner.Err() != nil {
-		return nil, scanner.Err()
+		// write the blocks' contents
+		gen, err := f.generateBlock()
+		if err != nil {
+			return errors.Wrap(err, "failed to generate hosts entries")
+		}
+		contents = append(contents, gen)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// if we never wrote the block, then append it to the end of the file
@@ -283,51 +451,196 @@ func (f *File) Marshal(ctx context.Context) ([]byte, error) { //nolint:funlen
 	return []byte(strings.Join(contents, "\n")), nil
 }
 
-// Save marshalls the hosts file and then saves it to disk.
+// Save marshalls the hosts file and then saves it to disk. An advisory lock
+// is held on the hosts file while it is read and written to prevent other
+// instances from writing to it at the same time. The previous contents are
+// backed up next to it, see MaxBackups and originalSuffix, and the new
+// contents are written to a temporary file that's renamed over it, so a
+// crash part way through can't leave it truncated.
 func (f *File) Save(ctx context.Context) error {
 	if f.fileLocation == "" {
 		return fmt.Errorf("can't write, was not loaded from a file")
 	}
 
-	var b []byte
-	var err error
-	if f.fileLocation != "" {
-		f.lock.Lock()
-		// re-read the hosts file to get potential
-		// changes outside of our block
-		//nolint:govet // Why: We're OK shadowing err
-		b, err := ioutil.ReadFile(f.fileLocation)
+	// ensure we don't write to the file at the same time
+	f.saveLock.Lock()
+	defer f.saveLock.Unlock()
+
+	fd, err := f.openLocked()
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	//nolint:errcheck // Why: Closing the file releases the lock anyways
+	defer unlockFile(fd)
+
+	// re-read the hosts file to get potential
+	// changes outside of our block
+	old, err := ioutil.ReadAll(fd)
+	if err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+	f.contents = old
+	f.lock.Unlock()
+
+	b, err := f.Marshal(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal hostsfile")
+	}
+
+	if bytes.Equal(old, b) {
+		return nil
+	}
+
+	if err := f.backup(old); err != nil {
+		return errors.Wrap(err, "failed to back up hosts file")
+	}
+
+	return f.replace(fd, b)
+}
+
+// openLocked opens the hosts file and locks it. The file is replaced when
+// it's saved, so if that happened while waiting for the lock, the new file
+// is opened instead, otherwise we'd read what it used to contain.
+func (f *File) openLocked() (*os.File, error) {
+	for {
+		fd, err := os.OpenFile(f.fileLocation, os.O_RDWR, 0)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open hosts file")
+		}
+
+		if err := lockFile(fd); err != nil {
+			fd.Close()
+			return nil, errors.Wrap(err, "failed to lock hosts file")
+		}
+
+		locked, err := fd.Stat()
+		if err == nil {
+			var current os.FileInfo
+			current, err = os.Stat(f.fileLocation)
+			if err == nil && os.SameFile(locked, current) {
+				return fd, nil
+			}
+		}
+
+		//nolint:errcheck // Why: Closing the file releases the lock anyways
+		unlockFile(fd)
+		fd.Close()
 		if err != nil {
+			return nil, errors.Wrap(err, "failed to stat hosts file")
+		}
+	}
+}
+
+// backup writes the previous contents of the hosts file next to it, named
+// after when it was replaced, and removes all but the newest MaxBackups. The
+// first time it's called the contents are also kept as the original.
+func (f *File) backup(contents []byte) error {
+	//nolint:gosec // Why: The hosts file is world readable
+	orig, err := os.OpenFile(f.fileLocation+originalSuffix, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err == nil {
+		_, err = orig.Write(contents)
+		if cerr := orig.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			// don't keep a partial original, it'd never be replaced
+			os.Remove(orig.Name()) //nolint:errcheck // Why: Best effort
 			return err
 		}
-		f.contents = b
-		f.lock.Unlock()
+	} else if !os.IsExist(err) {
+		return err
+	}
+
+	prefix := f.fileLocation + backupSuffix
+	name := prefix + f.clock.Now().UTC().Format("20060102T150405.000000000")
+
+	//nolint:gosec // Why: The hosts file is world readable
+	if err := ioutil.WriteFile(name, contents, 0644); err != nil {
+		return err
 	}
 
-	b, err = f.Marshal(ctx)
+	// the timestamps sort in the order they were written
+	backups, err := filepath.Glob(prefix + "*")
 	if err != nil {
-		return errors.Wrap(err, "failed to marshal hostsfile")
+		return err
+	}
+	sort.Strings(backups)
+	for len(backups) > MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
 	}
 
-	// ensure we don't write to the file at the same time
-	f.saveLock.Lock()
-	defer f.saveLock.Unlock()
+	return nil
+}
+
+// replace writes contents to a temporary file, syncs it, and renames it over
+// the hosts file. Hosts files that can't be replaced, like one bind mounted
+// into a container or, on Windows, one that's open, are written in place.
+func (f *File) replace(fd *os.File, contents []byte) error {
+	fi, err := fd.Stat()
+	if err != nil {
+		return errors.Wrap(err, "failed to stat hosts file")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.fileLocation), "."+filepath.Base(f.fileLocation)+".localizer-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary hosts file")
+	}
+	//nolint:errcheck // Why: Only exists if we failed to rename it
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(contents)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to write temporary hosts file")
+	}
+
+	// the new file needs to be readable by everyone, like the old one
+	if err := os.Chmod(tmp.Name(), fi.Mode().Perm()); err != nil {
+		return errors.Wrap(err, "failed to set mode of temporary hosts file")
+	}
+	if copyOwner(fi, tmp.Name()) != nil || os.Rename(tmp.Name(), f.fileLocation) != nil {
+		return writeInPlace(fd, contents)
+	}
+
+	syncDir(filepath.Dir(f.fileLocation))
+	return nil
+}
+
+// writeInPlace replaces the contents of fd with contents
+func writeInPlace(fd *os.File, contents []byte) error {
+	if err := fd.Truncate(0); err != nil {
+		return errors.Wrap(err, "failed to truncate hosts file")
+	}
 
-	//nolint:gosec // We should eventually just use the existing perms here
-	return ioutil.WriteFile(f.fileLocation, b, 0644)
+	if _, err := fd.WriteAt(contents, 0); err != nil {
+		return err
+	}
+	return fd.Sync()
 }
 
 // AddHosts adds a line into the hosts file for the given hosts to resolve
-// to specified IP. Any existing hosts are replaced.
+// to specified IP. Any existing hosts are replaced. Hosts are written in
+// the form returned by NormalizeHostname, once each.
 func (f *File) AddHosts(ipAddress string, hosts []string) error {
+	hosts, err := NormalizeHostnames(hosts)
+	if err != nil {
+		return err
+	}
+
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
-	for _, h := range hosts {
-		if !govalidator.IsDNSName(h) {
-			return fmt.Errorf("'%s' is not a valid dns name", h)
-		}
-	}
 	f.hostsFile[ipAddress] = &HostLine{Addresses: hosts}
 	return nil
 }
@@ -341,3 +654,15 @@ func (f *File) RemoveAddress(ipAddress string) error {
 	delete(f.hostsFile, ipAddress)
 	return nil
 }
+
+// Hosts returns the hosts of each IP address in our block
+func (f *File) Hosts() map[string][]string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	hosts := make(map[string][]string, len(f.hostsFile))
+	for ip, line := range f.hostsFile {
+		hosts[ip] = append([]string{}, line.Addresses...)
+	}
+	return hosts
+}
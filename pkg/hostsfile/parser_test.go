@@ -16,9 +16,15 @@ package hostsfile
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/benbjohnson/clock"
 	"github.com/google/go-cmp/cmp"
@@ -125,7 +131,9 @@ func TestFile_Marshal(t *testing.T) {
 
 	expected := bytes.Join([][]byte{
 		f.contents,
-		[]byte("###start-hostfile\n###{\"blockName\":\"localizer\",\"last_modified_at\":\"1970-01-01T00:00:00Z\"}\n###end-hostfile"),
+		[]byte("# BEGIN LOCALIZER BLOCK localizer\n" +
+			"###{\"version\":2,\"blockName\":\"localizer\",\"last_modified_at\":\"1970-01-01T00:00:00Z\",\"checksum\":\"e3b0c44298fc1c14\"}\n" +
+			"# END LOCALIZER BLOCK localizer"),
 	}, []byte("\n"))
 
 	if !reflect.DeepEqual(expected, b) {
@@ -193,6 +201,155 @@ func TestFile_HandleCorruption(t *testing.T) {
 	}
 }
 
+// Ensure that entries added to our block by another tool are detected
+// and kept outside of our block, rather than being clobbered.
+func TestFile_HandleConflict(t *testing.T) {
+	f, err := New("./testdata/load/hosts-with-block.hosts", "")
+	if err != nil {
+		t.Error(err)
+	}
+	f.clock = clock.NewMock()
+
+	err = f.Load(context.Background())
+	if err != nil {
+		t.Error(errors.Wrap(err, "failed to load valid hosts file"))
+	}
+
+	// add an entry to our block, and remove one of ours
+	f.contents = bytes.Replace(f.contents,
+		[]byte("127.0.0.1 hello-world\n"), []byte("10.0.0.1 someone-else\n"), 1)
+
+	b, err := f.Marshal(context.Background())
+	if err != nil {
+		t.Error(errors.Wrap(err, "failed to marshal hosts file"))
+	}
+
+	expectedRepaired := []string{"10.0.0.1 someone-else"}
+	if !reflect.DeepEqual(f.Repaired(), expectedRepaired) {
+		t.Error("expected: ", cmp.Diff(expectedRepaired, f.Repaired()))
+	}
+
+	origContents, err := ioutil.ReadFile("./testdata/load/hosts-with-block.hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := bytes.Replace(origContents,
+		[]byte("# BEGIN"), []byte("10.0.0.1 someone-else\n# BEGIN"), 1)
+	if !reflect.DeepEqual(expected, b) {
+		t.Error("expected: ", cmp.Diff(string(expected), string(b)))
+	}
+
+	// marshaling an unmodified block shouldn't repair anything
+	f.contents = b
+	if _, err := f.Marshal(context.Background()); err != nil {
+		t.Error(errors.Wrap(err, "failed to marshal hosts file"))
+	}
+
+	if len(f.Repaired()) != 0 {
+		t.Errorf("expected no repaired lines, got %v", f.Repaired())
+	}
+}
+
+// Ensure that entries we wrote, but have since removed, aren't mistaken for
+// ones added by another tool when the block was modified
+func TestFile_HandleConflictRemovedEntry(t *testing.T) {
+	f, err := New("./testdata/load/hosts-with-block.hosts", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.clock = clock.NewMock()
+
+	if err := f.Load(context.Background()); err != nil {
+		t.Fatal(errors.Wrap(err, "failed to load valid hosts file"))
+	}
+
+	if err := f.AddHosts("127.0.0.2", []string{"added"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := f.Marshal(context.Background())
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "failed to marshal hosts file"))
+	}
+
+	// remove both the loaded and the added entries, after another tool
+	// added to our block
+	f.contents = bytes.Replace(b, []byte("127.0.0.2 added\n"), []byte("127.0.0.2 added\n10.0.0.1 someone-else\n"), 1)
+	if err := f.RemoveAddress("127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.RemoveAddress("127.0.0.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = f.Marshal(context.Background())
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "failed to marshal hosts file"))
+	}
+
+	expectedRepaired := []string{"10.0.0.1 someone-else"}
+	if !reflect.DeepEqual(f.Repaired(), expectedRepaired) {
+		t.Error("expected: ", cmp.Diff(expectedRepaired, f.Repaired()))
+	}
+	if bytes.Contains(b, []byte("hello-world")) || bytes.Contains(b, []byte("added")) {
+		t.Errorf("expected removed entries to not be kept, got:\n%s", b)
+	}
+}
+
+// Ensure that blocks written by older versions are rewritten in the current
+// format, and that duplicates of our block are removed
+func TestFile_MigrateLegacyBlock(t *testing.T) {
+	legacy, err := ioutil.ReadFile("./testdata/load/hosts-with-legacy-block.hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := ioutil.ReadFile("./testdata/load/hosts-with-block.hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an old block left behind after the current one
+	contents := bytes.Join([][]byte{current, bytes.SplitN(legacy, []byte("\n\n"), 2)[1]}, []byte("\n"))
+	for _, c := range [][]byte{legacy, contents} {
+		f := NewWithContents("", c)
+		f.clock = clock.NewMock()
+
+		if err := f.Load(context.Background()); err != nil {
+			t.Fatal(errors.Wrap(err, "failed to load hosts file"))
+		}
+
+		expected := map[string]*HostLine{"127.0.0.1": {Addresses: []string{"hello-world"}}}
+		if !reflect.DeepEqual(f.hostsFile, expected) {
+			t.Error("expected: ", cmp.Diff(expected, f.hostsFile))
+		}
+
+		b, err := f.Marshal(context.Background())
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "failed to marshal hosts file"))
+		}
+		if !reflect.DeepEqual(current, b) {
+			t.Error("expected: ", cmp.Diff(string(current), string(b)))
+		}
+	}
+}
+
+// Ensure that only the managed blocks are returned, whatever their format
+func TestBlocks(t *testing.T) {
+	contents := []byte("127.0.0.1 localhost\n" +
+		"###start-hostfile\n###{\"blockName\":\"old\"}\n127.0.0.2 old\n###end-hostfile\n" +
+		"127.0.1.1 desktop\n" +
+		"# BEGIN LOCALIZER BLOCK new\n###{\"version\":2,\"blockName\":\"new\"}\n127.0.0.3 new\n# END LOCALIZER BLOCK new\n" +
+		"::1 localhost")
+
+	expected := []string{
+		"###start-hostfile", "###{\"blockName\":\"old\"}", "127.0.0.2 old", "###end-hostfile",
+		"# BEGIN LOCALIZER BLOCK new", "###{\"version\":2,\"blockName\":\"new\"}", "127.0.0.3 new", "# END LOCALIZER BLOCK new",
+	}
+	if got := Blocks(contents); !reflect.DeepEqual(expected, got) {
+		t.Error("expected: ", cmp.Diff(expected, got))
+	}
+}
+
 func TestFile_AddHosts(t *testing.T) {
 	f, err := New("./testdata/load/hosts-with-block.hosts", "")
 	if err != nil {
@@ -250,6 +407,44 @@ func TestFile_AddHosts(t *testing.T) {
 	}
 }
 
+func TestFile_AddHostsNormalizes(t *testing.T) {
+	f := NewWithContents("", []byte("127.0.0.1 localhost\n"))
+	if err := f.AddHosts("127.0.1.1", []string{"API.Default", "api.default.", "Bücher.default", "xn--bcher-kva.default"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"api.default", "xn--bcher-kva.default"}
+	if got := f.Hosts()["127.0.1.1"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestNormalizeHostname(t *testing.T) {
+	tests := []struct {
+		hostname string
+		want     string
+		wantErr  bool
+	}{
+		{hostname: "api.default.svc.cluster.local", want: "api.default.svc.cluster.local"},
+		{hostname: "API.Default.SVC", want: "api.default.svc"},
+		{hostname: "api.default.", want: "api.default"},
+		{hostname: "zahlungen.tëst", want: "zahlungen.xn--tst-jma"},
+		{hostname: "ZAHLUNGEN.TËST", want: "zahlungen.xn--tst-jma"},
+		{hostname: "i am a hostname", wantErr: true},
+		{hostname: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeHostname(tt.hostname)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: expected error %v, got %v", tt.hostname, tt.wantErr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %q, got %q", tt.hostname, tt.want, got)
+		}
+	}
+}
+
 func TestFile_RemoveHosts(t *testing.T) {
 	f, err := New("./testdata/load/hosts-with-block.hosts", "")
 	if err != nil {
@@ -282,3 +477,95 @@ func TestFile_RemoveHosts(t *testing.T) {
 		t.Error("expected: ", cmp.Diff(f.contents, b))
 	}
 }
+
+func TestFile_Save(t *testing.T) {
+	dir, err := ioutil.TempDir("", "localizer-hostsfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hosts")
+	orig := []byte("127.0.0.1 localhost")
+	if err := ioutil.WriteFile(path, orig, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := New(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := clock.NewMock()
+	f.clock = mock
+
+	for i := 0; i < MaxBackups+2; i++ {
+		mock.Add(time.Second)
+		if err := f.AddHosts(fmt.Sprintf("127.0.1.%d", i), []string{fmt.Sprintf("host-%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Save(context.Background()); err != nil {
+			t.Fatal(errors.Wrap(err, "failed to save hosts file"))
+		}
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, orig) || !bytes.Contains(b, []byte("127.0.1.6 host-6")) {
+		t.Errorf("expected the original contents and every host, got:\n%s", b)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm() != 0644 {
+		t.Errorf("expected mode of the hosts file to be kept, got %v", fi.Mode().Perm())
+	}
+
+	// only the newest backups are kept, the newest being what was replaced
+	// by the last save
+	backups, err := filepath.Glob(path + backupSuffix + "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != MaxBackups {
+		t.Fatalf("expected %d backups, got %d", MaxBackups, len(backups))
+	}
+	sort.Strings(backups)
+	last, err := ioutil.ReadFile(backups[len(backups)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(last, []byte("127.0.1.5 host-5")) || bytes.Contains(last, []byte("127.0.1.6")) {
+		t.Errorf("expected the newest backup to be the previous contents, got:\n%s", last)
+	}
+
+	// the original is kept, no matter how many times it's saved
+	original, err := ioutil.ReadFile(path + originalSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(original, orig) {
+		t.Errorf("expected the original backup to be the original contents, got:\n%s", original)
+	}
+
+	// saving without changes doesn't write anything
+	mock.Add(time.Second)
+	if err := f.Save(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := filepath.Glob(path + backupSuffix + "*"); !reflect.DeepEqual(after, backups) {
+		t.Errorf("expected no new backups, got %v", after)
+	}
+
+	// no temporary files are left behind
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != MaxBackups+2 {
+		t.Errorf("expected only the hosts file and its backups, got %d files", len(entries))
+	}
+}
@@ -0,0 +1,24 @@
+package localizer
+
+import (
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/status"
+
+	"github.com/getoutreach/localizer/api"
+)
+
+// ErrorDetails returns the details attached to an error returned by the
+// daemon, or nil if it has none. err may have been wrapped.
+func ErrorDetails(err error) *api.ErrorDetails {
+	st, ok := status.FromError(errors.Cause(err))
+	if !ok {
+		return nil
+	}
+
+	for _, d := range st.Details() {
+		if details, ok := d.(*api.ErrorDetails); ok {
+			return details
+		}
+	}
+	return nil
+}
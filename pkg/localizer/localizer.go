@@ -4,8 +4,9 @@ package localizer
 
 import (
 	"context"
-	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/getoutreach/localizer/api"
 	"github.com/pkg/errors"
@@ -13,12 +14,118 @@ import (
 )
 
 // Socket is the communication endpoint that the localizer server is listening
-// on.
+// on. On Windows, the named pipe returned by SocketPath is used instead.
 const Socket = "/var/run/localizer.sock"
 
-// IsRunning checks to see if the localizer socket exists.
+// InstanceEnvVar is the environment variable that selects which instance of
+// localizer to talk to when more than one is running on a machine. The
+// default instance is used when it's empty.
+const InstanceEnvVar = "LOCALIZER_INSTANCE"
+
+// instanceName returns the name used for files belonging to an instance
+func instanceName(instance string) string {
+	if instance == "" {
+		return "localizer"
+	}
+
+	return "localizer-" + instance
+}
+
+// SocketPath returns the socket used by the given instance of localizer, the
+// default instance uses Socket.
+func SocketPath(instance string) string {
+	return socketFor(instanceName(instance))
+}
+
+// PidFile returns the path of the file that the given instance of localizer
+// writes its process ID to.
+func PidFile(instance string) string {
+	return runPath(instanceName(instance) + ".pid")
+}
+
+// unprivilegedRunPath returns the path of a runtime file with the given
+// name for a daemon that isn't running as root, and can't write to
+// /var/run
+func unprivilegedRunPath(name string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "run", name), nil
+}
+
+// UnprivilegedSocketPath returns the socket used by the given instance of
+// localizer when it's ran without root, using the privileged helper.
+// Clients find it through the state file.
+func UnprivilegedSocketPath(instance string) (string, error) {
+	return unprivilegedRunPath(instanceName(instance) + ".sock")
+}
+
+// UnprivilegedPidFile returns the path of the pid file of the given
+// instance of localizer when it's ran without root
+func UnprivilegedPidFile(instance string) (string, error) {
+	return unprivilegedRunPath(instanceName(instance) + ".pid")
+}
+
+// AddressFor returns the address of the daemon listening on the given socket
+func AddressFor(socket string) string {
+	return addressScheme + socket
+}
+
+// AddressEnvVar is the environment variable that can be used to point clients
+// at a localizer daemon that isn't listening on the default socket, e.g. one
+// running in-cluster with a TCP listener. It should be in the format of
+// host:port or unix:///path/to/socket.
+const AddressEnvVar = "LOCALIZER_ADDRESS"
+
+// TokenEnvVar is the environment variable that holds the token to present to
+// a daemon serving its API over TCP with --listen-token
+const TokenEnvVar = "LOCALIZER_TOKEN"
+
+// tokenCredentials sends a token with every RPC
+type tokenCredentials string
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (t tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// Address returns the address clients should use to talk to localizer. In
+// order, this is AddressEnvVar, SocketEnvVar, the socket in the state file
+// of the current instance, and finally the default socket of the instance.
+func Address() string {
+	if addr := os.Getenv(AddressEnvVar); addr != "" {
+		return addr
+	}
+
+	if socket := os.Getenv(SocketEnvVar); socket != "" {
+		return AddressFor(socket)
+	}
+
+	instance := os.Getenv(InstanceEnvVar)
+	if s, err := ReadState(instance); err == nil && s.Socket != "" {
+		return AddressFor(s.Socket)
+	}
+
+	return AddressFor(SocketPath(instance))
+}
+
+// IsRunning checks to see if the localizer socket exists. If the daemon is
+// configured to be reached over TCP, this always returns true and Connect
+// should be used instead.
 func IsRunning() bool {
-	if _, err := os.Stat(Socket); err != nil {
+	addr := Address()
+	if !strings.HasPrefix(addr, "unix://") {
+		return true
+	}
+
+	if _, err := os.Stat(strings.TrimPrefix(addr, "unix://")); err != nil {
 		return false
 	}
 
@@ -28,7 +135,18 @@ func IsRunning() bool {
 // Connect returns a new instance of LocalizerServiceClient given a gRPC client
 // connection (returned from grpc.Dial*).
 func Connect(ctx context.Context, opts ...grpc.DialOption) (client api.LocalizerServiceClient, closer func(), err error) {
-	clientConn, err := grpc.DialContext(ctx, fmt.Sprintf("unix://%s", Socket), opts...)
+	return ConnectAddress(ctx, Address(), opts...)
+}
+
+// ConnectAddress is like Connect, but talks to the daemon at the given address
+// instead of the one returned by Address.
+func ConnectAddress(ctx context.Context, address string,
+	opts ...grpc.DialOption) (client api.LocalizerServiceClient, closer func(), err error) {
+	target, transportOpts := dialOptions(address)
+	if token := os.Getenv(TokenEnvVar); token != "" && !strings.HasPrefix(address, "unix://") {
+		transportOpts = append(transportOpts, grpc.WithPerRPCCredentials(tokenCredentials(token)))
+	}
+	clientConn, err := grpc.DialContext(ctx, target, append(transportOpts, opts...)...)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "dial localizer")
 	}
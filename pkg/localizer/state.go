@@ -0,0 +1,413 @@
+package localizer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// SocketEnvVar is the environment variable that overrides the path of the
+// unix socket used to talk to localizer.
+const SocketEnvVar = "LOCALIZER_SOCKET"
+
+// State is written by a running daemon so that clients can discover how to
+// talk to it without any configuration.
+type State struct {
+	// Socket is the unix socket the daemon is listening on
+	Socket string `json:"socket"`
+
+	// Pid is the process ID of the daemon
+	Pid int `json:"pid"`
+}
+
+// HomeDir returns the home directory of the user running localizer. When ran
+// through sudo, this is the home directory of the user that invoked sudo so
+// that the daemon and clients agree on it.
+func HomeDir() (string, error) {
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" && os.Geteuid() == 0 {
+		if u, err := user.Lookup(sudoUser); err == nil {
+			return u.HomeDir, nil
+		}
+	}
+
+	return os.UserHomeDir()
+}
+
+// Dir returns the directory localizer stores its configuration and state
+// in, ~/.localizer
+func Dir() (string, error) {
+	home, err := HomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to find home directory")
+	}
+
+	return filepath.Join(home, ".localizer"), nil
+}
+
+// StatePath returns the path of the state file for the given instance
+func StatePath(instance string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	if instance == "" {
+		instance = "default"
+	}
+
+	return filepath.Join(dir, "run", instance+".json"), nil
+}
+
+// ReadState reads the state file of the given instance
+func ReadState(instance string) (*State, error) {
+	path, err := StatePath(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s State
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, errors.Wrap(err, "failed to parse state file")
+	}
+
+	return &s, nil
+}
+
+// WriteState writes the state file of the given instance. When ran through
+// sudo, the file is owned by the invoking user.
+func WriteState(instance string, s *State) error {
+	path, err := StatePath(instance)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create state directory")
+	}
+
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return errors.Wrap(err, "failed to write state file")
+	}
+
+	chownToSudoUser(filepath.Dir(filepath.Dir(path)), filepath.Dir(path), path)
+	return nil
+}
+
+// chownToSudoUser changes the owner of paths to the user that invoked sudo,
+// if we were ran through it
+func chownToSudoUser(paths ...string) {
+	uid, uidErr := strconv.Atoi(os.Getenv("SUDO_UID"))
+	gid, gidErr := strconv.Atoi(os.Getenv("SUDO_GID"))
+	if uidErr != nil || gidErr != nil {
+		return
+	}
+
+	for _, p := range paths {
+		os.Chown(p, uid, gid) //nolint:errcheck // Why: Best effort, the file is still readable
+	}
+}
+
+// servicesPath returns the path of a file in dir that lists services for
+// the given instance. Unlike the state file, these are kept when the
+// daemon exits.
+func servicesPath(dir, instance string) (string, error) {
+	base, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	if instance == "" {
+		instance = "default"
+	}
+
+	return filepath.Join(base, dir, instance+".json"), nil
+}
+
+// readServices reads a list of services, as namespace/name, from path. A
+// missing file is an empty list.
+func readServices(path string) ([]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var services []string
+	if err := json.Unmarshal(b, &services); err != nil {
+		return nil, err
+	}
+
+	return services, nil
+}
+
+// writeServices writes a list of services, as namespace/name, to path
+func writeServices(path string, services []string) error {
+	b, err := json.Marshal(services)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return err
+	}
+
+	chownToSudoUser(filepath.Dir(filepath.Dir(path)), filepath.Dir(path), path)
+	return nil
+}
+
+// DisabledPath returns the path of the file that lists the services that
+// have been disabled in the given instance
+func DisabledPath(instance string) (string, error) {
+	return servicesPath("disabled", instance)
+}
+
+// ReadDisabled returns the services, as namespace/name, that have been
+// disabled in the given instance
+func ReadDisabled(instance string) ([]string, error) {
+	path, err := DisabledPath(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := readServices(path)
+	return services, errors.Wrap(err, "failed to read disabled services")
+}
+
+// WriteDisabled writes the services, as namespace/name, that have been
+// disabled in the given instance
+func WriteDisabled(instance string, services []string) error {
+	path, err := DisabledPath(instance)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(writeServices(path, services), "failed to write disabled services")
+}
+
+// SelectedPath returns the path of the file that lists the services picked
+// with --interactive in the given instance
+func SelectedPath(instance string) (string, error) {
+	return servicesPath("selected", instance)
+}
+
+// ReadSelected returns the services, as namespace/name, that were picked to
+// be forwarded in the given instance. Nil means every service.
+func ReadSelected(instance string) ([]string, error) {
+	path, err := SelectedPath(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := readServices(path)
+	return services, errors.Wrap(err, "failed to read selected services")
+}
+
+// WriteSelected writes the services, as namespace/name, that were picked to
+// be forwarded in the given instance. Nil forwards every service again.
+func WriteSelected(instance string, services []string) error {
+	path, err := SelectedPath(instance)
+	if err != nil {
+		return err
+	}
+
+	if services == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "failed to remove selected services")
+		}
+		return nil
+	}
+
+	return errors.Wrap(writeServices(path, services), "failed to write selected services")
+}
+
+// Snapshot is what a daemon was forwarding, written whenever it changes so
+// that the next run can give services the same IPs, and clean up after it
+// if it didn't exit cleanly
+type Snapshot struct {
+	// Clean is set when the daemon exited cleanly
+	Clean bool `json:"clean"`
+
+	Forwards []SnapshotForward `json:"forwards"`
+
+	// Exposes are the services that were being exposed, which are exposed
+	// again when the daemon next starts
+	Exposes []SnapshotExpose `json:"exposes,omitempty"`
+}
+
+// SnapshotExpose is a service being exposed in a Snapshot, stored as the
+// options it was exposed with so its ports are resolved again
+type SnapshotExpose struct {
+	// Service is the key of the service, e.g. namespace/name
+	Service string `json:"service"`
+
+	PortMap     []string          `json:"portMap,omitempty"`
+	TargetPorts map[string]uint32 `json:"targetPorts,omitempty"`
+
+	// Annotations are the annotations given for this expose, not including
+	// the configured ones
+	Annotations    map[string]string `json:"annotations,omitempty"`
+	LogConnections bool              `json:"logConnections,omitempty"`
+	Mirror         bool              `json:"mirror,omitempty"`
+}
+
+// SnapshotForward is a single forward in a Snapshot
+type SnapshotForward struct {
+	// Service is the key of the service, e.g. namespace/name
+	Service string `json:"service"`
+
+	IP        string   `json:"ip,omitempty"`
+	Ports     []string `json:"ports,omitempty"`
+	Hostnames []string `json:"hostnames,omitempty"`
+
+	// Mode is how the service is being forwarded, e.g. forwarded or
+	// exposed
+	Mode string `json:"mode"`
+}
+
+// IPs returns the IP of every forward that had one, keyed by service
+func (s *Snapshot) IPs() map[string]string {
+	ips := make(map[string]string)
+	for _, f := range s.Forwards {
+		if f.IP != "" {
+			ips[f.Service] = f.IP
+		}
+	}
+	return ips
+}
+
+// SnapshotPath returns the path of the snapshot of the given instance
+func SnapshotPath(instance string) (string, error) {
+	return servicesPath("snapshots", instance)
+}
+
+// ReadSnapshot reads the snapshot of the given instance, returning nil if
+// there isn't one
+func ReadSnapshot(instance string) (*Snapshot, error) {
+	path, err := SnapshotPath(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read snapshot")
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, errors.Wrap(err, "failed to parse snapshot")
+	}
+
+	return &s, nil
+}
+
+// WriteSnapshot writes the snapshot of the given instance
+func WriteSnapshot(instance string, s *Snapshot) error {
+	path, err := SnapshotPath(instance)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create snapshot directory")
+	}
+
+	// write it somewhere else first, so a crash while writing it doesn't
+	// leave a partial snapshot behind
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return errors.Wrap(err, "failed to write snapshot")
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Wrap(err, "failed to write snapshot")
+	}
+
+	chownToSudoUser(filepath.Dir(filepath.Dir(path)), filepath.Dir(path), path)
+	return nil
+}
+
+// OwnerPath returns the path of the file storing the owner ID of the given
+// instance
+func OwnerPath(instance string) (string, error) {
+	return servicesPath("owners", instance)
+}
+
+// OwnerID returns an ID unique to this machine and instance, which marks
+// the objects the daemon creates in the cluster so that it only ever
+// cleans up its own. It's generated the first time it's needed and kept
+// across restarts, and is a valid DNS label and label value.
+func OwnerID(instance string) (string, error) {
+	path, err := OwnerPath(instance)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err == nil {
+		var id string
+		if err := json.Unmarshal(b, &id); err == nil && id != "" {
+			return id, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", errors.Wrap(err, "failed to read owner ID")
+	}
+
+	raw := make([]byte, 4)
+	if _, err := rand.Read(raw); err != nil {
+		return "", errors.Wrap(err, "failed to generate owner ID")
+	}
+	id := hex.EncodeToString(raw)
+
+	b, err = json.Marshal(id)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", errors.Wrap(err, "failed to create owner directory")
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return "", errors.Wrap(err, "failed to write owner ID")
+	}
+
+	chownToSudoUser(filepath.Dir(filepath.Dir(path)), filepath.Dir(path), path)
+	return id, nil
+}
+
+// RemoveState removes the state file of the given instance
+func RemoveState(instance string) error {
+	path, err := StatePath(instance)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
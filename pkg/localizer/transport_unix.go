@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package localizer
+
+import (
+	"path/filepath"
+
+	"google.golang.org/grpc"
+)
+
+// addressScheme is the scheme of addresses that point to a local daemon
+const addressScheme = "unix://"
+
+// socketFor returns the path of the socket with the given name
+func socketFor(name string) string {
+	return runPath(name + ".sock")
+}
+
+// runPath returns the path of a runtime file with the given name
+func runPath(name string) string {
+	return filepath.Join("/var/run", name)
+}
+
+// dialOptions returns the gRPC target and options needed to dial address,
+// gRPC supports unix sockets natively.
+func dialOptions(address string) (string, []grpc.DialOption) {
+	return address, nil
+}
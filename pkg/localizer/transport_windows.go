@@ -0,0 +1,42 @@
+package localizer
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Microsoft/go-winio"
+	"google.golang.org/grpc"
+)
+
+// addressScheme is the scheme of addresses that point to a local daemon
+const addressScheme = "npipe://"
+
+// socketFor returns the path of the named pipe with the given name
+func socketFor(name string) string {
+	return `\\.\pipe\` + name
+}
+
+// runPath returns the path of a runtime file with the given name
+func runPath(name string) string {
+	dir := filepath.Join(os.Getenv("ProgramData"), "localizer")
+	os.MkdirAll(dir, 0755) //nolint:errcheck // Why: Writing to the file will fail instead
+	return filepath.Join(dir, name)
+}
+
+// dialOptions returns the gRPC target and options needed to dial address,
+// named pipes need a custom dialer as gRPC doesn't support them.
+func dialOptions(address string) (string, []grpc.DialOption) {
+	if !strings.HasPrefix(address, addressScheme) {
+		return address, nil
+	}
+
+	pipe := strings.TrimPrefix(address, addressScheme)
+	return "passthrough:///localizer", []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return winio.DialPipeContext(ctx, pipe)
+		}),
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package localizertest_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/getoutreach/localizer/pkg/localizertest"
+	"github.com/getoutreach/localizer/pkg/proxier"
+)
+
+// startEcho forwards an echo service in a fake cluster, returning the
+// address to connect to it on
+func startEcho(b *testing.B) string {
+	ctx, c := localizertest.NewLoopbackCluster(b, 5*time.Minute)
+	if err := c.AddService(ctx, "default", "echo", []int32{18080}, localizertest.EchoHandler); err != nil {
+		b.Fatal(err)
+	}
+
+	p, stop := startProxier(ctx, b, c, c.ProxyOpts())
+	b.Cleanup(stop)
+
+	status, err := localizertest.WaitForStatus(ctx, p, "default", "echo", proxier.PortForwardStatusRunning)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return net.JoinHostPort(status.IP, "18080")
+}
+
+func BenchmarkClusterConnect(b *testing.B) {
+	addr := startEcho(b)
+
+	buf := make([]byte, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		// round trip a byte to ensure the connection made it to the pod
+		if _, err := conn.Write(buf); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			b.Fatal(err)
+		}
+		conn.Close()
+	}
+}
+
+func BenchmarkClusterThroughput(b *testing.B) {
+	addr := startEcho(b)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 32*1024)
+
+	// wait for everything to be echoed back, rather than relying on a
+	// half-close making it through to the pod
+	done := make(chan struct{})
+	go func() {
+		io.CopyN(ioutil.Discard, conn, int64(b.N*len(buf))) //nolint:errcheck
+		close(done)
+	}()
+
+	b.SetBytes(int64(len(buf)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := conn.Write(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+	<-done
+}
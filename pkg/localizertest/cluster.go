@@ -0,0 +1,368 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package localizertest provides a fake Kubernetes cluster for testing
+// tools that embed localizer's proxier, without needing a real cluster or
+// root. Services are backed by fake pods whose connections are handled
+// in-process by a Handler, and the hosts file is a temporary file.
+package localizertest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getoutreach/localizer/pkg/proxier"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// Cluster is a fake Kubernetes cluster
+type Cluster struct {
+	// Client is the fake clientset, objects can be created with it directly
+	Client *fake.Clientset
+
+	// Informers is the informer factory the proxier should watch with
+	Informers informers.SharedInformerFactory
+
+	// HostsFile is the path to the temporary hosts file
+	HostsFile string
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+
+	// generations is the number of times the pod of a service has been
+	// replaced, keyed by namespace/name
+	generations map[string]int
+}
+
+// NewCluster creates a new, empty, fake cluster. Its temporary files are
+// removed when the test finishes.
+func NewCluster(tb testing.TB) *Cluster {
+	tb.Helper()
+
+	hostsFile := filepath.Join(tb.TempDir(), "hosts")
+	if err := ioutil.WriteFile(hostsFile, []byte("127.0.0.1 localhost\n"), 0o600); err != nil {
+		tb.Fatalf("failed to create hosts file: %v", err)
+	}
+
+	client := fake.NewSimpleClientset()
+	return &Cluster{
+		Client:    client,
+		Informers: informers.NewSharedInformerFactory(client, 0),
+		HostsFile: hostsFile,
+		handlers:  make(map[string]Handler),
+
+		generations: make(map[string]int),
+	}
+}
+
+// NewLoopbackCluster creates a new cluster for a test that forwards
+// services with ProxyOpts, skipping it on platforms that don't route all
+// of 127.0.0.0/8 to the loopback interface. The returned context is
+// canceled after timeout, or when the test finishes.
+func NewLoopbackCluster(tb testing.TB, timeout time.Duration) (context.Context, *Cluster) {
+	tb.Helper()
+
+	if runtime.GOOS != "linux" {
+		tb.Skip("requires 127.0.0.0/8 to be routed to the loopback interface")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	tb.Cleanup(cancel)
+
+	return ctx, NewCluster(tb)
+}
+
+// Start starts the informers of the cluster and waits for them to sync.
+// Informers are only started if they've been requested, so this should
+// be called after proxier.NewProxier.
+func (c *Cluster) Start(ctx context.Context) {
+	c.Informers.Start(ctx.Done())
+	c.Informers.WaitForCacheSync(ctx.Done())
+}
+
+// ProxyOpts returns options for proxier.NewProxier that use this cluster.
+// IPs are allocated from 127.0.0.0/8, which is only fully routed to the
+// loopback interface on Linux.
+func (c *Cluster) ProxyOpts() *proxier.ProxyOpts {
+	return &proxier.ProxyOpts{
+		ClusterDomain: "cluster.local",
+		IPCidr:        "127.0.0.1/8",
+		HostsFile:     c.HostsFile,
+		Informers:     c.Informers,
+		Dialer:        c.Dialer,
+	}
+}
+
+// RestConfig returns a rest.Config for proxier.NewProxier, it isn't used
+// to talk to anything when using ProxyOpts.
+func (c *Cluster) RestConfig() *rest.Config {
+	return &rest.Config{Host: "http://localizertest.invalid"}
+}
+
+// Dialer returns a dialer that connects to a pod's Handler, it's a
+// proxier.DialerFunc
+func (c *Cluster) Dialer(namespace, pod string) (httpstream.Dialer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.handlers[namespace+"/"+pod]
+	if !ok {
+		return nil, fmt.Errorf("pod %s/%s doesn't exist", namespace, pod)
+	}
+	return &dialer{handler: h}, nil
+}
+
+// createPod creates the next pod of a service, returning its endpoint
+// address
+func (c *Cluster) createPod(ctx context.Context, namespace, name string, h Handler) (corev1.EndpointAddress, error) {
+	c.mu.Lock()
+	gen := c.generations[namespace+"/"+name]
+	c.generations[namespace+"/"+name]++
+	podName := fmt.Sprintf("%s-%d", name, gen)
+	c.handlers[namespace+"/"+podName] = h
+	c.mu.Unlock()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: podName, Labels: map[string]string{"app": name}},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			PodIP: fmt.Sprintf("10.0.0.%d", gen+1),
+		},
+	}
+	if _, err := c.Client.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return corev1.EndpointAddress{}, err
+	}
+
+	return corev1.EndpointAddress{
+		IP: pod.Status.PodIP,
+		TargetRef: &corev1.ObjectReference{
+			Kind:      proxier.PodKind,
+			Namespace: namespace,
+			Name:      podName,
+		},
+	}, nil
+}
+
+// AddService creates a service with the given ports, backed by a single
+// running pod whose connections are handled by h. The pod is named
+// <name>-0.
+func (c *Cluster) AddService(ctx context.Context, namespace, name string, ports []int32, h Handler) error {
+	address, err := c.createPod(ctx, namespace, name, h)
+	if err != nil {
+		return err
+	}
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": name},
+		},
+	}
+	endpointPorts := make([]corev1.EndpointPort, len(ports))
+	for i, port := range ports {
+		portName := fmt.Sprintf("port-%d", port)
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+			Name:       portName,
+			Port:       port,
+			TargetPort: intstr.FromInt(int(port)),
+			Protocol:   corev1.ProtocolTCP,
+		})
+		endpointPorts[i] = corev1.EndpointPort{Name: portName, Port: port, Protocol: corev1.ProtocolTCP}
+	}
+	if _, err := c.Client.CoreV1().Services(namespace).Create(ctx, svc, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{address},
+			Ports:     endpointPorts,
+		}},
+	}
+	_, err = c.Client.CoreV1().Endpoints(namespace).Create(ctx, endpoints, metav1.CreateOptions{})
+	return err
+}
+
+// ReplacePod replaces the pod of a service like a rolling deploy would. A new
+// pod is created and added to the service's endpoints, and the old one is
+// marked as terminating. The name of the new pod is returned.
+func (c *Cluster) ReplacePod(ctx context.Context, namespace, name string) (string, error) {
+	c.mu.Lock()
+	oldName := fmt.Sprintf("%s-%d", name, c.generations[namespace+"/"+name]-1)
+	h := c.handlers[namespace+"/"+oldName]
+	c.mu.Unlock()
+
+	address, err := c.createPod(ctx, namespace, name, h)
+	if err != nil {
+		return "", err
+	}
+
+	endpoints, err := c.Client.CoreV1().Endpoints(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	endpoints.Subsets[0].Addresses = append(endpoints.Subsets[0].Addresses, address)
+	if _, err := c.Client.CoreV1().Endpoints(namespace).Update(ctx, endpoints, metav1.UpdateOptions{}); err != nil {
+		return "", err
+	}
+
+	old, err := c.Client.CoreV1().Pods(namespace).Get(ctx, oldName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	now := metav1.Now()
+	old.DeletionTimestamp = &now
+	if _, err := c.Client.CoreV1().Pods(namespace).Update(ctx, old, metav1.UpdateOptions{}); err != nil {
+		return "", err
+	}
+
+	return address.TargetRef.Name, nil
+}
+
+// AddPod adds another ready pod to a service, like scaling it up would. The
+// new pod is handled by the same Handler, and its name is returned.
+func (c *Cluster) AddPod(ctx context.Context, namespace, name string) (string, error) {
+	c.mu.Lock()
+	h := c.handlers[fmt.Sprintf("%s/%s-0", namespace, name)]
+	c.mu.Unlock()
+
+	address, err := c.createPod(ctx, namespace, name, h)
+	if err != nil {
+		return "", err
+	}
+
+	endpoints, err := c.Client.CoreV1().Endpoints(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	endpoints.Subsets[0].Addresses = append(endpoints.Subsets[0].Addresses, address)
+	if _, err := c.Client.CoreV1().Endpoints(namespace).Update(ctx, endpoints, metav1.UpdateOptions{}); err != nil {
+		return "", err
+	}
+
+	return address.TargetRef.Name, nil
+}
+
+// SetReady marks the pod of a service as ready or not ready, moving it
+// in or out of the ready addresses of the service's endpoints
+func (c *Cluster) SetReady(ctx context.Context, namespace, name string, ready bool) error {
+	endpoints, err := c.Client.CoreV1().Endpoints(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	for i := range endpoints.Subsets {
+		subset := &endpoints.Subsets[i]
+		addresses := make([]corev1.EndpointAddress, 0, len(subset.Addresses)+len(subset.NotReadyAddresses))
+		addresses = append(addresses, subset.Addresses...)
+		addresses = append(addresses, subset.NotReadyAddresses...)
+
+		subset.Addresses, subset.NotReadyAddresses = nil, nil
+		if ready {
+			subset.Addresses = addresses
+		} else {
+			subset.NotReadyAddresses = addresses
+		}
+	}
+
+	_, err = c.Client.CoreV1().Endpoints(namespace).Update(ctx, endpoints, metav1.UpdateOptions{})
+	return err
+}
+
+// DeleteService deletes a service, its endpoints, and its pods
+func (c *Cluster) DeleteService(ctx context.Context, namespace, name string) error {
+	pods, err := c.Client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: "app=" + name})
+	if err != nil {
+		return err
+	}
+
+	//nolint:govet // Why: We're OK shadowing err
+	if err := c.Client.CoreV1().Endpoints(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		return err
+	}
+
+	for i := range pods.Items {
+		c.mu.Lock()
+		delete(c.handlers, namespace+"/"+pods.Items[i].Name)
+		c.mu.Unlock()
+
+		//nolint:govet // Why: We're OK shadowing err
+		if err := c.Client.CoreV1().Pods(namespace).Delete(ctx, pods.Items[i].Name, metav1.DeleteOptions{}); err != nil {
+			return err
+		}
+	}
+
+	return c.Client.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+// Hosts returns the contents of the hosts file
+func (c *Cluster) Hosts() (string, error) {
+	b, err := ioutil.ReadFile(c.HostsFile)
+	return string(b), err
+}
+
+// WaitFor waits for cond to return true for the statuses of the
+// port-forwards of p, or ctx to be canceled
+func WaitFor(ctx context.Context, p proxier.ForwardManager, cond func([]proxier.ServiceStatus) bool) error {
+	t := time.NewTicker(50 * time.Millisecond)
+	defer t.Stop()
+
+	for {
+		if statuses, err := p.List(ctx); err == nil && cond(statuses) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// WaitForStatus waits for the service's port-forward to be in the given
+// status, returning it.
+func WaitForStatus(ctx context.Context, p proxier.ForwardManager, namespace, name string,
+	status proxier.PortForwardStatus) (*proxier.ServiceStatus, error) {
+	var found *proxier.ServiceStatus
+	err := WaitFor(ctx, p, func(statuses []proxier.ServiceStatus) bool {
+		for i := range statuses {
+			s := &statuses[i]
+			if s.ServiceInfo.Namespace == namespace && s.ServiceInfo.Name == name &&
+				len(s.Statuses) > 0 && s.Statuses[0] == status {
+				found = s
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, fmt.Errorf("timed out waiting for %s/%s to be %s", namespace, name, status)
+	}
+
+	return found, nil
+}
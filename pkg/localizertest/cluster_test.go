@@ -0,0 +1,1003 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package localizertest_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getoutreach/localizer/pkg/hostsfile"
+	"github.com/getoutreach/localizer/pkg/localizertest"
+	"github.com/getoutreach/localizer/pkg/proxier"
+	"github.com/sirupsen/logrus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// startProxier starts a proxier for the cluster, the returned function stops
+// it and waits for it to clean up
+func startProxier(ctx context.Context, tb testing.TB, c *localizertest.Cluster,
+	opts *proxier.ProxyOpts) (*proxier.Proxier, func()) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	p, err := proxier.NewProxier(ctx, c.Client, c.RestConfig(), log, opts)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	c.Start(ctx)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		p.Start(ctx) //nolint:errcheck // Why: Only returns an error on start up
+		close(done)
+	}()
+
+	return p, func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestClusterForward(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	if err := c.AddService(ctx, "default", "echo", []int32{18080}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+
+	p, stop := startProxier(ctx, t, c, c.ProxyOpts())
+	defer stop()
+
+	status, err := localizertest.WaitForStatus(ctx, p, "default", "echo", proxier.PortForwardStatusRunning)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hosts, err := c.Hosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(hosts, status.IP+" echo ") {
+		t.Fatalf("expected hosts file to contain an entry for %s, got:\n%s", status.IP, hosts)
+	}
+
+	conn, err := net.Dial("tcp", net.JoinHostPort(status.IP, "18080"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 5)
+	if _, err := conn.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "hello" {
+		t.Fatalf("expected echo of hello, got %q", buf)
+	}
+}
+
+func TestClusterNewServices(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	opts := c.ProxyOpts()
+	opts.Namespaces = []string{"default"}
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	// created after the proxier started, and in a namespace that isn't
+	// being forwarded
+	if err := c.AddService(ctx, "other", "ignored", []int32{18081}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddService(ctx, "default", "new", []int32{18082}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := localizertest.WaitForStatus(ctx, p, "default", "new", proxier.PortForwardStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := p.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.ServiceInfo.Namespace == "other" {
+			t.Fatalf("expected services in namespace other to not be forwarded, got %s", s.ServiceInfo.Key())
+		}
+	}
+
+	if err := c.DeleteService(ctx, "default", "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = localizertest.WaitFor(ctx, p, func(statuses []proxier.ServiceStatus) bool {
+		return len(statuses) == 0
+	})
+	if err != nil {
+		t.Fatal("expected the port-forward to be removed when the service was deleted")
+	}
+}
+
+func TestClusterSelectedServices(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	for i, name := range []string{"picked", "skipped"} {
+		if err := c.AddService(ctx, "default", name, []int32{int32(18150 + i)}, localizertest.EchoHandler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := c.ProxyOpts()
+	opts.Services = []string{"default/picked"}
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	if _, err := localizertest.WaitForStatus(ctx, p, "default", "picked", proxier.PortForwardStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := p.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 {
+		t.Fatalf("expected only default/picked to be forwarded, got %d services", len(statuses))
+	}
+}
+
+func TestClusterSystemNamespaces(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	if err := c.AddService(ctx, "kube-system", "kube-dns", []int32{18160}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddService(ctx, "default", "app", []int32{18161}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+
+	p, stop := startProxier(ctx, t, c, c.ProxyOpts())
+	defer stop()
+
+	if _, err := localizertest.WaitForStatus(ctx, p, "default", "app", proxier.PortForwardStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := p.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.ServiceInfo.Namespace == "kube-system" {
+			t.Fatal("expected services in kube-system to not be forwarded")
+		}
+	}
+}
+
+func TestClusterWaitingForEndpoints(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	if err := c.AddService(ctx, "default", "web", []int32{18083}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetReady(ctx, "default", "web", false); err != nil {
+		t.Fatal(err)
+	}
+
+	p, stop := startProxier(ctx, t, c, c.ProxyOpts())
+	defer stop()
+
+	if _, err := localizertest.WaitForStatus(ctx, p, "default", "web", proxier.PortForwardStatusWaiting); err != nil {
+		t.Fatal(err)
+	}
+
+	prog := p.Progress()
+	if prog.Running != 0 || len(prog.Waiting) != 1 || prog.Waiting[0] != "default/web" {
+		t.Fatalf("expected default/web to be reported as waiting, got %+v", prog)
+	}
+
+	if err := c.SetReady(ctx, "default", "web", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := localizertest.WaitForStatus(ctx, p, "default", "web", proxier.PortForwardStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterReplacePod(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	if err := c.AddService(ctx, "default", "api", []int32{18084}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+
+	p, stop := startProxier(ctx, t, c, c.ProxyOpts())
+	defer stop()
+
+	if _, err := localizertest.WaitForStatus(ctx, p, "default", "api", proxier.PortForwardStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	newPod, err := c.ReplacePod(ctx, "default", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = localizertest.WaitFor(ctx, p, func(statuses []proxier.ServiceStatus) bool {
+		return len(statuses) == 1 && statuses[0].Endpoint.Name == newPod &&
+			statuses[0].Statuses[0] == proxier.PortForwardStatusRunning
+	})
+	if err != nil {
+		t.Fatalf("expected the port-forward to move to the new pod %s", newPod)
+	}
+}
+
+func TestClusterIPPoolExhausted(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	opts := c.ProxyOpts()
+
+	// only has room for two services, the network and broadcast addresses
+	// can't be used
+	opts.IPCidr = "127.0.2.0/30"
+
+	for i, name := range []string{"one", "two", "three"} {
+		if err := c.AddService(ctx, "default", name, []int32{int32(18090 + i)}, localizertest.EchoHandler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	var waiting string
+	err := localizertest.WaitFor(ctx, p, func(statuses []proxier.ServiceStatus) bool {
+		running := 0
+		for i := range statuses {
+			switch statuses[i].Statuses[0] {
+			case proxier.PortForwardStatusRunning:
+				running++
+			case proxier.PortForwardStatusWaiting:
+				if strings.Contains(statuses[i].Reason, "exhausted") {
+					waiting = statuses[i].ServiceInfo.Name
+				}
+			}
+		}
+		return running == 2 && waiting != ""
+	})
+	if err != nil {
+		t.Fatal("timed out waiting for the IP pool to be exhausted")
+	}
+
+	usage, err := p.IPPoolUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Acquired != usage.Available {
+		t.Fatalf("expected the IP pool to be full, got %d/%d", usage.Acquired, usage.Available)
+	}
+
+	// freeing an IP should let the waiting service be forwarded
+	deleted := "one"
+	if waiting == deleted {
+		deleted = "two"
+	}
+	if err := c.DeleteService(ctx, "default", deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := localizertest.WaitForStatus(ctx, p, "default", waiting, proxier.PortForwardStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterPauseResume(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	if err := c.AddService(ctx, "default", "echo", []int32{18100}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+
+	p, stop := startProxier(ctx, t, c, c.ProxyOpts())
+	defer stop()
+
+	status, err := localizertest.WaitForStatus(ctx, p, "default", "echo", proxier.PortForwardStatusRunning)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Pause(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := localizertest.WaitForStatus(ctx, p, "default", "echo", proxier.PortForwardStatusPaused); err != nil {
+		t.Fatal(err)
+	}
+
+	// the tunnel should be closed, but the hosts entry kept
+	if conn, err := net.Dial("tcp", net.JoinHostPort(status.IP, "18100")); err == nil {
+		conn.Close()
+		t.Fatal("expected the tunnel to be closed while paused")
+	}
+	hosts, err := c.Hosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(hosts, " echo ") {
+		t.Fatalf("expected hosts file to keep the entry for echo while paused, got:\n%s", hosts)
+	}
+
+	if err := p.Resume(ctx); err != nil {
+		t.Fatal(err)
+	}
+	status, err = localizertest.WaitForStatus(ctx, p, "default", "echo", proxier.PortForwardStatusRunning)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("tcp", net.JoinHostPort(status.IP, "18100"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
+
+func TestClusterDisableService(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	for i, name := range []string{"one", "two"} {
+		if err := c.AddService(ctx, "default", name, []int32{int32(18110 + i)}, localizertest.EchoHandler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := c.ProxyOpts()
+	opts.Disabled = []string{"default/two"}
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	if _, err := localizertest.WaitForStatus(ctx, p, "default", "one", proxier.PortForwardStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	forwarded := func(name string) func([]proxier.ServiceStatus) bool {
+		return func(statuses []proxier.ServiceStatus) bool {
+			for i := range statuses {
+				if statuses[i].ServiceInfo.Name == name {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	p.SetEnabled("default/one", false)
+	err := localizertest.WaitFor(ctx, p, func(statuses []proxier.ServiceStatus) bool {
+		return !forwarded("one")(statuses)
+	})
+	if err != nil {
+		t.Fatal("timed out waiting for one to stop being forwarded")
+	}
+	if statuses, _ := p.List(ctx); forwarded("two")(statuses) { //nolint:errcheck // Why: Checked by WaitFor
+		t.Fatal("expected two to not be forwarded while disabled")
+	}
+
+	p.SetEnabled("default/two", true)
+	if _, err := localizertest.WaitForStatus(ctx, p, "default", "two", proxier.PortForwardStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	if disabled := p.Disabled(); len(disabled) != 1 || disabled[0] != "default/one" {
+		t.Fatalf("expected only default/one to be disabled, got %v", disabled)
+	}
+}
+
+func TestClusterForwardPod(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	if err := c.AddService(ctx, "default", "db", []int32{18120}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+
+	p, stop := startProxier(ctx, t, c, c.ProxyOpts())
+	defer stop()
+
+	if err := p.ForwardPod("default", "db-0", []string{"18121:18120"}); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := localizertest.WaitForStatus(ctx, p, "default", "db-0", proxier.PortForwardStatusRunning)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ServiceInfo.Kind != proxier.PodKind {
+		t.Fatalf("expected a port-forward to a pod, got kind %q", status.ServiceInfo.Kind)
+	}
+
+	hosts, err := c.Hosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(hosts, status.IP+" db-0.default.pod") {
+		t.Fatalf("expected hosts file to contain an entry for %s, got:\n%s", status.IP, hosts)
+	}
+
+	conn, err := net.Dial("tcp", net.JoinHostPort(status.IP, "18121"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err := conn.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if string(buf) != "hello" {
+		t.Fatalf("expected echo of hello, got %q", buf)
+	}
+
+	if err := p.StopForwardPod("default", "db-0"); err != nil {
+		t.Fatal(err)
+	}
+	err = localizertest.WaitFor(ctx, p, func(statuses []proxier.ServiceStatus) bool {
+		for i := range statuses {
+			if statuses[i].ServiceInfo.Kind == proxier.PodKind {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal("timed out waiting for the pod to stop being forwarded")
+	}
+}
+
+func TestClusterLeaderLock(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	if err := c.AddService(ctx, "default", "controller", []int32{18130}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+	leader, err := c.AddPod(ctx, "default", "controller")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// controller-runtime style identity, <pod>_<uuid>
+	holder := leader + "_6c1b8f2e"
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "controller-leader"},
+		Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holder},
+	}
+	if _, err := c.Client.CoordinationV1().Leases("default").Create(ctx, lease, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := c.ProxyOpts()
+	opts.LeaderLocks = map[string]string{"default/controller": "lease/controller-leader"}
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	status, err := localizertest.WaitForStatus(ctx, p, "default", "controller", proxier.PortForwardStatusRunning)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Endpoint.Name != leader {
+		t.Fatalf("expected the leader %s to be used, got %s", leader, status.Endpoint.Name)
+	}
+
+	// move the lease back to the first pod, without touching the endpoints,
+	// the lease being watched should move the port-forward
+	holder = "controller-0"
+	if _, err := c.Client.CoordinationV1().Leases("default").Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = localizertest.WaitFor(ctx, p, func(statuses []proxier.ServiceStatus) bool {
+		for i := range statuses {
+			if statuses[i].ServiceInfo.Name == "controller" && statuses[i].Endpoint.Name == "controller-0" &&
+				statuses[i].Statuses[0] == proxier.PortForwardStatusRunning {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		t.Fatal("timed out waiting for the port-forward to move to the new leader")
+	}
+}
+
+func TestClusterFanOut(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	if err := c.AddService(ctx, "default", "cache", []int32{18140}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.AddPod(ctx, "default", "cache"); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := c.ProxyOpts()
+	opts.FanOut = map[string]bool{"default/cache": true}
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	ips := make(map[string]string)
+	err := localizertest.WaitFor(ctx, p, func(statuses []proxier.ServiceStatus) bool {
+		for i := range statuses {
+			s := &statuses[i]
+			if s.Statuses[0] == proxier.PortForwardStatusRunning {
+				ips[s.ServiceInfo.Key()] = s.IP
+			}
+		}
+		return len(ips) == 3
+	})
+	if err != nil {
+		t.Fatalf("timed out waiting for the service and both replicas to be forwarded, got %v", ips)
+	}
+
+	hosts, err := c.Hosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pod := range []string{"cache-0", "cache-1"} {
+		ip := ips["pod/default/"+pod]
+		if !strings.Contains(hosts, ip+" "+pod+".default.pod "+pod+".default "+pod+".cache.default") {
+			t.Fatalf("expected hosts file to contain entries for %s on %s, got:\n%s", pod, ip, hosts)
+		}
+
+		conn, err := net.Dial("tcp", net.JoinHostPort(ip, "18140"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}
+}
+
+func TestClusterTopology(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	for i, name := range []string{"local", "hinted"} {
+		if err := c.AddService(ctx, "default", name, []int32{int32(18170 + i)}, localizertest.EchoHandler); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.AddPod(ctx, "default", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// local only routes to pods on the same node, and its second pod is
+	// on ours
+	local, err := c.Client.CoreV1().Services("default").Get(ctx, "local", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := corev1.ServiceInternalTrafficPolicyLocal
+	local.Spec.InternalTrafficPolicy = &policy
+	if _, err := c.Client.CoreV1().Services("default").Update(ctx, local, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	endpoints, err := c.Client.CoreV1().Endpoints("default").Get(ctx, "local", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, node := range []string{"node-a", "node-b"} {
+		node := node
+		endpoints.Subsets[0].Addresses[i].NodeName = &node
+	}
+	if _, err := c.Client.CoreV1().Endpoints("default").Update(ctx, endpoints, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// hinted has its second pod hinted for our zone
+	hinted, err := c.Client.CoreV1().Services("default").Get(ctx, "hinted", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hinted.Annotations = map[string]string{proxier.TopologyHintsAnnotation: "Auto"}
+	if _, err := c.Client.CoreV1().Services("default").Update(ctx, hinted, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "hinted-abc",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "hinted"},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"10.0.0.1"}, Hints: &discoveryv1.EndpointHints{ForZones: []discoveryv1.ForZone{{Name: "zone-a"}}}},
+			{Addresses: []string{"10.0.0.2"}, Hints: &discoveryv1.EndpointHints{ForZones: []discoveryv1.ForZone{{Name: "zone-b"}}}},
+		},
+	}
+	if _, err := c.Client.DiscoveryV1().EndpointSlices("default").Create(ctx, slice, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := c.ProxyOpts()
+	opts.Node = "node-b"
+	opts.Zone = "zone-b"
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	for _, name := range []string{"local", "hinted"} {
+		status, err := localizertest.WaitForStatus(ctx, p, "default", name, proxier.PortForwardStatusRunning)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Endpoint.Name != name+"-1" {
+			t.Fatalf("expected %s-1 to be used, got %s", name, status.Endpoint.Name)
+		}
+	}
+}
+
+// hasHostname returns true if ip has the hostname name in hosts
+func hasHostname(hosts, ip, name string) bool {
+	for _, line := range strings.Split(hosts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != ip {
+			continue
+		}
+		for _, f := range fields[1:] {
+			if f == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestClusterHostnameCollisions(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	for i, ns := range []string{"default", "other"} {
+		if err := c.AddService(ctx, ns, "api", []int32{int32(18180 + i)}, localizertest.EchoHandler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := c.ProxyOpts()
+	opts.HostnameCollisions = string(proxier.HostnameCollisionPriority)
+	opts.HostnamePriority = []string{"other"}
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	ips := make(map[string]string)
+	for _, ns := range []string{"default", "other"} {
+		status, err := localizertest.WaitForStatus(ctx, p, ns, "api", proxier.PortForwardStatusRunning)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ips[ns] = status.IP
+	}
+
+	hosts, err := c.Hosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(hosts, ips["other"]+" api ") {
+		t.Fatalf("expected other/api to get the api hostname, got:\n%s", hosts)
+	}
+	if strings.Contains(hosts, ips["default"]+" api ") || !strings.Contains(hosts, ips["default"]+" api.default ") {
+		t.Fatalf("expected default/api to only get its qualified hostnames, got:\n%s", hosts)
+	}
+
+	collisions := p.HostnameCollisions()
+	if len(collisions) != 1 || collisions[0].Hostname != "api" || collisions[0].Owner != "other/api" {
+		t.Fatalf("expected a collision on api owned by other/api, got %+v", collisions)
+	}
+
+	// the hostname is handed to default/api once other/api is gone
+	if err := c.DeleteService(ctx, "other", "api"); err != nil {
+		t.Fatal(err)
+	}
+	for !hasHostname(hosts, ips["default"], "api") {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("expected default/api to get the api hostname once other/api was deleted, got:\n%s", hosts)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		if hosts, err = c.Hosts(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if collisions := p.HostnameCollisions(); len(collisions) != 0 {
+		t.Fatalf("expected no collisions once other/api was deleted, got %+v", collisions)
+	}
+}
+
+func TestClusterHostnameSuffixes(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	for i, ns := range []string{"default", "payments"} {
+		if err := c.AddService(ctx, ns, "api", []int32{int32(18190 + i)}, localizertest.EchoHandler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := c.ProxyOpts()
+	opts.HostnameSuffixes = map[string]string{"payments": "*.payments.test"}
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	status, err := localizertest.WaitForStatus(ctx, p, "payments", "api", proxier.PortForwardStatusRunning)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := localizertest.WaitForStatus(ctx, p, "default", "api", proxier.PortForwardStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	hosts, err := c.Hosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(hosts, "api.payments.test") {
+		t.Fatalf("expected payments/api to get api.payments.test, got:\n%s", hosts)
+	}
+	for _, line := range strings.Split(hosts, "\n") {
+		if strings.Contains(line, "api.payments.test") && !strings.HasPrefix(line, status.IP+" ") {
+			t.Fatalf("expected api.payments.test to point to %s, got %q", status.IP, line)
+		}
+	}
+}
+
+func TestClusterWildcards(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	for i, name := range []string{"myapp", "other"} {
+		if err := c.AddService(ctx, "default", name, []int32{int32(18200 + i)}, localizertest.EchoHandler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := c.ProxyOpts()
+	opts.Wildcards = map[string]bool{"default/myapp": true}
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	status, err := localizertest.WaitForStatus(ctx, p, "default", "myapp", proxier.PortForwardStatusRunning)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := localizertest.WaitForStatus(ctx, p, "default", "other", proxier.PortForwardStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"myapp.default", "tenant.myapp.default", "a.b.myapp.default.svc.cluster.local", "Tenant.MyApp"} {
+		ip, ok := p.Resolve(name)
+		if !ok || ip.String() != status.IP {
+			t.Fatalf("expected %s to resolve to %s, got %v", name, status.IP, ip)
+		}
+	}
+
+	if ip, ok := p.Resolve("tenant.other.default"); ok {
+		t.Fatalf("expected subdomains of other to not resolve, got %v", ip)
+	}
+}
+
+func TestClusterPortNames(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	if err := c.AddService(ctx, "default", "api", []int32{18210, 18211}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddService(ctx, "default", "web", []int32{18212, 18213}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := c.ProxyOpts()
+	opts.PortNames = []string{"port-18210"}
+	opts.ServicePortNames = map[string][]string{"default/web": {"port-18213"}}
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	for name, want := range map[string]string{"api": "18210:18210", "web": "18213:18213"} {
+		status, err := localizertest.WaitForStatus(ctx, p, "default", name, proxier.PortForwardStatusRunning)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(status.Ports) != 1 || status.Ports[0] != want {
+			t.Fatalf("expected default/%s to only forward %s, got %v", name, want, status.Ports)
+		}
+	}
+}
+
+func TestClusterRandomPorts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c := localizertest.NewCluster(t)
+	for _, name := range []string{"api", "web"} {
+		if err := c.AddService(ctx, "default", name, []int32{18220}, localizertest.EchoHandler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := c.ProxyOpts()
+	opts.RandomPorts = true
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	addresses := make(map[string]bool)
+	for _, name := range []string{"api", "web"} {
+		status, err := localizertest.WaitForStatus(ctx, p, "default", name, proxier.PortForwardStatusRunning)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.IP != "127.0.0.1" || len(status.Ports) != 1 || strings.HasPrefix(status.Ports[0], "18220:") {
+			t.Fatalf("expected default/%s on a random port of 127.0.0.1, got %s %v", name, status.IP, status.Ports)
+		}
+
+		address := net.JoinHostPort(status.IP, strings.Split(status.Ports[0], ":")[0])
+		addresses[address] = true
+
+		conn, err := net.Dial("tcp", address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		if _, err := conn.Write([]byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 5)
+		if _, err := conn.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != "hello" {
+			t.Fatalf("expected echo of hello, got %q", buf)
+		}
+	}
+	if len(addresses) != 2 {
+		t.Fatalf("expected each service to get its own port, got %v", addresses)
+	}
+
+	hosts, err := c.Hosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(hosts, " api ") {
+		t.Fatalf("expected no hosts entries, got:\n%s", hosts)
+	}
+}
+
+func TestClusterDrain(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	if err := c.AddService(ctx, "default", "db", []int32{18230}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := c.ProxyOpts()
+	opts.DrainTimeout = 20 * time.Second
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	status, err := localizertest.WaitForStatus(ctx, p, "default", "db", proxier.PortForwardStatusRunning)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := net.JoinHostPort(status.IP, "18230")
+
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	echo := func(msg string) {
+		t.Helper()
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, len(msg))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != msg {
+			t.Fatalf("expected echo of %s, got %q", msg, buf)
+		}
+	}
+	echo("hello")
+
+	if err := c.DeleteService(ctx, "default", "db"); err != nil {
+		t.Fatal(err)
+	}
+
+	// new connections are refused once it starts draining
+	for {
+		newConn, err := net.Dial("tcp", address)
+		if err != nil {
+			break
+		}
+		newConn.Close()
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for new connections to be refused")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	// while the existing one keeps working until it's closed
+	echo("still here")
+	conn.Close()
+
+	started := time.Now()
+	err = localizertest.WaitFor(ctx, p, func(statuses []proxier.ServiceStatus) bool {
+		return len(statuses) == 0
+	})
+	if err != nil {
+		t.Fatal("timed out waiting for db to stop being forwarded")
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Fatalf("expected the port-forward to be closed once its connection was, took %s", elapsed)
+	}
+}
+
+func TestClusterPreviousRun(t *testing.T) {
+	ctx, c := localizertest.NewLoopbackCluster(t, 30*time.Second)
+	if err := c.AddService(ctx, "default", "db", []int32{18240}, localizertest.EchoHandler); err != nil {
+		t.Fatal(err)
+	}
+
+	// an entry left behind by a previous run for a service that's gone
+	hosts, err := hostsfile.New(c.HostsFile, "localizer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hosts.AddHosts("127.0.0.78", []string{"gone", "gone.default"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := hosts.Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := c.ProxyOpts()
+	opts.PreviousIPs = map[string]string{"default/db": "127.0.0.77", "default/gone": "127.0.0.78"}
+	opts.CleanupPrevious = true
+
+	p, stop := startProxier(ctx, t, c, opts)
+	defer stop()
+
+	status, err := localizertest.WaitForStatus(ctx, p, "default", "db", proxier.PortForwardStatusRunning)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.IP != "127.0.0.77" {
+		t.Fatalf("expected default/db to get its previous IP 127.0.0.77, got %s", status.IP)
+	}
+
+	contents, err := c.Hosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(contents, "gone") || !strings.Contains(contents, "127.0.0.77 db ") {
+		t.Fatalf("expected only the entries of default/db, got:\n%s", contents)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package localizertest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+)
+
+// Handler handles a connection to a port of a fake pod, it's the stand-in
+// for the process running in the pod. Closing conn signals the client that
+// nothing else will be written.
+type Handler func(port int, conn io.ReadWriteCloser)
+
+// EchoHandler is a Handler that writes everything it receives back
+func EchoHandler(_ int, conn io.ReadWriteCloser) {
+	defer conn.Close()
+	io.Copy(conn, conn) //nolint:errcheck // Why: The connection closing is the only error
+}
+
+// dialer is a httpstream.Dialer that connects port-forward streams to a
+// Handler instead of a pod
+type dialer struct {
+	handler Handler
+}
+
+// Dial returns a new connection to the pod
+func (d *dialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	proto := ""
+	if len(protocols) > 0 {
+		proto = protocols[0]
+	}
+
+	return &connection{
+		handler:      d.handler,
+		closed:       make(chan bool),
+		errorStreams: make(map[string]*stream),
+	}, proto, nil
+}
+
+// connection is a fake port-forward connection. Every request creates an
+// error stream and then a data stream, the error stream is closed once
+// the Handler for the data stream returns.
+type connection struct {
+	handler Handler
+
+	mu           sync.Mutex
+	closed       chan bool
+	isClosed     bool
+	streams      []*stream
+	errorStreams map[string]*stream
+}
+
+// CreateStream creates a new stream, starting the Handler for data streams
+func (c *connection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isClosed {
+		return nil, fmt.Errorf("connection is closed")
+	}
+
+	port, err := strconv.Atoi(headers.Get(corev1.PortHeader))
+	if err != nil {
+		return nil, fmt.Errorf("invalid port header '%s'", headers.Get(corev1.PortHeader))
+	}
+
+	s := newStream(headers, uint32(len(c.streams)+1))
+	c.streams = append(c.streams, s)
+
+	requestID := headers.Get(corev1.PortForwardRequestIDHeader)
+	switch headers.Get(corev1.StreamType) {
+	case corev1.StreamTypeError:
+		c.errorStreams[requestID] = s
+	case corev1.StreamTypeData:
+		errorStream := c.errorStreams[requestID]
+		delete(c.errorStreams, requestID)
+
+		go func() {
+			c.handler(port, s.remote())
+
+			// nothing went wrong, let the client know the request is done
+			if errorStream != nil {
+				errorStream.remote().Close()
+			}
+		}()
+	default:
+		return nil, fmt.Errorf("unknown stream type '%s'", headers.Get(corev1.StreamType))
+	}
+
+	return s, nil
+}
+
+// Close resets all streams and closes the connection
+func (c *connection) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isClosed {
+		return nil
+	}
+	c.isClosed = true
+
+	for _, s := range c.streams {
+		s.Reset() //nolint:errcheck // Why: Always nil
+	}
+	close(c.closed)
+	return nil
+}
+
+// CloseChan returns a channel that is closed when the connection is
+func (c *connection) CloseChan() <-chan bool {
+	return c.closed
+}
+
+// SetIdleTimeout is a no-op, fake connections don't time out
+func (c *connection) SetIdleTimeout(time.Duration) {}
+
+// stream is a fake port-forward stream. Closing it only closes the
+// direction the client writes to, like a SPDY stream.
+type stream struct {
+	headers http.Header
+	id      uint32
+
+	// toClient is read by the client and written by the Handler,
+	// fromClient is the reverse
+	toClientR, fromClientR *io.PipeReader
+	toClientW, fromClientW *io.PipeWriter
+}
+
+func newStream(headers http.Header, id uint32) *stream {
+	s := &stream{headers: headers, id: id}
+	s.toClientR, s.toClientW = io.Pipe()
+	s.fromClientR, s.fromClientW = io.Pipe()
+	return s
+}
+
+func (s *stream) Read(p []byte) (int, error)  { return s.toClientR.Read(p) }
+func (s *stream) Write(p []byte) (int, error) { return s.fromClientW.Write(p) }
+
+// Close closes the client's side of the stream
+func (s *stream) Close() error { return s.fromClientW.Close() }
+
+// Reset closes both directions of the stream
+func (s *stream) Reset() error {
+	s.toClientW.CloseWithError(io.ErrClosedPipe)   //nolint:errcheck // Why: Always nil
+	s.fromClientR.CloseWithError(io.ErrClosedPipe) //nolint:errcheck // Why: Always nil
+	return nil
+}
+
+func (s *stream) Headers() http.Header { return s.headers }
+func (s *stream) Identifier() uint32   { return s.id }
+
+// remote returns the pod's side of the stream
+func (s *stream) remote() io.ReadWriteCloser {
+	return &remoteStream{s}
+}
+
+// remoteStream is the pod's side of a stream
+type remoteStream struct {
+	s *stream
+}
+
+func (r *remoteStream) Read(p []byte) (int, error)  { return r.s.fromClientR.Read(p) }
+func (r *remoteStream) Write(p []byte) (int, error) { return r.s.toClientW.Write(p) }
+func (r *remoteStream) Close() error                { return r.s.toClientW.Close() }
@@ -0,0 +1,38 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package proxier is the forwarding engine of localizer. It watches the
+// services in a cluster and creates a port-forward, IP address, and hosts
+// file entry for each of them, recreating them as their endpoints change.
+//
+// It can be embedded in other tools:
+//
+//	factory := informers.NewSharedInformerFactory(k, 10*time.Minute)
+//	p, err := proxier.NewProxier(ctx, k, kconf, log, &proxier.ProxyOpts{
+//		ClusterDomain: "cluster.local",
+//		IPCidr:        "127.0.0.1/8",
+//		Informers:     factory,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//
+//	factory.Start(ctx.Done())
+//	factory.WaitForCacheSync(ctx.Done())
+//
+//	events := p.Subscribe(ctx)
+//	go p.Start(ctx) //nolint:errcheck
+//
+// Creating port-forwards requires permission to modify the hosts file, and
+// on macOS to add loopback aliases, so this usually needs to run as root.
+package proxier
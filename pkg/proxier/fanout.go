@@ -0,0 +1,112 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// FanOutAnnotation is an annotation on a service that, when "true", gives
+// every ready endpoint of the service its own IP and hostnames, so that
+// each replica can be addressed directly, e.g. for sharded caches.
+const FanOutAnnotation = "localizer.jaredallard.github.com/fan-out"
+
+// fanOutOf returns if every replica of a service should be forwarded, the
+// configuration is preferred over the annotation on the service
+func (p *Proxier) fanOutOf(svc *corev1.Service) bool {
+	if fanOut, ok := p.opts.FanOut[svc.Namespace+"/"+svc.Name]; ok {
+		return fanOut
+	}
+
+	return svc.Annotations[FanOutAnnotation] == "true"
+}
+
+// replicasOf returns the port-forwards to pods a service should have, keyed
+// by pod/namespace/name. This is empty unless the service is in fan-out
+// mode.
+func (p *Proxier) replicasOf(svc *corev1.Service) map[string]podForward {
+	if !p.fanOutOf(svc) {
+		return nil
+	}
+
+	obj, exists, err := p.endpointsInformer.GetStore().GetByKey(svc.Namespace + "/" + svc.Name)
+	if err != nil || !exists {
+		return nil
+	}
+
+	ports, err := p.servicePorts(svc)
+	if err != nil {
+		return nil
+	}
+
+	owner := svc.Namespace + "/" + svc.Name
+	replicas := make(map[string]podForward)
+	for _, subset := range obj.(*corev1.Endpoints).Subsets {
+		for _, address := range subset.Addresses {
+			if address.TargetRef == nil || address.TargetRef.Kind != PodKind {
+				continue
+			}
+
+			// these match the DNS names of pods behind a headless service
+			pod := address.TargetRef.Name
+			name := fmt.Sprintf("%s.%s", pod, svc.Name)
+			hostnames := []string{
+				fmt.Sprintf("%s.%s", pod, svc.Namespace),
+				fmt.Sprintf("%s.%s", name, svc.Namespace),
+				fmt.Sprintf("%s.%s.svc", name, svc.Namespace),
+				fmt.Sprintf("%s.%s.svc.%s", name, svc.Namespace, p.opts.ClusterDomain),
+			}
+			replicas[podKeyPrefix+svc.Namespace+"/"+pod] = podForward{
+				ports:     ports,
+				hostnames: append(hostnames, p.suffixed(svc.Namespace, name)...),
+				owner:     owner,
+			}
+		}
+	}
+
+	return replicas
+}
+
+// syncReplicas makes the port-forwards to pods owned by a service, by
+// namespace/name, match want. Pods that were forwarded with ForwardPod
+// are left alone.
+func (p *Proxier) syncReplicas(service string, want map[string]podForward) {
+	p.mu.Lock()
+	changed := make([]string, 0)
+	for key, fwd := range p.podForwards {
+		if _, ok := want[key]; !ok && fwd.owner == service {
+			delete(p.podForwards, key)
+			changed = append(changed, key)
+		}
+	}
+	for key, fwd := range want {
+		existing, ok := p.podForwards[key]
+		if ok && existing.owner != service {
+			continue
+		}
+
+		if !ok || !reflect.DeepEqual(existing, fwd) {
+			p.podForwards[key] = fwd
+			changed = append(changed, key)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, key := range changed {
+		p.queue.Add(key)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultGCInterval is how often the services being forwarded are checked
+// against the API server, unless ProxyOpts.GCInterval is set
+const DefaultGCInterval = time.Minute
+
+// GCOptions control the checks for services being forwarded that were
+// deleted without the informers noticing
+type GCOptions struct {
+	// Enabled is if the checks are made, and Interval how often
+	Enabled  bool
+	Interval time.Duration
+
+	// GracePeriod is how long a service has to be missing for before its
+	// port-forward is removed, so that services that are deleted and
+	// created again, e.g. by a redeploy, keep theirs
+	GracePeriod time.Duration
+}
+
+// GCOptions returns the options of the checks for deleted services
+func (p *Proxier) GCOptions() GCOptions {
+	p.gcMu.Lock()
+	defer p.gcMu.Unlock()
+
+	return p.gc
+}
+
+// SetGCOptions changes the options of the checks for deleted services,
+// taking effect right away
+func (p *Proxier) SetGCOptions(opts GCOptions) error {
+	if opts.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than zero")
+	}
+	if opts.GracePeriod < 0 {
+		return fmt.Errorf("grace period can't be negative")
+	}
+
+	p.gcMu.Lock()
+	p.gc = opts
+	p.gcMu.Unlock()
+
+	select {
+	case p.gcChanged <- struct{}{}:
+	default:
+	}
+
+	p.log.WithField("enabled", opts.Enabled).WithField("interval", opts.Interval).
+		WithField("grace-period", opts.GracePeriod).Info("changed checks for deleted services")
+	return nil
+}
+
+// runGC checks for deleted services every interval while they're enabled,
+// until ctx is done
+func (p *Proxier) runGC(ctx context.Context) {
+	for {
+		opts := p.GCOptions()
+
+		var timer *time.Timer
+		var tick <-chan time.Time
+		if opts.Enabled {
+			timer = time.NewTimer(opts.Interval)
+			tick = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-p.gcChanged:
+		case <-tick:
+			p.collectGarbage(ctx, opts.GracePeriod)
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// collectGarbage removes the port-forwards of services that no longer
+// exist, once they've been missing for gracePeriod. The informers normally
+// see services being deleted, but they can miss it, e.g. when a namespace
+// is deleted along with the role binding that let us watch it, which
+// leaves its services in their cache forever.
+func (p *Proxier) collectGarbage(ctx context.Context, gracePeriod time.Duration) {
+	w := p.portForwarder()
+	if w == nil {
+		return
+	}
+
+	// forget services the informer has caught up on
+	p.mu.Lock()
+	for key := range p.gone {
+		if _, exists, err := p.svcInformer.GetStore().GetByKey(key); err == nil && !exists {
+			delete(p.gone, key)
+		}
+	}
+	p.mu.Unlock()
+
+	byNamespace := make(map[string][]string)
+	for _, pf := range w.listPortForwards() {
+		if pf.Service.Kind != "" {
+			continue
+		}
+		byNamespace[pf.Service.Namespace] = append(byNamespace[pf.Service.Namespace], pf.Service.Name)
+	}
+
+	// missing is only used here, and this is only ran by one goroutine
+	if p.missing == nil {
+		p.missing = make(map[string]time.Time)
+	}
+	missing := make(map[string]time.Time)
+
+	now := time.Now()
+	for namespace, names := range byNamespace {
+		existing, err := p.servicesIn(ctx, namespace)
+		if err != nil {
+			p.log.WithError(err).WithField("namespace", namespace).Debug("failed to check for deleted services")
+			continue
+		}
+
+		for _, name := range names {
+			if existing[name] {
+				continue
+			}
+
+			key := namespace + "/" + name
+			since, ok := p.missing[key]
+			if !ok {
+				since = now
+			}
+			if now.Sub(since) < gracePeriod {
+				p.log.WithField("service", key).Debug("service no longer exists, waiting for the grace period to remove it")
+				missing[key] = since
+				continue
+			}
+
+			p.log.WithField("service", key).Info("service no longer exists, removing its port-forward")
+			p.markGone(key)
+			p.enqueue(key)
+		}
+	}
+
+	// services that came back, or were removed, start over
+	p.missing = missing
+}
+
+// servicesIn returns the names of the services in a namespace, from the
+// API server rather than the informer's cache. A namespace that was
+// deleted has none.
+func (p *Proxier) servicesIn(ctx context.Context, namespace string) (map[string]bool, error) {
+	// not served from the API server's cache, which could be missing
+	// services that were just created
+	list, err := p.k.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if kerrors.IsForbidden(err) {
+		// we may have lost access because the namespace was deleted
+		if _, nsErr := p.k.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{}); kerrors.IsNotFound(nsErr) {
+			return map[string]bool{}, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(list.Items))
+	for i := range list.Items {
+		names[list.Items[i].Name] = true
+	}
+	return names, nil
+}
+
+// markGone records that the service the informer has for key was deleted,
+// so that it's treated as deleted until the informer sees a new one
+func (p *Proxier) markGone(key string) {
+	o, exists, err := p.svcInformer.GetStore().GetByKey(key)
+	if err != nil || !exists {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gone[key] = o.(*corev1.Service).UID
+}
+
+// isGone returns if a service from the informer was found to be deleted.
+// A service created again with the same name has a new UID, so it isn't.
+func (p *Proxier) isGone(svc *corev1.Service) bool {
+	key := svc.Namespace + "/" + svc.Name
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	uid, ok := p.gone[key]
+	if ok && uid != svc.UID {
+		delete(p.gone, key)
+		return false
+	}
+	return ok
+}
@@ -0,0 +1,148 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestServicesIn(t *testing.T) {
+	k := fake.NewSimpleClientset(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api"}})
+	k.PrependReactor("list", "services", func(a k8stesting.Action) (bool, runtime.Object, error) {
+		if a.GetNamespace() == "torn-down" {
+			return true, nil, kerrors.NewForbidden(schema.GroupResource{Resource: "services"}, "", nil)
+		}
+		return false, nil, nil
+	})
+	p := &Proxier{k: k}
+
+	names, err := p.servicesIn(context.Background(), "default")
+	if err != nil || !names["api"] {
+		t.Errorf("expected api in default, got %v, %v", names, err)
+	}
+
+	// a deleted namespace we can no longer list has no services
+	names, err = p.servicesIn(context.Background(), "torn-down")
+	if err != nil || len(names) != 0 {
+		t.Errorf("expected no services in a deleted namespace, got %v, %v", names, err)
+	}
+}
+
+func TestIsGone(t *testing.T) {
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api", UID: types.UID("1")}}
+
+	svcInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Core().V1().Services().Informer()
+	if err := svcInformer.GetStore().Add(svc); err != nil {
+		t.Fatal(err)
+	}
+	p := &Proxier{svcInformer: svcInformer, gone: make(map[string]types.UID)}
+
+	if p.isGone(svc) {
+		t.Fatal("expected service to not be gone before it was marked")
+	}
+
+	p.markGone("default/api")
+	if !p.isGone(svc) {
+		t.Error("expected the cached service to be gone once marked")
+	}
+
+	recreated := svc.DeepCopy()
+	recreated.UID = types.UID("2")
+	if p.isGone(recreated) {
+		t.Error("expected a service created again to not be gone")
+	}
+	if p.isGone(svc) {
+		t.Error("expected the mark to be forgotten once a new service was seen")
+	}
+}
+
+func TestCollectGarbageGracePeriod(t *testing.T) {
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api", UID: types.UID("1")}}
+
+	svcInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Core().V1().Services().Informer()
+	if err := svcInformer.GetStore().Add(svc); err != nil {
+		t.Fatal(err)
+	}
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	// the API server doesn't have the service anymore
+	p := &Proxier{
+		k:           fake.NewSimpleClientset(),
+		log:         log,
+		opts:        &ProxyOpts{},
+		queue:       workqueue.NewRateLimitingQueue(workqueue.DefaultItemBasedRateLimiter()),
+		svcInformer: svcInformer,
+		gone:        make(map[string]types.UID),
+		worker: &worker{portForwards: map[string]*PortForwardConnection{
+			"default/api": {Service: ServiceInfo{Namespace: "default", Name: "api"}},
+		}},
+	}
+	defer p.queue.ShutDown()
+
+	p.collectGarbage(context.Background(), time.Hour)
+	if p.isGone(svc) || p.queue.Len() != 0 {
+		t.Fatal("expected the service to not be removed within the grace period")
+	}
+
+	// missing for longer than the grace period
+	p.missing["default/api"] = time.Now().Add(-2 * time.Hour)
+	p.collectGarbage(context.Background(), time.Hour)
+	if !p.isGone(svc) || p.queue.Len() != 1 {
+		t.Error("expected the service to be removed once it was missing for the grace period")
+	}
+}
+
+func TestSetGCOptions(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	p := &Proxier{log: log, gcChanged: make(chan struct{}, 1)}
+
+	if err := p.SetGCOptions(GCOptions{Enabled: true}); err == nil {
+		t.Error("expected an error for a zero interval")
+	}
+	if err := p.SetGCOptions(GCOptions{Interval: time.Minute, GracePeriod: -time.Second}); err == nil {
+		t.Error("expected an error for a negative grace period")
+	}
+
+	opts := GCOptions{Enabled: true, Interval: time.Minute, GracePeriod: time.Minute}
+	if err := p.SetGCOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	if p.GCOptions() != opts {
+		t.Errorf("expected %+v, got %+v", opts, p.GCOptions())
+	}
+
+	select {
+	case <-p.gcChanged:
+	default:
+		t.Error("expected the checks to be told the options changed")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// GroupLabel is a label that puts a service in a group, so it can be
+// restarted or stopped along with the rest of the group. Services can be
+// put in more groups with the configuration file.
+const GroupLabel = "localizer.jaredallard.github.com/group"
+
+// groupsOf returns the groups a service is in, sorted
+func (p *Proxier) groupsOf(svc *corev1.Service) []string {
+	groups := append([]string{}, p.opts.Groups[svc.Namespace+"/"+svc.Name]...)
+	if group := svc.Labels[GroupLabel]; group != "" && !containsString(groups, group) {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// GroupsOf returns the groups a service, by namespace/name, is in
+func (p *Proxier) GroupsOf(key string) []string {
+	obj, exists, err := p.svcInformer.GetStore().GetByKey(key)
+	if err != nil || !exists {
+		return nil
+	}
+	return p.groupsOf(obj.(*corev1.Service))
+}
+
+// GroupMembers returns the services, by namespace/name, in a group that
+// are forwarded, or would be if they weren't disabled, sorted
+func (p *Proxier) GroupMembers(group string) []string {
+	members := make([]string, 0)
+	for _, obj := range p.svcInformer.GetStore().List() {
+		svc := obj.(*corev1.Service)
+		key := svc.Namespace + "/" + svc.Name
+		if p.selected(key) && containsString(p.groupsOf(svc), group) {
+			members = append(members, key)
+		}
+	}
+	sort.Strings(members)
+	return members
+}
+
+// Restart recreates the port-forwards of services, by namespace/name,
+// returning the ones that were. Services that aren't being forwarded are
+// skipped.
+func (p *Proxier) Restart(keys []string) ([]string, error) {
+	w := p.portForwarder()
+	if w == nil {
+		return nil, fmt.Errorf("proxier not running")
+	}
+
+	restarted := make([]string, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		obj, exists, err := p.svcInformer.GetStore().GetByKey(key)
+		if err != nil || !exists {
+			continue
+		}
+
+		if pf, ok := w.portForward(key); !ok || pf.Status == PortForwardStatusPaused {
+			continue
+		}
+
+		p.createPortforward(obj.(*corev1.Service), "restarted with 'localizer restart'")
+		restarted = append(restarted, key)
+	}
+	return restarted, nil
+}
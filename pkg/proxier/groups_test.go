@@ -0,0 +1,55 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGroupMembers(t *testing.T) {
+	svcInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Core().V1().Services().Informer()
+	for _, svc := range []*corev1.Service{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "api", Labels: map[string]string{GroupLabel: "payments"}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "worker"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "redis", Labels: map[string]string{GroupLabel: "infra"}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "dns", Labels: map[string]string{GroupLabel: "infra"}}},
+	} {
+		if err := svcInformer.GetStore().Add(svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := &Proxier{svcInformer: svcInformer, opts: &ProxyOpts{
+		Groups: map[string][]string{"payments/worker": {"payments"}, "default/redis": {"infra", "cache"}},
+	}}
+
+	if got := p.GroupMembers("payments"); !reflect.DeepEqual(got, []string{"payments/api", "payments/worker"}) {
+		t.Errorf("expected the labeled and configured services in payments, got %v", got)
+	}
+
+	// services in system namespaces aren't forwarded, so aren't members
+	if got := p.GroupMembers("infra"); !reflect.DeepEqual(got, []string{"default/redis"}) {
+		t.Errorf("expected only default/redis in infra, got %v", got)
+	}
+
+	if got := p.GroupsOf("default/redis"); !reflect.DeepEqual(got, []string{"cache", "infra"}) {
+		t.Errorf("expected the groups of default/redis to be merged, got %v", got)
+	}
+}
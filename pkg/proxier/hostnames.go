@@ -0,0 +1,375 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/getoutreach/localizer/pkg/hostsfile"
+	"github.com/sirupsen/logrus"
+)
+
+// HostnameCollisionStrategy is how a hostname that more than one service
+// would get is handled
+type HostnameCollisionStrategy string
+
+var (
+	// HostnameCollisionFirst gives the hostname to the service that was
+	// forwarded first
+	HostnameCollisionFirst HostnameCollisionStrategy = "first"
+
+	// HostnameCollisionQualified gives the hostname to none of them, so
+	// they can only be reached by their namespace qualified hostnames
+	HostnameCollisionQualified HostnameCollisionStrategy = "qualified"
+
+	// HostnameCollisionPriority gives the hostname to the service in the
+	// namespace that comes first in the namespace priority list, falling
+	// back to the first service forwarded
+	HostnameCollisionPriority HostnameCollisionStrategy = "priority"
+)
+
+// contested is the owner of a hostname that no service is given
+const contested = ""
+
+// HostnameCollision is a hostname that more than one service would get
+type HostnameCollision struct {
+	Hostname string
+
+	// Services are the services, by key, that would get the hostname
+	Services []string
+
+	// Owner is the service that was given the hostname, if any
+	Owner string
+}
+
+// parseHostnameCollisionStrategy validates a strategy, defaulting to
+// HostnameCollisionFirst
+func parseHostnameCollisionStrategy(s string) (HostnameCollisionStrategy, error) {
+	switch strategy := HostnameCollisionStrategy(s); strategy {
+	case "":
+		return HostnameCollisionFirst, nil
+	case HostnameCollisionFirst, HostnameCollisionQualified, HostnameCollisionPriority:
+		return strategy, nil
+	}
+
+	return "", fmt.Errorf("unknown hostname collision strategy '%s', expected one of: first, qualified, priority", s)
+}
+
+// suffixed returns name under the hostname suffix configured for
+// namespace, if there is one. A leading "*." is allowed, so suffixes
+// can be written like the wildcard they stand for.
+func (p *Proxier) suffixed(namespace, name string) []string {
+	suffix := strings.TrimPrefix(strings.TrimPrefix(p.opts.HostnameSuffixes[namespace], "*"), ".")
+	if suffix == "" {
+		return nil
+	}
+	return []string{name + "." + suffix}
+}
+
+// Resolve returns the IP that a hostname points to. Subdomains of the
+// hostnames of services in Wildcards, e.g. tenant.myapp.ns, resolve to the
+// service as well, the longest matching hostname wins.
+func (p *Proxier) Resolve(name string) (net.IP, bool) {
+	w := p.portForwarder()
+	if w == nil {
+		return nil, false
+	}
+
+	if normalized, err := hostsfile.NormalizeHostname(name); err == nil {
+		name = normalized
+	} else {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+	}
+	hosts := w.dns.Hosts()
+	for ip, hostnames := range hosts {
+		for _, h := range hostnames {
+			if strings.EqualFold(h, name) {
+				return net.ParseIP(ip), true
+			}
+		}
+	}
+
+	// services are recognized by their namespace qualified hostname,
+	// which is the only one that's never given to another service
+	wildcards := make(map[string]bool)
+	for key, enabled := range p.opts.Wildcards {
+		if split := strings.SplitN(key, "/", 2); enabled && len(split) == 2 {
+			wildcards[strings.ToLower(split[1]+"."+split[0])] = true
+		}
+	}
+
+	var match net.IP
+	matchLen := 0
+	for ip, hostnames := range hosts {
+		isWildcard := false
+		for _, h := range hostnames {
+			isWildcard = isWildcard || wildcards[strings.ToLower(h)]
+		}
+		if !isWildcard {
+			continue
+		}
+
+		for _, h := range hostnames {
+			if len(h) > matchLen && strings.HasSuffix(name, "."+strings.ToLower(h)) {
+				match, matchLen = net.ParseIP(ip), len(h)
+			}
+		}
+	}
+	return match, match != nil
+}
+
+// namespaceRank returns the position of the namespace of a service key in
+// the namespace priority list, namespaces not in it are ranked last
+func (w *worker) namespaceRank(key string) int {
+	namespace := strings.TrimPrefix(key, podKeyPrefix)
+	namespace = strings.SplitN(namespace, "/", 2)[0]
+	for i, ns := range w.namespacePriority {
+		if ns == namespace {
+			return i
+		}
+	}
+	return len(w.namespacePriority)
+}
+
+// normalizeHostnames normalizes hostnames like they're written to the hosts
+// file, so that ones differing only in case are the same, removing the ones
+// that are invalid
+func normalizeHostnames(log logrus.FieldLogger, hostnames []string) []string {
+	normalized := make([]string, 0, len(hostnames))
+	seen := make(map[string]bool, len(hostnames))
+	for _, h := range hostnames {
+		n, err := hostsfile.NormalizeHostname(h)
+		if err != nil {
+			log.WithError(err).Warn("not adding invalid hostname")
+			continue
+		}
+
+		if !seen[n] {
+			seen[n] = true
+			normalized = append(normalized, n)
+		}
+	}
+	return normalized
+}
+
+// claimHostnames returns which of hostnames the service with key should
+// be given, resolving collisions with the hostnames of other services
+// using the collision strategy. Hostnames taken from other services are
+// removed from their hosts entries, the caller should save the hosts file.
+// A service keeps the hostnames it was given when it's recreated, only the
+// ones it no longer has are given up.
+func (w *worker) claimHostnames(key string, hostnames []string) []string {
+	w.hostsMu.Lock()
+	defer w.hostsMu.Unlock()
+
+	normalized := normalizeHostnames(w.log.WithField("service", key), hostnames)
+	keep := make(map[string]bool, len(normalized))
+	for _, h := range normalized {
+		keep[h] = true
+	}
+	w.releaseHostnamesLocked(key, keep)
+
+	claimed := make([]string, 0, len(normalized))
+	for _, h := range normalized {
+		owner, ok := w.hostOwners[h]
+		if !ok || owner == key {
+			w.hostOwners[h] = key
+			claimed = append(claimed, h)
+			continue
+		}
+
+		if w.collisions[h] == nil {
+			w.collisions[h] = make(map[string]uint64)
+		}
+		if _, ok := w.collisions[h][owner]; !ok && owner != contested {
+			w.claims++
+			w.collisions[h][owner] = w.claims
+		}
+		if _, ok := w.collisions[h][key]; !ok {
+			w.claims++
+			w.collisions[h][key] = w.claims
+		}
+		log := w.log.WithField("hostname", h).WithField("service", key)
+
+		switch w.collisionStrategy {
+		case HostnameCollisionQualified:
+			if owner != contested {
+				log.Warnf("hostname is also used by %s, removing it from both", owner)
+				w.dropHostname(owner, h)
+				w.hostOwners[h] = contested
+			}
+		case HostnameCollisionPriority:
+			if owner != contested && w.namespaceRank(key) < w.namespaceRank(owner) {
+				log.Warnf("hostname is also used by %s, taking it since its namespace has a higher priority", owner)
+				w.dropHostname(owner, h)
+				w.hostOwners[h] = key
+				claimed = append(claimed, h)
+				continue
+			}
+			log.Warnf("hostname is already used by %s, not adding it", owner)
+		case HostnameCollisionFirst:
+			log.Warnf("hostname is already used by %s, not adding it", owner)
+		}
+	}
+
+	return claimed
+}
+
+// dropHostname removes a hostname from the port-forward of a service, and
+// its hosts entry
+func (w *worker) dropHostname(key, hostname string) {
+	pf, ok := w.portForwards[key]
+	if !ok {
+		return
+	}
+
+	hostnames := make([]string, 0, len(pf.Hostnames))
+	for _, h := range pf.Hostnames {
+		if h != hostname {
+			hostnames = append(hostnames, h)
+		}
+	}
+	w.mu.Lock()
+	pf.Hostnames = hostnames
+	w.mu.Unlock()
+
+	if len(pf.IP) != 0 {
+		if err := w.dns.AddHosts(pf.IP.String(), pf.Hostnames); err != nil {
+			w.log.WithError(err).WithField("service", key).Warn("failed to update hosts entry")
+		}
+	}
+}
+
+// giveHostname adds a hostname to the port-forward of a service, and its
+// hosts entry. A port-forward without an IP gets it when it's created.
+func (w *worker) giveHostname(key, hostname string) {
+	pf, ok := w.portForwards[key]
+	if !ok || len(pf.IP) == 0 || w.randomPorts {
+		return
+	}
+
+	w.mu.Lock()
+	pf.Hostnames = append(pf.Hostnames, hostname)
+	w.mu.Unlock()
+
+	if err := w.dns.AddHosts(pf.IP.String(), pf.Hostnames); err != nil {
+		w.log.WithError(err).WithField("service", key).Warn("failed to update hosts entry")
+	}
+}
+
+// nextOwner returns which of the services waiting on a hostname should be
+// given it, if any
+func (w *worker) nextOwner(services map[string]uint64) (string, bool) {
+	// with the qualified strategy, a hostname only has an owner once one
+	// service is left wanting it
+	if w.collisionStrategy == HostnameCollisionQualified && len(services) > 1 {
+		return "", false
+	}
+
+	next := ""
+	for key, seq := range services {
+		switch {
+		case next == "":
+		case w.collisionStrategy == HostnameCollisionPriority && w.namespaceRank(key) != w.namespaceRank(next):
+			if w.namespaceRank(key) > w.namespaceRank(next) {
+				continue
+			}
+		case seq > services[next]:
+			continue
+		}
+		next = key
+	}
+	return next, next != ""
+}
+
+// releaseHostnamesLocked gives up the hostnames claimed by the service
+// with key, other than the ones in keep, handing them to the services
+// that were waiting on them. It returns true if any were handed over, in
+// which case the caller should save the hosts file. hostsMu must be held.
+func (w *worker) releaseHostnamesLocked(key string, keep map[string]bool) bool {
+	released := make(map[string]bool)
+	for h, owner := range w.hostOwners {
+		if owner == key && !keep[h] {
+			delete(w.hostOwners, h)
+			released[h] = true
+		}
+	}
+
+	for h, services := range w.collisions {
+		if _, ok := services[key]; !ok || keep[h] {
+			continue
+		}
+		delete(services, key)
+
+		if owner, ok := w.hostOwners[h]; ok && owner == contested {
+			delete(w.hostOwners, h)
+			released[h] = true
+		} else if len(services) < 2 {
+			delete(w.collisions, h)
+		}
+	}
+
+	changed := false
+	for h := range released {
+		services := w.collisions[h]
+		if next, ok := w.nextOwner(services); ok {
+			w.log.WithField("hostname", h).WithField("service", next).
+				Infof("hostname was released by %s, giving it to the service waiting on it", key)
+			w.hostOwners[h] = next
+			w.giveHostname(next, h)
+			changed = true
+		} else if len(services) > 1 {
+			w.hostOwners[h] = contested
+		}
+
+		if len(services) < 2 {
+			delete(w.collisions, h)
+		}
+	}
+
+	return changed
+}
+
+// releaseHostnames gives up the hostnames claimed by the service with key,
+// e.g. when it's deleted, handing them to the services that were waiting
+// on them. It returns true if the caller should save the hosts file.
+func (w *worker) releaseHostnames(key string) bool {
+	w.hostsMu.Lock()
+	defer w.hostsMu.Unlock()
+
+	return w.releaseHostnamesLocked(key, nil)
+}
+
+// hostnameCollisions returns the hostnames that more than one service
+// would get, sorted by hostname
+func (w *worker) hostnameCollisions() []HostnameCollision {
+	w.hostsMu.Lock()
+	defer w.hostsMu.Unlock()
+
+	collisions := make([]HostnameCollision, 0, len(w.collisions))
+	for h, services := range w.collisions {
+		c := HostnameCollision{Hostname: h, Owner: w.hostOwners[h]}
+		for key := range services {
+			c.Services = append(c.Services, key)
+		}
+		sort.Strings(c.Services)
+		collisions = append(collisions, c)
+	}
+
+	sort.Slice(collisions, func(i, j int) bool { return collisions[i].Hostname < collisions[j].Hostname })
+	return collisions
+}
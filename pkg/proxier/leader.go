@@ -0,0 +1,250 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// LeaderLockAnnotation is an annotation on a service that names the lock
+// its pods use for leader election, e.g. lease/my-controller. When set,
+// port-forwards are created to the pod holding it.
+const LeaderLockAnnotation = "localizer.jaredallard.github.com/leader-lock"
+
+// leaderAnnotation is the annotation ConfigMap and Endpoints based leader
+// election locks store the leader in
+const leaderAnnotation = "control-plane.alpha.kubernetes.io/leader"
+
+// leaderLookupTimeout is how long looking up the leader of a lock is given
+const leaderLookupTimeout = 10 * time.Second
+
+// parseLock returns the kind and name of a leader election lock, which is
+// <kind>/<name>. A lock without a kind is a lease.
+func parseLock(lock string) (kind, name string) {
+	kind, name = "lease", lock
+	if spl := strings.SplitN(lock, "/", 2); len(spl) == 2 {
+		kind, name = strings.ToLower(spl[0]), spl[1]
+	}
+	return kind, name
+}
+
+// leaderOf returns the identity of the holder of a leader election lock in
+// namespace. lock is <kind>/<name>, where kind is lease, configmap, or
+// endpoints. A lock without a kind is a lease.
+func leaderOf(ctx context.Context, k kubernetes.Interface, namespace, lock string) (string, error) {
+	kind, name := parseLock(lock)
+
+	var annotations map[string]string
+	switch kind {
+	case "lease":
+		lease, err := k.CoordinationV1().Leases(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get lease '%s'", name)
+		}
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+			return "", fmt.Errorf("lease '%s' has no holder", name)
+		}
+		return *lease.Spec.HolderIdentity, nil
+	case "configmap":
+		cm, err := k.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get configmap '%s'", name)
+		}
+		annotations = cm.Annotations
+	case "endpoints":
+		e, err := k.CoreV1().Endpoints(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get endpoints '%s'", name)
+		}
+		annotations = e.Annotations
+	default:
+		return "", fmt.Errorf("unknown leader lock kind '%s', expected lease, configmap, or endpoints", kind)
+	}
+
+	var record struct {
+		HolderIdentity string `json:"holderIdentity"`
+	}
+	if err := json.Unmarshal([]byte(annotations[leaderAnnotation]), &record); err != nil {
+		return "", errors.Wrapf(err, "failed to parse leader of '%s'", lock)
+	}
+	if record.HolderIdentity == "" {
+		return "", fmt.Errorf("'%s' has no leader", lock)
+	}
+	return record.HolderIdentity, nil
+}
+
+// isLeader returns if a pod is the holder of a lock. Identities are usually
+// the name of the pod, but some libraries add a unique suffix to it, e.g.
+// controller-runtime uses <pod>_<uuid>.
+func isLeader(podName, holder string) bool {
+	return holder == podName || strings.HasPrefix(holder, podName+"_")
+}
+
+// leaderWatch watches a leader election lock, so that the services whose
+// pods use it are reconciled when the leader changes, which doesn't always
+// change their endpoints
+type leaderWatch struct {
+	stop     chan struct{}
+	services map[string]bool
+}
+
+// lockListWatch returns a ListWatch for the object of a leader election lock
+func lockListWatch(k kubernetes.Interface, namespace, kind, name string) (*cache.ListWatch, runtime.Object, error) {
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	withName := func(opts *metav1.ListOptions) { opts.FieldSelector = selector }
+
+	lw := &cache.ListWatch{}
+	var obj runtime.Object
+	switch kind {
+	case "lease":
+		obj = &coordinationv1.Lease{}
+		lw.ListFunc = func(opts metav1.ListOptions) (runtime.Object, error) {
+			withName(&opts)
+			return k.CoordinationV1().Leases(namespace).List(context.TODO(), opts)
+		}
+		lw.WatchFunc = func(opts metav1.ListOptions) (watch.Interface, error) {
+			withName(&opts)
+			return k.CoordinationV1().Leases(namespace).Watch(context.TODO(), opts)
+		}
+	case "configmap":
+		obj = &corev1.ConfigMap{}
+		lw.ListFunc = func(opts metav1.ListOptions) (runtime.Object, error) {
+			withName(&opts)
+			return k.CoreV1().ConfigMaps(namespace).List(context.TODO(), opts)
+		}
+		lw.WatchFunc = func(opts metav1.ListOptions) (watch.Interface, error) {
+			withName(&opts)
+			return k.CoreV1().ConfigMaps(namespace).Watch(context.TODO(), opts)
+		}
+	case "endpoints":
+		obj = &corev1.Endpoints{}
+		lw.ListFunc = func(opts metav1.ListOptions) (runtime.Object, error) {
+			withName(&opts)
+			return k.CoreV1().Endpoints(namespace).List(context.TODO(), opts)
+		}
+		lw.WatchFunc = func(opts metav1.ListOptions) (watch.Interface, error) {
+			withName(&opts)
+			return k.CoreV1().Endpoints(namespace).Watch(context.TODO(), opts)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown leader lock kind '%s', expected lease, configmap, or endpoints", kind)
+	}
+
+	return lw, obj, nil
+}
+
+// syncLeaderWatch makes a service, by namespace/name, be reconciled
+// whenever its leader election lock changes. An empty lock stops it being
+// reconciled for the lock it had. Locks are watched until no service uses
+// them.
+func (p *Proxier) syncLeaderWatch(service, namespace, lock string) {
+	p.leaderMu.Lock()
+	defer p.leaderMu.Unlock()
+
+	if p.leaderWatches == nil {
+		p.leaderWatches = make(map[string]*leaderWatch)
+	}
+
+	key := ""
+	kind, name := parseLock(lock)
+	if lock != "" {
+		key = namespace + "/" + kind + "/" + name
+	}
+
+	for k, lw := range p.leaderWatches {
+		if k == key || !lw.services[service] {
+			continue
+		}
+
+		delete(lw.services, service)
+		if len(lw.services) == 0 {
+			close(lw.stop)
+			delete(p.leaderWatches, k)
+		}
+	}
+
+	if key == "" || p.leadersStopped {
+		return
+	}
+
+	lw, ok := p.leaderWatches[key]
+	if !ok {
+		listWatch, obj, err := lockListWatch(p.k, namespace, kind, name)
+		if err != nil {
+			p.log.WithError(err).WithField("service", service).Warn("failed to watch leader election lock")
+			return
+		}
+
+		lw = &leaderWatch{stop: make(chan struct{}), services: make(map[string]bool)}
+		informer := cache.NewSharedInformer(listWatch, obj, 0)
+		changed := func(obj interface{}) {
+			// not every client honors the field selector
+			if m, err := meta.Accessor(obj); err == nil && m.GetName() == name {
+				p.enqueueLeaderWatchers(key)
+			}
+		}
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    changed,
+			UpdateFunc: func(_, obj interface{}) { changed(obj) },
+		})
+		go informer.Run(lw.stop)
+		p.leaderWatches[key] = lw
+	}
+	lw.services[service] = true
+}
+
+// enqueueLeaderWatchers enqueues the services that use a leader election
+// lock, by namespace/kind/name
+func (p *Proxier) enqueueLeaderWatchers(key string) {
+	p.leaderMu.Lock()
+	services := make([]string, 0)
+	if lw, ok := p.leaderWatches[key]; ok {
+		for service := range lw.services {
+			services = append(services, service)
+		}
+	}
+	p.leaderMu.Unlock()
+
+	for _, service := range services {
+		p.enqueue(service)
+	}
+}
+
+// stopLeaderWatches stops watching every leader election lock, no more are
+// watched after
+func (p *Proxier) stopLeaderWatches() {
+	p.leaderMu.Lock()
+	defer p.leaderMu.Unlock()
+
+	for key, lw := range p.leaderWatches {
+		close(lw.stop)
+		delete(p.leaderWatches, key)
+	}
+	p.leadersStopped = true
+}
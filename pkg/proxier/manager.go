@@ -0,0 +1,143 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ForwardManager manages port-forwards to Kubernetes services. Proxier
+// implements it.
+type ForwardManager interface {
+	// Create requests a port-forward be created for a service. Creation
+	// happens in the background, use Subscribe to find out the result.
+	Create(ctx context.Context, req *CreatePortForwardRequest) error
+
+	// Delete requests the port-forward of a service be deleted, this also
+	// happens in the background.
+	Delete(ctx context.Context, req *DeletePortForwardRequest) error
+
+	// List returns the status of every port-forward
+	List(ctx context.Context) ([]ServiceStatus, error)
+
+	// Subscribe returns a channel that receives an Event whenever a
+	// port-forward changes. The channel is closed when ctx is canceled.
+	// Events are dropped if the channel isn't read from fast enough.
+	Subscribe(ctx context.Context) <-chan Event
+}
+
+var _ ForwardManager = &Proxier{}
+
+// Event is a change to a port-forward
+type Event struct {
+	// Service is the service the port-forward is for
+	Service ServiceInfo
+
+	// Status is the new status of the port-forward, and Reason is why
+	// it's in that status
+	Status PortForwardStatus
+	Reason string
+
+	// IP is the IP address allocated to the port-forward, if any
+	IP string
+
+	// Deleted is set when the port-forward was deleted
+	Deleted bool
+
+	// Err is set when creating the port-forward failed
+	Err error
+}
+
+// subscriberBuffer is the number of events that can be waiting to be
+// read by a subscriber before more are dropped
+const subscriberBuffer = 100
+
+// subscribers fans out events to every subscriber
+type subscribers struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+func newSubscribers() *subscribers {
+	return &subscribers{subs: make(map[chan Event]struct{})}
+}
+
+// subscribe adds a subscriber until ctx is canceled
+func (s *subscribers) subscribe(ctx context.Context) <-chan Event {
+	c := make(chan Event, subscriberBuffer)
+
+	s.mu.Lock()
+	s.subs[c] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		s.mu.Lock()
+		delete(s.subs, c)
+		close(c)
+		s.mu.Unlock()
+	}()
+
+	return c
+}
+
+// publish sends an event to every subscriber without blocking
+func (s *subscribers) publish(e Event) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.subs {
+		select {
+		case c <- e:
+		default:
+		}
+	}
+}
+
+// Create requests a port-forward be created for a service
+func (p *Proxier) Create(ctx context.Context, req *CreatePortForwardRequest) error {
+	return p.request(ctx, PortForwardRequest{CreatePortForwardRequest: req})
+}
+
+// Delete requests the port-forward of a service be deleted
+func (p *Proxier) Delete(ctx context.Context, req *DeletePortForwardRequest) error {
+	return p.request(ctx, PortForwardRequest{DeletePortForwardRequest: req})
+}
+
+// Subscribe returns a channel that receives changes to port-forwards
+func (p *Proxier) Subscribe(ctx context.Context) <-chan Event {
+	return p.subscribers.subscribe(ctx)
+}
+
+// request sends a request to the worker
+func (p *Proxier) request(ctx context.Context, req PortForwardRequest) error {
+	w := p.portForwarder()
+	if w == nil {
+		return fmt.Errorf("proxier not running")
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case w.reqChan <- req:
+		return nil
+	}
+}
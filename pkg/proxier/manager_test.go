@@ -0,0 +1,49 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubscribers(t *testing.T) {
+	s := newSubscribers()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := s.subscribe(ctx)
+
+	s.publish(Event{Service: ServiceInfo{Namespace: "default", Name: "web"}, Status: PortForwardStatusRunning})
+	e := <-events
+	if e.Service.Name != "web" || e.Status != PortForwardStatusRunning {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	// publishing to a full subscriber shouldn't block
+	for i := 0; i < subscriberBuffer+1; i++ {
+		s.publish(Event{})
+	}
+
+	cancel()
+	// drain until the channel is closed
+	for range events {
+	}
+}
+
+func TestProxierRequestNotRunning(t *testing.T) {
+	p := &Proxier{}
+	if err := p.Create(context.Background(), &CreatePortForwardRequest{}); err == nil {
+		t.Fatal("expected an error when the proxier isn't running")
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/getoutreach/localizer/internal/ipam"
+)
+
+// PlannedForward is a port-forward that would be created for a service
+type PlannedForward struct {
+	Service  ServiceInfo
+	Priority int
+
+	// IP is the IP the service would be given, nil if there wouldn't be
+	// one free
+	IP        net.IP
+	Ports     []string
+	Hostnames []string
+
+	// Reason is why the port-forward wouldn't be running right away,
+	// empty if it would be
+	Reason string
+}
+
+// Plan returns the port-forwards that would be created for the services in
+// the informers' caches, which must've been synced, in the order they'd be
+// created. Nothing is created, and nothing on this machine is changed.
+func (p *Proxier) Plan() ([]PlannedForward, error) {
+	strategy, err := parseHostnameCollisionStrategy(p.opts.HostnameCollisions)
+	if err != nil {
+		return nil, err
+	}
+
+	reqs := make([]*CreatePortForwardRequest, 0)
+	for _, obj := range p.svcInformer.GetStore().List() {
+		svc, ok := obj.(*corev1.Service)
+		if !ok || svc.DeletionTimestamp != nil {
+			continue
+		}
+
+		key := svc.Namespace + "/" + svc.Name
+		if !p.selected(key) || !p.IsEnabled(key) {
+			continue
+		}
+
+		req, err := p.portForwardRequest(svc)
+		if err != nil {
+			continue
+		}
+		reqs = append(reqs, req)
+	}
+
+	// higher priority services get the free tunnels, and IPs, first
+	sort.SliceStable(reqs, func(i, j int) bool {
+		if reqs[i].Priority != reqs[j].Priority {
+			return reqs[i].Priority > reqs[j].Priority
+		}
+		return reqs[i].Service.Key() < reqs[j].Service.Key()
+	})
+
+	// IPs are always planned in memory, so an allocator that's shared
+	// with other daemons isn't touched
+	ippool, err := ipam.NewMemory(p.opts.IPCidr, net.ParseIP("127.0.0.1"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create ip pool")
+	}
+
+	// services get the IPs they had in the previous run, if they're free
+	previous := make(map[string]net.IP)
+	for _, req := range reqs {
+		ip := net.ParseIP(p.opts.PreviousIPs[req.Service.Key()])
+		if ip != nil && !p.opts.RandomPorts && ippool.AcquireSpecific(ip) == nil {
+			previous[req.Service.Key()] = ip
+		}
+	}
+
+	// hostnames are claimed the same way the worker does
+	w := &worker{
+		log:               p.log,
+		collisionStrategy: strategy,
+		namespacePriority: p.opts.HostnamePriority,
+		portForwards:      make(map[string]*PortForwardConnection),
+		hostOwners:        make(map[string]string),
+		collisions:        make(map[string]map[string]uint64),
+	}
+
+	plan := make([]PlannedForward, 0, len(reqs))
+	for _, req := range reqs {
+		key := req.Service.Key()
+		pf := PlannedForward{Service: req.Service, Priority: req.Priority, Ports: req.Ports}
+
+		if p.opts.MaxTunnels > 0 && len(plan) >= p.opts.MaxTunnels {
+			pf.Reason = fmt.Sprintf("Tunnel limit of %d reached.", p.opts.MaxTunnels)
+			plan = append(plan, pf)
+			continue
+		}
+
+		switch {
+		case p.opts.RandomPorts:
+			pf.IP = net.ParseIP("127.0.0.1")
+			pf.Ports = make([]string, len(req.Ports))
+			for i, port := range req.Ports {
+				pf.Ports[i] = "random:" + port[strings.Index(port, ":")+1:]
+			}
+		case previous[key] != nil:
+			pf.IP = previous[key]
+		default:
+			pf.IP, err = ippool.Acquire()
+			if errors.Is(err, ErrNoIPAvailable) {
+				pf.Reason = fmt.Sprintf("IP pool %s is exhausted.", ippool.CIDR())
+				plan = append(plan, pf)
+				continue
+			} else if err != nil {
+				return nil, errors.Wrap(err, "failed to allocate IP")
+			}
+		}
+
+		if !p.opts.RandomPorts {
+			pf.Hostnames = w.claimHostnames(key, req.Hostnames)
+		}
+
+		if p.opts.ServiceDialer == nil {
+			e, exists, err := p.endpointsInformer.GetStore().GetByKey(key)
+			if err != nil || !exists || !hasPodEndpoint(e.(*corev1.Endpoints)) {
+				pf.Reason = "No endpoints."
+			}
+		}
+		plan = append(plan, pf)
+	}
+
+	// services that lost a hostname to one planned after them don't have
+	// it anymore
+	for i := range plan {
+		hostnames := make([]string, 0, len(plan[i].Hostnames))
+		for _, h := range plan[i].Hostnames {
+			if w.hostOwners[h] == plan[i].Service.Key() {
+				hostnames = append(hostnames, h)
+			}
+		}
+		plan[i].Hostnames = hostnames
+	}
+
+	return plan, nil
+}
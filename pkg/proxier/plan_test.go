@@ -0,0 +1,88 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPlan(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+
+	service := func(namespace, name string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}}},
+		}
+	}
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1", TargetRef: &corev1.ObjectReference{Kind: PodKind, Name: "api-0"}}},
+			Ports:     []corev1.EndpointPort{{Port: 8080}},
+		}},
+	}
+
+	k := fake.NewSimpleClientset(service("default", "api"), service("other", "api"), service("default", "db"),
+		service("kube-system", "dns"), endpoints)
+	factory := informers.NewSharedInformerFactory(k, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p, err := NewProxier(ctx, k, nil, log, &ProxyOpts{
+		IPCidr:        "127.0.0.0/24",
+		ClusterDomain: "cluster.local",
+		Informers:     factory,
+		Priorities:    map[string]int{"other/api": 10},
+		PreviousIPs:   map[string]string{"default/db": "127.0.0.50"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	plan, err := p.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, 0, len(plan))
+	for i := range plan {
+		pf := &plan[i]
+		got = append(got, strings.Join([]string{pf.Service.Key(), pf.IP.String(), strings.Join(pf.Ports, ","),
+			pf.Hostnames[0], pf.Reason}, " "))
+	}
+	want := []string{
+		// the higher priority service is first, and gets the api hostname
+		"other/api 127.0.0.2 80:8080 api No endpoints.",
+		"default/api 127.0.0.3 80:8080 api.default ",
+		"default/db 127.0.0.50 80:8080 db No endpoints.",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected plan:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// podKeyPrefix is the prefix of the keys of port-forwards to pods
+const podKeyPrefix = "pod/"
+
+// podForward is a pod that was requested to be forwarded
+type podForward struct {
+	ports     []string
+	hostnames []string
+
+	// owner is the service, by namespace/name, that this was created for
+	// in fan-out mode. Empty if it was requested with ForwardPod.
+	owner string
+}
+
+// ForwardPod port-forwards directly to a pod, rather than a service. ports
+// are local:remote pairs. The pod gets its own IP and the hostname
+// name.namespace.pod, and is forwarded until StopForwardPod is called.
+// If the pod goes away the port-forward waits for a pod with the same
+// name to come back, e.g. a StatefulSet replica. hostnames are added to
+// the hosts file in addition to name.namespace.pod.
+func (p *Proxier) ForwardPod(namespace, name string, ports []string, hostnames ...string) error {
+	if len(ports) == 0 {
+		return fmt.Errorf("at least one port is required")
+	}
+
+	for _, port := range ports {
+		spl := strings.Split(port, ":")
+		if len(spl) != 2 {
+			return fmt.Errorf("invalid port '%s', expected 'local:remote'", port)
+		}
+		for _, s := range spl {
+			if _, err := strconv.ParseUint(s, 10, 16); err != nil {
+				return fmt.Errorf("invalid port '%s', expected 'local:remote'", port)
+			}
+		}
+	}
+
+	info := ServiceInfo{Namespace: namespace, Name: name, Kind: PodKind}
+
+	p.mu.Lock()
+	p.podForwards[info.Key()] = podForward{ports: ports, hostnames: hostnames}
+	p.mu.Unlock()
+
+	p.queue.Add(info.Key())
+	return nil
+}
+
+// StopForwardPod stops a port-forward created by ForwardPod
+func (p *Proxier) StopForwardPod(namespace, name string) error {
+	info := ServiceInfo{Namespace: namespace, Name: name, Kind: PodKind}
+	key := info.Key()
+
+	p.mu.Lock()
+	_, ok := p.podForwards[key]
+	delete(p.podForwards, key)
+	p.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("pod '%s/%s' isn't being forwarded", namespace, name)
+	}
+
+	p.queue.Add(key)
+	return nil
+}
+
+// podForwardKeys returns the keys of the pods that have been requested to
+// be forwarded
+func (p *Proxier) podForwardKeys() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys := make([]string, 0, len(p.podForwards))
+	for key := range p.podForwards {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// enqueuePodForward enqueues the port-forward of a pod, if it's been
+// requested
+func (p *Proxier) enqueuePodForward(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	key = podKeyPrefix + key
+
+	p.mu.Lock()
+	_, ok := p.podForwards[key]
+	p.mu.Unlock()
+
+	if ok {
+		p.queue.Add(key)
+	}
+}
+
+// reconcilePod creates, recreates, or deletes the port-forward of a pod
+// requested with ForwardPod
+func (p *Proxier) reconcilePod(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(strings.TrimPrefix(key, podKeyPrefix))
+	if err != nil {
+		return err
+	}
+	info := ServiceInfo{Namespace: namespace, Name: name, Kind: PodKind}
+	pod := PodInfo{Namespace: namespace, Name: name}
+
+	p.mu.Lock()
+	fwd, ok := p.podForwards[key]
+	p.mu.Unlock()
+
+	if !ok {
+		p.pfrequest <- PortForwardRequest{
+			DeletePortForwardRequest: &DeletePortForwardRequest{Service: info},
+		}
+		return nil
+	}
+
+	if p.IsPaused() {
+		return nil
+	}
+
+	w := p.portForwarder()
+	if w == nil {
+		return fmt.Errorf("proxier hasn't started")
+	}
+
+	running := isRunningPod(p.podInformer.GetStore(), pod)
+	existingForward, ok := w.portForward(key)
+	if !ok {
+		p.createPodPortforward(info, fwd, "")
+		return nil
+	}
+
+	switch existingForward.Status {
+	case PortForwardStatusWaiting:
+		if running && !w.isPending(key) {
+			p.createPodPortforward(info, fwd, "pod became available")
+		}
+	case PortForwardStatusRunning:
+		if !running {
+			p.createPodPortforward(info, fwd, fmt.Sprintf("pod '%s' is no longer running", pod.Key()))
+		}
+	case PortForwardStatusPaused:
+		p.createPodPortforward(info, fwd, "resumed")
+	case PortForwardStatusFailed:
+		if running {
+			p.createPodPortforward(info, fwd, "retrying failed port-forward")
+		}
+	case PortForwardStatusRecreating:
+		//make exhaustive linter happy
+	}
+
+	return nil
+}
+
+func (p *Proxier) createPodPortforward(info ServiceInfo, fwd podForward, recreate string) {
+	req := CreatePortForwardRequest{
+		Service:   info,
+		Ports:     fwd.ports,
+		Endpoint:  &PodInfo{Namespace: info.Namespace, Name: info.Name},
+		Hostnames: append([]string{fmt.Sprintf("%s.%s.pod", info.Name, info.Namespace)}, fwd.hostnames...),
+	}
+
+	if recreate != "" {
+		req.Recreate = true
+		req.RecreateReason = recreate
+	}
+
+	p.pfrequest <- PortForwardRequest{
+		CreatePortForwardRequest: &req,
+	}
+}
+
+// isRunningPod returns if a pod exists, is running, and isn't being deleted
+func isRunningPod(pods cache.Store, pod PodInfo) bool {
+	obj, exists, err := pods.GetByKey(pod.Key())
+	if err != nil || !exists {
+		return false
+	}
+
+	po := obj.(*corev1.Pod)
+	return po.DeletionTimestamp == nil && po.Status.Phase == corev1.PodRunning
+}
@@ -0,0 +1,1565 @@
+// Copyright 2020 Jared Allard
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getoutreach/localizer/internal/ipam"
+	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/getoutreach/localizer/internal/nftables"
+	"github.com/getoutreach/localizer/internal/privhelper"
+	"github.com/getoutreach/localizer/pkg/hostsfile"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/util/workqueue"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type worker struct {
+	k    kubernetes.Interface
+	rest *rest.Config
+	log  logrus.FieldLogger
+
+	ippool IPAllocator
+	dns    *hostsfile.File
+
+	// hostsBlock is the name of our block in the hosts file
+	hostsBlock string
+
+	// helper, if set, writes the hosts file and creates loopback aliases
+	// for us, as we aren't root
+	helper Helper
+
+	// redirector, if set, redirects traffic sent to a service's ClusterIP
+	// to the port-forward created for it
+	redirector *nftables.Redirector
+
+	reqChan  chan PortForwardRequest
+	doneChan chan<- struct{}
+
+	// backlog are requests that have been received but not processed yet,
+	// this is used to handle higher priority requests first. backlogLen is
+	// its length, for reading outside of the worker.
+	backlog    []PortForwardRequest
+	backlogLen int64
+
+	// portForwards are existing port-forwards
+	portForwards map[string]*PortForwardConnection
+
+	// maxTunnels is the maximum number of running port-forwards, 0 is
+	// unlimited. pendingTunnels are the requests waiting for a free slot.
+	maxTunnels     int
+	pendingTunnels []*CreatePortForwardRequest
+
+	// mu protects portForwards, the exported fields of the port-forwards
+	// in it, and pendingTunnels. They're only changed by the worker, which
+	// holds it while doing so, so it doesn't need it to read them. Anything
+	// else must use portForward, listPortForwards, or isPending.
+	mu sync.RWMutex
+
+	// maxRecreates is the number of times a port-forward can be recreated
+	// within recreateWindow before it's failed, 0 is unlimited
+	maxRecreates   int
+	recreateWindow time.Duration
+
+	// operationTimeout, if set, is how long each request is given before
+	// what it's waiting on, e.g. the API server, is given up on.
+	// timeoutBackoff is how long port-forwards that timed out wait before
+	// they're tried again.
+	operationTimeout time.Duration
+	timeoutBackoff   workqueue.RateLimiter
+
+	// recreateLimiter, if set, limits how often port-forwards are
+	// recreated, so that when the network drops every port-forward isn't
+	// recreated at once as soon as it's back
+	recreateLimiter *rate.Limiter
+
+	// connSem limits the number of connections being relayed at once, and
+	// bufferSize is the size of the buffers used by relays. If neither,
+	// nor drainTimeout, is set, port-forwards listen directly instead of
+	// using a relay.
+	connSem    chan struct{}
+	bufferSize int
+
+	// hooks, if set, is called when port-forwards are created or fail
+	hooks HookFunc
+
+	// subscribers are sent changes to port-forwards
+	subscribers *subscribers
+
+	// dialer, if set, replaces port-forwarding through the API server
+	dialer DialerFunc
+
+	// pods is used to avoid choosing pods that are being replaced, and
+	// nodes to avoid choosing ones on unhealthy nodes
+	pods  cache.Store
+	nodes *nodeCache
+
+	// serviceDialer, if set, is used to connect to services instead of
+	// port-forwarding to their pods. clusterDomain is used to build the
+	// address of services for it.
+	serviceDialer ServiceDialerFunc
+	clusterDomain string
+
+	// node and zone are where connections are treated as coming from when
+	// choosing an endpoint
+	node string
+	zone string
+
+	// hostOwners are the services, by key, that have been given each
+	// hostname, and collisions are the services that would get a hostname
+	// when more than one would, with the order they wanted it in from
+	// claims. hostsMu protects all of them.
+	collisionStrategy HostnameCollisionStrategy
+	namespacePriority []string
+	hostOwners        map[string]string
+	collisions        map[string]map[string]uint64
+	claims            uint64
+	hostsMu           sync.Mutex
+
+	// lastTouchTime is the the worker has done any work, whether it
+	// be creating, releasing, or updating port-forwards. The mutex
+	// proceeding it is used to protect this value from concurrent
+	// access.
+	lastTouchTime time.Time
+	touchMu       sync.Mutex
+
+	// randomPorts listens on random ports of 127.0.0.1 instead of
+	// allocating an IP to each port-forward. localPorts are the ports
+	// given to each service, keyed by service port, so they're kept when
+	// it's recreated.
+	randomPorts bool
+	localPorts  map[string]map[string]string
+
+	// drainTimeout is how long connections are given to finish when a
+	// port-forward is deleted, 0 closes them right away
+	drainTimeout time.Duration
+
+	// reservedIPs are the IPs services had in the previous run, keyed by
+	// service, which are held for them until they're forwarded again
+	reservedIPs map[string]net.IP
+
+	// recorders are sent the requests made to a service, by key, while
+	// it's being recorded. recMu protects them.
+	recorders map[string][]chan RecordedRequest
+	recMu     sync.Mutex
+
+	// protocols are the protocols detected on the ports of services,
+	// keyed by service and then service port. protoMu protects them.
+	protocols map[string]map[string]Protocol
+	protoMu   sync.Mutex
+}
+
+// newPortForwarder creates a new port-forward worker that handles
+// creating port-forwards and destroying port-forwards.
+//
+//nolint:gocritic // We're OK not naming these.
+func newPortForwarder(ctx context.Context, k kubernetes.Interface, r *rest.Config, log logrus.FieldLogger,
+	opts *ProxyOpts, subs *subscribers, pods cache.Store) (chan<- PortForwardRequest, <-chan struct{}, *worker, error) {
+	newAllocator := opts.IPAllocator
+	if newAllocator == nil {
+		newAllocator = func(cidr string, reserved ...net.IP) (IPAllocator, error) {
+			return ipam.NewMemory(cidr, reserved...)
+		}
+	}
+
+	// 127.0.0.1 is used by everything else, so it's never handed out
+	ippool, err := newAllocator(opts.IPCidr, net.ParseIP("127.0.0.1"))
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to create ip pool")
+	}
+
+	blockName := "localizer"
+	if opts.Instance != "" {
+		blockName = "localizer-" + opts.Instance
+	}
+
+	hosts, err := hostsfile.New(opts.HostsFile, blockName)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to open up hosts file for r/w")
+	}
+
+	var redirector *nftables.Redirector
+	if opts.RedirectClusterIPs {
+		redirector, err = nftables.NewRedirector(log, blockName)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "failed to setup clusterIP redirection")
+		}
+	}
+
+	collisionStrategy, err := parseHostnameCollisionStrategy(opts.HostnameCollisions)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	doneChan := make(chan struct{})
+	reqChan := make(chan PortForwardRequest, 1024)
+
+	w := &worker{
+		k:              k,
+		rest:           r,
+		log:            log,
+		ippool:         ippool,
+		dns:            hosts,
+		hostsBlock:     blockName,
+		helper:         opts.Helper,
+		redirector:     redirector,
+		reqChan:        reqChan,
+		doneChan:       doneChan,
+		portForwards:   make(map[string]*PortForwardConnection),
+		lastTouchTime:  time.Now(),
+		maxTunnels:     opts.MaxTunnels,
+		maxRecreates:   opts.MaxRecreates,
+		recreateWindow: opts.RecreateWindow,
+		bufferSize:     opts.BufferSize,
+		hooks:          opts.Hooks,
+		subscribers:    subs,
+		dialer:         opts.Dialer,
+		pods:           pods,
+		nodes:          newNodeCache(ctx, k, log),
+		serviceDialer:  opts.ServiceDialer,
+		clusterDomain:  opts.ClusterDomain,
+		node:           opts.Node,
+		zone:           opts.Zone,
+		randomPorts:    opts.RandomPorts,
+		localPorts:     make(map[string]map[string]string),
+		drainTimeout:   opts.DrainTimeout,
+
+		operationTimeout: opts.OperationTimeout,
+		timeoutBackoff:   workqueue.NewItemExponentialFailureRateLimiter(time.Second, 5*time.Minute),
+		reservedIPs:      make(map[string]net.IP),
+		recorders:        make(map[string][]chan RecordedRequest),
+		protocols:        make(map[string]map[string]Protocol),
+
+		collisionStrategy: collisionStrategy,
+		namespacePriority: opts.HostnamePriority,
+		hostOwners:        make(map[string]string),
+		collisions:        make(map[string]map[string]uint64),
+	}
+	if w.zone == "" && w.node != "" {
+		w.zone, err = zoneOfNode(ctx, k, w.node)
+		if err != nil {
+			log.WithError(err).Warn("failed to find zone of node, topology aware hints will be ignored")
+		}
+	}
+	if opts.MaxConnections > 0 {
+		w.connSem = make(chan struct{}, opts.MaxConnections)
+	}
+	if opts.RecreateRate > 0 {
+		burst := opts.RecreateBurst
+		if burst <= 0 {
+			burst = 1
+		}
+		w.recreateLimiter = rate.NewLimiter(rate.Limit(opts.RecreateRate), burst)
+	}
+	if opts.CleanupPrevious {
+		w.cleanupPrevious(ctx, opts.PreviousIPs)
+	}
+	if !w.randomPorts {
+		w.reserveIPs(opts.PreviousIPs)
+	}
+
+	go w.Start(ctx)
+
+	return reqChan, doneChan, w, nil
+}
+
+// Start starts the worker process. This is done when the worker is created
+// and should be run in a goroutine if this is created manually.
+func (w *worker) Start(ctx context.Context) {
+	for {
+		req, ok := w.nextRequest(ctx)
+		if !ok {
+			// drain everything at once, rather than one at a time below
+			all := make([]*PortForwardConnection, 0, len(w.portForwards))
+			for _, pf := range w.portForwards {
+				all = append(all, pf)
+			}
+			w.drain(all...)
+
+			for info := range w.portForwards {
+				err := w.deletePortForward(ctx, &DeletePortForwardRequest{
+					Service: w.portForwards[info].Service,
+				}, false)
+				if err != nil {
+					w.log.WithError(err).Warn("failed to clean up port-forward")
+				}
+			}
+
+			if w.redirector != nil {
+				if err := w.redirector.Close(); err != nil {
+					w.log.WithError(err).Warn("failed to clean up clusterIP redirects")
+				}
+			}
+
+			// close our channel(s)
+			close(w.doneChan)
+
+			return
+		}
+
+		var err error
+		if req.CreatePortForwardRequest != nil {
+			err = w.CreatePortForward(ctx, req.CreatePortForwardRequest)
+		} else if req.DeletePortForwardRequest != nil {
+			err = w.DeletePortForward(ctx, req.DeletePortForwardRequest)
+		} else if req.PausePortForwardRequest != nil {
+			err = w.PausePortForward(ctx, req.PausePortForwardRequest)
+		}
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			serv := req.service()
+			w.log.WithField("service", serv.Key()).WithError(err).Errorf("gave up on request after %s", w.operationTimeout)
+		} else if err != nil {
+			serv := req.service()
+			w.log.WithField("service", serv.Key()).WithError(err).Errorf("encountered an error: %v", err)
+		}
+
+		w.schedulePending()
+	}
+}
+
+// nextRequest returns the next request to process, blocking until there is
+// one. Every request that is waiting is considered, and the one with the
+// highest priority is returned. Requests for the same service are always
+// returned in the order they were received. Recreations are skipped while
+// they're being throttled. False is returned if the context was canceled.
+func (w *worker) nextRequest(ctx context.Context) (PortForwardRequest, bool) {
+	for {
+		if len(w.backlog) == 0 {
+			select {
+			case <-ctx.Done():
+				return PortForwardRequest{}, false
+			case req := <-w.reqChan:
+				w.addToBacklog(req)
+			}
+		}
+
+		// drain everything that is waiting so it can be ordered
+	loop:
+		for {
+			select {
+			case <-ctx.Done():
+				return PortForwardRequest{}, false
+			case req := <-w.reqChan:
+				w.addToBacklog(req)
+			default:
+				break loop
+			}
+		}
+
+		next, wait := w.pickRequest()
+		if next != -1 {
+			req := w.backlog[next]
+			w.backlog = append(w.backlog[:next], w.backlog[next+1:]...)
+			atomic.StoreInt64(&w.backlogLen, int64(len(w.backlog)))
+			return req, true
+		}
+
+		// everything waiting is a throttled recreation, wait until one
+		// can be done or something else comes in
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return PortForwardRequest{}, false
+		case req := <-w.reqChan:
+			t.Stop()
+			w.addToBacklog(req)
+		case <-t.C:
+		}
+	}
+}
+
+// addToBacklog adds a request to the backlog, coalescing it with the
+// requests that are waiting for the same service. Requests are often sent
+// more than once, e.g. by both the reaper and a port-forward failing, and
+// only the latest state of the service matters:
+//
+//   - a delete replaces every request waiting for the service, since it
+//     undoes whatever they would've done
+//   - a create replaces a create that's the last request for the service,
+//     keeping if it was a recreation
+//   - a pause is dropped if the last request for the service is a pause
+func (w *worker) addToBacklog(req PortForwardRequest) {
+	defer func() { atomic.StoreInt64(&w.backlogLen, int64(len(w.backlog))) }()
+
+	serv := req.service()
+	key := serv.Key()
+
+	if req.DeletePortForwardRequest != nil {
+		kept := w.backlog[:0]
+		for _, r := range w.backlog {
+			if other := r.service(); other.Key() != key {
+				kept = append(kept, r)
+			}
+		}
+		w.backlog = append(kept, req)
+		return
+	}
+
+	for i := len(w.backlog) - 1; i >= 0; i-- {
+		prev := w.backlog[i]
+		if other := prev.service(); other.Key() != key {
+			continue
+		}
+
+		switch {
+		case req.CreatePortForwardRequest != nil && prev.CreatePortForwardRequest != nil:
+			if prev.CreatePortForwardRequest.Recreate && !req.CreatePortForwardRequest.Recreate {
+				create := *req.CreatePortForwardRequest
+				create.Recreate = true
+				create.RecreateReason = prev.CreatePortForwardRequest.RecreateReason
+				req.CreatePortForwardRequest = &create
+			}
+			w.backlog[i] = req
+			return
+		case req.PausePortForwardRequest != nil && prev.PausePortForwardRequest != nil:
+			return
+		}
+		break
+	}
+
+	w.backlog = append(w.backlog, req)
+}
+
+// isRecreate returns if req recreates a port-forward
+func isRecreate(req PortForwardRequest) bool {
+	return req.CreatePortForwardRequest != nil && req.CreatePortForwardRequest.Recreate
+}
+
+// pickRequest returns the index of the highest priority request in the
+// backlog that can be processed now. If none can, -1 is returned with how
+// long it is until a throttled recreation can be done.
+func (w *worker) pickRequest() (int, time.Duration) {
+	// only the first request for each service can be processed
+	seen := make(map[string]bool)
+	candidates := []int{}
+	for i := range w.backlog {
+		serv := w.backlog[i].service()
+		key := serv.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		candidates = append(candidates, i)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return w.backlog[candidates[i]].priority() > w.backlog[candidates[j]].priority()
+	})
+
+	var wait time.Duration
+	for _, i := range candidates {
+		if w.recreateLimiter == nil || !isRecreate(w.backlog[i]) {
+			return i, 0
+		}
+
+		r := w.recreateLimiter.Reserve()
+		delay := r.Delay()
+		if delay == 0 {
+			return i, 0
+		}
+		r.Cancel()
+
+		if wait == 0 || delay < wait {
+			wait = delay
+		}
+	}
+
+	return -1, wait
+}
+
+// runningTunnels returns the number of port-forwards that are running
+func (w *worker) runningTunnels() int {
+	running := 0
+	for _, pf := range w.portForwards {
+		if pf.Status == PortForwardStatusRunning {
+			running++
+		}
+	}
+	return running
+}
+
+// hasFreeSlot returns if another port-forward can be created, i.e. the
+// tunnel limit hasn't been reached and there is a free IP
+func (w *worker) hasFreeSlot() bool {
+	if w.maxTunnels > 0 && w.runningTunnels() >= w.maxTunnels {
+		return false
+	}
+
+	usage := w.ipPoolUsage()
+	return usage.Acquired < usage.Available
+}
+
+// ipPoolUsage returns the usage of the IP pool
+func (w *worker) ipPoolUsage() IPPoolUsage {
+	acquired, available := w.ippool.Usage()
+	return IPPoolUsage{
+		CIDR:      w.ippool.CIDR(),
+		Acquired:  acquired,
+		Available: available,
+	}
+}
+
+// schedulePending retries the highest priority port-forward waiting for a
+// free slot, if there is one
+func (w *worker) schedulePending() {
+	if len(w.pendingTunnels) == 0 || !w.hasFreeSlot() {
+		return
+	}
+
+	next := 0
+	for i, req := range w.pendingTunnels {
+		if req.Priority > w.pendingTunnels[next].Priority {
+			next = i
+		}
+	}
+
+	req := w.pendingTunnels[next]
+	w.mu.Lock()
+	w.pendingTunnels = append(w.pendingTunnels[:next], w.pendingTunnels[next+1:]...)
+	w.mu.Unlock()
+	req.Recreate = true
+	req.RecreateReason = "tunnel slot became available"
+
+	// we're the consumer of this channel, so we can't block on it
+	go func() {
+		w.reqChan <- PortForwardRequest{CreatePortForwardRequest: req}
+	}()
+}
+
+// addPending adds a request to the port-forwards waiting for a slot,
+// replacing the one for its service if there is one
+func (w *worker) addPending(req *CreatePortForwardRequest) {
+	w.removePending(req.Service.Key())
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pendingTunnels = append(w.pendingTunnels, req)
+}
+
+// removePending removes a service from the port-forwards waiting for a slot
+func (w *worker) removePending(serviceKey string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i, req := range w.pendingTunnels {
+		if req.Service.Key() == serviceKey {
+			w.pendingTunnels = append(w.pendingTunnels[:i], w.pendingTunnels[i+1:]...)
+			return
+		}
+	}
+}
+
+// isPending returns if a service is waiting for a tunnel slot
+func (w *worker) isPending(serviceKey string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	for _, req := range w.pendingTunnels {
+		if req.Service.Key() == serviceKey {
+			return true
+		}
+	}
+	return false
+}
+
+// withDeadline returns a context for a single request to the worker, so
+// that a hung API server, or helper, can't stall the worker and every
+// request queued behind it
+func (w *worker) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if w.operationTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, w.operationTimeout)
+}
+
+// touch notes that the worker is being touched by the proxier.
+func (w *worker) touch() {
+	w.touchMu.Lock()
+	defer w.touchMu.Unlock()
+
+	w.lastTouchTime = time.Now()
+}
+
+// isStable returns true if the worker hasn't been touched in 2 seconds or
+// longer, and returns false otherwise. This was inteded to be used to denote
+// some sort of "readiness", as the worker will be constantly creating
+// port-forwards when starting up, draining the initial queue created by
+// the proxier.
+func (w *worker) isStable() bool {
+	w.touchMu.Lock()
+	defer w.touchMu.Unlock()
+
+	return time.Since(w.lastTouchTime) >= time.Second*2
+}
+
+// getPodForService finds an available endpoint for the service of req. If
+// it has a leader lock, only the pod holding it is considered. Otherwise,
+// endpoints are chosen like the cluster would for a client on our node and
+// zone, if they're known, preferring the ones least likely to go away.
+func (w *worker) getPodForService(ctx context.Context, req *CreatePortForwardRequest) (PodInfo, error) {
+	si := &req.Service
+	e, err := w.k.CoreV1().Endpoints(si.Namespace).Get(ctx, si.Name, metav1.GetOptions{})
+	if err != nil {
+		return PodInfo{}, err
+	}
+
+	holder := ""
+	if req.LeaderLock != "" {
+		holder, err = leaderOf(ctx, w.k, si.Namespace, req.LeaderLock)
+		if err != nil {
+			return PodInfo{}, err
+		}
+	}
+
+	nodeLocal := req.NodeLocal && w.node != "" && holder == ""
+
+	var hinted map[string]bool
+	if req.TopologyHints && w.zone != "" && holder == "" && !nodeLocal {
+		hinted, err = hintedAddresses(ctx, w.k, si.Namespace, si.Name, w.zone)
+		if err != nil {
+			w.log.WithError(err).WithField("service", si.Key()).Warn("ignoring topology aware hints")
+		}
+	}
+
+	endpoints := []endpoint{}
+	for _, subset := range e.Subsets {
+		for _, addr := range subset.Addresses {
+			if addr.TargetRef == nil {
+				continue
+			}
+
+			if addr.TargetRef.Kind != PodKind {
+				continue
+			}
+
+			if holder != "" && !isLeader(addr.TargetRef.Name, holder) {
+				continue
+			}
+
+			if nodeLocal && (addr.NodeName == nil || *addr.NodeName != w.node) {
+				continue
+			}
+
+			if hinted != nil && !hinted[addr.IP] {
+				continue
+			}
+
+			ep := endpoint{info: PodInfo{Name: addr.TargetRef.Name, Namespace: addr.TargetRef.Namespace}}
+			if addr.NodeName != nil {
+				ep.node = *addr.NodeName
+			}
+
+			// skip pods that are being replaced, the cache may not know
+			// about new pods yet so those are still used
+			if obj, exists, err := w.pods.GetByKey(ep.info.Key()); err == nil && exists {
+				ep.pod = obj.(*corev1.Pod)
+				if ep.pod.DeletionTimestamp != nil {
+					continue
+				}
+			}
+
+			endpoints = append(endpoints, ep)
+		}
+	}
+	if len(endpoints) == 0 && holder != "" {
+		return PodInfo{}, fmt.Errorf("leader '%s' isn't an endpoint of the service", holder)
+	} else if len(endpoints) == 0 && nodeLocal {
+		return PodInfo{}, fmt.Errorf("no endpoints on node '%s'", w.node)
+	} else if len(endpoints) == 0 {
+		return PodInfo{}, fmt.Errorf("failed to find endpoint for service")
+	}
+
+	return w.bestEndpoint(ctx, endpoints), nil
+}
+
+func (w *worker) CreatePortForward(ctx context.Context, req *CreatePortForwardRequest) (returnedError error) { //nolint:funlen,gocyclo
+	serviceKey := req.Service.Key()
+	log := w.log.WithField("service", serviceKey)
+	if req.Endpoint != nil {
+		log = log.WithField("endpoint", req.Endpoint.Key())
+	}
+
+	// skip port-forwards that are already being managed
+	// unless it's marked as being recreated
+	if _, ok := w.portForwards[serviceKey]; ok && !req.Recreate {
+		return fmt.Errorf("already have a port-forward for this service")
+	}
+
+	// a port-forward that failed while being deleted asks to be recreated
+	// after it's gone
+	if _, ok := w.portForwards[serviceKey]; !ok && req.Recreate {
+		log.Debug("not recreating port-forward, it was deleted")
+		return nil
+	}
+
+	// The worker is doing meaningful work, not a no-op, note this.
+	w.touch()
+
+	// ctx outlives this request, e.g. it's used by the port-forward, so
+	// opCtx is used for everything this request waits on
+	opCtx, cancel := w.withDeadline(ctx)
+	defer cancel()
+
+	pf := &PortForwardConnection{
+		Service:  req.Service,
+		Status:   PortForwardStatusRunning,
+		Ports:    req.Ports,
+		Compress: req.Compress,
+	}
+
+	if prev := w.portForwards[serviceKey]; req.Recreate && prev != nil {
+		pf.RecreateCount = prev.RecreateCount
+		pf.Recreations = prev.Recreations
+
+		// only recreating a port-forward that was running counts against
+		// its budget, e.g. resuming or getting a free slot doesn't
+		counted := prev.Status == PortForwardStatusRunning || prev.Status == PortForwardStatusRecreating
+		if counted {
+			w.recordRecreation(pf, req.RecreateReason)
+		}
+
+		if (counted || prev.Status == PortForwardStatusFailed) && w.overRecreateBudget(pf) {
+			if prev.Status != PortForwardStatusFailed {
+				log.Warnf("not recreating port-forward, it was recreated more than %d times in %s", w.maxRecreates, w.recreateWindow)
+			}
+
+			if err := w.stopPortForward(ctx, prev); err != nil {
+				log.WithError(err).Warn("failed to cleanup previous port-forward")
+			}
+
+			pf.Status = PortForwardStatusFailed
+			pf.StatusReason = fmt.Sprintf("Recreated more than %d times in %s, last due to: %s.",
+				w.maxRecreates, w.recreateWindow, pf.Recreations[len(pf.Recreations)-1].Reason)
+			w.store(pf)
+			if counted {
+				w.fireEvent(ctx, HookForwardFailed, pf, pf.StatusReason)
+			}
+			return nil
+		}
+	}
+
+	if req.Recreate {
+		log.Infof("recreating port-forward due to: %v", req.RecreateReason)
+		w.setPortForwardConnectionStatus(ctx, req.Service, PortForwardStatusRecreating, req.RecreateReason)
+		err := w.stopPortForward(ctx, w.portForwards[serviceKey])
+		if err != nil {
+			log.WithError(err).Warn("failed to cleanup previous port-forward")
+		}
+	}
+
+	if w.maxTunnels > 0 && w.runningTunnels() >= w.maxTunnels {
+		log.Warnf("not creating tunnel, limit of %d tunnels reached", w.maxTunnels)
+		w.addPending(req)
+
+		pf.Status = PortForwardStatusWaiting
+		pf.StatusReason = fmt.Sprintf("Tunnel limit of %d reached.", w.maxTunnels)
+		w.store(pf)
+		return nil
+	}
+
+	// cleanup after failed tunnel (that failed to be created)
+	// using named returns we can check if an error occurred
+	defer func() {
+		if returnedError != nil {
+			w.subscribers.publish(Event{Service: req.Service, Err: returnedError})
+			w.fireEvent(ctx, HookForwardFailed, pf, returnedError.Error())
+
+			// whatever timed out might never come back, so everything is
+			// given up before trying again
+			timedOut := errors.Is(returnedError, context.DeadlineExceeded)
+			if timedOut {
+				w.releaseHostnames(serviceKey)
+			}
+			if err := w.stopPortForward(ctx, pf); err != nil {
+				log.WithError(err).Warn("failed to cleanup failed tunnel")
+			}
+			if timedOut {
+				pf.Status = PortForwardStatusWaiting
+				pf.StatusReason = fmt.Sprintf("Timed out after %s, retrying.", w.operationTimeout)
+				w.store(pf)
+				w.retryAfterTimeout(ctx, req)
+			}
+		}
+	}()
+
+	ip := "127.0.0.1"
+	if w.randomPorts {
+		// every port-forward listens on 127.0.0.1 on ports of its own, so
+		// there's no IP to allocate or hosts entries to write
+		ports, err := w.randomizePorts(serviceKey, req.Ports)
+		if err != nil {
+			return err
+		}
+		pf.Ports = ports
+		pf.IP = net.ParseIP(ip)
+	} else {
+		// TODO: need to release on error
+		ipAddress, err := w.acquireIP(serviceKey)
+		if errors.Is(err, ErrNoIPAvailable) {
+			usage := w.ipPoolUsage()
+			log.Warnf("not creating tunnel, IP pool %s is exhausted (%d/%d addresses in use), a larger IP CIDR is needed",
+				usage.CIDR, usage.Acquired, usage.Available)
+			w.addPending(req)
+
+			pf.Status = PortForwardStatusWaiting
+			pf.StatusReason = fmt.Sprintf("IP pool %s is exhausted.", usage.CIDR)
+			w.store(pf)
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "failed to allocate IP")
+		}
+		pf.IP = ipAddress
+		ip = ipAddress.String()
+
+		// We only need to create alias on darwin, on other platforms
+		// lo0 becomes lo and routes the full /8
+		if runtime.GOOS == "darwin" && os.Getenv("DISABLE_LOOPBACK_ALIAS") == "" {
+			//nolint:govet // Why: We're OK shadowing err
+			if err := w.loopbackAlias(opCtx, ip, true); err != nil {
+				return errors.Wrap(err, "failed to create ip link")
+			}
+		}
+		pf.Hostnames = w.claimHostnames(serviceKey, req.Hostnames)
+
+		//nolint:govet // Why: We're OK shadowing err
+		if err := w.dns.AddHosts(ip, pf.Hostnames); err != nil {
+			return errors.Wrap(err, "failed to add host entry")
+		}
+
+		//nolint:govet // Why: We're OK shadowing err
+		if err := w.saveHosts(opCtx); err != nil {
+			return errors.Wrap(err, "failed to save host changes")
+		}
+
+		if w.redirector != nil && req.ClusterIP != "" && req.ClusterIP != "None" {
+			//nolint:govet // Why: We're OK shadowing err
+			if err := w.redirector.Add(req.ClusterIP, ip, req.Ports); err != nil {
+				return errors.Wrap(err, "failed to redirect clusterIP")
+			}
+			pf.ClusterIP = req.ClusterIP
+		}
+	}
+
+	// pods that are forwarded directly, or services that need their leader,
+	// always use a port-forward
+	useServiceDialer := w.serviceDialer != nil && req.Service.Kind != PodKind && req.LeaderLock == ""
+
+	var pod *PodInfo
+	waitingReason := "No endpoints were found."
+	endpointTimedOut := false
+	if useServiceDialer {
+		// no pod is needed, connections go to the service
+	} else if req.Service.Kind == PodKind {
+		if isRunningPod(w.pods, *req.Endpoint) {
+			pod = req.Endpoint
+		}
+		waitingReason = "Pod isn't running."
+	} else if req.Endpoint == nil {
+		podInfo, err := w.getPodForService(opCtx, req)
+		if err == nil {
+			pod = &podInfo
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			waitingReason = fmt.Sprintf("Timed out finding an endpoint after %s, retrying.", w.operationTimeout)
+			endpointTimedOut = true
+		} else if req.LeaderLock != "" {
+			waitingReason = fmt.Sprintf("Failed to find leader: %v.", err)
+		} else if req.NodeLocal && w.node != "" {
+			waitingReason = fmt.Sprintf("No endpoints on node %s, and the service's internalTrafficPolicy is Local.", w.node)
+		}
+	} else {
+		pod = req.Endpoint
+	}
+
+	// only create the tunnel if we found a pod, if we didn't
+	// then it will be created once one shows up in its endpoints
+	if useServiceDialer {
+		log.Debug("creating tunnel through service dialer")
+		//nolint:govet // Why: We're OK shadowing err
+		if err := w.startServiceRelays(ctx, pf, ip, req.Ports); err != nil {
+			return err
+		}
+	} else if pod != nil {
+		log = log.WithField("endpoint", pod.Key())
+		pf.Pod = *pod
+
+		log.Debug("creating tunnel")
+		dialer, err := w.dialerFor(pod)
+		if err != nil {
+			return err
+		}
+
+		// when relaying, the port-forward listens on random local ports
+		// and the relays listen on the service's IP instead
+		useRelay := w.connSem != nil || w.bufferSize > 0 || w.drainTimeout > 0 ||
+			w.recording(req.Service.Key()) || w.detecting(req.Service.Key(), req.Ports)
+		listenAddress := ip
+		ports := pf.Ports
+		if useRelay {
+			listenAddress = "127.0.0.1"
+			ports = make([]string, len(req.Ports))
+			for i, p := range req.Ports {
+				ports[i] = "0:" + strings.Split(p, ":")[1]
+			}
+		}
+
+		// closing the port-forward only closes its listeners, its context
+		// has to be canceled to close the connection to the pod. It isn't
+		// derived from ctx so that connections keep working while they're
+		// drained on shutdown, closeTunnel cancels it.
+		fwCtx, cancel := context.WithCancel(context.Background())
+		pf.cancel = cancel
+
+		readyChan := make(chan struct{})
+		fw, err := portforward.NewOnAddresses(dialer, []string{listenAddress}, ports, fwCtx.Done(), readyChan, ioutil.Discard, ioutil.Discard)
+		if err != nil {
+			return errors.Wrap(err, "failed to create port-forward")
+		}
+		pf.pf = fw
+
+		fwDone := make(chan struct{})
+		go func() {
+			err := fw.ForwardPorts()
+			close(fwDone)
+
+			// if it was stopped, or we're exiting, then we can ignore the error
+			select {
+			case <-fwCtx.Done():
+				return
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			// otherwise, recreate it
+			w.fireEvent(ctx, HookForwardFailed, pf, fmt.Sprintf("%v", err))
+			w.reqChan <- PortForwardRequest{
+				CreatePortForwardRequest: &CreatePortForwardRequest{
+					Service:        req.Service,
+					Hostnames:      req.Hostnames,
+					Ports:          req.Ports,
+					Endpoint:       endpointForRecreate(req),
+					ClusterIP:      req.ClusterIP,
+					Priority:       req.Priority,
+					Compress:       req.Compress,
+					LeaderLock:     req.LeaderLock,
+					NodeLocal:      req.NodeLocal,
+					TopologyHints:  req.TopologyHints,
+					Recreate:       true,
+					RecreateReason: fmt.Sprintf("%v", err),
+				},
+			}
+		}()
+
+		if useRelay {
+			//nolint:govet // Why: We're OK shadowing err
+			if err := w.startRelays(opCtx, pf, readyChan, fwDone, ip, req.Ports); err != nil {
+				return err
+			}
+		}
+	} else {
+		log.Warn("skipping tunnel creation due to no endpoint being found")
+		pf.Status = PortForwardStatusWaiting
+		pf.StatusReason = waitingReason
+		if endpointTimedOut {
+			w.releaseHostnames(serviceKey)
+		}
+		if err := w.stopPortForward(ctx, pf); err != nil {
+			return err
+		}
+
+		// endpoints changing won't tell us when the API server is back
+		if endpointTimedOut {
+			w.retryAfterTimeout(ctx, req)
+		}
+	}
+
+	// mark that this is allocated
+	w.store(pf)
+
+	if pf.Status == PortForwardStatusRunning {
+		w.timeoutBackoff.Forget(serviceKey)
+		w.fireEvent(ctx, HookForwardCreated, pf, req.RecreateReason)
+	}
+
+	return nil
+}
+
+// retryAfterTimeout creates a port-forward that timed out again later,
+// backing off each time it times out in a row
+func (w *worker) retryAfterTimeout(ctx context.Context, req *CreatePortForwardRequest) {
+	delay := w.timeoutBackoff.When(req.Service.Key())
+	w.log.WithField("service", req.Service.Key()).Infof("trying port-forward again in %s", delay)
+
+	retry := *req
+	retry.Recreate = true
+	retry.RecreateReason = fmt.Sprintf("timed out after %s", w.operationTimeout)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+			w.reqChan <- PortForwardRequest{CreatePortForwardRequest: &retry}
+		}
+	}()
+}
+
+// recentRecreations is the number of recreations of a port-forward that
+// are kept regardless of how long ago they were
+const recentRecreations = 3
+
+// recordRecreation records that pf is being recreated, dropping
+// recreations that are no longer needed to enforce the recreate budget
+func (w *worker) recordRecreation(pf *PortForwardConnection, reason string) {
+	now := time.Now()
+	pf.RecreateCount++
+	pf.Recreations = append(pf.Recreations, Recreation{Time: now, Reason: reason})
+
+	keep := make([]Recreation, 0, len(pf.Recreations))
+	for i, r := range pf.Recreations {
+		if len(pf.Recreations)-i <= recentRecreations || now.Sub(r.Time) < w.recreateWindow {
+			keep = append(keep, r)
+		}
+	}
+	pf.Recreations = keep
+}
+
+// overRecreateBudget returns if pf has been recreated more than the
+// allowed number of times within the recreate window
+func (w *worker) overRecreateBudget(pf *PortForwardConnection) bool {
+	if w.maxRecreates <= 0 {
+		return false
+	}
+
+	recent := 0
+	for _, r := range pf.Recreations {
+		if time.Since(r.Time) < w.recreateWindow {
+			recent++
+		}
+	}
+	return recent > w.maxRecreates
+}
+
+// endpointForRecreate returns the endpoint to use when recreating a
+// port-forward that failed. Services pick a new one, while pods that are
+// forwarded directly always use the same pod.
+func endpointForRecreate(req *CreatePortForwardRequest) *PodInfo {
+	if req.Service.Kind == PodKind {
+		return req.Endpoint
+	}
+	return nil
+}
+
+// dialerFor returns the dialer used to port-forward to a pod
+func (w *worker) dialerFor(pod *PodInfo) (httpstream.Dialer, error) {
+	if w.dialer != nil {
+		return w.dialer(pod.Namespace, pod.Name)
+	}
+
+	transport, err := kube.TransportFor(w.rest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create transport")
+	}
+
+	return transport.DialerFor(w.k.CoreV1().RESTClient(), pod.Namespace, pod.Name), nil
+}
+
+// fireEvent runs the hooks for an event about a port-forward
+func (w *worker) fireEvent(ctx context.Context, t HookEventType, pf *PortForwardConnection, reason string) {
+	if w.hooks == nil {
+		return
+	}
+
+	// this is called by port-forwards that failed as well
+	w.mu.RLock()
+	e := HookEvent{
+		Type:      t,
+		Namespace: pf.Service.Namespace,
+		Service:   pf.Service.Name,
+		Hostnames: pf.Hostnames,
+		Ports:     pf.Ports,
+		Reason:    reason,
+	}
+	if len(pf.IP) != 0 {
+		e.IP = pf.IP.String()
+	}
+	if pf.Pod.Name != "" {
+		e.Endpoint = pf.Pod.Key()
+	}
+	w.mu.RUnlock()
+
+	w.hooks(ctx, e)
+}
+
+// cleanupPrevious removes what a previous run that didn't exit cleanly
+// left behind, the loopback aliases of ips and the entries in our block of
+// the hosts file
+func (w *worker) cleanupPrevious(ctx context.Context, ips map[string]string) {
+	w.log.Warn("previous run didn't exit cleanly, cleaning up after it")
+
+	if runtime.GOOS == "darwin" && os.Getenv("DISABLE_LOOPBACK_ALIAS") == "" {
+		for _, ip := range ips {
+			if err := w.loopbackAlias(ctx, ip, false); err != nil {
+				w.log.WithError(err).Debugf("failed to remove loopback alias %s", ip)
+			}
+		}
+	}
+
+	// nothing has been added to the block yet, so this empties it
+	if err := w.saveHosts(ctx); err != nil {
+		w.log.WithError(err).Warn("failed to remove hosts entries left by the previous run")
+	}
+}
+
+// reserveIPs holds the IPs services had in the previous run, keyed by
+// service, so that they get them again
+func (w *worker) reserveIPs(ips map[string]string) {
+	for key, ip := range ips {
+		ipAddress := net.ParseIP(ip)
+		if ipAddress == nil || w.ippool.AcquireSpecific(ipAddress) != nil {
+			continue
+		}
+		w.reservedIPs[key] = ipAddress
+	}
+}
+
+// acquireIP allocates an IP for a service, preferring the one it had in
+// the previous run. When the pool runs out, IPs held for services that
+// haven't come back are given up.
+func (w *worker) acquireIP(serviceKey string) (net.IP, error) {
+	if ipAddress, ok := w.reservedIPs[serviceKey]; ok {
+		delete(w.reservedIPs, serviceKey)
+		return ipAddress, nil
+	}
+
+	ipAddress, err := w.ippool.Acquire()
+	if errors.Is(err, ErrNoIPAvailable) && len(w.reservedIPs) > 0 {
+		for key, reserved := range w.reservedIPs {
+			//nolint:errcheck // Why: Best effort, it's only a reservation
+			w.ippool.Release(reserved)
+			delete(w.reservedIPs, key)
+		}
+		return w.ippool.Acquire()
+	}
+	return ipAddress, err
+}
+
+// loopbackAlias adds, or removes, a loopback alias for ip. This is done
+// by the privileged helper if we aren't root.
+func (w *worker) loopbackAlias(ctx context.Context, ip string, add bool) error {
+	if w.helper == nil {
+		return privhelper.LoopbackAlias(ip, add)
+	}
+
+	if add {
+		return w.helper.AddLoopbackAlias(ctx, ip)
+	}
+	return w.helper.RemoveLoopbackAlias(ctx, ip)
+}
+
+// saveHosts saves the hosts file, warning if our block in it was modified
+// by something else.
+func (w *worker) saveHosts(ctx context.Context) error {
+	if w.helper != nil {
+		return w.helper.WriteHosts(ctx, w.hostsBlock, w.dns.Hosts())
+	}
+
+	if err := w.dns.Save(ctx); err != nil {
+		return err
+	}
+
+	if repaired := w.dns.Repaired(); len(repaired) > 0 {
+		w.log.WithField("lines", repaired).
+			Warn("localizer hosts block was modified by another tool, moved foreign entries outside of it")
+	}
+
+	return nil
+}
+
+func (w *worker) setPortForwardConnectionStatus(_ context.Context, si ServiceInfo, status PortForwardStatus, reason string) {
+	pf, ok := w.portForwards[si.Key()]
+	if !ok {
+		return
+	}
+
+	w.mu.Lock()
+	pf.Status = status
+	pf.StatusReason = reason
+	w.mu.Unlock()
+	w.publish(pf)
+}
+
+// store records pf as the port-forward of its service, and lets
+// subscribers know about it
+func (w *worker) store(pf *PortForwardConnection) {
+	w.mu.Lock()
+	w.portForwards[pf.Service.Key()] = pf
+	w.mu.Unlock()
+	w.publish(pf)
+}
+
+// portForward returns a copy of the port-forward of a service, by key
+func (w *worker) portForward(key string) (PortForwardConnection, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	pf, ok := w.portForwards[key]
+	if !ok {
+		return PortForwardConnection{}, false
+	}
+	return pf.copy(), true
+}
+
+// listPortForwards returns a copy of every port-forward
+func (w *worker) listPortForwards() []PortForwardConnection {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	pfs := make([]PortForwardConnection, 0, len(w.portForwards))
+	for _, pf := range w.portForwards {
+		pfs = append(pfs, pf.copy())
+	}
+	return pfs
+}
+
+// publish sends the current state of a port-forward to subscribers
+func (w *worker) publish(pf *PortForwardConnection) {
+	e := Event{Service: pf.Service, Status: pf.Status, Reason: pf.StatusReason}
+	if len(pf.IP) != 0 {
+		e.IP = pf.IP.String()
+	}
+	w.subscribers.publish(e)
+}
+
+// startRelays waits for a port-forward to become ready, and then starts a
+// relay for each of its ports on ip. servicePorts are the ports of the
+// service, which pf.Ports listen for.
+func (w *worker) startRelays(ctx context.Context, pf *PortForwardConnection,
+	readyChan, fwDone <-chan struct{}, ip string, servicePorts []string) error {
+	select {
+	case <-readyChan:
+	case <-fwDone:
+		return fmt.Errorf("port-forward exited before becoming ready")
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(30 * time.Second):
+		return fmt.Errorf("timed out waiting for port-forward to become ready")
+	}
+
+	forwarded, err := pf.pf.GetPorts()
+	if err != nil {
+		return errors.Wrap(err, "failed to get port-forward ports")
+	}
+
+	for i, fp := range forwarded {
+		localPort := strings.Split(pf.Ports[i], ":")[0]
+		servicePort := strings.Split(servicePorts[i], ":")[0]
+		r, err := newRelay(w.log.WithField("service", pf.Service.Key()), net.JoinHostPort(ip, localPort),
+			fmt.Sprintf("127.0.0.1:%d", fp.Local), w.bufferSize, w.connSem, w.tapFor(pf.Service.Key(), servicePort))
+		if err != nil {
+			return err
+		}
+		pf.relays = append(pf.relays, r)
+	}
+
+	return nil
+}
+
+// randomizePorts replaces the local side of ports, in the format of
+// local:remote, with free ports of 127.0.0.1. The same local ports are
+// returned for a service until it's deleted.
+func (w *worker) randomizePorts(serviceKey string, ports []string) ([]string, error) {
+	if w.localPorts[serviceKey] == nil {
+		w.localPorts[serviceKey] = make(map[string]string)
+	}
+	given := w.localPorts[serviceKey]
+
+	randomized := make([]string, len(ports))
+	for i, p := range ports {
+		spl := strings.Split(p, ":")
+		if _, ok := given[spl[0]]; !ok {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to find a free local port")
+			}
+			_, port, err := net.SplitHostPort(l.Addr().String())
+			l.Close()
+			if err != nil {
+				return nil, err
+			}
+			given[spl[0]] = port
+		}
+		randomized[i] = given[spl[0]] + ":" + spl[len(spl)-1]
+	}
+
+	return randomized, nil
+}
+
+// startServiceRelays starts a relay for each port of a service on ip, that
+// connects to the service using the ServiceDialer. servicePorts are the
+// ports of the service, which pf.Ports listen for.
+func (w *worker) startServiceRelays(ctx context.Context, pf *PortForwardConnection, ip string, servicePorts []string) error {
+	for i, p := range servicePorts {
+		servicePort := strings.Split(p, ":")[0]
+		localPort := strings.Split(pf.Ports[i], ":")[0]
+		target := net.JoinHostPort(
+			fmt.Sprintf("%s.%s.svc.%s", pf.Service.Name, pf.Service.Namespace, w.clusterDomain), servicePort)
+
+		r, err := newRelayWithDialer(w.log.WithField("service", pf.Service.Key()), net.JoinHostPort(ip, localPort),
+			func() (net.Conn, error) {
+				return w.serviceDialer(ctx, target, pf.Compress)
+			}, w.bufferSize, w.connSem, w.tapFor(pf.Service.Key(), servicePort))
+		if err != nil {
+			return err
+		}
+		pf.relays = append(pf.relays, r)
+	}
+
+	return nil
+}
+
+// forgetDialer drops the cached dialer for the pod of conn, unless another
+// port-forward is still using it
+func (w *worker) forgetDialer(conn *PortForwardConnection) {
+	for _, pf := range w.portForwards {
+		if pf != conn && pf.pf != nil && pf.Pod == conn.Pod {
+			return
+		}
+	}
+
+	if t, err := kube.TransportFor(w.rest); err == nil {
+		t.Forget(conn.Pod.Namespace, conn.Pod.Name)
+	}
+}
+
+// drain stops conns from accepting new connections, then waits up to
+// drainTimeout for the connections they're handling to finish. The
+// caller should close them afterwards.
+func (w *worker) drain(conns ...*PortForwardConnection) {
+	if w.drainTimeout <= 0 {
+		return
+	}
+
+	active := int64(0)
+	wg := sync.WaitGroup{}
+	for _, conn := range conns {
+		for _, r := range conn.relays {
+			r.Close() //nolint:errcheck // Why: Best effort
+			active += r.Active()
+
+			wg.Add(1)
+			go func(r *relay) {
+				defer wg.Done()
+				r.conns.Wait()
+			}(r)
+		}
+	}
+	if active == 0 {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	w.log.Infof("waiting up to %s for %d connection(s) to finish", w.drainTimeout, active)
+	select {
+	case <-done:
+	case <-time.After(w.drainTimeout):
+		w.log.Warnf("closing connections that didn't finish within %s", w.drainTimeout)
+	}
+}
+
+// closeTunnel closes the listeners of a port-forward and its connection
+// to the pod
+func (w *worker) closeTunnel(conn *PortForwardConnection) {
+	for _, r := range conn.relays {
+		r.Close() //nolint:errcheck // Why: Best effort
+	}
+	conn.relays = nil
+
+	if conn.pf != nil {
+		conn.pf.Close()
+		w.forgetDialer(conn)
+		conn.pf = nil
+	}
+
+	if conn.cancel != nil {
+		conn.cancel()
+		conn.cancel = nil
+	}
+}
+
+func (w *worker) stopPortForward(_ context.Context, conn *PortForwardConnection) error {
+	// We don't use the context provided because if it's canceled we need to be able to remove it still
+	ctx, cancel := w.withDeadline(context.Background())
+	defer cancel()
+
+	w.closeTunnel(conn)
+
+	errs := make([]error, 0)
+	if w.redirector != nil && conn.ClusterIP != "" {
+		if err := w.redirector.Remove(conn.ClusterIP); err != nil {
+			errs = append(errs, errors.Wrap(err, "failed to remove clusterIP redirect"))
+		}
+		w.mu.Lock()
+		conn.ClusterIP = ""
+		w.mu.Unlock()
+	}
+
+	if len(conn.IP) > 0 && w.randomPorts {
+		w.mu.Lock()
+		conn.IP = net.IP{}
+		w.mu.Unlock()
+	} else if len(conn.IP) > 0 {
+		// If we are on a platform that needs aliases
+		// then we need to remove it
+		if runtime.GOOS == "darwin" && os.Getenv("DISABLE_LOOPBACK_ALIAS") == "" {
+			if err := w.loopbackAlias(ctx, conn.IP.String(), false); err != nil {
+				errs = append(errs, errors.Wrap(err, "failed to release ip alias"))
+			}
+		}
+
+		err := w.ippool.Release(conn.IP)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "failed to release ip address"))
+		}
+
+		if err := w.dns.RemoveAddress(conn.IP.String()); err != nil {
+			errs = append(errs, errors.Wrap(err, "failed to remove ip address from hostsfile"))
+		}
+
+		if err := w.saveHosts(ctx); err != nil {
+			errs = append(errs, errors.Wrap(err, "failed to save hosts file after modification(s)"))
+		}
+
+		w.mu.Lock()
+		conn.IP = net.IP{}
+		w.mu.Unlock()
+	}
+
+	// if we have errors, return them
+	if len(errs) > 0 {
+		strs := []string{}
+		for _, err := range errs {
+			strs = append(strs, err.Error())
+		}
+		return fmt.Errorf("%v", strs)
+	}
+
+	return nil
+}
+
+func (w *worker) DeletePortForward(ctx context.Context, req *DeletePortForwardRequest) error {
+	return w.deletePortForward(ctx, req, true)
+}
+
+// deletePortForward stops a port-forward and forgets about it, draining its
+// connections first if drain is set. It isn't set when they've already
+// been drained, e.g. on shutdown where every port-forward is drained at
+// once.
+func (w *worker) deletePortForward(ctx context.Context, req *DeletePortForwardRequest, drain bool) error {
+	serviceKey := req.Service.Key()
+	log := w.log.WithField("service", serviceKey)
+
+	w.removePending(serviceKey)
+
+	// nothing to do for non exiting forwards.
+	if w.portForwards[serviceKey] == nil {
+		return nil
+	}
+
+	// The worker is doing meaningful work, not a no-op, note this.
+	w.touch()
+
+	if drain {
+		w.drain(w.portForwards[serviceKey])
+	}
+	if err := w.stopPortForward(ctx, w.portForwards[serviceKey]); err != nil {
+		log.WithError(err).Warn("failed to cleanup port-forward")
+	}
+
+	// hostnames are only given up once a port-forward is deleted, so a
+	// service doesn't lose them to another while it's being recreated
+	if w.releaseHostnames(serviceKey) {
+		if err := w.saveHosts(ctx); err != nil {
+			log.WithError(err).Warn("failed to save hosts file after giving away hostnames")
+		}
+	}
+
+	// now mark it as not being allocated
+	w.mu.Lock()
+	delete(w.portForwards, serviceKey)
+	w.mu.Unlock()
+	delete(w.localPorts, serviceKey)
+	w.protoMu.Lock()
+	delete(w.protocols, serviceKey)
+	w.protoMu.Unlock()
+	w.timeoutBackoff.Forget(serviceKey)
+	w.subscribers.publish(Event{Service: req.Service, Deleted: true})
+
+	log.Info("stopped port-forward")
+
+	return nil
+}
+
+// PausePortForward closes the tunnel of a port-forward, its IP and hosts
+// entries are kept so that it can be resumed by recreating it
+func (w *worker) PausePortForward(_ context.Context, req *PausePortForwardRequest) error {
+	serviceKey := req.Service.Key()
+	log := w.log.WithField("service", serviceKey)
+
+	pf, ok := w.portForwards[serviceKey]
+	if !ok || pf.Status == PortForwardStatusPaused {
+		return nil
+	}
+
+	// The worker is doing meaningful work, not a no-op, note this.
+	w.touch()
+
+	w.removePending(serviceKey)
+	w.closeTunnel(pf)
+
+	w.mu.Lock()
+	pf.Status = PortForwardStatusPaused
+	pf.StatusReason = "Paused."
+	w.mu.Unlock()
+	w.publish(pf)
+
+	log.Info("paused port-forward")
+
+	return nil
+}
@@ -0,0 +1,334 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWorkerNextRequest(t *testing.T) {
+	create := func(name string, priority int) PortForwardRequest {
+		return PortForwardRequest{CreatePortForwardRequest: &CreatePortForwardRequest{
+			Service:  ServiceInfo{Namespace: "default", Name: name},
+			Priority: priority,
+		}}
+	}
+	del := func(name string) PortForwardRequest {
+		return PortForwardRequest{DeletePortForwardRequest: &DeletePortForwardRequest{
+			Service: ServiceInfo{Namespace: "default", Name: name},
+		}}
+	}
+
+	w := &worker{reqChan: make(chan PortForwardRequest, 10)}
+	for _, req := range []PortForwardRequest{
+		create("a", 0),
+		create("b", 0),
+		del("c"),
+		create("c", 10),
+		create("d", 5),
+		create("e", 10),
+	} {
+		w.reqChan <- req
+	}
+
+	// c's create can't skip ahead of its delete
+	want := []string{"e", "d", "a", "b", "c", "c"}
+	for i, name := range want {
+		req, ok := w.nextRequest(context.Background())
+		if !ok {
+			t.Fatal("expected a request")
+		}
+
+		serv := req.service()
+		if serv.Name != name {
+			t.Fatalf("request %d: expected service %s, got %s", i, name, serv.Name)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, ok := w.nextRequest(ctx); ok {
+		t.Fatal("expected no request after the context was canceled")
+	}
+}
+
+func TestWorkerThrottledRecreates(t *testing.T) {
+	recreate := func(name, reason string) PortForwardRequest {
+		return PortForwardRequest{CreatePortForwardRequest: &CreatePortForwardRequest{
+			Service:        ServiceInfo{Namespace: "default", Name: name},
+			Recreate:       true,
+			RecreateReason: reason,
+		}}
+	}
+
+	// one recreation now, then one every 100ms
+	w := &worker{
+		reqChan:         make(chan PortForwardRequest, 10),
+		recreateLimiter: rate.NewLimiter(rate.Every(100*time.Millisecond), 1),
+	}
+	for _, req := range []PortForwardRequest{
+		recreate("a", "first"),
+		recreate("a", "second"),
+		recreate("b", "failed"),
+		{DeletePortForwardRequest: &DeletePortForwardRequest{Service: ServiceInfo{Namespace: "default", Name: "c"}}},
+	} {
+		w.reqChan <- req
+	}
+
+	// a's recreations are collapsed into the latest, and c's delete isn't
+	// held up by b's throttled recreation
+	start := time.Now()
+	want := []string{"a/second", "c/", "b/failed"}
+	for i, name := range want {
+		req, ok := w.nextRequest(context.Background())
+		if !ok {
+			t.Fatal("expected a request")
+		}
+
+		serv := req.service()
+		got := serv.Name + "/"
+		if req.CreatePortForwardRequest != nil {
+			got += req.CreatePortForwardRequest.RecreateReason
+		}
+		if got != name {
+			t.Fatalf("request %d: expected %s, got %s", i, name, got)
+		}
+	}
+
+	if took := time.Since(start); took < 50*time.Millisecond {
+		t.Fatalf("expected the second recreation to be throttled, took %s", took)
+	}
+}
+
+func TestWorkerCoalesceRequests(t *testing.T) {
+	service := func(name string) ServiceInfo {
+		return ServiceInfo{Namespace: "default", Name: name}
+	}
+	create := func(name string, recreate bool) PortForwardRequest {
+		return PortForwardRequest{CreatePortForwardRequest: &CreatePortForwardRequest{
+			Service:        service(name),
+			Recreate:       recreate,
+			RecreateReason: name + " failed",
+		}}
+	}
+	del := func(name string) PortForwardRequest {
+		return PortForwardRequest{DeletePortForwardRequest: &DeletePortForwardRequest{Service: service(name)}}
+	}
+	pause := func(name string) PortForwardRequest {
+		return PortForwardRequest{PausePortForwardRequest: &PausePortForwardRequest{Service: service(name)}}
+	}
+
+	w := &worker{}
+	for _, req := range []PortForwardRequest{
+		// a's delete undoes everything before it
+		create("a", false), pause("a"), create("a", true), del("a"), del("a"),
+		// b is still a recreation after being updated
+		create("b", true), create("b", false),
+		// c's pauses are collapsed, but not across its create
+		pause("c"), pause("c"), create("c", false), pause("c"),
+	} {
+		w.addToBacklog(req)
+	}
+
+	describe := func(req PortForwardRequest) string {
+		serv := req.service()
+		switch {
+		case req.DeletePortForwardRequest != nil:
+			return "delete " + serv.Name
+		case req.PausePortForwardRequest != nil:
+			return "pause " + serv.Name
+		case req.CreatePortForwardRequest.Recreate:
+			return "recreate " + serv.Name + " (" + req.CreatePortForwardRequest.RecreateReason + ")"
+		}
+		return "create " + serv.Name
+	}
+
+	want := []string{"delete a", "recreate b (b failed)", "pause c", "create c", "pause c"}
+	got := make([]string, len(w.backlog))
+	for i, req := range w.backlog {
+		got[i] = describe(req)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expected backlog %q, got %q", want, got)
+	}
+}
+
+func TestWorkerRecreateBudget(t *testing.T) {
+	w := &worker{maxRecreates: 3, recreateWindow: time.Minute}
+	pf := &PortForwardConnection{}
+
+	// old recreations don't count against the budget
+	pf.Recreations = []Recreation{{Time: time.Now().Add(-time.Hour), Reason: "old"}}
+	for i := 0; i < 3; i++ {
+		w.recordRecreation(pf, fmt.Sprintf("failure %d", i))
+		if w.overRecreateBudget(pf) {
+			t.Fatalf("recreation %d: expected to be within the budget", i)
+		}
+	}
+
+	w.recordRecreation(pf, "failure 3")
+	if !w.overRecreateBudget(pf) {
+		t.Fatal("expected to be over the budget")
+	}
+	if pf.RecreateCount != 4 {
+		t.Fatalf("expected 4 recreations, got %d", pf.RecreateCount)
+	}
+
+	// the old recreation is dropped, and the rest are needed for the budget
+	if len(pf.Recreations) != 4 || pf.Recreations[0].Reason != "failure 0" {
+		t.Fatalf("unexpected recreations kept: %v", pf.Recreations)
+	}
+
+	// without a budget only the most recent are kept
+	w.maxRecreates = 0
+	w.recreateWindow = 0
+	w.recordRecreation(pf, "failure 4")
+	if w.overRecreateBudget(pf) {
+		t.Fatal("expected no budget")
+	}
+	if len(pf.Recreations) != recentRecreations || pf.Recreations[recentRecreations-1].Reason != "failure 4" {
+		t.Fatalf("unexpected recreations kept: %v", pf.Recreations)
+	}
+}
+
+// brokenDialer fails every connection, which fails port-forwards as soon
+// as they're created
+type brokenDialer struct{}
+
+func (brokenDialer) Dial(...string) (httpstream.Connection, string, error) {
+	return nil, "", fmt.Errorf("connection refused")
+}
+
+// TestWorkerRecreateStorm recreates port-forwards as fast as possible,
+// while they're read like the API does. This is meant to be ran with -race.
+func TestWorkerRecreateStorm(t *testing.T) {
+	hosts := filepath.Join(t.TempDir(), "hosts")
+	if err := ioutil.WriteFile(hosts, []byte("127.0.0.1 localhost\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	services := []string{"a", "b", "c", "d"}
+	k := fake.NewSimpleClientset()
+	for _, name := range services {
+		_, err := k.CoreV1().Endpoints("default").Create(context.Background(), &corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Subsets: []corev1.EndpointSubset{{
+				Addresses: []corev1.EndpointAddress{{
+					IP:        "10.0.0.1",
+					TargetRef: &corev1.ObjectReference{Kind: PodKind, Namespace: "default", Name: name + "-pod"},
+				}},
+			}},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := &ProxyOpts{
+		IPCidr:      "127.0.0.1/8",
+		HostsFile:   hosts,
+		RandomPorts: true,
+		Dialer: func(namespace, pod string) (httpstream.Dialer, error) {
+			return brokenDialer{}, nil
+		},
+	}
+	reqChan, done, w, err := newPortForwarder(ctx, k, nil, log, opts, newSubscribers(), cache.NewStore(cache.MetaNamespaceKeyFunc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svcInformer := informers.NewSharedInformerFactory(k, 0).Core().V1().Services().Informer()
+	p := &Proxier{worker: w, pfrequest: reqChan, svcInformer: svcInformer}
+
+	readers := sync.WaitGroup{}
+	readCtx, stopReading := context.WithCancel(ctx)
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for readCtx.Err() == nil {
+				if _, err := p.List(readCtx); err != nil {
+					t.Error(err)
+					return
+				}
+				for _, name := range services {
+					w.portForward("default/" + name)
+					w.isPending("default/" + name)
+				}
+			}
+		}()
+	}
+
+	for _, name := range services {
+		reqChan <- PortForwardRequest{CreatePortForwardRequest: &CreatePortForwardRequest{
+			Service: ServiceInfo{Namespace: "default", Name: name},
+			Ports:   []string{"80:8080"},
+		}}
+	}
+
+	// every port-forward fails right away, so each is recreated over and
+	// over again
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		recreated := 0
+		for _, pf := range w.listPortForwards() {
+			if pf.RecreateCount >= 10 {
+				recreated++
+			}
+		}
+		if recreated == len(services) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for port-forwards to be recreated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// pausing and deleting them races with their recreations
+	for _, name := range services {
+		reqChan <- PortForwardRequest{PausePortForwardRequest: &PausePortForwardRequest{
+			Service: ServiceInfo{Namespace: "default", Name: name},
+		}}
+		reqChan <- PortForwardRequest{DeletePortForwardRequest: &DeletePortForwardRequest{
+			Service: ServiceInfo{Namespace: "default", Name: name},
+		}}
+	}
+
+	stopReading()
+	readers.Wait()
+	cancel()
+	<-done
+}
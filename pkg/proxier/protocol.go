@@ -0,0 +1,254 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Protocol is the protocol spoken on a port of a service
+type Protocol string
+
+const (
+	// ProtocolUnknown is a port that hasn't been used yet, and doesn't
+	// say what it speaks
+	ProtocolUnknown Protocol = ""
+	ProtocolHTTP    Protocol = "http/1.1"
+	ProtocolHTTP2   Protocol = "http2"
+	ProtocolGRPC    Protocol = "grpc"
+	ProtocolTLS     Protocol = "tls"
+	ProtocolTCP     Protocol = "tcp"
+)
+
+// maxSniffed is the most of a connection that's read to detect its
+// protocol, before giving up and calling it TCP
+const maxSniffed = 16 * 1024
+
+// PortProtocol is the protocol of a port of a service
+type PortProtocol struct {
+	// Port is the port of the service
+	Port string
+
+	Protocol Protocol
+
+	// Detected is set if Protocol was seen on a connection, rather than
+	// inferred from the service
+	Detected bool
+}
+
+// http2Preface is what every HTTP/2 connection starts with
+var http2Preface = []byte(http2.ClientPreface)
+
+// http1Methods are the methods a HTTP/1.x request line can start with
+var http1Methods = []string{
+	"GET", "HEAD", "POST", "PUT", "DELETE", "CONNECT", "OPTIONS", "TRACE", "PATCH",
+}
+
+// detectProtocol returns the protocol of a connection from what its client
+// has sent so far, b. If that isn't enough to tell, false is returned.
+func detectProtocol(b []byte) (Protocol, bool) {
+	if len(b) == 0 {
+		return ProtocolUnknown, false
+	}
+
+	// TLS records of a handshake start with 0x16
+	if b[0] == 0x16 {
+		return ProtocolTLS, true
+	}
+
+	if len(b) < len(http2Preface) && bytes.HasPrefix(http2Preface, b) {
+		return ProtocolUnknown, false
+	}
+	if bytes.HasPrefix(b, http2Preface) {
+		return detectHTTP2(b[len(http2Preface):])
+	}
+
+	line, complete := b, false
+	if i := bytes.IndexByte(b, '\n'); i != -1 {
+		line, complete = b[:i], true
+	}
+	for _, m := range http1Methods {
+		prefix := []byte(m + " ")
+		if !bytes.HasPrefix(line, prefix) {
+			if !complete && bytes.HasPrefix(prefix, line) {
+				return ProtocolUnknown, false
+			}
+			continue
+		}
+
+		// the request line has to be finished to see its version
+		if !complete {
+			return ProtocolUnknown, false
+		}
+		if bytes.Contains(line, []byte(" HTTP/1.")) {
+			return ProtocolHTTP, true
+		}
+		break
+	}
+
+	return ProtocolTCP, true
+}
+
+// detectHTTP2 tells gRPC apart from other HTTP/2 by the content-type of
+// the first request in frames
+func detectHTTP2(frames []byte) (Protocol, bool) {
+	fr := http2.NewFramer(nil, bytes.NewReader(frames))
+	fr.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	for {
+		f, err := fr.ReadFrame()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ProtocolUnknown, false
+		}
+		if err != nil {
+			return ProtocolHTTP2, true
+		}
+
+		if mh, ok := f.(*http2.MetaHeadersFrame); ok {
+			for _, hf := range mh.RegularFields() {
+				if hf.Name == "content-type" && strings.HasPrefix(hf.Value, "application/grpc") {
+					return ProtocolGRPC, true
+				}
+			}
+			return ProtocolHTTP2, true
+		}
+	}
+}
+
+// inferProtocol returns the protocol of a port from its appProtocol, or
+// the name of it, following the conventions Istio and Kubernetes use
+func inferProtocol(sp *corev1.ServicePort) Protocol {
+	if sp.Protocol != "" && sp.Protocol != corev1.ProtocolTCP {
+		return ProtocolUnknown
+	}
+
+	name := sp.Name
+	if sp.AppProtocol != nil {
+		name = *sp.AppProtocol
+	}
+	name = strings.ToLower(name)
+
+	switch strings.SplitN(name, "-", 2)[0] {
+	case "http":
+		return ProtocolHTTP
+	case "http2", "h2c", "kubernetes.io/h2c":
+		return ProtocolHTTP2
+	case "grpc":
+		return ProtocolGRPC
+	case "https", "tls":
+		return ProtocolTLS
+	case "tcp", "mongo", "mysql", "redis":
+		return ProtocolTCP
+	}
+	return ProtocolUnknown
+}
+
+// protocol returns the protocol detected on a port of a service
+func (w *worker) protocol(key, port string) Protocol {
+	w.protoMu.Lock()
+	defer w.protoMu.Unlock()
+
+	return w.protocols[key][port]
+}
+
+// detecting returns if the protocol of any of ports, in the format of
+// port:targetPort, of a service hasn't been detected yet. Connections are
+// only seen when they're relayed, so port-forwards are relayed until
+// every port of theirs has been detected.
+func (w *worker) detecting(key string, ports []string) bool {
+	for _, p := range ports {
+		if w.protocol(key, strings.Split(p, ":")[0]) == ProtocolUnknown {
+			return true
+		}
+	}
+	return false
+}
+
+// setProtocol sets the protocol detected on a port of a service
+func (w *worker) setProtocol(key, port string, proto Protocol) {
+	w.protoMu.Lock()
+	defer w.protoMu.Unlock()
+
+	if w.protocols[key] == nil {
+		w.protocols[key] = make(map[string]Protocol)
+	}
+	if w.protocols[key][port] != proto {
+		w.log.WithField("service", key).Debugf("detected %s on port %s", proto, port)
+	}
+	w.protocols[key][port] = proto
+}
+
+// protocolSniffer is written to with what a client sends through a relay,
+// until there's enough of it to detect the protocol of the port. Like
+// requestTap, it never fails.
+type protocolSniffer struct {
+	w    *worker
+	key  string
+	port string
+
+	buf  []byte
+	done bool
+}
+
+func (s *protocolSniffer) Write(p []byte) (int, error) {
+	if s.done {
+		return len(p), nil
+	}
+
+	s.buf = append(s.buf, p...)
+	if proto, ok := detectProtocol(s.buf); ok {
+		s.finish(proto)
+	} else if len(s.buf) >= maxSniffed {
+		s.finish(ProtocolTCP)
+	}
+	return len(p), nil
+}
+
+// Close gives up on a connection that ended before it could be told
+// apart. Connections that never sent anything are left alone, they're
+// often health checks or protocols where the server speaks first.
+func (s *protocolSniffer) Close() error {
+	if !s.done && len(s.buf) > 0 {
+		s.finish(ProtocolTCP)
+	}
+	return nil
+}
+
+func (s *protocolSniffer) finish(proto Protocol) {
+	s.done = true
+	s.buf = nil
+	s.w.setProtocol(s.key, s.port, proto)
+}
+
+// multiTap writes to each of its taps, closing all of them
+type multiTap []io.WriteCloser
+
+func (m multiTap) Write(p []byte) (int, error) {
+	for _, t := range m {
+		t.Write(p) //nolint:errcheck // Why: Taps never fail
+	}
+	return len(p), nil
+}
+
+func (m multiTap) Close() error {
+	for _, t := range m {
+		t.Close() //nolint:errcheck // Why: Taps never fail
+	}
+	return nil
+}
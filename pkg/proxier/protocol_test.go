@@ -0,0 +1,133 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// http2Request returns the start of a HTTP/2 connection making a request
+// with contentType
+func http2Request(t *testing.T, contentType string) []byte {
+	var hdrs bytes.Buffer
+	enc := hpack.NewEncoder(&hdrs)
+	for _, f := range []hpack.HeaderField{
+		{Name: ":method", Value: "POST"},
+		{Name: ":scheme", Value: "http"},
+		{Name: ":path", Value: "/orders.v1.Orders/Get"},
+		{Name: ":authority", Value: "orders"},
+		{Name: "content-type", Value: contentType},
+	} {
+		if err := enc.WriteField(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := bytes.NewBufferString(http2.ClientPreface)
+	fr := http2.NewFramer(buf, nil)
+	if err := fr.WriteSettings(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fr.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      1,
+		BlockFragment: hdrs.Bytes(),
+		EndHeaders:    true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectProtocol(t *testing.T) {
+	grpc := http2Request(t, "application/grpc+proto")
+
+	tests := []struct {
+		name     string
+		b        string
+		expected Protocol
+		ok       bool
+	}{
+		{"nothing sent", "", ProtocolUnknown, false},
+		{"http/1.1", "GET / HTTP/1.1\r\nHost: api\r\n\r\n", ProtocolHTTP, true},
+		{"partial method", "PO", ProtocolUnknown, false},
+		{"partial request line", "POST /orders HT", ProtocolUnknown, false},
+		{"tls", "\x16\x03\x01\x02\x00", ProtocolTLS, true},
+		{"partial preface", "PRI * HTTP/2.0\r\n", ProtocolUnknown, false},
+		{"preface only", http2.ClientPreface, ProtocolUnknown, false},
+		{"grpc", string(grpc), ProtocolGRPC, true},
+		{"partial grpc headers", string(grpc[:len(grpc)-4]), ProtocolUnknown, false},
+		{"http2", string(http2Request(t, "application/json")), ProtocolHTTP2, true},
+		{"redis", "*1\r\n$4\r\nPING\r\n", ProtocolTCP, true},
+		{"not http", "GET\x00\x01", ProtocolTCP, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := detectProtocol([]byte(tt.b))
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestInferProtocol(t *testing.T) {
+	h2c := "kubernetes.io/h2c"
+
+	tests := []struct {
+		name     string
+		port     corev1.ServicePort
+		expected Protocol
+	}{
+		{"named http", corev1.ServicePort{Name: "http-api"}, ProtocolHTTP},
+		{"named grpc", corev1.ServicePort{Name: "grpc"}, ProtocolGRPC},
+		{"app protocol", corev1.ServicePort{Name: "http", AppProtocol: &h2c}, ProtocolHTTP2},
+		{"unnamed", corev1.ServicePort{}, ProtocolUnknown},
+		{"udp", corev1.ServicePort{Name: "http", Protocol: corev1.ProtocolUDP}, ProtocolUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferProtocol(&tt.port); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDetectingUntilEveryPortIsDetected(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	w := &worker{log: log, protocols: make(map[string]map[string]Protocol)}
+
+	ports := []string{"80:8080", "9090:9090"}
+	if !w.detecting("default/api", ports) {
+		t.Fatal("expected ports to be detected before any were seen")
+	}
+
+	w.setProtocol("default/api", "80", ProtocolHTTP)
+	if !w.detecting("default/api", ports) {
+		t.Fatal("expected port 9090 to still be detected")
+	}
+
+	w.setProtocol("default/api", "9090", ProtocolGRPC)
+	if w.detecting("default/api", ports) {
+		t.Fatal("expected nothing left to detect")
+	}
+}
@@ -0,0 +1,1201 @@
+// Copyright 2020 Jared Allard
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getoutreach/localizer/internal/ipam"
+	"github.com/getoutreach/localizer/internal/kube"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// PriorityAnnotation is an annotation on a service that sets the priority
+// of its port-forward, higher priorities are created and recreated first.
+const PriorityAnnotation = "localizer.jaredallard.github.com/priority"
+
+// CompressAnnotation is an annotation on a service that, when "true",
+// compresses its connections. Only supported with a ServiceDialer.
+const CompressAnnotation = "localizer.jaredallard.github.com/compress"
+
+// SystemNamespaces are namespaces whose services aren't forwarded unless
+// IncludeSystemNamespaces is set, or they're listed in Namespaces. These
+// are mostly webhooks and DaemonSets that aren't useful locally.
+var SystemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease", "local-path-storage"}
+
+// IsSystemNamespace returns if namespace is one of SystemNamespaces
+func IsSystemNamespace(namespace string) bool {
+	for _, ns := range SystemNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// Proxier handles creating an maintaining proxies to a remote
+// Kubernetes service
+type Proxier struct {
+	k    kubernetes.Interface
+	rest *rest.Config
+	log  logrus.FieldLogger
+
+	// worker is set once the proxier has started, use portForwarder to
+	// get it
+	worker *worker
+
+	opts *ProxyOpts
+
+	queue             workqueue.RateLimitingInterface
+	threadiness       int
+	svcInformer       cache.SharedIndexInformer
+	endpointsInformer cache.SharedIndexInformer
+	podInformer       cache.SharedIndexInformer
+	informers         informers.SharedInformerFactory
+	pfrequest         chan<- PortForwardRequest
+
+	// ownInformers is set when informers was created by us, rather than
+	// given in ProxyOpts, so it's up to us to start it
+	ownInformers bool
+
+	// namespaces are the namespaces to forward services in, nil is all
+	namespaces map[string]bool
+
+	// services are the services to forward, by namespace/name, nil is all
+	services map[string]bool
+
+	// subscribers receive status changes of port-forwards
+	subscribers *subscribers
+
+	// mu protects worker, pfrequest, paused, disabled, podForwards, and
+	// gone. paused is set when all port-forwards have been paused, new ones
+	// aren't created until resumed.
+	// disabled are the services, by namespace/name, that shouldn't be
+	// forwarded. podForwards are the ports of pods requested with
+	// ForwardPod, keyed by pod/namespace/name. gone are the UIDs of services
+	// the informer still has, but that were found to be deleted.
+	mu          sync.Mutex
+	paused      bool
+	disabled    map[string]bool
+	podForwards map[string]podForward
+	gone        map[string]types.UID
+
+	// gc are the options of the checks for deleted services, gcMu protects
+	// them. gcChanged is sent to when they're changed. missing are when
+	// services were first found to be missing by the checks.
+	gc        GCOptions
+	gcMu      sync.Mutex
+	gcChanged chan struct{}
+	missing   map[string]time.Time
+
+	// leaderWatches are the leader election locks being watched, keyed by
+	// namespace/kind/name. leadersStopped is set once the proxier has
+	// stopped, so no more are watched. leaderMu protects both.
+	leaderWatches  map[string]*leaderWatch
+	leadersStopped bool
+	leaderMu       sync.Mutex
+}
+
+type ServiceStatus struct {
+	ServiceInfo ServiceInfo
+
+	Endpoint PodInfo
+
+	// Statuses is dependent on the number of tunnels that exist for this
+	// connection. Generally this is one, since a service is usually one
+	// connection. Currently only one is supported, but in the future
+	// certain services will have more than one.
+	Statuses []PortForwardStatus
+
+	// Reason is the reason that this service is in this status.
+	// This is generally only set for services that are in a
+	// non-running state.
+	Reason string
+
+	// IP is the IP address of this tunnel
+	IP string
+
+	// Ports are the ports this service is exposing
+	Ports []string
+
+	// Hostnames are the hosts file entries pointing to IP
+	Hostnames []string
+
+	// RecreateCount is the number of times this service's port-forward
+	// has been recreated, Recreations are the most recent of them
+	RecreateCount int
+	Recreations   []Recreation
+
+	// Groups are the groups the service is in
+	Groups []string
+
+	// Protocols are the protocols of the service's forwarded ports
+	Protocols []PortProtocol
+}
+
+type ProxyOpts struct {
+	ClusterDomain string
+	IPCidr        string
+
+	// IPAllocator, if set, creates the allocator IPs are given to services
+	// from. Defaults to one that keeps them in memory.
+	IPAllocator IPAllocatorFunc
+
+	// HostsFile is the path to the hosts file to manage, defaults to
+	// /etc/hosts if not set.
+	HostsFile string
+
+	// RedirectClusterIPs enables redirecting traffic sent to a service's
+	// ClusterIP to the local port-forward for it. Linux only.
+	RedirectClusterIPs bool
+
+	// Instance is the name of the localizer instance, used to namespace
+	// the hosts file block and nftables table so that multiple instances
+	// don't conflict.
+	Instance string
+
+	// MaxTunnels, if set, is the maximum number of port-forwards that can
+	// be running at once. Services past the limit wait for a free slot.
+	MaxTunnels int
+
+	// MaxConnections, if set, is the maximum number of connections that can
+	// be handled at once, across all port-forwards.
+	MaxConnections int
+
+	// BufferSize, if set, is the size of the buffers, in bytes, used for
+	// each direction of a connection.
+	BufferSize int
+
+	// MaxRecreates, if set, is the number of times a port-forward can be
+	// recreated within RecreateWindow before it's marked as failed. Failed
+	// port-forwards are tried again once they're back under the budget.
+	MaxRecreates   int
+	RecreateWindow time.Duration
+
+	// OperationTimeout, if set, is how long the worker waits on anything,
+	// e.g. the API server, while creating or deleting a port-forward,
+	// before giving up on it and moving on to the next request
+	OperationTimeout time.Duration
+
+	// RecreateRate, if set, is the number of port-forwards that can be
+	// recreated per second, with bursts of up to RecreateBurst. Pending
+	// recreations of the same service are collapsed into one.
+	RecreateRate  float64
+	RecreateBurst int
+
+	// Priorities are the priorities of services, keyed by namespace/name.
+	// These take precedence over the PriorityAnnotation.
+	Priorities map[string]int
+
+	// Compress are the services whose connections should be compressed,
+	// keyed by namespace/name. These take precedence over the
+	// CompressAnnotation.
+	Compress map[string]bool
+
+	// FanOut are the services, keyed by namespace/name, that every replica
+	// of should be forwarded. These take precedence over the
+	// FanOutAnnotation.
+	FanOut map[string]bool
+
+	// LeaderLocks are the leader election locks of services, keyed by
+	// namespace/name. These take precedence over the LeaderLockAnnotation.
+	LeaderLocks map[string]string
+
+	// Hooks, if set, is called when port-forwards are created or fail and
+	// once the proxier becomes stable. It shouldn't block.
+	Hooks HookFunc
+
+	// Informers is the informer factory used to watch services and
+	// endpoints, it's up to the caller to start it. If not set, the
+	// proxier creates, and starts, one that watches the entire cluster.
+	Informers informers.SharedInformerFactory
+
+	// Dialer, if set, is used to connect to pods instead of port-forwarding
+	// through the API server. This is mostly useful for tests.
+	Dialer DialerFunc
+
+	// Namespaces, if set, restricts forwarding to services in these
+	// namespaces. New services in them are forwarded as they're created.
+	Namespaces []string
+
+	// PortNames, if set, are the only named ports of services that are
+	// forwarded. ServicePortNames overrides it for specific services,
+	// keyed by namespace/name. Unnamed ports are always forwarded, as a
+	// service with only one port doesn't need to name it.
+	PortNames        []string
+	ServicePortNames map[string][]string
+
+	// Disabled are services, by namespace/name, that shouldn't be forwarded
+	// until they're enabled with SetEnabled
+	Disabled []string
+
+	// Services, if set, restricts forwarding to these services, by
+	// namespace/name. Services created later aren't forwarded.
+	Services []string
+
+	// IncludeSystemNamespaces forwards services in SystemNamespaces, which
+	// are skipped by default
+	IncludeSystemNamespaces bool
+
+	// HostnameCollisions is the strategy used when more than one service
+	// would get the same hostname: first (the default), qualified, or
+	// priority. HostnamePriority are the namespaces, in order, whose
+	// services win with the priority strategy.
+	HostnameCollisions string
+	HostnamePriority   []string
+
+	// HostnameSuffixes are extra DNS suffixes, keyed by namespace, that
+	// services in the namespace get a hostname under, e.g. payments.test
+	// gives the api service api.payments.test
+	HostnameSuffixes map[string]string
+
+	// Groups are the groups of services, keyed by namespace/name, in
+	// addition to the one from their GroupLabel
+	Groups map[string][]string
+
+	// Wildcards are the services, keyed by namespace/name, whose
+	// hostnames' subdomains also resolve to them with Resolve. Hosts files
+	// can't express wildcards, so these only work with a DNS server.
+	Wildcards map[string]bool
+
+	// Node and Zone, if set, are the node and zone that endpoints are
+	// chosen as if connections came from, so that services with an
+	// internalTrafficPolicy of Local or topology aware hints use the
+	// endpoints the cluster would. Zone defaults to the zone of Node.
+	Node string
+	Zone string
+
+	// GCInterval, if set, is how often the services being forwarded are
+	// checked against the API server, removing the port-forwards of ones
+	// that were deleted without the informers noticing. GCGracePeriod is
+	// how long they have to be missing for first. GCDisabled disables the
+	// checks, like an unset GCInterval, which can be enabled later with
+	// SetGCOptions.
+	GCInterval    time.Duration
+	GCGracePeriod time.Duration
+	GCDisabled    bool
+
+	// DrainTimeout, if set, is how long the connections of a port-forward
+	// are given to finish when it's deleted, or the proxier is stopped,
+	// after it stops accepting new ones. Deleting a port-forward holds up
+	// other changes until they finish.
+	DrainTimeout time.Duration
+
+	// PreviousIPs are the IPs services had in a previous run, keyed by
+	// ServiceInfo.Key, which they're given again if they're free. With
+	// CleanupPrevious, their loopback aliases, and the entries in the hosts
+	// file, are removed first because the previous run didn't exit cleanly.
+	PreviousIPs     map[string]string
+	CleanupPrevious bool
+
+	// RandomPorts forwards every service on random ports of 127.0.0.1,
+	// rather than the service's ports on an IP of its own. No loopback
+	// aliases or hosts entries are created, so root isn't needed, and the
+	// ports are found with List.
+	RandomPorts bool
+
+	// Helper, if set, is used to write to the hosts file and create
+	// loopback aliases when not running as root
+	Helper Helper
+
+	// ServiceDialer, if set, is used to connect to services instead of
+	// creating a port-forward per service.
+	ServiceDialer ServiceDialerFunc
+}
+
+// DialerFunc returns a dialer that port-forwards to the given pod
+type DialerFunc func(namespace, pod string) (httpstream.Dialer, error)
+
+// IPAllocator hands out the IPs services are forwarded on
+type IPAllocator interface {
+	// CIDR returns the CIDR IPs are allocated from
+	CIDR() string
+
+	// Acquire allocates a free IP, returning ErrNoIPAvailable when every
+	// IP is allocated
+	Acquire() (net.IP, error)
+
+	// AcquireSpecific allocates ip, if it's free
+	AcquireSpecific(ip net.IP) error
+
+	// Release frees ip so it can be allocated again
+	Release(ip net.IP) error
+
+	// Usage returns how many of the IPs in the CIDR are allocated, and how
+	// many there are
+	Usage() (acquired, available uint64)
+}
+
+// ErrNoIPAvailable is returned by an IPAllocator when every IP is allocated
+var ErrNoIPAvailable = ipam.ErrNoIPAvailable
+
+// IPAllocatorFunc creates an allocator for cidr, which never hands out the
+// reserved IPs
+type IPAllocatorFunc func(cidr string, reserved ...net.IP) (IPAllocator, error)
+
+// Helper does what needs root for the proxier when it isn't running as
+// root, e.g. by asking a privileged process to do it
+type Helper interface {
+	// WriteHosts replaces block in the hosts file with hosts, keyed by IP
+	WriteHosts(ctx context.Context, block string, hosts map[string][]string) error
+
+	// AddLoopbackAlias and RemoveLoopbackAlias add, and remove, ip as an
+	// alias of the loopback interface
+	AddLoopbackAlias(ctx context.Context, ip string) error
+	RemoveLoopbackAlias(ctx context.Context, ip string) error
+}
+
+// HookFunc is called with the hook events of a proxier
+type HookFunc func(ctx context.Context, e HookEvent)
+
+// ServiceDialerFunc connects to a service from inside of the cluster. It's
+// passed the address of the service, e.g. name.namespace.svc.cluster.local:80,
+// and if the connection should be compressed.
+type ServiceDialerFunc func(ctx context.Context, address string, compress bool) (net.Conn, error)
+
+// NewProxier creates a new proxier instance
+func NewProxier(ctx context.Context, k kubernetes.Interface, kconf *rest.Config, log logrus.FieldLogger, opts *ProxyOpts) (*Proxier, error) { //nolint:lll
+	factory := opts.Informers
+	if factory == nil {
+		factory = informers.NewSharedInformerFactory(k, 0)
+	}
+
+	svcInformer := factory.Core().V1().Services().Informer()
+	endpointsInformer := factory.Core().V1().Endpoints().Informer()
+	podInformer := factory.Core().V1().Pods().Informer()
+
+	p := &Proxier{
+		k:                 k,
+		rest:              kconf,
+		log:               log,
+		opts:              opts,
+		queue:             workqueue.NewRateLimitingQueue(workqueue.DefaultItemBasedRateLimiter()),
+		threadiness:       1,
+		svcInformer:       svcInformer,
+		endpointsInformer: endpointsInformer,
+		podInformer:       podInformer,
+		informers:         factory,
+		ownInformers:      opts.Informers == nil,
+		subscribers:       newSubscribers(),
+		disabled:          make(map[string]bool, len(opts.Disabled)),
+		podForwards:       make(map[string]podForward),
+		gone:              make(map[string]types.UID),
+		gcChanged:         make(chan struct{}, 1),
+		missing:           make(map[string]time.Time),
+		gc: GCOptions{
+			Enabled:     opts.GCInterval > 0 && !opts.GCDisabled,
+			Interval:    opts.GCInterval,
+			GracePeriod: opts.GCGracePeriod,
+		},
+	}
+	if p.gc.Interval <= 0 {
+		p.gc.Interval = DefaultGCInterval
+	}
+	for _, key := range opts.Disabled {
+		p.disabled[key] = true
+	}
+
+	if len(opts.Namespaces) > 0 {
+		p.namespaces = make(map[string]bool, len(opts.Namespaces))
+		for _, ns := range opts.Namespaces {
+			p.namespaces[ns] = true
+		}
+	}
+
+	if len(opts.Services) > 0 {
+		p.services = make(map[string]bool, len(opts.Services))
+		for _, key := range opts.Services {
+			p.services[key] = true
+		}
+	}
+
+	svcInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			key, err := cache.MetaNamespaceKeyFunc(obj)
+			if err == nil {
+				p.enqueue(key)
+			}
+		},
+		UpdateFunc: func(oldObj, obj interface{}) {
+			key, err := cache.MetaNamespaceKeyFunc(obj)
+			if err == nil {
+				p.enqueue(key)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err == nil {
+				p.enqueue(key)
+			}
+		},
+	})
+
+	endpointsInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			key, err := cache.MetaNamespaceKeyFunc(obj)
+			if err == nil {
+				p.enqueue(key)
+			}
+		},
+		UpdateFunc: func(oldObj, obj interface{}) {
+			key, err := cache.MetaNamespaceKeyFunc(obj)
+			if err == nil {
+				p.enqueue(key)
+			}
+		},
+	})
+	// pods are watched so that port-forwards can move off of pods being
+	// replaced before the connection to them breaks, and so pods that are
+	// forwarded directly are forwarded once they're running
+	podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: p.enqueuePodForward,
+		UpdateFunc: func(oldObj, obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok && pod.DeletionTimestamp != nil {
+				p.enqueueForPod(PodInfo{Namespace: pod.Namespace, Name: pod.Name})
+			}
+			p.enqueuePodForward(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			p.enqueuePodForward(obj)
+
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return
+			}
+
+			//nolint:govet // Why: We're OK shadowing err
+			namespace, name, err := cache.SplitMetaNamespaceKey(key)
+			if err == nil {
+				p.enqueueForPod(PodInfo{Namespace: namespace, Name: name})
+			}
+		},
+	})
+	return p, nil
+}
+
+// enqueueForPod enqueues the services whose port-forwards use the given pod
+func (p *Proxier) enqueueForPod(pod PodInfo) {
+	w := p.portForwarder()
+	if w == nil {
+		return
+	}
+
+	for _, pf := range w.listPortForwards() {
+		if pf.Pod == pod {
+			p.enqueue(pf.Service.Key())
+		}
+	}
+}
+
+// portForwarder returns the worker, or nil if the proxier hasn't started
+func (p *Proxier) portForwarder() *worker {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.worker
+}
+
+// enqueue adds a service's key to the queue, if it's selected. Pods
+// forwarded with ForwardPod are always enqueued.
+func (p *Proxier) enqueue(key string) {
+	if strings.HasPrefix(key, podKeyPrefix) || p.selected(key) {
+		p.queue.Add(key)
+	}
+}
+
+// selected returns if the service with key is in a namespace that is
+// being forwarded and was selected, if services were
+func (p *Proxier) selected(key string) bool {
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return false
+	}
+
+	if p.namespaces != nil && !p.namespaces[namespace] {
+		return false
+	}
+
+	// system namespaces are only forwarded if asked for
+	if p.namespaces == nil && !p.opts.IncludeSystemNamespaces && IsSystemNamespace(namespace) {
+		return false
+	}
+
+	return p.services == nil || p.services[key]
+}
+
+// IsStable is a pass-through function to *worker.isStable. This is mostly to
+// determine if the initial queue has been drained by checking if the worker
+// that the proxier is using has created, deleted, or updated a port-forward
+// in the last 2 seconds.
+func (p *Proxier) IsStable() bool {
+	w := p.portForwarder()
+	if w == nil {
+		// Proxier hasn't actually finished being created yet, definitely not
+		// stable.
+		return false
+	}
+
+	return w.isStable()
+}
+
+// HostnameCollisions returns the hostnames that more than one service would
+// get, and which of them was given it
+func (p *Proxier) HostnameCollisions() []HostnameCollision {
+	w := p.portForwarder()
+	if w == nil {
+		return nil
+	}
+
+	return w.hostnameCollisions()
+}
+
+// Progress is how far along the proxier is in creating port-forwards
+type Progress struct {
+	// Running is the number of running port-forwards. Total is the number
+	// of services known about, including ones that haven't been processed.
+	Running int
+	Total   int
+
+	// Queued is the number of services that haven't been processed yet.
+	// Creating is how many of them have been handed to the worker.
+	Queued   int
+	Creating int
+
+	// Waiting are the services, by key, waiting for an endpoint or a free
+	// tunnel slot
+	Waiting []string
+}
+
+// Progress returns how far along the proxier is in creating
+// port-forwards, this is used to report on start up
+func (p *Proxier) Progress() Progress {
+	w := p.portForwarder()
+	if w == nil {
+		return Progress{Queued: p.queue.Len(), Total: p.queue.Len()}
+	}
+
+	// requests the worker has received are in its backlog until it gets
+	// to them
+	creating := len(w.reqChan) + int(atomic.LoadInt64(&w.backlogLen))
+	prog := Progress{Queued: p.queue.Len() + creating, Creating: creating}
+	pfs := w.listPortForwards()
+	for i := range pfs {
+		switch pfs[i].Status {
+		case PortForwardStatusRunning:
+			prog.Running++
+		case PortForwardStatusWaiting, PortForwardStatusRecreating:
+			prog.Waiting = append(prog.Waiting, pfs[i].Service.Key())
+		case PortForwardStatusPaused, PortForwardStatusFailed:
+		}
+	}
+	prog.Total = len(pfs) + prog.Queued
+	sort.Strings(prog.Waiting)
+
+	return prog
+}
+
+// Start starts the proxier
+// TODO: replace raw cluster domain with options struct, maybe also
+// move into NewProxier
+func (p *Proxier) Start(ctx context.Context) error {
+	defer p.queue.ShutDown()
+
+	log := p.log.WithField("component", "proxier")
+	if p.ownInformers {
+		p.informers.Start(ctx.Done())
+		p.informers.WaitForCacheSync(ctx.Done())
+	}
+
+	portForwarder, pfdoneChan, worker, err := newPortForwarder(ctx, p.k, p.rest, p.log, p.opts, p.subscribers, p.podInformer.GetStore())
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.pfrequest = portForwarder
+	p.worker = worker
+	p.mu.Unlock()
+
+	log.Infof("Starting %d proxier worker(s)", p.threadiness)
+	for i := 0; i < p.threadiness; i++ {
+		go wait.Until(p.runWorker, time.Second, ctx.Done())
+	}
+
+	go p.waitForStable(ctx)
+	go p.runGC(ctx)
+
+	<-ctx.Done()
+	p.stopLeaderWatches()
+	log.Info("waiting for port-forward worker to finish")
+	<-pfdoneChan
+	return nil
+}
+
+// waitForStable fires the stable event once the proxier has become stable
+func (p *Proxier) waitForStable(ctx context.Context) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if p.IsStable() {
+				if p.opts.Hooks != nil {
+					p.opts.Hooks(ctx, HookEvent{Type: HookStable})
+				}
+				return
+			}
+		}
+	}
+}
+
+func (p *Proxier) runWorker() {
+	for p.processNextWorkItem() {
+
+	}
+}
+
+func (p *Proxier) processNextWorkItem() bool {
+	key, quit := p.queue.Get()
+	if quit {
+		return false
+	}
+	defer p.queue.Done(key)
+
+	// Invoke the method containing the business logic
+	err := p.reconcile(key.(string))
+
+	if err == nil {
+		// Forget about the #AddRateLimited history of the key on every successful synchronization.
+		// This ensures that future processing of updates for this key is not delayed because of
+		// an outdated error history.
+		p.queue.Forget(key)
+		return true
+	}
+
+	if p.queue.NumRequeues(key) < 5 {
+		// Re-enqueue the key rate limited. Based on the rate limiter on the
+		// queue and the re-enqueue history, the key will be processed later again.
+		p.queue.AddRateLimited(key)
+		return true
+	}
+
+	// Retries exceeded. Forgetting for this reconciliation loop
+	p.queue.Forget(key)
+	return true
+}
+
+func (p *Proxier) reconcile(key string) error { //nolint:funlen
+	if strings.HasPrefix(key, podKeyPrefix) {
+		return p.reconcilePod(key)
+	}
+
+	o, exists, err := p.svcInformer.GetStore().GetByKey(key)
+	if err != nil {
+		return err
+	}
+
+	if !exists || p.isGone(o.(*corev1.Service)) {
+		// we don't have the service object anymore, we need to get the namespace/name from the key
+		//nolint:govet // Why: We're OK shadowing err
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			return err
+		}
+		p.syncReplicas(key, nil)
+		p.syncLeaderWatch(key, "", "")
+		p.pfrequest <- PortForwardRequest{
+			DeletePortForwardRequest: &DeletePortForwardRequest{
+				Service: ServiceInfo{Namespace: namespace, Name: name},
+			},
+		}
+		return nil
+	}
+	svc := o.(*corev1.Service)
+
+	if svc.DeletionTimestamp != nil {
+		p.syncReplicas(key, nil)
+		p.syncLeaderWatch(key, "", "")
+		p.pfrequest <- PortForwardRequest{
+			DeletePortForwardRequest: &DeletePortForwardRequest{
+				Service: ServiceInfo{Namespace: svc.Namespace, Name: svc.Name},
+			},
+		}
+		return nil
+	}
+
+	if !p.IsEnabled(key) {
+		p.syncReplicas(key, nil)
+		p.syncLeaderWatch(key, "", "")
+		if _, ok := p.worker.portForward(key); ok {
+			p.pfrequest <- PortForwardRequest{
+				DeletePortForwardRequest: &DeletePortForwardRequest{
+					Service: ServiceInfo{Namespace: svc.Namespace, Name: svc.Name},
+				},
+			}
+		}
+		return nil
+	}
+
+	if p.IsPaused() {
+		return nil
+	}
+
+	p.syncReplicas(key, p.replicasOf(svc))
+	p.syncLeaderWatch(key, svc.Namespace, p.leaderLockOf(svc))
+
+	existingForward, ok := p.worker.portForward(key)
+	if !ok {
+		//create a new port forward
+		p.createPortforward(svc, "")
+		return nil
+	}
+
+	e, exists, err := p.endpointsInformer.GetStore().GetByKey(key)
+	if !exists || err != nil {
+		// no endpoints for service nothing we can do atm
+		return nil
+	}
+	endpoints := e.(*corev1.Endpoints)
+
+	switch existingForward.Status {
+	case PortForwardStatusWaiting:
+		// forwards waiting for a tunnel slot are handled by the worker
+		if hasPodEndpoint(endpoints) && !p.worker.isPending(key) {
+			p.createPortforward(svc, "endpoint became available")
+		}
+
+	case PortForwardStatusRunning:
+		lock := p.leaderLockOf(svc)
+
+		// connections through a ServiceDialer aren't tied to a pod
+		if p.opts.ServiceDialer != nil && lock == "" {
+			break
+		}
+
+		if !isActiveEndpoint(existingForward.Pod.Name, endpoints) {
+			p.createPortforward(svc, fmt.Sprintf("endpoints '%s' was removed", existingForward.Pod.Key()))
+		} else if isTerminating(p.podInformer.GetStore(), existingForward.Pod) {
+			p.createPortforward(svc, fmt.Sprintf("pod '%s' is being replaced", existingForward.Pod.Key()))
+		} else if lock != "" {
+			// leader changes don't always change endpoints, so this is also
+			// checked whenever the lock changes
+			ctx, cancel := context.WithTimeout(context.Background(), leaderLookupTimeout)
+			holder, err := leaderOf(ctx, p.k, svc.Namespace, lock)
+			cancel()
+			if err == nil && !isLeader(existingForward.Pod.Name, holder) {
+				p.createPortforward(svc, fmt.Sprintf("leader moved to '%s'", holder))
+			}
+		}
+	case PortForwardStatusPaused:
+		p.createPortforward(svc, "resumed")
+	case PortForwardStatusFailed:
+		// the worker keeps it failed until it's under its recreate budget
+		p.createPortforward(svc, "retrying failed port-forward")
+	case PortForwardStatusRecreating:
+		//make exhaustive linter happy
+	}
+
+	return nil
+}
+
+// servicePorts returns the ports of a service as local:remote pairs,
+// resolving named ports using its endpoints if possible
+func (p *Proxier) servicePorts(svc *corev1.Service) ([]string, error) {
+	resolvedPorts, err := kube.ResolveServicePorts(p.log, p.informers, svc)
+	if err != nil {
+		return nil, err
+	}
+
+	names, ok := p.opts.ServicePortNames[svc.Namespace+"/"+svc.Name]
+	if !ok {
+		names = p.opts.PortNames
+	}
+
+	ports := make([]string, 0, len(resolvedPorts))
+	for _, sp := range resolvedPorts {
+		if sp.Name != "" && len(names) > 0 && !containsString(names, sp.Name) {
+			continue
+		}
+		ports = append(ports, fmt.Sprintf("%d:%d", sp.Port, sp.TargetPort.IntValue()))
+	}
+	if len(ports) == 0 {
+		p.log.WithField("service", svc.Namespace+"/"+svc.Name).
+			Debugf("skipping service, none of its ports are named one of: %s", strings.Join(names, ", "))
+		return nil, fmt.Errorf("no ports named one of: %s", strings.Join(names, ", "))
+	}
+	return ports, nil
+}
+
+// containsString returns if s is in strs
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Proxier) createPortforward(svc *corev1.Service, recreate string) {
+	req, err := p.portForwardRequest(svc)
+	if err != nil {
+		return
+	}
+
+	if recreate != "" {
+		req.Recreate = true
+		req.RecreateReason = recreate
+	}
+
+	p.pfrequest <- PortForwardRequest{
+		CreatePortForwardRequest: req,
+	}
+}
+
+// portForwardRequest returns the request to create a port-forward for a
+// service, with the ports and hostnames it should get
+func (p *Proxier) portForwardRequest(svc *corev1.Service) (*CreatePortForwardRequest, error) {
+	info := ServiceInfo{Namespace: svc.Namespace, Name: svc.Name}
+	ports, err := p.servicePorts(svc)
+	if err != nil {
+		return nil, err
+	}
+
+	req := CreatePortForwardRequest{
+		Service:    info,
+		Ports:      ports,
+		ClusterIP:  svc.Spec.ClusterIP,
+		Priority:   p.priorityOf(svc),
+		Compress:   p.compressOf(svc),
+		LeaderLock: p.leaderLockOf(svc),
+
+		NodeLocal:     isNodeLocal(svc),
+		TopologyHints: hasTopologyHints(svc),
+		Hostnames: []string{
+			info.Name,
+			fmt.Sprintf("%s.%s", info.Name, info.Namespace),
+			fmt.Sprintf("%s.%s.svc", info.Name, info.Namespace),
+			fmt.Sprintf("%s.%s.svc.%s", info.Name, info.Namespace, p.opts.ClusterDomain),
+		},
+	}
+	req.Hostnames = append(req.Hostnames, p.suffixed(info.Namespace, info.Name)...)
+	// hack for basic support of stateful sets.
+	// grab the first endpoint to build the name. This sucks, but it's
+	// needed for Outreach's usecases. Please remove this. In fan-out mode
+	// every pod gets these names on its own IP instead.
+	obj, exists, err := p.endpointsInformer.GetStore().GetByKey(svc.Namespace + "/" + svc.Name)
+	if err == nil && exists && !p.fanOutOf(svc) {
+		endpoints := obj.(*corev1.Endpoints)
+		refName := ""
+	loop:
+		for _, sub := range endpoints.Subsets {
+			for _, a := range sub.Addresses {
+				if a.TargetRef != nil && a.TargetRef.Kind == PodKind {
+					refName = a.TargetRef.Name
+					break loop
+				}
+			}
+		}
+		if refName != "" {
+			name := fmt.Sprintf("%s.%s", refName, info.Name)
+			req.Hostnames = append(req.Hostnames,
+				fmt.Sprintf("%s.%s", name, info.Namespace),
+				fmt.Sprintf("%s.%s.svc", name, info.Namespace),
+				fmt.Sprintf("%s.%s.svc.%s", name, info.Namespace, p.opts.ClusterDomain),
+			)
+			req.Hostnames = append(req.Hostnames, p.suffixed(info.Namespace, name)...)
+		}
+	}
+
+	return &req, nil
+}
+
+// priorityOf returns the priority of a service, the configured priority is
+// preferred over the annotation on the service
+func (p *Proxier) priorityOf(svc *corev1.Service) int {
+	if priority, ok := p.opts.Priorities[svc.Namespace+"/"+svc.Name]; ok {
+		return priority
+	}
+
+	v, ok := svc.Annotations[PriorityAnnotation]
+	if !ok {
+		return 0
+	}
+
+	priority, err := strconv.Atoi(v)
+	if err != nil {
+		p.log.WithField("service", svc.Namespace+"/"+svc.Name).
+			Warnf("ignoring invalid %s annotation '%s'", PriorityAnnotation, v)
+		return 0
+	}
+	return priority
+}
+
+// compressOf returns if connections to a service should be compressed, the
+// configuration is preferred over the annotation on the service
+func (p *Proxier) compressOf(svc *corev1.Service) bool {
+	if compress, ok := p.opts.Compress[svc.Namespace+"/"+svc.Name]; ok {
+		return compress
+	}
+
+	return svc.Annotations[CompressAnnotation] == "true"
+}
+
+// leaderLockOf returns the leader election lock of a service's pods, if
+// any. The configuration is preferred over the annotation on the service.
+func (p *Proxier) leaderLockOf(svc *corev1.Service) string {
+	if lock, ok := p.opts.LeaderLocks[svc.Namespace+"/"+svc.Name]; ok {
+		return lock
+	}
+
+	return svc.Annotations[LeaderLockAnnotation]
+}
+
+func (p *Proxier) List(ctx context.Context) ([]ServiceStatus, error) {
+	w := p.portForwarder()
+	if w == nil {
+		return nil, fmt.Errorf("proxier not running")
+	}
+
+	statuses := make([]ServiceStatus, 0)
+	for _, pf := range w.listPortForwards() {
+		ip := pf.IP.String()
+		if len(pf.IP) == 0 {
+			ip = ""
+		}
+
+		statuses = append(statuses, ServiceStatus{
+			ServiceInfo: pf.Service,
+			Endpoint:    pf.Pod,
+			Reason:      pf.StatusReason,
+			Statuses:    []PortForwardStatus{pf.Status},
+			IP:          ip,
+			Ports:       pf.Ports,
+			Hostnames:   pf.Hostnames,
+
+			RecreateCount: pf.RecreateCount,
+			Recreations:   pf.Recreations,
+			Groups:        p.GroupsOf(pf.Service.Key()),
+			Protocols:     p.protocolsOf(w, pf.Service.Key()),
+		})
+	}
+
+	return statuses, nil
+}
+
+// Protocols returns the protocols of the forwarded ports of a service, by
+// key, or nil if it isn't being forwarded
+func (p *Proxier) Protocols(key string) []PortProtocol {
+	w := p.portForwarder()
+	if w == nil {
+		return nil
+	}
+	if _, ok := w.portForward(key); !ok {
+		return nil
+	}
+	return p.protocolsOf(w, key)
+}
+
+// protocolsOf returns the protocols of the forwarded ports of a service,
+// preferring the ones detected on its connections over what the service
+// says they are
+func (p *Proxier) protocolsOf(w *worker, key string) []PortProtocol {
+	obj, exists, err := p.svcInformer.GetStore().GetByKey(key)
+	if err != nil || !exists {
+		return nil
+	}
+	svc := obj.(*corev1.Service)
+
+	ports, err := p.servicePorts(svc)
+	if err != nil {
+		return nil
+	}
+
+	protocols := make([]PortProtocol, 0, len(ports))
+	for _, port := range ports {
+		port = strings.Split(port, ":")[0]
+		pp := PortProtocol{Port: port, Protocol: w.protocol(key, port)}
+		pp.Detected = pp.Protocol != ProtocolUnknown
+		if !pp.Detected {
+			for i := range svc.Spec.Ports {
+				if strconv.Itoa(int(svc.Spec.Ports[i].Port)) == port {
+					pp.Protocol = inferProtocol(&svc.Spec.Ports[i])
+				}
+			}
+		}
+		protocols = append(protocols, pp)
+	}
+	return protocols
+}
+
+// Pause closes the tunnels of every port-forward, without releasing their
+// IPs or hosts entries, until Resume is called. Services that are created
+// while paused are forwarded once resumed.
+func (p *Proxier) Pause(ctx context.Context) error {
+	w := p.portForwarder()
+	if w == nil {
+		return fmt.Errorf("proxier not running")
+	}
+
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+
+	for _, pf := range w.listPortForwards() {
+		req := PortForwardRequest{PausePortForwardRequest: &PausePortForwardRequest{Service: pf.Service}}
+		select {
+		case w.reqChan <- req:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Resume recreates the port-forwards paused by Pause
+func (p *Proxier) Resume(_ context.Context) error {
+	if p.portForwarder() == nil {
+		return fmt.Errorf("proxier not running")
+	}
+
+	p.mu.Lock()
+	p.paused = false
+	p.mu.Unlock()
+
+	for _, key := range p.svcInformer.GetStore().ListKeys() {
+		p.enqueue(key)
+	}
+	for _, key := range p.podForwardKeys() {
+		p.enqueue(key)
+	}
+
+	return nil
+}
+
+// IsPaused returns if port-forwards are paused
+func (p *Proxier) IsPaused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.paused
+}
+
+// SetEnabled enables or disables forwarding a service, by namespace/name.
+// Disabling a service removes its port-forward.
+func (p *Proxier) SetEnabled(key string, enabled bool) {
+	p.mu.Lock()
+	if enabled {
+		delete(p.disabled, key)
+	} else {
+		p.disabled[key] = true
+	}
+	p.mu.Unlock()
+
+	p.enqueue(key)
+}
+
+// Sync reconciles a service, by namespace/name, right away instead of
+// waiting for the informer to notice a change to it
+func (p *Proxier) Sync(key string) {
+	p.enqueue(key)
+}
+
+// IsEnabled returns if a service, by namespace/name, should be forwarded
+func (p *Proxier) IsEnabled(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return !p.disabled[key]
+}
+
+// Disabled returns the services, by namespace/name, that have been disabled
+func (p *Proxier) Disabled() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	disabled := make([]string, 0, len(p.disabled))
+	for key := range p.disabled {
+		disabled = append(disabled, key)
+	}
+	sort.Strings(disabled)
+	return disabled
+}
+
+// IPPoolUsage returns the usage of the pool of IPs that port-forwards are
+// allocated from
+func (p *Proxier) IPPoolUsage() (IPPoolUsage, error) {
+	w := p.portForwarder()
+	if w == nil {
+		return IPPoolUsage{}, fmt.Errorf("proxier not running")
+	}
+
+	return w.ipPoolUsage(), nil
+}
+
+// isTerminating returns if a pod is being deleted, or is already gone
+func isTerminating(pods cache.Store, pod PodInfo) bool {
+	obj, exists, err := pods.GetByKey(pod.Key())
+	if err != nil {
+		return false
+	}
+	if !exists {
+		return true
+	}
+
+	return obj.(*corev1.Pod).DeletionTimestamp != nil
+}
+
+// hasPodEndpoint returns if any of the ready endpoints are pods
+func hasPodEndpoint(endpoints *corev1.Endpoints) bool {
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.TargetRef != nil && address.TargetRef.Kind == PodKind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isActiveEndpoint(podName string, endpoints *corev1.Endpoints) bool {
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.TargetRef != nil && address.TargetRef.Kind == PodKind && address.TargetRef.Name == podName {
+				return true
+			}
+		}
+	}
+	return false
+}
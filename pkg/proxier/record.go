@@ -0,0 +1,223 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// maxRecordedBody is the most of a request's body that's recorded
+	maxRecordedBody = 1024 * 1024
+
+	// recordBuffer is how many requests can be waiting to be read by a
+	// recorder before new ones are dropped
+	recordBuffer = 128
+)
+
+// RecordedRequest is a HTTP request made through a port-forward
+type RecordedRequest struct {
+	Time time.Time
+
+	// Port is the port of the service the request was made to
+	Port string
+
+	Method string
+	URI    string
+	Host   string
+	Header http.Header
+
+	// Body is the request's body, up to maxRecordedBody bytes of it.
+	// BodyTruncated is set if there was more.
+	Body          []byte
+	BodyTruncated bool
+}
+
+// Record returns the HTTP requests made through the port-forward of a
+// service, by key, until ctx is canceled. Requests are dropped if they
+// aren't read fast enough, rather than slowing down the connections they
+// were made on. Port-forwards only see requests when they're relayed, so
+// the port-forward is recreated with relays if it isn't already.
+func (p *Proxier) Record(ctx context.Context, key string) (<-chan RecordedRequest, error) {
+	w := p.portForwarder()
+	if w == nil {
+		return nil, fmt.Errorf("proxier not running")
+	}
+
+	obj, exists, err := p.svcInformer.GetStore().GetByKey(key)
+	if err != nil || !exists {
+		return nil, fmt.Errorf("service '%s' not found", key)
+	}
+
+	if _, ok := w.portForward(key); !ok {
+		return nil, fmt.Errorf("service '%s' isn't being forwarded", key)
+	}
+
+	ch := make(chan RecordedRequest, recordBuffer)
+	w.recMu.Lock()
+	w.recorders[key] = append(w.recorders[key], ch)
+	w.recMu.Unlock()
+
+	if !w.relayed(key) {
+		p.createPortforward(obj.(*corev1.Service), "recording requests")
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		w.recMu.Lock()
+		defer w.recMu.Unlock()
+
+		recorders := w.recorders[key]
+		for i := range recorders {
+			if recorders[i] == ch {
+				w.recorders[key] = append(recorders[:i], recorders[i+1:]...)
+				break
+			}
+		}
+		if len(w.recorders[key]) == 0 {
+			delete(w.recorders, key)
+		}
+		close(ch)
+	}()
+
+	return ch, nil
+}
+
+// recording returns if the requests made to a service are being recorded
+func (w *worker) recording(key string) bool {
+	w.recMu.Lock()
+	defer w.recMu.Unlock()
+
+	return len(w.recorders[key]) > 0
+}
+
+// relayed returns if the port-forward of a service uses relays
+func (w *worker) relayed(key string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	pf, ok := w.portForwards[key]
+	return ok && len(pf.relays) > 0
+}
+
+// publishRequest sends a request to the recorders of a service
+func (w *worker) publishRequest(key string, req *RecordedRequest) {
+	w.recMu.Lock()
+	defer w.recMu.Unlock()
+
+	for _, ch := range w.recorders[key] {
+		select {
+		case ch <- *req:
+		default:
+			w.log.WithField("service", key).Debug("dropping recorded request, recorder isn't keeping up")
+		}
+	}
+}
+
+// tapFor returns a tapFunc for a port of a service, which detects the
+// protocol of the port until it's known, and parses the requests sent
+// through the relay while the service is being recorded
+func (w *worker) tapFor(key, port string) tapFunc {
+	return func() io.WriteCloser {
+		proto := w.protocol(key, port)
+
+		var taps multiTap
+		if proto == ProtocolUnknown {
+			taps = append(taps, &protocolSniffer{w: w, key: key, port: port})
+		}
+		if w.recording(key) && (proto == ProtocolUnknown || proto == ProtocolHTTP) {
+			pr, pw := io.Pipe()
+			go w.parseRequests(key, port, pr)
+			taps = append(taps, &requestTap{pw: pw})
+		}
+
+		switch len(taps) {
+		case 0:
+			return nil
+		case 1:
+			return taps[0]
+		}
+		return taps
+	}
+}
+
+// parseRequests parses the HTTP requests in r, publishing them to the
+// recorders of a service. Anything that isn't HTTP/1.x is ignored.
+func (w *worker) parseRequests(key, port string, r *io.PipeReader) {
+	// whatever is left has to be read, so the tap doesn't block
+	defer io.Copy(ioutil.Discard, r) //nolint:errcheck // Why: Draining
+
+	br := bufio.NewReader(r)
+	for {
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			if err != io.EOF {
+				w.log.WithField("service", key).WithError(err).Debug("not recording connection, it isn't HTTP")
+			}
+			return
+		}
+
+		body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxRecordedBody+1))
+		if err != nil {
+			return
+		}
+		if _, err := io.Copy(ioutil.Discard, req.Body); err != nil {
+			return
+		}
+
+		rec := &RecordedRequest{
+			Time:   time.Now(),
+			Port:   port,
+			Method: req.Method,
+			URI:    req.RequestURI,
+			Host:   req.Host,
+			Header: req.Header,
+			Body:   body,
+		}
+		if len(body) > maxRecordedBody {
+			rec.Body = body[:maxRecordedBody]
+			rec.BodyTruncated = true
+		}
+		w.publishRequest(key, rec)
+	}
+}
+
+// requestTap is written to with what a client sends through a relay. It
+// never fails, so that recording can't break the connection.
+type requestTap struct {
+	pw     *io.PipeWriter
+	failed bool
+}
+
+func (t *requestTap) Write(p []byte) (int, error) {
+	if !t.failed {
+		if _, err := t.pw.Write(p); err != nil {
+			t.failed = true
+		}
+	}
+	return len(p), nil
+}
+
+func (t *requestTap) Close() error {
+	return t.pw.Close()
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRelayRecordsRequests(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+
+	w := &worker{
+		log:       log,
+		recorders: make(map[string][]chan RecordedRequest),
+		protocols: make(map[string]map[string]Protocol),
+	}
+	ch := make(chan RecordedRequest, 2)
+	w.recorders["default/api"] = []chan RecordedRequest{ch}
+
+	echo := newEchoServer(t)
+	defer echo.Close()
+
+	r, err := newRelay(log, "127.0.0.1:0", echo.Addr().String(), 0, nil, w.tapFor("default/api", "80"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	conn, err := net.Dial("tcp", r.l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	reqs := "GET /health HTTP/1.1\r\nHost: api\r\n\r\n" +
+		"POST /orders HTTP/1.1\r\nHost: api\r\nContent-Length: 2\r\n\r\n{}"
+	if _, err := conn.Write([]byte(reqs)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the connection still works while it's recorded
+	b := make([]byte, len(reqs))
+	if _, err := conn.Read(b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []RecordedRequest{
+		{Method: "GET", URI: "/health", Host: "api", Port: "80"},
+		{Method: "POST", URI: "/orders", Host: "api", Port: "80", Body: []byte("{}")},
+	} {
+		select {
+		case got := <-ch:
+			if got.Method != expected.Method || got.URI != expected.URI || got.Host != expected.Host ||
+				got.Port != expected.Port || string(got.Body) != string(expected.Body) {
+				t.Errorf("expected %+v, got %+v", expected, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s %s to be recorded", expected.Method, expected.URI)
+		}
+	}
+
+	if proto := w.protocol("default/api", "80"); proto != ProtocolHTTP {
+		t.Errorf("expected port 80 to be detected as %s, got %q", ProtocolHTTP, proto)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultBufferSize is the size of the buffers used to copy data
+// between connections, this matches io.Copy.
+const defaultBufferSize = 32 * 1024
+
+// dialFunc connects to the target of a relay
+type dialFunc func() (net.Conn, error)
+
+// tapFunc returns a writer that is sent a copy of what a client sends
+// through a relay, or nil if it shouldn't be. It's called for every
+// connection, and the writer is closed when the connection is.
+type tapFunc func() io.WriteCloser
+
+// relay accepts connections on a listener and copies them to a target,
+// using fixed size buffers and an optional limit on the number of
+// connections being handled at once. This is used in front of port-forwards
+// when buffer sizes, connection limits, or draining are configured, as
+// port-forwards don't support them, and in front of ProxyOpts.ServiceDialer.
+type relay struct {
+	log logrus.FieldLogger
+	l   net.Listener
+
+	dial       dialFunc
+	bufferSize int
+
+	// tap, if set, is sent what clients send
+	tap tapFunc
+
+	// sem, if set, limits the number of connections being handled at once,
+	// this is shared between all relays.
+	sem chan struct{}
+
+	// conns are the connections being handled, active is how many
+	conns  sync.WaitGroup
+	active int64
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newRelay listens on addr and starts relaying connections to target
+func newRelay(log logrus.FieldLogger, addr, target string, bufferSize int, sem chan struct{}, tap tapFunc) (*relay, error) {
+	return newRelayWithDialer(log, addr, func() (net.Conn, error) {
+		return net.DialTimeout("tcp", target, 10*time.Second)
+	}, bufferSize, sem, tap)
+}
+
+// newRelayWithDialer listens on addr and starts relaying connections to
+// the connections created by dial
+func newRelayWithDialer(log logrus.FieldLogger, addr string, dial dialFunc, bufferSize int, sem chan struct{},
+	tap tapFunc) (*relay, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on %s", addr)
+	}
+
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+
+	r := &relay{
+		log:        log.WithField("relay", addr),
+		l:          l,
+		dial:       dial,
+		bufferSize: bufferSize,
+		sem:        sem,
+		tap:        tap,
+		done:       make(chan struct{}),
+	}
+	go r.serve()
+
+	return r, nil
+}
+
+// acquire waits for a connection slot, returning false if the relay
+// was closed while waiting
+func (r *relay) acquire() bool {
+	if r.sem == nil {
+		return true
+	}
+
+	select {
+	case r.sem <- struct{}{}:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+// release frees a connection slot
+func (r *relay) release() {
+	if r.sem != nil {
+		<-r.sem
+	}
+}
+
+func (r *relay) serve() {
+	for {
+		// wait for a slot before accepting, so that connections past the
+		// limit queue in the kernel instead of in goroutines
+		if !r.acquire() {
+			return
+		}
+
+		conn, err := r.l.Accept()
+		if err != nil {
+			r.release()
+			return
+		}
+
+		r.conns.Add(1)
+		atomic.AddInt64(&r.active, 1)
+		go func() {
+			defer r.release()
+			defer r.conns.Done()
+			defer atomic.AddInt64(&r.active, -1)
+			r.handle(conn)
+		}()
+	}
+}
+
+// handle copies data between conn and the target until either side is done
+func (r *relay) handle(conn net.Conn) {
+	defer conn.Close()
+
+	target, err := r.dial()
+	if err != nil {
+		r.log.WithError(err).Warn("failed to dial relay target")
+		return
+	}
+	defer target.Close()
+
+	var client io.Reader = conn
+	if r.tap != nil {
+		if t := r.tap(); t != nil {
+			defer t.Close()
+			client = io.TeeReader(conn, t)
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	pipe := func(dst net.Conn, src io.Reader) {
+		defer wg.Done()
+
+		if _, err := io.CopyBuffer(dst, src, make([]byte, r.bufferSize)); err != nil {
+			r.log.WithError(err).Debug("connection closed with error")
+		}
+
+		// signal that we're done writing, allowing the other direction to finish
+		if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+			cw.CloseWrite() //nolint:errcheck // Why: Best effort
+		} else {
+			dst.Close()
+		}
+	}
+
+	go pipe(target, client)
+	go pipe(conn, target)
+	wg.Wait()
+}
+
+// Active returns the number of connections being handled
+func (r *relay) Active() int64 {
+	return atomic.LoadInt64(&r.active)
+}
+
+// Close stops accepting new connections, existing connections are closed
+// when the port-forward behind them is.
+func (r *relay) Close() error {
+	var err error
+	r.closeOnce.Do(func() {
+		close(r.done)
+		err = r.l.Close()
+	})
+	return err
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newEchoServer starts a server that echos everything sent to it, standing
+// in for a pod behind a port-forward
+func newEchoServer(tb testing.TB) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn) //nolint:errcheck
+			}()
+		}
+	}()
+
+	return l
+}
+
+func newTestRelay(tb testing.TB, bufferSize int, sem chan struct{}) (*relay, func()) {
+	echo := newEchoServer(tb)
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+
+	r, err := newRelay(log, "127.0.0.1:0", echo.Addr().String(), bufferSize, sem, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return r, func() {
+		r.Close()
+		echo.Close()
+	}
+}
+
+func TestRelay(t *testing.T) {
+	// a single slot ensures connections are released when they finish
+	r, cleanup := newTestRelay(t, 4, make(chan struct{}, 1))
+	defer cleanup()
+
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("tcp", r.l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := conn.Write([]byte("hello world")); err != nil {
+			t.Fatal(err)
+		}
+		conn.(*net.TCPConn).CloseWrite() //nolint:errcheck
+
+		b, err := ioutil.ReadAll(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+
+		if string(b) != "hello world" {
+			t.Fatalf("expected 'hello world', got '%s'", string(b))
+		}
+	}
+}
+
+func BenchmarkRelayConnect(b *testing.B) {
+	r, cleanup := newTestRelay(b, 0, nil)
+	defer cleanup()
+
+	buf := make([]byte, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn, err := net.Dial("tcp", r.l.Addr().String())
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		// round trip a byte to ensure the connection made it to the target
+		if _, err := conn.Write(buf); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			b.Fatal(err)
+		}
+		conn.Close()
+	}
+}
+
+func BenchmarkRelayThroughput(b *testing.B) {
+	r, cleanup := newTestRelay(b, 0, nil)
+	defer cleanup()
+
+	conn, err := net.Dial("tcp", r.l.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, conn) //nolint:errcheck
+		close(done)
+	}()
+
+	buf := make([]byte, 32*1024)
+	b.SetBytes(int64(len(buf)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := conn.Write(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+	conn.(*net.TCPConn).CloseWrite() //nolint:errcheck
+	<-done
+}
@@ -0,0 +1,234 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+// These are how much each thing that makes an endpoint less likely to stay
+// up lowers its score. A pod that's crash-looping is usually Ready for a
+// moment between restarts, so restarts, and especially recent ones, count
+// for more than the rest.
+const (
+	restartPenalty      = 10
+	maxRestartPenalty   = 100
+	recentCrashPenalty  = 100
+	recentReadyPenalty  = 50
+	nodeNotReadyPenalty = 200
+	nodePressurePenalty = 25
+	nodeCordonedPenalty = 10
+	maxAgeBonus         = 10
+	recentCrashWindow   = 10 * time.Minute
+	recentReadyWindow   = time.Minute
+	ageBonusPeriod      = time.Minute
+)
+
+// endpoint is a pod that's a ready endpoint of a service
+type endpoint struct {
+	info PodInfo
+
+	// pod is the pod, or nil if it's not in the cache yet. node is the
+	// node it's on, if known.
+	pod  *corev1.Pod
+	node string
+}
+
+// bestEndpoint returns the endpoint that's most likely to stay up, based on
+// the restarts and age of its pod and the conditions of its node. Ties are
+// broken by the order of the endpoints.
+func (w *worker) bestEndpoint(ctx context.Context, endpoints []endpoint) PodInfo {
+	if len(endpoints) == 1 {
+		return endpoints[0].info
+	}
+
+	now := time.Now()
+	best, bestScore := 0, 0
+	for i := range endpoints {
+		ep := &endpoints[i]
+
+		var node *corev1.Node
+		if ep.node != "" && w.nodes != nil {
+			node = w.nodes.Get(ctx, ep.node)
+		}
+
+		score := scoreEndpoint(ep.pod, node, now)
+		if i == 0 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	if best != 0 {
+		w.log.WithField("pod", endpoints[best].info.Key()).WithField("score", bestScore).
+			Debug("preferring endpoint over ones more likely to go away")
+	}
+
+	return endpoints[best].info
+}
+
+// scoreEndpoint scores how likely an endpoint's pod is to stay up, higher
+// being more likely. pod and node may be nil if they aren't known, in which
+// case they don't count against it.
+func scoreEndpoint(pod *corev1.Pod, node *corev1.Node, now time.Time) int {
+	score := 0
+	if pod != nil {
+		score -= podPenalty(pod, now)
+
+		if age := now.Sub(pod.CreationTimestamp.Time); !pod.CreationTimestamp.IsZero() && age > 0 {
+			bonus := int(age / ageBonusPeriod)
+			if bonus > maxAgeBonus {
+				bonus = maxAgeBonus
+			}
+			score += bonus
+		}
+	}
+
+	if node != nil {
+		score -= nodePenalty(node)
+	}
+
+	return score
+}
+
+// podPenalty returns how much the restarts of a pod's containers, and how
+// recently it became ready, count against it
+func podPenalty(pod *corev1.Pod, now time.Time) int {
+	penalty := 0
+
+	restarts := 0
+	for i := range pod.Status.ContainerStatuses {
+		cs := &pod.Status.ContainerStatuses[i]
+		restarts += int(cs.RestartCount)
+
+		if t := cs.LastTerminationState.Terminated; t != nil && now.Sub(t.FinishedAt.Time) < recentCrashWindow {
+			penalty += recentCrashPenalty
+		}
+	}
+
+	restartsPenalty := restarts * restartPenalty
+	if restartsPenalty > maxRestartPenalty {
+		restartsPenalty = maxRestartPenalty
+	}
+	penalty += restartsPenalty
+
+	for i := range pod.Status.Conditions {
+		c := &pod.Status.Conditions[i]
+		if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue && now.Sub(c.LastTransitionTime.Time) < recentReadyWindow {
+			penalty += recentReadyPenalty
+		}
+	}
+
+	return penalty
+}
+
+// nodePenalty returns how much the conditions of a node count against the
+// pods on it
+func nodePenalty(node *corev1.Node) int {
+	penalty := 0
+	if node.Spec.Unschedulable {
+		// it's likely being drained
+		penalty += nodeCordonedPenalty
+	}
+
+	for i := range node.Status.Conditions {
+		c := &node.Status.Conditions[i]
+		switch c.Type {
+		case corev1.NodeReady:
+			if c.Status != corev1.ConditionTrue {
+				penalty += nodeNotReadyPenalty
+			}
+		case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure:
+			if c.Status == corev1.ConditionTrue {
+				penalty += nodePressurePenalty
+			}
+		case corev1.NodeNetworkUnavailable:
+			if c.Status == corev1.ConditionTrue {
+				penalty += nodeNotReadyPenalty
+			}
+		}
+	}
+
+	return penalty
+}
+
+// nodeCache caches the nodes that endpoints are on, for scoring them. The
+// informer behind it isn't started until a node is first needed, and never
+// is if we aren't allowed to list nodes.
+type nodeCache struct {
+	k    kubernetes.Interface
+	log  logrus.FieldLogger
+	done <-chan struct{}
+
+	// mu protects lister and forbidden. lister is set once the informer
+	// has been started, forbidden once we've found we can't list nodes.
+	mu        sync.Mutex
+	lister    corelisters.NodeLister
+	forbidden bool
+}
+
+// newNodeCache creates a nodeCache, its informer is stopped when ctx is
+func newNodeCache(ctx context.Context, k kubernetes.Interface, log logrus.FieldLogger) *nodeCache {
+	return &nodeCache{k: k, log: log, done: ctx.Done()}
+}
+
+// Get returns a node, or nil if it isn't known. Nodes aren't known until the
+// informer has synced, which only makes scores less accurate until then.
+func (c *nodeCache) Get(ctx context.Context, name string) *corev1.Node {
+	c.mu.Lock()
+	if c.lister == nil && !c.forbidden {
+		c.start(ctx)
+	}
+	lister := c.lister
+	c.mu.Unlock()
+
+	if lister == nil {
+		return nil
+	}
+
+	node, err := lister.Get(name)
+	if err != nil {
+		return nil
+	}
+	return node
+}
+
+// start starts the informer, if we're allowed to list nodes. An informer
+// that isn't allowed to would retry, and log, forever, so that's checked
+// first. c.mu must be held.
+func (c *nodeCache) start(ctx context.Context) {
+	_, err := c.k.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: 1})
+	if kerrors.IsForbidden(err) {
+		c.log.WithError(err).Debug("not allowed to list nodes, endpoints won't be scored by their node")
+		c.forbidden = true
+		return
+	} else if err != nil {
+		// tried again on the next lookup
+		c.log.WithError(err).Debug("failed to list nodes")
+		return
+	}
+
+	factory := informers.NewSharedInformerFactory(c.k, 0)
+	c.lister = factory.Core().V1().Nodes().Lister()
+	factory.Start(c.done)
+}
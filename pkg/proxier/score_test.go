@@ -0,0 +1,152 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestGetPodForServiceScoring(t *testing.T) {
+	now := time.Now()
+	old := metav1.NewTime(now.Add(-time.Hour))
+	ready := []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: old}}
+
+	pods := []*corev1.Pod{
+		{
+			// crash-looping, but Ready right now
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "crashing", CreationTimestamp: old},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{
+					Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-5 * time.Second)),
+				}},
+				ContainerStatuses: []corev1.ContainerStatus{{
+					RestartCount: 7,
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(now.Add(-30 * time.Second))},
+					},
+				}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bad-node", CreationTimestamp: old},
+			Status:     corev1.PodStatus{Conditions: ready},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "healthy", CreationTimestamp: old},
+			Status:     corev1.PodStatus{Conditions: ready},
+		},
+	}
+	nodes := map[string]string{"crashing": "good", "bad-node": "bad", "healthy": "good"}
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	addrs := []corev1.EndpointAddress{}
+	for _, pod := range pods {
+		if err := store.Add(pod); err != nil {
+			t.Fatal(err)
+		}
+		node := nodes[pod.Name]
+		addrs = append(addrs, corev1.EndpointAddress{
+			NodeName:  &node,
+			TargetRef: &corev1.ObjectReference{Kind: PodKind, Namespace: "default", Name: pod.Name},
+		})
+	}
+
+	k := fake.NewSimpleClientset(
+		&corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api"}, Subsets: []corev1.EndpointSubset{{Addresses: addrs}}},
+		&corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unknown"}, Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{
+				{TargetRef: &corev1.ObjectReference{Kind: PodKind, Namespace: "default", Name: "new-1"}},
+				{TargetRef: &corev1.ObjectReference{Kind: PodKind, Namespace: "default", Name: "new-2"}},
+			},
+		}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "good"}, Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "bad"}, Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionUnknown}},
+		}},
+	)
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := &worker{k: k, log: log, pods: store, nodes: newNodeCache(ctx, k, log)}
+
+	// start the node informer, and wait for it to see the nodes
+	w.nodes.Get(ctx, "good")
+	err := wait.PollImmediateUntil(10*time.Millisecond, func() (bool, error) {
+		return w.nodes.Get(ctx, "bad") != nil, nil
+	}, time.After(10*time.Second))
+	if err != nil {
+		t.Fatal("expected the nodes to be seen by the informer")
+	}
+
+	pod, err := w.getPodForService(ctx, &CreatePortForwardRequest{Service: ServiceInfo{Namespace: "default", Name: "api"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Name != "healthy" {
+		t.Errorf("expected the healthy pod on a ready node to be chosen, got %s", pod.Name)
+	}
+
+	// with nothing known about the pods, the first one is used
+	pod, err = w.getPodForService(ctx, &CreatePortForwardRequest{Service: ServiceInfo{Namespace: "default", Name: "unknown"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Name != "new-1" {
+		t.Errorf("expected the first endpoint to be chosen, got %s", pod.Name)
+	}
+}
+
+func TestNodeCacheForbidden(t *testing.T) {
+	k := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "good"}})
+
+	lists := 0
+	k.PrependReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lists++
+		return true, nil, kerrors.NewForbidden(corev1.Resource("nodes"), "", nil)
+	})
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := newNodeCache(ctx, k, log)
+	for i := 0; i < 3; i++ {
+		if node := c.Get(ctx, "good"); node != nil {
+			t.Fatal("expected no node when nodes can't be listed")
+		}
+	}
+	if lists != 1 {
+		t.Errorf("expected nodes to only be listed once when forbidden, got %d", lists)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 Outreach.io
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// TopologyHintsAnnotation is the annotation that enables topology aware
+// hints on a service
+const TopologyHintsAnnotation = "service.kubernetes.io/topology-aware-hints"
+
+// isNodeLocal returns if a service only routes to endpoints on the same
+// node as the client
+func isNodeLocal(svc *corev1.Service) bool {
+	policy := svc.Spec.InternalTrafficPolicy
+	return policy != nil && *policy == corev1.ServiceInternalTrafficPolicyLocal
+}
+
+// hasTopologyHints returns if a service uses topology aware hints
+func hasTopologyHints(svc *corev1.Service) bool {
+	return strings.EqualFold(svc.Annotations[TopologyHintsAnnotation], "auto")
+}
+
+// zoneOfNode returns the zone of a node, from its topology label
+func zoneOfNode(ctx context.Context, k kubernetes.Interface, node string) (string, error) {
+	n, err := k.CoreV1().Nodes().Get(ctx, node, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get node '%s'", node)
+	}
+
+	return n.Labels[corev1.LabelTopologyZone], nil
+}
+
+// hintedAddresses returns the addresses of a service's endpoints that are
+// hinted for zone. Like kube-proxy, nil is returned if any endpoint has no
+// hints, or none are hinted for the zone, meaning every endpoint can be used.
+func hintedAddresses(ctx context.Context, k kubernetes.Interface, namespace, service, zone string) (map[string]bool, error) {
+	slices, err := k.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + service,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list endpoint slices")
+	}
+
+	hinted := make(map[string]bool)
+	for i := range slices.Items {
+		for _, e := range slices.Items[i].Endpoints {
+			if e.Hints == nil {
+				return nil, nil
+			}
+
+			for _, z := range e.Hints.ForZones {
+				if z.Name != zone {
+					continue
+				}
+				for _, addr := range e.Addresses {
+					hinted[addr] = true
+				}
+			}
+		}
+	}
+	if len(hinted) == 0 {
+		return nil, nil
+	}
+
+	return hinted, nil
+}
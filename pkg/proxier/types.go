@@ -0,0 +1,263 @@
+// Copyright 2020 Jared Allard
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package proxier
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/tools/portforward"
+)
+
+const PodKind = "Pod"
+
+type PodInfo struct {
+	// Name is the name of this pod
+	Name string
+
+	// Namespace is the namespace this pod is in
+	Namespace string
+}
+
+func (s *PodInfo) Key() string {
+	return fmt.Sprintf("%s/%s", s.Namespace, s.Name)
+}
+
+type ServiceInfo struct {
+	// Name is the name of this service
+	Name string
+
+	// Namespace is the namespace of this service
+	Namespace string
+
+	// Kind is the kind of object being forwarded, empty for services.
+	// PodKind is used for port-forwards directly to a pod.
+	Kind string
+}
+
+// Key returns namespace/name, prefixed with the kind if this isn't a
+// service, e.g. pod/namespace/name
+func (s *ServiceInfo) Key() string {
+	if s.Kind != "" {
+		return fmt.Sprintf("%s/%s/%s", strings.ToLower(s.Kind), s.Namespace, s.Name)
+	}
+	return fmt.Sprintf("%s/%s", s.Namespace, s.Name)
+}
+
+// CreatePortForwardRequest is a request to create port-forward
+type CreatePortForwardRequest struct {
+	// Service is the service this port-forward implements.
+	Service ServiceInfo
+
+	// Hostnames are the DNS entries to inject into our host's DNS
+	// for this port-forward. This will be attached to the IP that
+	// is created for this service.
+	Hostnames []string
+
+	// Ports are the ports this port-forward exposes
+	Ports []string
+
+	// ClusterIP is the ClusterIP of the service, if it has one. This is
+	// used to redirect traffic sent to it to this port-forward.
+	ClusterIP string
+
+	// Endpoint is the specific pod to use for this service.
+	Endpoint *PodInfo
+
+	// Priority is the priority of this port-forward, requests with a
+	// higher priority are handled first.
+	Priority int
+
+	// Compress specifies if connections should be compressed, this is only
+	// supported when using a ServiceDialer
+	Compress bool
+
+	// LeaderLock, if set, is the leader election lock of the service's
+	// pods, e.g. lease/my-controller. The pod holding it is used.
+	LeaderLock string
+
+	// NodeLocal is if the service's internalTrafficPolicy is Local, and
+	// TopologyHints is if it uses topology aware hints. These are only
+	// used if the node or zone localizer is treated as being in is set.
+	NodeLocal     bool
+	TopologyHints bool
+
+	// Recreate specifies if this should be recreated if it already
+	// exists
+	Recreate       bool
+	RecreateReason string
+}
+
+// DeletePortForwardRequest is a request to delete a port-forward
+type DeletePortForwardRequest struct {
+	// Service is the service that should delete being port-forwarded
+	Service ServiceInfo
+}
+
+// PausePortForwardRequest is a request to close the tunnel of a
+// port-forward, while keeping its IP and hosts entries
+type PausePortForwardRequest struct {
+	// Service is the service whose port-forward should be paused
+	Service ServiceInfo
+}
+
+// PortForwardRequest is a port-forward request, the non-nil struct is the type
+// of request this is. There should only ever be one non-nil struct.
+type PortForwardRequest struct {
+	DeletePortForwardRequest *DeletePortForwardRequest
+	CreatePortForwardRequest *CreatePortForwardRequest
+	PausePortForwardRequest  *PausePortForwardRequest
+}
+
+// service returns the service this request is for
+func (r *PortForwardRequest) service() ServiceInfo {
+	if r.CreatePortForwardRequest != nil {
+		return r.CreatePortForwardRequest.Service
+	}
+	if r.PausePortForwardRequest != nil {
+		return r.PausePortForwardRequest.Service
+	}
+	return r.DeletePortForwardRequest.Service
+}
+
+// priority returns the priority of this request. Deletes aren't prioritized.
+func (r *PortForwardRequest) priority() int {
+	if r.CreatePortForwardRequest != nil {
+		return r.CreatePortForwardRequest.Priority
+	}
+	return 0
+}
+
+// PortForwardConnection is a port-forward that is managed by the port-forward
+// worker.
+type PortForwardConnection struct {
+	Service      ServiceInfo
+	Pod          PodInfo
+	Status       PortForwardStatus
+	StatusReason string
+
+	// IP that this port-forward allocates
+	IP        net.IP
+	Hostnames []string
+
+	// Ports is a local -> remote port list
+	Ports []string
+
+	// ClusterIP is the ClusterIP that is being redirected to this
+	// port-forward, if any.
+	ClusterIP string
+
+	// Compress is if connections are compressed
+	Compress bool
+
+	// RecreateCount is the number of times this port-forward has been
+	// recreated after it was running. Recreations are the most recent of
+	// them, oldest first.
+	RecreateCount int
+	Recreations   []Recreation
+
+	pf *portforward.PortForwarder
+
+	// cancel stops pf, closing its connection to the pod
+	cancel context.CancelFunc
+
+	// relays sit in front of pf when connection limits or buffer sizes
+	// are configured
+	relays []*relay
+}
+
+// copy returns a copy of pf without its tunnel, which only the worker uses
+func (pf *PortForwardConnection) copy() PortForwardConnection {
+	return PortForwardConnection{
+		Service:       pf.Service,
+		Pod:           pf.Pod,
+		Status:        pf.Status,
+		StatusReason:  pf.StatusReason,
+		IP:            pf.IP,
+		Hostnames:     pf.Hostnames,
+		Ports:         pf.Ports,
+		ClusterIP:     pf.ClusterIP,
+		Compress:      pf.Compress,
+		RecreateCount: pf.RecreateCount,
+		Recreations:   pf.Recreations,
+	}
+}
+
+// Recreation is a time a port-forward was recreated
+type Recreation struct {
+	Time   time.Time
+	Reason string
+}
+
+// IPPoolUsage is the usage of the pool of IPs port-forwards are allocated
+// from
+type IPPoolUsage struct {
+	// CIDR is the range of the pool
+	CIDR string
+
+	// Acquired is the number of IPs in use, including the network and
+	// broadcast addresses. Available is the size of the pool.
+	Acquired  uint64
+	Available uint64
+}
+
+// HookEventType is the type of a HookEvent
+type HookEventType string
+
+var (
+	// HookForwardCreated is when a port-forward is created, or recreated
+	HookForwardCreated HookEventType = "forward-created"
+
+	// HookForwardFailed is when a port-forward fails, Reason is why
+	HookForwardFailed HookEventType = "forward-failed"
+
+	// HookStable is when every port-forward is running for the first time
+	HookStable HookEventType = "stable"
+)
+
+// HookEvent is something that happened to a port-forward, or the proxier,
+// given to ProxyOpts.Hooks
+type HookEvent struct {
+	Type HookEventType
+
+	// Namespace and Service are the service this event is about, if any
+	Namespace string
+	Service   string
+
+	// Endpoint is the pod backing the service, in the format of
+	// namespace/name
+	Endpoint string
+
+	// IP is the IP address allocated to the service
+	IP        string
+	Hostnames []string
+	Ports     []string
+
+	// Reason is why this event happened, e.g. the error a port-forward
+	// failed with
+	Reason string
+}
+
+type PortForwardStatus string
+
+var (
+	PortForwardStatusRunning    PortForwardStatus = "running"
+	PortForwardStatusRecreating PortForwardStatus = "recreating"
+	PortForwardStatusWaiting    PortForwardStatus = "waiting"
+	PortForwardStatusPaused     PortForwardStatus = "paused"
+	PortForwardStatusFailed     PortForwardStatus = "failed"
+)